@@ -15,20 +15,34 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 var (
-	configPath = flag.String("config", "./configs/config.yaml", "配置文件路径")
-	rulesPath  = flag.String("rules", "./configs/rules", "规则文件目录")
+	configPath   = flag.String("config", "./configs/config.yaml", "配置文件路径")
+	rulesPath    = flag.String("rules", "./configs/rules", "规则文件目录")
+	hashPassword = flag.String("hash-password", "", "生成密码的 bcrypt 哈希并退出（用于 auth.users[].password）")
 )
 
 func main() {
 	flag.Parse()
 
+	// 一次性子命令：生成密码哈希
+	if *hashPassword != "" {
+		hash, err := config.HashPassword(*hashPassword)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(hash)
+		return
+	}
+
 	// 检测用户是否显式传入了 -rules 参数
 	rulesFlagProvided := false
 	flag.Visit(func(f *flag.Flag) {
@@ -84,18 +98,20 @@ func main() {
 		logger.Fatalf("加载配置失败: %v", err)
 	}
 
-	// 初始化日志
-	logger := logrus.New()
+	// 初始化日志：使用全局日志器，规则加载、OpenSearch 客户端等模块输出格式保持一致
+	logger := logrus.StandardLogger()
 	logger.SetLevel(logrus.InfoLevel)
-	logger.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp: true,
-	})
+	logger.SetFormatter(newLogFormatter(cfg.Logging.Format))
 
 	// 设置日志级别
 	if level, err := logrus.ParseLevel(cfg.Logging.Level); err == nil {
 		logger.SetLevel(level)
 	}
 
+	// 最近日志保留在内存中，供 Web 管理台查看与实时跟踪
+	logBuffer := web.NewLogBuffer(web.DefaultLogBufferSize)
+	logger.AddHook(logBuffer)
+
 	// 设置日志文件输出（同时输出到终端和文件）
 	if cfg.Logging.File != "" {
 		// 确保日志目录存在
@@ -103,21 +119,27 @@ func main() {
 		if err := os.MkdirAll(logDir, 0755); err != nil {
 			logger.Warnf("创建日志目录失败: %v", err)
 		} else {
-			// 创建文件输出
-			file, err := os.OpenFile(cfg.Logging.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-			if err != nil {
-				logger.Warnf("打开日志文件失败: %v", err)
-			} else {
-				// 使用 MultiWriter 同时输出到终端和文件
-				multiWriter := io.MultiWriter(os.Stdout, file)
-				logger.SetOutput(multiWriter)
-				logger.Info("日志将同时输出到终端和文件: " + cfg.Logging.File)
-			}
+			// 按 max_size 与 backup_count 滚动的文件输出
+			rotator := newLogRotator(cfg.Logging)
+			defer rotator.Close()
+
+			// 使用 MultiWriter 同时输出到终端和文件
+			multiWriter := io.MultiWriter(os.Stdout, rotator)
+			logger.SetOutput(multiWriter)
+			logger.Infof("日志将同时输出到终端和文件: %s（单文件上限 %dMB，保留 %d 个备份）", cfg.Logging.File, rotator.MaxSize, rotator.MaxBackups)
 		}
 	}
 
 	logger.Info("🚀 启动 OpenSearch 告警工具...")
 	logger.Infof("📁 配置文件: %s", *configPath)
+
+	// 校验配置，一次性列出全部问题后退出
+	if err := config.ValidateConfig(cfg); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			logger.Errorf("❌ 配置错误: %s", line)
+		}
+		logger.Fatal("配置校验失败，请修正以上问题后重新启动")
+	}
 	logger.Infof("📁 规则目录(参数): %s", *rulesPath)
 	// 若命令行未显式指定，优先使用配置中的 rules_folder
 	if !rulesFlagProvided && cfg.Rules.RulesFolder != "" {
@@ -134,13 +156,20 @@ func main() {
 	}
 
 	// 显示 OpenSearch 连接信息
-	logger.Infof("🔗 OpenSearch 连接: %s://%s:%d", cfg.OpenSearch.Protocol, cfg.OpenSearch.Host, cfg.OpenSearch.Port)
+	if len(cfg.OpenSearch.Hosts) > 0 {
+		logger.Infof("🔗 OpenSearch 连接: %v（协议 %s，默认端口 %d）", cfg.OpenSearch.Hosts, cfg.OpenSearch.Protocol, cfg.OpenSearch.Port)
+	} else {
+		logger.Infof("🔗 OpenSearch 连接: %s://%s:%d", cfg.OpenSearch.Protocol, cfg.OpenSearch.Host, cfg.OpenSearch.Port)
+	}
 	logger.Infof("👤 用户名: %s", cfg.OpenSearch.Username)
 	logger.Infof("⏱️  超时时间: %d秒", cfg.OpenSearch.Timeout)
 
 	// 创建 OpenSearch 客户端
 	logger.Info("🔧 创建 OpenSearch 客户端...")
-	opensearchClient := opensearch.NewClient(cfg.OpenSearch)
+	opensearchClient, err := opensearch.NewClient(cfg.OpenSearch)
+	if err != nil {
+		logger.Fatalf("创建 OpenSearch 客户端失败: %v", err)
+	}
 
 	// 测试 OpenSearch 连接
 	logger.Info("🔍 测试 OpenSearch 连接...")
@@ -149,6 +178,9 @@ func main() {
 
 	if err := opensearchClient.TestConnection(ctx); err != nil {
 		logger.Errorf("❌ OpenSearch 连接测试失败: %v", err)
+		if opensearch.IsAuthError(err) {
+			logger.Fatalf("OpenSearch 认证/授权失败，请检查 opensearch.username/password 及账号权限，程序退出")
+		}
 		logger.Fatal("OpenSearch 连接失败，程序退出")
 	} else {
 		logger.Info("✅ OpenSearch 连接测试成功")
@@ -179,14 +211,7 @@ func main() {
 	}
 
 	// 使用配置默认值回填缺失的 timeframe 与 threshold
-	for i := range rules {
-		if rules[i].Timeframe == 0 {
-			rules[i].Timeframe = cfg.Rules.DefaultTimeframe
-		}
-		if rules[i].Threshold == 0 {
-			rules[i].Threshold = cfg.Rules.DefaultThreshold
-		}
-	}
+	config.ApplyRuleDefaults(rules, cfg.Rules)
 
 	if len(rules) == 0 {
 		logger.Warn("⚠️  没有找到启用的告警规则")
@@ -202,18 +227,16 @@ func main() {
 	notifier := notification.NewNotifier(cfg, logger)
 
 	// 显示启用的通知渠道
+	// 仅统计实际生效的渠道（配置错误的渠道已被自动停用）
 	enabledChannels := []string{}
-	if cfg.Notifications.Email.Enabled {
-		enabledChannels = append(enabledChannels, "邮件")
-	}
-	if cfg.Notifications.DingTalk.Enabled {
-		enabledChannels = append(enabledChannels, "钉钉")
-	}
-	if cfg.Notifications.WeChat.Enabled {
-		enabledChannels = append(enabledChannels, "企业微信")
-	}
-	if cfg.Notifications.Feishu.Enabled {
-		enabledChannels = append(enabledChannels, "飞书")
+	channelStatuses := notifier.ChannelStatuses()
+	for _, ch := range []struct{ key, label string }{
+		{"email", "邮件"}, {"dingtalk", "钉钉"}, {"wechat", "企业微信"}, {"feishu", "飞书"},
+		{"ntfy", "ntfy"}, {"pagerduty", "PagerDuty"},
+	} {
+		if channelStatuses[ch.key].Effective {
+			enabledChannels = append(enabledChannels, ch.label)
+		}
 	}
 	if len(enabledChannels) > 0 {
 		logger.Infof("📢 启用的通知渠道: %v", enabledChannels)
@@ -240,11 +263,22 @@ func main() {
 		logger.Fatalf("❌ 启动告警引擎失败: %v", err)
 	}
 
+	// 监听规则目录，规则文件变化后自动热加载
+	rulesWatcher, err := config.NewRulesWatcher(*rulesPath, 2*time.Second, func(rules []types.AlertRule) {
+		config.ApplyRuleDefaults(rules, cfg.Rules)
+		alertEngine.LoadRules(rules)
+	}, logger)
+	if err != nil {
+		logger.Warnf("⚠️  规则热加载未开启: %v", err)
+	} else {
+		rulesWatcher.Start()
+	}
+
 	// 服务启动测试通知（放到最后）
 	if len(enabledChannels) > 0 {
 		logger.Info("🎉 服务启动成功！发送启动测试通知...")
 		testAlert := &types.Alert{
-			ID:        fmt.Sprintf("startup-test-%d", time.Now().Unix()),
+			ID:        types.NewAlertID("startup-test"),
 			RuleName:  "服务启动测试",
 			Level:     "Info",
 			Message:   "🚀 OpenSearch 告警工具启动成功！",
@@ -253,7 +287,7 @@ func main() {
 			Count:     1,
 			Matches:   1,
 		}
-		if err := notifier.SendAlert(testAlert); err != nil {
+		if _, err := notifier.SendAlert(testAlert); err != nil {
 			logger.Errorf("❌ 启动测试通知发送失败: %v", err)
 		} else {
 			logger.Info("✅ 启动测试通知发送完成")
@@ -264,7 +298,8 @@ func main() {
 	var webServer *web.Server
 	if cfg.Web.Enabled {
 		logger.Info("🌐 启动 Web 服务器...")
-		webServer = web.NewServer(cfg, db, notifier, alertEngine, logger)
+		webServer = web.NewServer(cfg, db, notifier, alertEngine, opensearchClient, logger)
+		webServer.SetLogBuffer(logBuffer)
 
 		go func() {
 			if err := webServer.Start(); err != nil {
@@ -272,9 +307,16 @@ func main() {
 			}
 		}()
 
-		logger.Infof("🌐 Web 服务器已启动: http://%s:%d", cfg.Web.Host, cfg.Web.Port)
-		logger.Infof("📊 Dashboard: http://%s:%d/dashboard", cfg.Web.Host, cfg.Web.Port)
-		logger.Infof("🔐 登录页面: http://%s:%d/login", cfg.Web.Host, cfg.Web.Port)
+		scheme := "http"
+		if cfg.Web.TLSEnabled() {
+			scheme = "https"
+		}
+		logger.Infof("🌐 Web 服务器已启动: %s://%s:%d", scheme, cfg.Web.Host, cfg.Web.Port)
+		logger.Infof("📊 Dashboard: %s://%s:%d/dashboard", scheme, cfg.Web.Host, cfg.Web.Port)
+		logger.Infof("🔐 登录页面: %s://%s:%d/login", scheme, cfg.Web.Host, cfg.Web.Port)
+		if cfg.Web.AdminAddr != "" {
+			logger.Infof("🩺 运维端点: http://%s/healthz", cfg.Web.AdminAddr)
+		}
 	}
 
 	logger.Info("🎉 OpenSearch 告警工具已成功启动！")
@@ -288,8 +330,54 @@ func main() {
 	<-sigChan
 	logger.Info("收到退出信号，正在关闭...")
 
+	// 停止 Web 服务器
+	if webServer != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := webServer.Shutdown(shutdownCtx); err != nil {
+			logger.Warnf("关闭 Web 服务器失败: %v", err)
+		}
+		shutdownCancel()
+	}
+
+	// 停止规则目录监听
+	if rulesWatcher != nil {
+		rulesWatcher.Stop()
+	}
+
 	// 停止告警引擎
 	alertEngine.Stop()
 
+	// 停止通知器（发送剩余的汇总邮件）
+	notifier.Stop()
+
 	logger.Info("OpenSearch 告警工具已关闭")
 }
+
+// defaultLogMaxSizeMB 未配置 max_size 时单个日志文件的大小上限（MB）
+const defaultLogMaxSizeMB = 100
+
+// newLogRotator 创建滚动日志文件：超过 max_size 后滚动，保留 backup_count 个旧文件（0 表示全部保留）
+func newLogRotator(cfg types.LoggingConfig) *lumberjack.Logger {
+	maxSizeMB := defaultLogMaxSizeMB
+	if cfg.MaxSize != "" {
+		// 已在 ValidateConfig 中校验；lumberjack 以 MB 为单位，不足 1MB 按 1MB 计
+		if size, err := config.ParseSize(cfg.MaxSize); err == nil {
+			maxSizeMB = int((size + (1 << 20) - 1) >> 20)
+		}
+	}
+
+	return &lumberjack.Logger{
+		Filename:   cfg.File,
+		MaxSize:    maxSizeMB,
+		MaxBackups: cfg.BackupCount,
+		LocalTime:  true,
+	}
+}
+
+// newLogFormatter 按 logging.format 创建日志格式：json 输出结构化日志，其他值使用文本格式
+func newLogFormatter(format string) logrus.Formatter {
+	if strings.EqualFold(format, "json") {
+		return &logrus.JSONFormatter{TimestampFormat: time.RFC3339}
+	}
+	return &logrus.TextFormatter{FullTimestamp: true}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"opensearch-alert/internal/config"
+	"opensearch-alert/pkg/types"
+
+	"github.com/sirupsen/logrus"
+)
+
+// jsonLines 逐行解析日志输出，任一行不是合法 JSON 时测试失败
+func jsonLines(t *testing.T, output []byte) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("日志行不是合法 JSON: %q (%v)", scanner.Text(), err)
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestNewLogFormatterJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(newLogFormatter("JSON"))
+
+	logger.WithField("rule", "errors").Warn("规则 \"errors\" 执行失败\n下一行")
+	logger.Info("第二条")
+
+	lines := jsonLines(t, buf.Bytes())
+	if len(lines) != 2 {
+		t.Fatalf("应输出 2 行日志，实际 %d 行:\n%s", len(lines), buf.String())
+	}
+	first := lines[0]
+	if first["level"] != "warning" || first["msg"] != "规则 \"errors\" 执行失败\n下一行" || first["rule"] != "errors" {
+		t.Errorf("JSON 日志字段不符: %v", first)
+	}
+	if _, ok := first["time"]; !ok {
+		t.Errorf("JSON 日志缺少 time 字段: %v", first)
+	}
+}
+
+func TestNewLogFormatterDefaultsToText(t *testing.T) {
+	for _, format := range []string{"", "text", "unknown"} {
+		if _, ok := newLogFormatter(format).(*logrus.TextFormatter); !ok {
+			t.Errorf("format %q 应使用文本格式", format)
+		}
+	}
+}
+
+func TestLoadRulesLogsAsJSON(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "errors.yaml"), []byte("name: errors\ntype: any\nenabled: true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// config.LoadRules 使用全局日志器，与 main 中的配置一致
+	std := logrus.StandardLogger()
+	out, formatter, level := std.Out, std.Formatter, std.Level
+	t.Cleanup(func() {
+		std.SetOutput(out)
+		std.SetFormatter(formatter)
+		std.SetLevel(level)
+	})
+	var buf bytes.Buffer
+	std.SetOutput(&buf)
+	std.SetFormatter(newLogFormatter("json"))
+	std.SetLevel(logrus.DebugLevel)
+
+	if _, err := config.LoadRules(dir); err != nil {
+		t.Fatalf("加载规则失败: %v", err)
+	}
+	if lines := jsonLines(t, buf.Bytes()); len(lines) == 0 {
+		t.Error("加载规则应输出日志")
+	}
+}
+
+func TestNewLogRotatorSize(t *testing.T) {
+	tests := []struct {
+		maxSize string
+		wantMB  int
+	}{
+		{"", defaultLogMaxSizeMB},
+		{"100MB", 100},
+		{"1G", 1024},
+		{"512KB", 1},
+		{"1.5MB", 2},
+		{"invalid", defaultLogMaxSizeMB},
+	}
+	for _, tt := range tests {
+		rotator := newLogRotator(types.LoggingConfig{File: "alert.log", MaxSize: tt.maxSize, BackupCount: 3})
+		if rotator.MaxSize != tt.wantMB || rotator.MaxBackups != 3 {
+			t.Errorf("max_size %q: MaxSize = %dMB, MaxBackups = %d，期望 %dMB, 3", tt.maxSize, rotator.MaxSize, rotator.MaxBackups, tt.wantMB)
+		}
+	}
+}
+
+func TestLogRotatorRotatesAfterMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "alert.log")
+	rotator := newLogRotator(types.LoggingConfig{File: file, MaxSize: "1MB", BackupCount: 2})
+	t.Cleanup(func() { rotator.Close() })
+
+	line := []byte(strings.Repeat("x", 1023) + "\n")
+	write := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			if _, err := rotator.Write(line); err != nil {
+				t.Fatalf("写入日志失败: %v", err)
+			}
+		}
+	}
+	backups := func() []string {
+		t.Helper()
+		matches, err := filepath.Glob(filepath.Join(dir, "alert-*.log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return matches
+	}
+
+	// 恰好写满 1MB 不滚动
+	write(1024)
+	if got := backups(); len(got) != 0 {
+		t.Fatalf("未超过上限时不应滚动，实际备份 %v", got)
+	}
+
+	// 超过上限后滚动，当前文件只包含新写入的内容
+	write(1)
+	if got := backups(); len(got) != 1 {
+		t.Fatalf("超过上限后应生成 1 个备份，实际 %v", got)
+	}
+	fi, err := os.Stat(file)
+	if err != nil {
+		t.Fatalf("读取当前日志文件失败: %v", err)
+	}
+	if fi.Size() != int64(len(line)) {
+		t.Errorf("滚动后当前文件大小 = %d，期望 %d", fi.Size(), len(line))
+	}
+}
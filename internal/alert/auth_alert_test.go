@@ -0,0 +1,39 @@
+package alert
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"opensearch-alert/internal/notification"
+	"opensearch-alert/internal/opensearch"
+	"opensearch-alert/pkg/types"
+)
+
+func TestAuthMetaAlertPerRule(t *testing.T) {
+	config, sent := countingNtfyConfig(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":{"type":"security_exception"}}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := opensearch.NewClient(types.OpenSearchConfig{Host: server.URL, Timeout: 5})
+	if err != nil {
+		t.Fatalf("创建 OpenSearch 客户端失败: %v", err)
+	}
+	e := NewEngine(config, client, notification.NewNotifier(config, newTestLogger()), newTestDatabase(t), newTestLogger())
+
+	first := types.AlertRule{Name: "first", Type: "any", Index: "logs-a", Enabled: true}
+	second := types.AlertRule{Name: "second", Type: "any", Index: "logs-b", Enabled: true}
+	for _, rule := range []types.AlertRule{first, second, first} {
+		if result := e.executeRule(rule, true); result.Error == "" {
+			t.Fatalf("规则 %s 查询应返回认证错误", rule.Name)
+		}
+	}
+
+	// 每条规则各发一次自监控告警，同一规则在去重窗口内不重复发送
+	if got := atomic.LoadInt32(sent); got != 2 {
+		t.Errorf("应按规则各发送 1 条认证告警，实际 %d 条", got)
+	}
+}
@@ -0,0 +1,118 @@
+package alert
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+// switchableHits 命中数可在运行中修改的 OpenSearch 桩
+type switchableHits struct {
+	total int32
+}
+
+func (s *switchableHits) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	total := atomic.LoadInt32(&s.total)
+	if strings.HasSuffix(r.URL.Path, "/_count") {
+		fmt.Fprintf(w, `{"count": %d}`, total)
+		return
+	}
+	fmt.Fprintf(w, `{"hits": {"total": {"value": %d, "relation": "eq"}, "hits": [{"_id": "1", "_source": {"message": "pod crashloop"}}]}}`, total)
+}
+
+func TestAutoResolveFireThenResolve(t *testing.T) {
+	var mu sync.Mutex
+	var tags []string
+	ntfy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tags = append(tags, r.Header.Get("Tags"))
+		mu.Unlock()
+	}))
+	t.Cleanup(ntfy.Close)
+	sentTags := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), tags...)
+	}
+
+	config := &types.Config{}
+	config.Notifications.Ntfy = types.NtfyConfig{Enabled: true, ServerURL: ntfy.URL, Topic: "alerts"}
+	stub := &switchableHits{total: 2}
+	e := newStubDBEngine(t, config, stub)
+	rule := types.AlertRule{Name: "events", Type: "any", Index: "events-*", Timeframe: 300, Level: "High", Enabled: true, AutoResolve: true}
+
+	// 条件成立：触发告警并记录为未恢复
+	result := e.executeRule(rule, false)
+	if !result.Fired {
+		t.Fatalf("命中 2 条时应触发，实际 %+v", result)
+	}
+	alertID := result.Alert.ID
+	if open, err := e.database.GetOpenAlert(rule.Name); err != nil || open != alertID {
+		t.Fatalf("未恢复告警 = %q（%v），期望 %s", open, err, alertID)
+	}
+
+	// 条件仍成立：不重复恢复，也不重复发送
+	e.executeRule(rule, false)
+	if got := sentTags(); len(got) != 1 {
+		t.Fatalf("条件持续期间应只发送 1 次，实际 %v", got)
+	}
+
+	// 条件解除：发送恢复通知，标记历史记录并清除未恢复状态
+	atomic.StoreInt32(&stub.total, 0)
+	if result := e.executeRule(rule, false); result.Fired {
+		t.Errorf("条件解除时不应触发新告警: %+v", result)
+	}
+	got := sentTags()
+	if len(got) != 2 || !strings.Contains(got[1], "white_check_mark") || !strings.Contains(got[1], "resolved") {
+		t.Fatalf("应发送一条恢复通知，实际标签 %v", got)
+	}
+	detail, err := e.database.GetAlertByID(alertID)
+	if err != nil || detail == nil {
+		t.Fatalf("查询告警失败: %v", err)
+	}
+	if !detail.Resolved || detail.ResolvedAt == nil {
+		t.Errorf("告警应标记为已恢复: %+v", detail)
+	}
+	if open, _ := e.database.GetOpenAlert(rule.Name); open != "" {
+		t.Errorf("恢复后不应保留未恢复告警，实际 %q", open)
+	}
+
+	// 已恢复后再次运行不重复发送恢复通知
+	e.executeRule(rule, false)
+	if got := sentTags(); len(got) != 2 {
+		t.Errorf("已恢复时不应再发送通知，实际 %v", got)
+	}
+
+	// 问题再次出现时立即告警（恢复清除了去重与抑制）
+	atomic.StoreInt32(&stub.total, 5)
+	if result := e.executeRule(rule, false); !result.Fired || result.Alert.ID == alertID {
+		t.Errorf("再次出现时应触发新告警，实际 %+v", result)
+	}
+}
+
+func TestAutoResolveDisabledDoesNotResolve(t *testing.T) {
+	config, sent := countingNtfyConfig(t)
+	stub := &switchableHits{total: 2}
+	e := newStubDBEngine(t, config, stub)
+	rule := types.AlertRule{Name: "plain", Type: "any", Index: "events-*", Timeframe: 300, Level: "High", Enabled: true}
+
+	result := e.executeRule(rule, false)
+	if !result.Fired {
+		t.Fatalf("应触发告警: %+v", result)
+	}
+	atomic.StoreInt32(&stub.total, 0)
+	e.executeRule(rule, false)
+
+	if got := atomic.LoadInt32(sent); got != 1 {
+		t.Errorf("未开启 auto_resolve 时不应发送恢复通知，实际发送 %d 次", got)
+	}
+	if open, _ := e.database.GetOpenAlert(rule.Name); open != "" {
+		t.Errorf("未开启 auto_resolve 时不应记录未恢复告警，实际 %q", open)
+	}
+}
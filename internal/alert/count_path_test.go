@@ -0,0 +1,144 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"opensearch-alert/internal/opensearch"
+	"opensearch-alert/pkg/types"
+)
+
+// countStub 记录请求的 OpenSearch 桩：_count 返回 count，_search 返回 1 条样本且总数封顶 10000
+type countStub struct {
+	count int
+
+	mu       sync.Mutex
+	paths    []string
+	requests []map[string]interface{}
+}
+
+func (s *countStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var req map[string]interface{}
+	json.Unmarshal(body, &req)
+
+	s.mu.Lock()
+	s.paths = append(s.paths, r.URL.Path)
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	if strings.HasSuffix(r.URL.Path, "/_count") {
+		fmt.Fprintf(w, `{"count": %d}`, s.count)
+		return
+	}
+	w.Write([]byte(`{"hits": {"total": {"value": 10000, "relation": "gte"}, "hits": [{"_id": "1", "_source": {"log": "sample"}}]}}`))
+}
+
+// endpoints 返回按顺序请求的接口（_count/_search）
+func (s *countStub) endpoints() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var endpoints []string
+	for _, path := range s.paths {
+		endpoints = append(endpoints, path[strings.LastIndex(path, "/")+1:])
+	}
+	return endpoints
+}
+
+func TestUseCountPath(t *testing.T) {
+	tests := []struct {
+		rule types.AlertRule
+		want bool
+	}{
+		{types.AlertRule{Type: "frequency"}, true},
+		{types.AlertRule{Type: "any"}, true},
+		{types.AlertRule{Type: "frequency", FetchAll: true}, false},
+		{types.AlertRule{Type: "any", AlertTextType: "go_template"}, false},
+		{types.AlertRule{Type: "spike"}, false},
+		{types.AlertRule{Type: "flatline"}, false},
+		{types.AlertRule{Type: "metric"}, false},
+	}
+	for _, tt := range tests {
+		if got := useCountPath(tt.rule); got != tt.want {
+			t.Errorf("useCountPath(%+v) = %v, 期望 %v", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestCountThenSample(t *testing.T) {
+	zero := 0
+	tests := []struct {
+		name          string
+		rule          types.AlertRule
+		count         int
+		wantEndpoints string
+		wantHits      int
+	}{
+		{"未达到阈值只计数", types.AlertRule{Type: "frequency", Threshold: 50}, 49, "_count", 0},
+		{"达到阈值拉取 1 条样本", types.AlertRule{Type: "frequency", Threshold: 50}, 25000, "_count,_search", 1},
+		{"any 无命中只计数", types.AlertRule{Type: "any"}, 0, "_count", 0},
+		{"max_hits 为 0 不拉取样本", types.AlertRule{Type: "any", MaxHits: &zero}, 3, "_count", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &countStub{count: tt.count}
+			e := newTestEngine(t, stub)
+			rule := tt.rule
+			rule.Name = "count"
+			rule.Index = "logs-*"
+			rule.Timeframe = 300
+
+			query := e.opensearchClient.BuildWindowQuery(rule, opensearch.SlidingWindow(rule, time.Now()))
+			response, err := e.countThenSample(context.Background(), rule, query)
+			if err != nil {
+				t.Fatalf("countThenSample 失败: %v", err)
+			}
+
+			if got := strings.Join(stub.endpoints(), ","); got != tt.wantEndpoints {
+				t.Errorf("请求接口 = %s, 期望 %s", got, tt.wantEndpoints)
+			}
+			// 总数以 _count 为准，不受 _search 的总数上限影响
+			if response.Hits.Total.Value != tt.count || response.Hits.Total.Relation != "eq" {
+				t.Errorf("总数 = %d (%s), 期望 %d (eq)", response.Hits.Total.Value, response.Hits.Total.Relation, tt.count)
+			}
+			if got := len(response.Hits.Hits); got != tt.wantHits {
+				t.Errorf("样本文档数 = %d, 期望 %d", got, tt.wantHits)
+			}
+
+			// _count 请求只包含 query（_count 不接受 size/sort）
+			countReq := stub.requests[0]
+			if _, ok := countReq["query"]; !ok || len(countReq) != 1 {
+				t.Errorf("_count 请求体应只包含 query，实际 %v", countReq)
+			}
+			if len(stub.requests) > 1 && stub.requests[1]["size"] != float64(1) {
+				t.Errorf("样本查询 size = %v, 期望 1", stub.requests[1]["size"])
+			}
+		})
+	}
+}
+
+func TestExecuteRuleUsesCountPath(t *testing.T) {
+	stub := &countStub{count: 2}
+	e := newTestEngine(t, stub)
+	e.database = newTestDatabase(t)
+	rule := types.AlertRule{Name: "count", Type: "frequency", Index: "logs-*", Timeframe: 300, Threshold: 5, Enabled: true}
+	e.LoadRules([]types.AlertRule{rule})
+
+	result := e.executeRule(rule, false)
+	if result.Error != "" {
+		t.Fatalf("执行失败: %s", result.Error)
+	}
+	if result.Hits != 2 || result.Fired {
+		t.Errorf("执行结果不符: %+v", result)
+	}
+	if got := strings.Join(stub.endpoints(), ","); got != "_count" {
+		t.Errorf("未达到阈值时只应请求 _count，实际 %s", got)
+	}
+}
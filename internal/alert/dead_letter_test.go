@@ -0,0 +1,69 @@
+package alert
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+// ntfyConfig 返回指向 ntfy 桩服务的配置
+func ntfyConfig(t *testing.T, status int) *types.Config {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	config := &types.Config{}
+	config.Notifications.Ntfy = types.NtfyConfig{Enabled: true, ServerURL: server.URL, Topic: "alerts"}
+	return config
+}
+
+func TestTriggerAlertWritesDeadLetterWhenAllChannelsFail(t *testing.T) {
+	e := newDBTestEngine(t, ntfyConfig(t, http.StatusServiceUnavailable))
+	rule := types.AlertRule{Name: "dead-letter", Type: "any", Index: "logs-*", Level: "High"}
+
+	alert := e.triggerAlert(rule, hitsResponse(map[string]interface{}{"log": "boom"}), false)
+	if alert == nil {
+		t.Fatal("应生成告警")
+	}
+
+	failed, total, err := e.database.ListFailedAlerts(1, 20)
+	if err != nil {
+		t.Fatalf("列出死信失败: %v", err)
+	}
+	if total != 1 || failed[0].AlertID != alert.ID || failed[0].Errors["ntfy"] == "" {
+		t.Fatalf("所有渠道失败时应写入死信: total=%d %+v", total, failed)
+	}
+
+	results, err := e.database.GetNotificationResults(alert.ID)
+	if err != nil || len(results) != 1 || results[0].Success {
+		t.Errorf("应记录失败的发送结果: %+v %v", results, err)
+	}
+}
+
+func TestTriggerAlertSkipsDeadLetterOnSuccess(t *testing.T) {
+	e := newDBTestEngine(t, ntfyConfig(t, http.StatusOK))
+	rule := types.AlertRule{Name: "delivered", Type: "any", Index: "logs-*", Level: "High"}
+
+	if e.triggerAlert(rule, hitsResponse(map[string]interface{}{"log": "ok"}), false) == nil {
+		t.Fatal("应生成告警")
+	}
+	if _, total, _ := e.database.ListFailedAlerts(1, 20); total != 0 {
+		t.Errorf("发送成功时不应写入死信，实际 %d 条", total)
+	}
+}
+
+func TestTriggerAlertSkipsDeadLetterWithoutChannels(t *testing.T) {
+	e := newDBTestEngine(t, &types.Config{})
+	rule := types.AlertRule{Name: "no-channels", Type: "any", Index: "logs-*"}
+
+	if e.triggerAlert(rule, hitsResponse(map[string]interface{}{"log": "x"}), false) == nil {
+		t.Fatal("应生成告警")
+	}
+	if _, total, _ := e.database.ListFailedAlerts(1, 20); total != 0 {
+		t.Errorf("没有启用渠道时不应写入死信，实际 %d 条", total)
+	}
+}
@@ -0,0 +1,91 @@
+package alert
+
+import (
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestDedupeTTLPrecedence(t *testing.T) {
+	config := &types.Config{}
+	e := NewEngine(config, nil, nil, nil, newTestLogger())
+
+	if got := e.dedupeTTL(types.AlertRule{}); got != defaultDedupeTTL {
+		t.Errorf("未配置时 dedupeTTL = %d, 期望 %d", got, defaultDedupeTTL)
+	}
+	config.AlertEngine.DedupeTTL = 600
+	if got := e.dedupeTTL(types.AlertRule{}); got != 600 {
+		t.Errorf("全局配置 dedupeTTL = %d, 期望 600", got)
+	}
+	if got := e.dedupeTTL(types.AlertRule{DedupeTTL: 30}); got != 30 {
+		t.Errorf("规则配置应优先，dedupeTTL = %d, 期望 30", got)
+	}
+}
+
+func TestQueryKeyValue(t *testing.T) {
+	e := NewEngine(&types.Config{}, nil, nil, nil, newTestLogger())
+	response := hitsResponse(map[string]interface{}{
+		"host":       "web-1",
+		"kubernetes": map[string]interface{}{"namespace_name": "prod"},
+	})
+
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"host"}, "host=web-1"},
+		{[]string{"host", "kubernetes.namespace_name"}, "host=web-1,kubernetes.namespace_name=prod"},
+		{[]string{"missing"}, "missing="},
+	}
+	for _, tt := range tests {
+		if got := e.queryKeyValue(types.AlertRule{QueryKey: tt.keys}, response); got != tt.want {
+			t.Errorf("queryKeyValue(%v) = %q, 期望 %q", tt.keys, got, tt.want)
+		}
+	}
+	if got := e.queryKeyValue(types.AlertRule{QueryKey: []string{"host"}}, hitsResponse()); got != "" {
+		t.Errorf("无命中时应返回空，实际 %q", got)
+	}
+}
+
+func TestTriggerAlertDedupeByQueryKey(t *testing.T) {
+	e := newDBTestEngine(t, &types.Config{})
+	rule := types.AlertRule{Name: "dedupe", Type: "any", Index: "logs-*", Level: "High", QueryKey: []string{"host"}, DedupeTTL: 3600}
+
+	web1 := hitsResponse(map[string]interface{}{"host": "web-1", "log": "first"})
+	alert := e.triggerAlert(rule, web1, false)
+	if alert == nil {
+		t.Fatal("首次触发应发送告警")
+	}
+	if alert.Data["query_key"] != "host=web-1" {
+		t.Errorf("告警数据应记录 query_key，实际 %v", alert.Data["query_key"])
+	}
+
+	// 同一对象即使消息不同也在 TTL 内去重
+	if e.triggerAlert(rule, hitsResponse(map[string]interface{}{"host": "web-1", "log": "second"}), false) != nil {
+		t.Error("同一 query_key 在 TTL 内应去重")
+	}
+	// 不同对象互不影响
+	if e.triggerAlert(rule, hitsResponse(map[string]interface{}{"host": "web-2", "log": "first"}), false) == nil {
+		t.Error("不同 query_key 的告警不应被去重")
+	}
+	// 手动强制执行不受去重限制
+	if e.triggerAlert(rule, web1, true) == nil {
+		t.Error("强制执行应跳过去重")
+	}
+}
+
+func TestTriggerAlertDedupeByMessage(t *testing.T) {
+	e := newDBTestEngine(t, &types.Config{})
+	rule := types.AlertRule{Name: "dedupe", Type: "any", Index: "logs-*", Level: "High", AlertText: "${log}", TemplateMode: "replace"}
+
+	if e.triggerAlert(rule, hitsResponse(map[string]interface{}{"log": "a"}), false) == nil {
+		t.Fatal("首次触发应发送告警")
+	}
+	if e.triggerAlert(rule, hitsResponse(map[string]interface{}{"log": "a"}), false) != nil {
+		t.Error("未配置 query_key 时相同消息应去重")
+	}
+	if e.triggerAlert(rule, hitsResponse(map[string]interface{}{"log": "b"}), false) == nil {
+		t.Error("未配置 query_key 时不同消息不应去重")
+	}
+}
@@ -0,0 +1,152 @@
+package alert
+
+import (
+	"fmt"
+	"opensearch-alert/pkg/types"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxDigestKeys 汇总消息中最多列出的不同 query_key 值
+const maxDigestKeys = 20
+
+// ruleDigest 规则在汇总窗口内缓冲的告警
+type ruleDigest struct {
+	rule   types.AlertRule
+	alerts []*types.Alert
+	keys   []string
+	timer  *time.Timer
+}
+
+// bufferDigest 将告警加入规则的汇总缓冲，首条告警启动窗口定时器，窗口结束时合并发送
+func (e *Engine) bufferDigest(rule types.AlertRule, alert *types.Alert, key string) {
+	e.digestMutex.Lock()
+	defer e.digestMutex.Unlock()
+
+	digest := e.digests[rule.Name]
+	if digest == nil {
+		digest = &ruleDigest{rule: rule}
+		e.digests[rule.Name] = digest
+		window := time.Duration(rule.DigestSeconds) * time.Second
+		digest.timer = time.AfterFunc(window, func() { e.flushDigest(rule.Name) })
+		e.logger.Infof("规则 %s 开启汇总，%s 后合并发送", rule.Name, window)
+	}
+	digest.alerts = append(digest.alerts, alert)
+	if key != "" && !containsString(digest.keys, key) {
+		digest.keys = append(digest.keys, key)
+	}
+	e.logger.Debugf("规则 %s 告警 %s 已加入汇总（当前 %d 条）", rule.Name, alert.ID, len(digest.alerts))
+}
+
+// flushDigest 取出规则的汇总缓冲并发送一条汇总通知
+func (e *Engine) flushDigest(ruleName string) {
+	e.digestMutex.Lock()
+	digest := e.digests[ruleName]
+	delete(e.digests, ruleName)
+	e.digestMutex.Unlock()
+
+	if digest == nil || len(digest.alerts) == 0 {
+		return
+	}
+	digest.timer.Stop()
+
+	alert := e.buildDigestAlert(digest)
+	results, err := e.notifier.SendAlert(alert)
+	if err != nil {
+		e.logger.Errorf("发送规则 %s 汇总通知失败: %v", ruleName, err)
+	}
+	// 汇总通知的发送结果记录到其包含的每条告警上
+	for _, buffered := range digest.alerts {
+		e.saveNotificationResults(buffered.ID, results)
+	}
+	e.saveFailedAlert(alert, results)
+	e.logger.Infof("规则 %s 汇总通知已发送，包含 %d 条告警", ruleName, len(digest.alerts))
+}
+
+// flushAllDigests 立即发送全部规则的汇总缓冲（引擎停止时调用）
+func (e *Engine) flushAllDigests() {
+	e.digestMutex.Lock()
+	names := make([]string, 0, len(e.digests))
+	for name := range e.digests {
+		names = append(names, name)
+	}
+	e.digestMutex.Unlock()
+
+	sort.Strings(names)
+	for _, name := range names {
+		e.flushDigest(name)
+	}
+}
+
+// buildDigestAlert 将缓冲的告警合并为一条汇总告警：次数、不同 query_key 值、首条与末条示例
+func (e *Engine) buildDigestAlert(digest *ruleDigest) *types.Alert {
+	alerts := digest.alerts
+	first, last := alerts[0], alerts[len(alerts)-1]
+
+	level := first.Level
+	total := 0
+	ids := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		if types.LevelRank(alert.Level) > types.LevelRank(level) {
+			level = alert.Level
+		}
+		total += alert.Count
+		ids = append(ids, alert.ID)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "规则 **%s** 在 %d 秒内触发 %d 次告警，累计匹配 %d 条记录。\n\n", digest.rule.Name, digest.rule.DigestSeconds, len(alerts), total)
+	fmt.Fprintf(&b, "**时间范围:** %s ~ %s\n",
+		first.Timestamp.In(e.config.Location()).Format("2006-01-02 15:04:05"),
+		last.Timestamp.In(e.config.Location()).Format("2006-01-02 15:04:05"))
+	if len(digest.keys) > 0 {
+		keys := digest.keys
+		more := ""
+		if len(keys) > maxDigestKeys {
+			more = fmt.Sprintf(" 等 %d 个", len(keys))
+			keys = keys[:maxDigestKeys]
+		}
+		fmt.Fprintf(&b, "**不同对象（query_key）:** %s%s\n", strings.Join(keys, "; "), more)
+	}
+	fmt.Fprintf(&b, "\n---\n**首条告警:**\n\n%s", first.Message)
+	if len(alerts) > 1 {
+		fmt.Fprintf(&b, "\n\n---\n**末条告警:**\n\n%s", last.Message)
+	}
+
+	data := map[string]interface{}{
+		"digest":           true,
+		"digest_count":     len(alerts),
+		"digest_alert_ids": ids,
+	}
+	if len(digest.keys) > 0 {
+		data["distinct_keys"] = digest.keys
+	}
+	if sample, ok := last.Data["sample_hit"]; ok {
+		data["sample_hit"] = sample
+	}
+	if fields, ok := last.Data["fields"]; ok {
+		data["fields"] = fields
+	}
+
+	return &types.Alert{
+		ID:        types.NewAlertID(digest.rule.Name + "-digest"),
+		RuleName:  digest.rule.Name,
+		Level:     level,
+		Message:   b.String(),
+		Timestamp: time.Now(),
+		Data:      data,
+		Count:     total,
+		Matches:   len(alerts),
+	}
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package alert
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// countingNtfyConfig 返回指向 ntfy 桩服务的配置，并统计收到的通知数
+func countingNtfyConfig(t *testing.T) (*types.Config, *int32) {
+	t.Helper()
+	var sent int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&sent, 1)
+	}))
+	t.Cleanup(server.Close)
+
+	config := &types.Config{}
+	config.Notifications.Ntfy = types.NtfyConfig{Enabled: true, ServerURL: server.URL, Topic: "alerts"}
+	return config, &sent
+}
+
+func TestDigestBuffersUntilFlush(t *testing.T) {
+	config, sent := countingNtfyConfig(t)
+	e := newDBTestEngine(t, config)
+	rule := types.AlertRule{Name: "digest", Type: "any", Index: "logs-*", Level: "Medium", QueryKey: []string{"host"}, DigestSeconds: 3600}
+
+	var ids []string
+	for i, host := range []string{"a", "b", "a"} {
+		alert := e.triggerAlert(rule, hitsResponse(map[string]interface{}{"host": host, "seq": i}), true)
+		if alert == nil {
+			t.Fatalf("第 %d 次应生成告警", i+1)
+		}
+		ids = append(ids, alert.ID)
+	}
+	// 手动强制执行立即发送，不进入汇总
+	if got := atomic.LoadInt32(sent); got != 3 {
+		t.Fatalf("强制执行应立即发送，实际 %d 次", got)
+	}
+
+	atomic.StoreInt32(sent, 0)
+	ids = ids[:0]
+	for i, host := range []string{"c", "d", "e"} {
+		alert := e.triggerAlert(rule, hitsResponse(map[string]interface{}{"host": host, "seq": i + 10}), false)
+		if alert == nil {
+			t.Fatalf("第 %d 条缓冲告警应生成", i+1)
+		}
+		ids = append(ids, alert.ID)
+	}
+	if got := atomic.LoadInt32(sent); got != 0 {
+		t.Fatalf("汇总窗口内不应发送，实际 %d 次", got)
+	}
+
+	e.flushDigest(rule.Name)
+	if got := atomic.LoadInt32(sent); got != 1 {
+		t.Fatalf("窗口结束应合并为 1 条通知，实际 %d 次", got)
+	}
+	for _, id := range ids {
+		results, err := e.database.GetNotificationResults(id)
+		if err != nil || len(results) != 1 || !results[0].Success {
+			t.Errorf("告警 %s 应记录汇总的发送结果: %+v %v", id, results, err)
+		}
+	}
+
+	// 缓冲已清空，重复 flush 不再发送
+	e.flushDigest(rule.Name)
+	if got := atomic.LoadInt32(sent); got != 1 {
+		t.Errorf("重复 flush 不应再次发送，实际 %d 次", got)
+	}
+}
+
+func TestFlushAllDigests(t *testing.T) {
+	config, sent := countingNtfyConfig(t)
+	e := newDBTestEngine(t, config)
+	for _, name := range []string{"first", "second"} {
+		rule := types.AlertRule{Name: name, Type: "any", Index: "logs-*", DigestSeconds: 3600}
+		if e.triggerAlert(rule, hitsResponse(map[string]interface{}{"log": name}), false) == nil {
+			t.Fatalf("规则 %s 应生成告警", name)
+		}
+	}
+
+	e.flushAllDigests()
+	if got := atomic.LoadInt32(sent); got != 2 {
+		t.Errorf("每条规则应各发送 1 条汇总通知，实际 %d 次", got)
+	}
+	if len(e.digests) != 0 {
+		t.Errorf("flush 后应清空全部汇总缓冲，剩余 %d 条", len(e.digests))
+	}
+}
+
+func TestBuildDigestAlert(t *testing.T) {
+	e := NewEngine(&types.Config{}, nil, nil, nil, newTestLogger())
+	rule := types.AlertRule{Name: "digest", DigestSeconds: 300}
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	digest := &ruleDigest{rule: rule}
+	levels := []string{"Low", "Critical", "Medium"}
+	for i, level := range levels {
+		digest.alerts = append(digest.alerts, &types.Alert{
+			ID:        fmt.Sprintf("alert-%d", i),
+			Level:     level,
+			Message:   fmt.Sprintf("message-%d", i),
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Count:     i + 1,
+			Data:      map[string]interface{}{"sample_hit": i},
+		})
+	}
+	for i := 0; i < maxDigestKeys+5; i++ {
+		digest.keys = append(digest.keys, fmt.Sprintf("host-%02d", i))
+	}
+
+	alert := e.buildDigestAlert(digest)
+	if alert.Level != "Critical" {
+		t.Errorf("汇总级别应取最高级别，实际 %s", alert.Level)
+	}
+	if alert.Count != 6 || alert.Matches != 3 {
+		t.Errorf("Count/Matches = %d/%d，期望 6/3", alert.Count, alert.Matches)
+	}
+	if alert.Data["digest_count"] != 3 || alert.Data["sample_hit"] != 2 {
+		t.Errorf("汇总数据不正确: %+v", alert.Data)
+	}
+	for _, want := range []string{"触发 3 次告警", "message-0", "message-2", "host-19", fmt.Sprintf("等 %d 个", maxDigestKeys+5)} {
+		if !strings.Contains(alert.Message, want) {
+			t.Errorf("汇总消息应包含 %q:\n%s", want, alert.Message)
+		}
+	}
+	if strings.Contains(alert.Message, "message-1") || strings.Contains(alert.Message, "host-20") {
+		t.Errorf("汇总消息只应包含首末条示例与前 %d 个 query_key:\n%s", maxDigestKeys, alert.Message)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"opensearch-alert/internal/opensearch"
 	"opensearch-alert/pkg/types"
 	"os"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -24,34 +26,133 @@ type Engine struct {
 	database         *database.Database
 	templateEngine   *TemplateEngine
 	rules            []types.AlertRule
+	rulesMutex       sync.RWMutex
 	alertStatuses    map[string]*types.AlertStatus
 	statusMutex      sync.RWMutex
 	logger           *logrus.Logger
 	cron             *cron.Cron
+	stopCh           chan struct{}
+	pendingAlerts    []*types.Alert
+	pendingMutex     sync.Mutex
+	ruleErrors       map[string]*RuleErrorState
+	ruleErrorMutex   sync.RWMutex
+	ruleEntries      map[string]cron.EntryID
+	runningRules     map[string]bool
+	scheduleMutex    sync.Mutex
+	digests          map[string]*ruleDigest
+	digestMutex      sync.Mutex
+	alertListeners   []func(alert *types.Alert)
+	listenerMutex    sync.RWMutex
+	// indexChecks 各规则索引是否存在的检查结果，避免频繁检查与重复输出日志
+	indexChecks map[string]indexCheck
+	indexMutex  sync.Mutex
+	// windowCounts spike 规则各窗口的命中数，参考窗口与之前的当前窗口重合时复用
+	windowCounts *windowCountCache
+	// overlapTails 增量规则上一窗口尾部（重叠部分）已统计的文档，下一轮按 _id 排除
+	overlapTails map[string]overlapTail
+	overlapMutex sync.Mutex
 }
 
+const (
+	// alertFlushInterval 待落库告警的刷新间隔
+	alertFlushInterval = 2 * time.Second
+	// alertFlushSize 待落库告警达到该数量时立即刷新
+	alertFlushSize = 50
+)
+
 // NewEngine 创建新的告警引擎
 func NewEngine(config *types.Config, opensearchClient *opensearch.Client, notifier *notification.Notifier, database *database.Database, logger *logrus.Logger) *Engine {
+	templateEngine := NewTemplateEngine(config.Location(), config.LevelTable())
+	templateEngine.logSnippetLength = config.AlertEngine.LogSnippetLength
+
 	return &Engine{
 		config:           config,
 		opensearchClient: opensearchClient,
 		notifier:         notifier,
 		database:         database,
-		templateEngine:   NewTemplateEngine(),
+		templateEngine:   templateEngine,
 		alertStatuses:    make(map[string]*types.AlertStatus),
+		ruleErrors:       make(map[string]*RuleErrorState),
+		ruleEntries:      make(map[string]cron.EntryID),
+		runningRules:     make(map[string]bool),
+		digests:          make(map[string]*ruleDigest),
+		indexChecks:      make(map[string]indexCheck),
+		windowCounts:     newWindowCountCache(),
+		overlapTails:     make(map[string]overlapTail),
 		logger:           logger,
-		cron:             cron.New(cron.WithSeconds()),
+		cron:             cron.New(cron.WithParser(cronParser)),
+		stopCh:           make(chan struct{}),
 	}
 }
 
 // LoadRules 加载告警规则
 func (e *Engine) LoadRules(rules []types.AlertRule) {
+	e.rulesMutex.Lock()
+	e.logRuleChanges(e.rules, rules)
+	e.invalidateWindowCounts(e.rules, rules)
 	e.rules = rules
+	e.rulesMutex.Unlock()
 	e.logger.Infof("加载了 %d 个告警规则", len(rules))
+	e.scheduleRules(rules)
+
+	for _, rule := range rules {
+		if !hasScript(rule) {
+			continue
+		}
+		if e.config.OpenSearch.AllowScriptQueries {
+			e.logger.Warnf("规则 %s 使用了脚本过滤，查询开销较大，请控制时间窗口与执行频率", rule.Name)
+		} else {
+			e.logger.Warnf("规则 %s 使用了脚本过滤，但未开启 opensearch.allow_script_queries，该规则将被跳过", rule.Name)
+		}
+	}
+}
+
+// logRuleChanges 记录重新加载前后新增、删除与修改的规则
+func (e *Engine) logRuleChanges(oldRules, newRules []types.AlertRule) {
+	if oldRules == nil {
+		return
+	}
+
+	oldByName := make(map[string]types.AlertRule, len(oldRules))
+	for _, rule := range oldRules {
+		oldByName[rule.Name] = rule
+	}
+
+	var added, modified []string
+	for _, rule := range newRules {
+		old, ok := oldByName[rule.Name]
+		if !ok {
+			added = append(added, rule.Name)
+			continue
+		}
+		if !reflect.DeepEqual(old, rule) {
+			modified = append(modified, rule.Name)
+		}
+		delete(oldByName, rule.Name)
+	}
+	removed := make([]string, 0, len(oldByName))
+	for name := range oldByName {
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+
+	if len(added)+len(removed)+len(modified) == 0 {
+		e.logger.Info("规则重新加载：无变化")
+		return
+	}
+	e.logger.Infof("规则重新加载：新增 %v，删除 %v，修改 %v", added, removed, modified)
+}
+
+// hasScript 判断规则是否包含脚本过滤
+func hasScript(rule types.AlertRule) bool {
+	return rule.Script != nil && rule.Script.Source != ""
 }
 
 // Start 启动告警引擎
 func (e *Engine) Start() error {
+	// 恢复重启前的告警抑制状态
+	e.restoreAlertStatuses()
+
 	// 添加定时任务
 	_, err := e.cron.AddFunc(fmt.Sprintf("@every %ds", e.config.AlertEngine.RunInterval), e.runRules)
 	if err != nil {
@@ -59,6 +160,13 @@ func (e *Engine) Start() error {
 	}
 
 	e.cron.Start()
+
+	// 启动告警历史清理任务
+	go e.startAlertCleaner()
+
+	// 启动告警批量落库任务
+	go e.startAlertFlusher()
+
 	e.logger.Info("告警引擎已启动")
 	return nil
 }
@@ -66,24 +174,159 @@ func (e *Engine) Start() error {
 // Stop 停止告警引擎
 func (e *Engine) Stop() {
 	e.cron.Stop()
+	close(e.stopCh)
+	e.flushAllDigests()
+	e.flushAlerts()
 	e.logger.Info("告警引擎已停止")
 }
 
+// OnAlert 注册新告警回调（如 Web 实时推送），在告警保存后同步调用，回调不应阻塞
+func (e *Engine) OnAlert(fn func(alert *types.Alert)) {
+	e.listenerMutex.Lock()
+	e.alertListeners = append(e.alertListeners, fn)
+	e.listenerMutex.Unlock()
+}
+
+// notifyListeners 通知所有新告警回调
+func (e *Engine) notifyListeners(alert *types.Alert) {
+	e.listenerMutex.RLock()
+	listeners := e.alertListeners
+	e.listenerMutex.RUnlock()
+	for _, fn := range listeners {
+		fn(alert)
+	}
+}
+
+// startAlertFlusher 定期将缓冲的告警批量写入数据库
+func (e *Engine) startAlertFlusher() {
+	ticker := time.NewTicker(alertFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.flushAlerts()
+		case <-e.stopCh:
+			return
+		}
+	}
+}
+
+// saveAlert 将告警加入落库缓冲，缓冲满时立即刷新
+func (e *Engine) saveAlert(alert *types.Alert) {
+	e.pendingMutex.Lock()
+	e.pendingAlerts = append(e.pendingAlerts, alert)
+	full := len(e.pendingAlerts) >= alertFlushSize
+	e.pendingMutex.Unlock()
+
+	if full {
+		e.flushAlerts()
+	}
+}
+
+// flushAlerts 批量写入缓冲的告警，批量失败时逐条重试
+func (e *Engine) flushAlerts() {
+	e.pendingMutex.Lock()
+	alerts := e.pendingAlerts
+	e.pendingAlerts = nil
+	e.pendingMutex.Unlock()
+
+	if len(alerts) == 0 {
+		return
+	}
+
+	if err := e.database.SaveAlertBatch(alerts); err != nil {
+		e.logger.Warnf("批量保存告警失败，改为逐条保存: %v", err)
+		for _, alert := range alerts {
+			if err := e.database.SaveAlert(alert); err != nil {
+				e.logger.Errorf("保存告警到数据库失败: %v", err)
+			}
+		}
+	}
+}
+
+// startAlertCleaner 按 AlertTimeLimit 每小时清理一次过期告警历史
+func (e *Engine) startAlertCleaner() {
+	e.cleanExpiredAlerts()
+
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.cleanExpiredAlerts()
+			e.cleanExpiredSilences()
+		case <-e.stopCh:
+			return
+		}
+	}
+}
+
+// cleanExpiredAlerts 执行一次告警历史清理
+func (e *Engine) cleanExpiredAlerts() {
+	n, err := e.database.CleanExpiredAlerts(e.config.AlertEngine.AlertTimeLimit)
+	if err != nil {
+		e.logger.Errorf("清理过期告警历史失败: %v", err)
+		return
+	}
+	if n > 0 {
+		e.logger.Infof("已清理 %d 条过期告警历史（保留 %d 秒）", n, e.config.AlertEngine.AlertTimeLimit)
+	}
+}
+
+// cleanExpiredSilences 清理已结束的一次性静默窗口
+func (e *Engine) cleanExpiredSilences() {
+	n, err := e.database.CleanExpiredSilences()
+	if err != nil {
+		e.logger.Warnf("清理过期静默失败: %v", err)
+		return
+	}
+	if n > 0 {
+		e.logger.Infof("已清理 %d 条已结束的静默窗口", n)
+	}
+}
+
 // runRules 运行所有规则
 func (e *Engine) runRules() {
 	e.logger.Debug("开始执行告警规则检查")
 
-	for _, rule := range e.rules {
+	e.rulesMutex.RLock()
+	rules := e.rules
+	e.rulesMutex.RUnlock()
+
+	for _, rule := range rules {
+		// 配置了独立调度的规则由各自的定时任务执行
+		if rule.Schedule != "" {
+			continue
+		}
 		go e.runRule(rule)
 	}
 }
 
 // runRule 运行单个规则
 func (e *Engine) runRule(rule types.AlertRule) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	e.executeRule(rule, false)
+}
+
+// executeRule 执行一次规则并返回结果；force 为 true 时跳过告警抑制与去重（用于手动执行）
+func (e *Engine) executeRule(rule types.AlertRule, force bool) *RuleRunResult {
+	ctx, cancel := context.WithTimeout(context.Background(), e.queryTimeout(rule))
 	defer cancel()
 
 	e.logger.Debugf("执行规则: %s", rule.Name)
+	result := &RuleRunResult{Rule: rule.Name}
+	skip := func(reason string) *RuleRunResult {
+		e.logger.Debugf("规则 %s %s", rule.Name, reason)
+		result.Skipped = reason
+		return result
+	}
+
+	// 同一实例内避免上一轮未结束时重复执行
+	if !e.tryStartRule(rule.Name) {
+		return skip("上一轮仍在执行，跳过本轮")
+	}
+	defer e.finishRule(rule.Name)
 
 	// 多副本互斥：获取规则级租约锁
 	instanceID := getInstanceID()
@@ -91,11 +334,11 @@ func (e *Engine) runRule(rule types.AlertRule) {
 	locked, err := e.database.AcquireRuleLock(rule.Name, instanceID, ttl)
 	if err != nil {
 		e.logger.Warnf("获取规则锁失败 %s: %v", rule.Name, err)
-		return
+		result.Error = fmt.Sprintf("获取规则锁失败: %v", err)
+		return result
 	}
 	if !locked {
-		e.logger.Debugf("规则 %s 未获得锁，跳过本轮", rule.Name)
-		return
+		return skip("未获得锁，跳过本轮")
 	}
 	defer func() {
 		if err := e.database.ReleaseRuleLock(rule.Name, instanceID); err != nil {
@@ -103,26 +346,236 @@ func (e *Engine) runRule(rule types.AlertRule) {
 		}
 	}()
 
-	// 检查告警抑制
-	if e.isSuppressed(rule.Name) {
-		e.logger.Debugf("规则 %s 被抑制", rule.Name)
-		return
+	// 脚本过滤需显式开启
+	if hasScript(rule) && !e.config.OpenSearch.AllowScriptQueries {
+		return skip("使用脚本过滤但未开启 allow_script_queries，跳过")
+	}
+
+	// 连续查询失败已被标记为出错的规则暂停执行
+	if e.isRuleErrored(rule.Name) {
+		return skip("已标记为出错，跳过")
+	}
+
+	// 维护/静默窗口内不查询、不告警
+	if silence := e.activeSilence(rule.Name); silence != nil {
+		return skip(fmt.Sprintf("处于静默窗口（%s），跳过", silence.Comment))
+	}
+
+	// 检查告警抑制；auto_resolve 规则抑制期内仍需查询以判断是否恢复
+	suppressed := !force && e.isSuppressed(rule.Name)
+	if suppressed && !rule.AutoResolve {
+		return skip("被抑制")
 	}
 
-	// 构建查询
-	query := e.opensearchClient.BuildTimeRangeQuery(rule, e.config.AlertEngine.BufferTime)
+	// 构建查询：增量规则从上次查询的终点接续
+	window, ok := e.queryWindow(rule, time.Now())
+	if !ok {
+		return skip("增量窗口为空，等待下一轮")
+	}
+	query := e.opensearchClient.BuildWindowQuery(rule, window)
 
 	// 执行查询
-	response, err := e.opensearchClient.Search(ctx, rule.Index, query)
+	var response *types.OpenSearchResponse
+	if useCountPath(rule) {
+		response, err = e.countThenSample(ctx, rule, query)
+	} else {
+		response, err = e.search(ctx, rule, query)
+	}
 	if err != nil {
+		result.Error = fmt.Sprintf("查询失败: %v", err)
+		if opensearch.IsNotFound(err) {
+			e.checkRuleIndex(ctx, rule, true)
+		}
+		if opensearch.IsAuthError(err) {
+			e.logger.Errorf("规则 %s 查询被拒绝，请检查 OpenSearch 凭据及索引 %s 的访问权限: %v", rule.Name, opensearch.RuleIndex(rule), err)
+			e.sendMetaAlert("opensearch-auth-"+rule.Name,
+				fmt.Sprintf("规则 **%s** 查询索引 `%s` 时认证/授权失败，请检查 OpenSearch 凭据及索引权限。\n\n错误: %v", rule.Name, opensearch.RuleIndex(rule), err))
+			return result
+		}
 		e.logger.Errorf("规则 %s 查询失败: %v", rule.Name, err)
-		return
+		if opensearch.IsQueryError(err) {
+			e.recordRuleFailure(rule.Name, err)
+		}
+		return result
+	}
+	result.Hits = response.Hits.Total.Value
+
+	// spike 规则需要参考窗口（紧邻的上一窗口）的命中数作对比
+	if rule.Type == "spike" {
+		result.Reference, err = e.referenceCount(ctx, rule, window)
+		if err != nil {
+			result.Error = fmt.Sprintf("参考窗口查询失败: %v", err)
+			e.logger.Errorf("规则 %s 参考窗口查询失败: %v", rule.Name, err)
+			return result
+		}
+	}
+	e.clearRuleFailure(rule.Name)
+	e.recordQueryWindow(ctx, rule, window)
+	e.recordWindowCount(rule, window, result.Hits)
+	e.recordPeakHits(rule, result.Hits)
+	if result.Hits == 0 {
+		e.checkRuleIndex(ctx, rule, false)
+	} else {
+		e.markIndexFound(rule)
+	}
+
+	// 对比类规则预热期内只累计基线，不告警
+	if e.inWarmup(rule) {
+		result.Skipped = "预热中，仅累计基线"
+		return result
 	}
 
 	// 检查是否触发告警
-	if e.shouldTriggerAlert(rule, response) {
-		e.triggerAlert(rule, response)
+	if !e.shouldTriggerAlert(rule, response, result.Reference) {
+		if !e.minHitsMet(rule, result.Hits) {
+			e.logger.Debugf("规则 %s 命中 %d 条，未达到 min_hits %d，不告警", rule.Name, result.Hits, rule.MinHits)
+			result.Skipped = "未达到 min_hits"
+		}
+		if rule.AutoResolve {
+			e.resolveRule(rule, response)
+		}
+		return result
+	}
+	if suppressed {
+		return skip("被抑制")
+	}
+	result.Alert = e.triggerAlert(rule, response, force)
+	result.Fired = result.Alert != nil
+	if !result.Fired {
+		result.Skipped = "去重命中，未发送"
+	}
+	return result
+}
+
+// search 执行规则查询，开启 fetch_all 的规则分页收集全部匹配文档
+func (e *Engine) search(ctx context.Context, rule types.AlertRule, query map[string]interface{}) (*types.OpenSearchResponse, error) {
+	if rule.FetchAll {
+		return e.opensearchClient.SearchAll(ctx, opensearch.RuleIndex(rule), query, e.config.AlertEngine.MaxFetchHits)
+	}
+	return e.opensearchClient.Search(ctx, opensearch.RuleIndex(rule), query)
+}
+
+// useCountPath 判断规则是否只需总数即可判定：frequency/any 规则且消息只用到首条命中
+func useCountPath(rule types.AlertRule) bool {
+	if rule.FetchAll || rule.AlertTextType == "go_template" {
+		return false
+	}
+	return rule.Type == "frequency" || rule.Type == "any"
+}
+
+// countThenSample 先用 _count 获取总数判定是否告警，仅在需要告警时再拉取 1 条样本文档用于渲染消息
+func (e *Engine) countThenSample(ctx context.Context, rule types.AlertRule, query map[string]interface{}) (*types.OpenSearchResponse, error) {
+	count, err := e.opensearchClient.Count(ctx, opensearch.RuleIndex(rule), map[string]interface{}{"query": query["query"]})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &types.OpenSearchResponse{}
+	response.Hits.Total.Value = count
+	response.Hits.Total.Relation = "eq"
+	maxHits := opensearch.RuleMaxHits(rule)
+	if !e.shouldTriggerAlert(rule, response, 0) {
+		e.logger.Debugf("规则 %s 计数 %d 未达到告警条件，跳过文档拉取（少拉取 %d 条 _source）", rule.Name, count, min(count, maxHits))
+		return response, nil
+	}
+	// max_hits 为 0 的规则只需总数，不拉取样本
+	if maxHits == 0 {
+		return response, nil
+	}
+
+	sampleQuery := make(map[string]interface{}, len(query))
+	for k, v := range query {
+		sampleQuery[k] = v
+	}
+	sampleQuery["size"] = 1
+
+	sample, err := e.opensearchClient.Search(ctx, opensearch.RuleIndex(rule), sampleQuery)
+	if err != nil {
+		return nil, err
+	}
+	e.logger.Debugf("规则 %s 计数 %d 触发告警，仅拉取 1 条样本文档（少拉取 %d 条 _source）", rule.Name, count, min(count, maxHits)-len(sample.Hits.Hits))
+
+	// 总数以 _count 为准（不受 track_total_hits 上限影响）
+	sample.Hits.Total.Value = count
+	sample.Hits.Total.Relation = "eq"
+	return sample, nil
+}
+
+// isComparativeRule 判断规则是否依赖历史窗口作为基线
+func isComparativeRule(rule types.AlertRule) bool {
+	switch rule.Type {
+	case "spike", "flatline", "change", "new_term":
+		return true
+	}
+	return false
+}
+
+// inWarmup 记录本次窗口并判断规则是否仍处于预热期
+func (e *Engine) inWarmup(rule types.AlertRule) bool {
+	if rule.WarmupWindows <= 0 || !isComparativeRule(rule) {
+		return false
+	}
+
+	seen, err := e.database.GetRuleWindowsSeen(rule.Name)
+	if err != nil {
+		e.logger.Warnf("读取规则 %s 预热状态失败（按已预热处理）: %v", rule.Name, err)
+		return false
+	}
+	if seen >= rule.WarmupWindows {
+		return false
 	}
+
+	seen, err = e.database.IncrementRuleWindows(rule.Name)
+	if err != nil {
+		e.logger.Warnf("更新规则 %s 预热状态失败: %v", rule.Name, err)
+	}
+	if seen >= rule.WarmupWindows {
+		e.logger.Infof("规则 %s 预热完成（%d 个窗口），后续将正常告警", rule.Name, rule.WarmupWindows)
+	} else {
+		e.logger.Debugf("规则 %s 预热中: %d/%d", rule.Name, seen, rule.WarmupWindows)
+	}
+	return true
+}
+
+// RuleTestResult 规则试运行结果
+type RuleTestResult struct {
+	Hits         int                    `json:"hits"`
+	Matches      int                    `json:"matches"`
+	WouldTrigger bool                   `json:"would_trigger"`
+	Level        string                 `json:"level"`
+	Message      string                 `json:"message"`
+	Query        map[string]interface{} `json:"query"`
+}
+
+// TestRule 试运行规则：执行查询并渲染消息，但不发送通知、不写库、不影响抑制状态
+func (e *Engine) TestRule(ctx context.Context, rule types.AlertRule) (*RuleTestResult, error) {
+	if hasScript(rule) && !e.config.OpenSearch.AllowScriptQueries {
+		return nil, fmt.Errorf("规则 %s 使用了脚本过滤，但未开启 opensearch.allow_script_queries", rule.Name)
+	}
+
+	window := opensearch.SlidingWindow(rule, opensearch.BufferedEnd(time.Now(), e.config.AlertEngine.BufferTime))
+	query := e.opensearchClient.BuildWindowQuery(rule, window)
+
+	response, err := e.search(ctx, rule, query)
+	if err != nil {
+		return nil, fmt.Errorf("规则 %s 查询失败: %w", rule.Name, err)
+	}
+
+	reference := 0
+	if rule.Type == "spike" {
+		if reference, err = e.referenceCount(ctx, rule, window); err != nil {
+			return nil, fmt.Errorf("规则 %s 参考窗口查询失败: %w", rule.Name, err)
+		}
+	}
+
+	return &RuleTestResult{
+		Hits:         response.Hits.Total.Value,
+		Matches:      len(response.Hits.Hits),
+		WouldTrigger: e.shouldTriggerAlert(rule, response, reference),
+		Level:        e.determineAlertLevel(rule, response),
+		Message:      e.buildAlertMessage(rule, response),
+		Query:        query,
+	}, nil
 }
 
 // getInstanceID 返回实例标识，用于分布式锁标记
@@ -134,36 +587,58 @@ func getInstanceID() string {
 	return h
 }
 
-// shouldTriggerAlert 检查是否应该触发告警
-func (e *Engine) shouldTriggerAlert(rule types.AlertRule, response *types.OpenSearchResponse) bool {
+// shouldTriggerAlert 检查是否应该触发告警，reference 为 spike 规则参考窗口的命中数（其他类型忽略）
+func (e *Engine) shouldTriggerAlert(rule types.AlertRule, response *types.OpenSearchResponse, reference int) bool {
 	count := response.Hits.Total.Value
 
+	// 安全检查：未达到 min_hits 的规则不告警，优先于各类型的判定
+	if !e.minHitsMet(rule, count) {
+		return false
+	}
+
 	switch rule.Type {
 	case "frequency":
 		return count >= rule.Threshold
 	case "any":
 		return count > 0
 	case "spike":
-		// 这里可以实现流量突增检测逻辑
-		return count >= rule.Threshold
+		return spikeDetected(rule, count, reference)
 	case "flatline":
-		// 这里可以实现流量低于阈值检测逻辑
 		return count < rule.Threshold
 	case "change":
 		// 这里可以实现字段值变化检测逻辑
 		return count > 0
+	case "metric":
+		value, ok := opensearch.MetricValue(response)
+		return ok && compareMetric(value, rule.MetricOperator, rule.MetricThreshold)
 	default:
 		return count >= rule.Threshold
 	}
 }
 
-// triggerAlert 触发告警
-func (e *Engine) triggerAlert(rule types.AlertRule, response *types.OpenSearchResponse) {
+// compareMetric 按比较方式比较指标值与阈值
+func compareMetric(value float64, operator string, threshold float64) bool {
+	switch operator {
+	case "gt":
+		return value > threshold
+	case "gte":
+		return value >= threshold
+	case "lt":
+		return value < threshold
+	case "lte":
+		return value <= threshold
+	default:
+		return false
+	}
+}
+
+// triggerAlert 触发告警，force 为 true 时跳过去重；去重命中未发送时返回 nil
+func (e *Engine) triggerAlert(rule types.AlertRule, response *types.OpenSearchResponse, force bool) *types.Alert {
 	e.logger.Infof("规则 %s 触发告警，匹配 %d 条记录", rule.Name, response.Hits.Total.Value)
 
 	// 创建告警
 	alert := &types.Alert{
-		ID:        fmt.Sprintf("%s-%d", rule.Name, time.Now().Unix()),
+		ID:        types.NewAlertID(rule.Name),
 		RuleName:  rule.Name,
 		Level:     e.determineAlertLevel(rule, response), // 根据规则和内容确定级别
 		Message:   e.buildAlertMessage(rule, response),
@@ -173,32 +648,170 @@ func (e *Engine) triggerAlert(rule types.AlertRule, response *types.OpenSearchRe
 		Matches:   len(response.Hits.Hits),
 	}
 
-	// 去重：在发送与落库前检查
-	dedupeTTL := 120 // 秒（可后续做成配置）
-	shouldSend, err := e.database.ShouldSendAndTouch(alert.RuleName, alert.Level, alert.Message, dedupeTTL)
+	// 拉取全部命中的规则保留匹配文档，供邮件附件使用
+	if rule.FetchAll {
+		alert.Hits = response.Hits.Hits
+	}
+
+	// 指标规则记录计算出的聚合值
+	if value, ok := opensearch.MetricValue(response); ok && rule.Type == "metric" {
+		alert.Data["metric_value"] = value
+		alert.Data["metric_agg"] = rule.MetricAgg
+		alert.Data["metric_field"] = rule.MetricField
+		alert.Data["metric_threshold"] = rule.MetricThreshold
+		alert.Data["metric_operator"] = rule.MetricOperator
+	}
+
+	// 去重：在发送与落库前检查；存在 query_key 时按键值去重，不同对象的告警互不合并，同一对象的告警即使消息不同也会被去重
+	dedupeContext := e.queryKeyValue(rule, response)
+	if dedupeContext != "" {
+		alert.Data["query_key"] = dedupeContext
+	}
+	shouldSend, err := e.database.ShouldSendAndTouch(alert.RuleName, alert.Level, dedupeContext, alert.Message, e.dedupeTTL(rule))
 	if err != nil {
 		e.logger.Warnf("去重检查失败（忽略错误继续）: %v", err)
 	}
-	if !shouldSend {
+	if !shouldSend && !force {
 		e.logger.Infof("规则 %s 去重命中，跳过发送与落库", rule.Name)
-		return
+		return nil
 	}
 
-	// 发送通知
-	if err := e.notifier.SendAlert(alert); err != nil {
-		e.logger.Errorf("发送告警通知失败: %v", err)
+	// 发送通知，并记录各渠道的发送结果；开启汇总的规则先缓冲，窗口结束时合并发送（手动强制执行仍立即发送）
+	if rule.DigestSeconds > 0 && !force {
+		e.bufferDigest(rule, alert, dedupeContext)
+	} else {
+		results, err := e.notifier.SendAlert(alert)
+		if err != nil {
+			e.logger.Errorf("发送告警通知失败: %v", err)
+		}
+		e.saveNotificationResults(alert.ID, results)
+		e.saveFailedAlert(alert, results)
 	}
 
-	// 保存告警到数据库
-	if err := e.database.SaveAlert(alert); err != nil {
-		e.logger.Errorf("保存告警到数据库失败: %v", err)
-	}
+	// 保存告警到数据库（批量缓冲写入），并通知订阅者
+	e.saveAlert(alert)
+	e.notifyListeners(alert)
 
 	// 更新告警状态
-	e.updateAlertStatus(rule.Name, alert)
+	e.updateAlertStatus(rule, alert)
+
+	// auto_resolve 规则记录未恢复的告警，条件解除后发送恢复通知
+	if rule.AutoResolve {
+		if err := e.database.SetOpenAlert(rule.Name, alert.ID); err != nil {
+			e.logger.Warnf("记录规则 %s 未恢复告警失败: %v", rule.Name, err)
+		}
+	}
 
 	// 记录告警到 OpenSearch
 	e.recordAlert(alert)
+	return alert
+}
+
+// resolveRule 条件不再满足时关闭规则未恢复的告警并发送恢复通知
+func (e *Engine) resolveRule(rule types.AlertRule, response *types.OpenSearchResponse) {
+	alertID, err := e.database.GetOpenAlert(rule.Name)
+	if err != nil {
+		e.logger.Warnf("查询规则 %s 未恢复告警失败: %v", rule.Name, err)
+		return
+	}
+	if alertID == "" {
+		return
+	}
+
+	// 告警可能仍在落库缓冲中，先刷新再更新状态
+	e.flushAlerts()
+
+	level := rule.Level
+	firedAt := ""
+	data := map[string]interface{}{"resolved_alert_id": alertID}
+	if detail, err := e.database.GetAlertByID(alertID); err == nil && detail != nil {
+		level = detail.Level
+		firedAt = detail.Timestamp.Format("2006-01-02 15:04:05")
+		// 沿用原告警的 query_key，便于在恢复通知中识别对象
+		if key, ok := detail.Data["query_key"]; ok {
+			data["query_key"] = key
+		}
+	}
+
+	e.logger.Infof("规则 %s 条件已解除，告警 %s 恢复", rule.Name, alertID)
+	alert := &types.Alert{
+		ID:        alertID,
+		RuleName:  rule.Name,
+		Level:     level,
+		Message:   fmt.Sprintf("规则 **%s** 的告警条件已解除（触发于 %s），当前匹配 %d 条记录。", rule.Name, firedAt, response.Hits.Total.Value),
+		Timestamp: time.Now(),
+		Data:      data,
+		Count:     response.Hits.Total.Value,
+		Resolved:  true,
+	}
+	results, err := e.notifier.SendAlert(alert)
+	if err != nil {
+		e.logger.Errorf("发送恢复通知失败: %v", err)
+	}
+	e.saveFailedAlert(alert, results)
+
+	if err := e.database.ResolveAlert(rule.Name, alertID); err != nil {
+		e.logger.Warnf("标记告警 %s 恢复失败: %v", alertID, err)
+	}
+
+	// 恢复后解除抑制，问题再次出现时立即告警
+	e.statusMutex.Lock()
+	status := e.alertStatuses[rule.Name]
+	var snapshot types.AlertStatus
+	if status != nil {
+		status.Suppressed = false
+		snapshot = *status
+	}
+	e.statusMutex.Unlock()
+	if status != nil {
+		e.persistAlertStatus(snapshot)
+	}
+}
+
+// defaultDedupeTTL 未配置时的发送去重窗口（秒）
+const defaultDedupeTTL = 120
+
+// dedupeTTL 规则的发送去重窗口：规则配置优先，其次全局配置，最后默认值
+func (e *Engine) dedupeTTL(rule types.AlertRule) int {
+	if rule.DedupeTTL > 0 {
+		return rule.DedupeTTL
+	}
+	if e.config.AlertEngine.DedupeTTL > 0 {
+		return e.config.AlertEngine.DedupeTTL
+	}
+	return defaultDedupeTTL
+}
+
+// defaultQueryTimeout 未配置时的规则执行超时（秒）
+const defaultQueryTimeout = 30
+
+// queryTimeout 规则执行超时：规则配置优先，其次全局配置，最后默认值；不超过 opensearch.timeout，
+// 避免请求先被 HTTP 客户端中断而报出与规则超时无关的错误
+func (e *Engine) queryTimeout(rule types.AlertRule) time.Duration {
+	seconds := defaultQueryTimeout
+	if rule.QueryTimeout > 0 {
+		seconds = rule.QueryTimeout
+	} else if e.config.AlertEngine.QueryTimeout > 0 {
+		seconds = e.config.AlertEngine.QueryTimeout
+	}
+	if limit := e.config.OpenSearch.Timeout; limit > 0 && seconds > limit {
+		e.logger.Debugf("规则 %s 的 query_timeout（%d 秒）超过 opensearch.timeout，按 %d 秒执行", rule.Name, seconds, limit)
+		seconds = limit
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// queryKeyValue 取首条命中中 query_key 字段的值，格式 field=value，多个字段以逗号分隔
+func (e *Engine) queryKeyValue(rule types.AlertRule, response *types.OpenSearchResponse) string {
+	if len(rule.QueryKey) == 0 || len(response.Hits.Hits) == 0 {
+		return ""
+	}
+	source := response.Hits.Hits[0].Source
+	parts := make([]string, 0, len(rule.QueryKey))
+	for _, key := range rule.QueryKey {
+		parts = append(parts, key+"="+e.templateEngine.getValueByPath(source, key))
+	}
+	return strings.Join(parts, ",")
 }
 
 // determineAlertLevel 根据规则和内容确定告警级别
@@ -258,8 +871,9 @@ func (e *Engine) extractAlertData(response *types.OpenSearchResponse) map[string
 	data := make(map[string]interface{})
 
 	if len(response.Hits.Hits) > 0 {
-		// 取第一条记录作为示例数据
+		// 取第一条记录作为示例数据，fields 为其按点路径展开后的字段，供通知渠道直接读取
 		data["sample_hit"] = response.Hits.Hits[0].Source
+		data["fields"] = flattenSource(response.Hits.Hits[0].Source)
 	}
 
 	data["total_hits"] = response.Hits.Total.Value
@@ -269,34 +883,82 @@ func (e *Engine) extractAlertData(response *types.OpenSearchResponse) map[string
 }
 
 // updateAlertStatus 更新告警状态
-func (e *Engine) updateAlertStatus(ruleName string, alert *types.Alert) {
+func (e *Engine) updateAlertStatus(rule types.AlertRule, alert *types.Alert) {
 	e.statusMutex.Lock()
-	defer e.statusMutex.Unlock()
-
-	status := e.alertStatuses[ruleName]
+	status := e.alertStatuses[rule.Name]
 	if status == nil {
 		status = &types.AlertStatus{
-			RuleName: ruleName,
+			RuleName: rule.Name,
 		}
-		e.alertStatuses[ruleName] = status
+		e.alertStatuses[rule.Name] = status
 	}
 
 	status.LastAlert = alert.Timestamp
 	status.AlertCount++
 
 	// 设置抑制时间
-	if e.config.AlertSuppression.Enabled {
-		suppressDuration := time.Duration(e.config.AlertSuppression.RealertMinutes) * time.Minute
+	if suppressDuration := e.suppressDuration(rule, status.AlertCount); suppressDuration > 0 {
+		status.Suppressed = true
+		status.SuppressUntil = time.Now().Add(suppressDuration)
+	}
+	snapshot := *status
+	e.statusMutex.Unlock()
 
-		// 指数级抑制
-		if e.config.AlertSuppression.ExponentialRealert.Enabled {
-			exponentialHours := e.config.AlertSuppression.ExponentialRealert.Hours
-			suppressDuration = time.Duration(exponentialHours) * time.Hour * time.Duration(status.AlertCount)
+	e.persistAlertStatus(snapshot)
+}
+
+// persistAlertStatus 将告警状态写入数据库，失败仅记录日志（内存状态仍然生效）
+func (e *Engine) persistAlertStatus(status types.AlertStatus) {
+	if err := e.database.SaveAlertStatus(status); err != nil {
+		e.logger.Warnf("保存规则 %s 的告警状态失败: %v", status.RuleName, err)
+	}
+}
+
+// restoreAlertStatuses 从数据库恢复告警状态，已过期的抑制在加载时解除
+func (e *Engine) restoreAlertStatuses() {
+	statuses, err := e.database.ListAlertStatuses()
+	if err != nil {
+		e.logger.Warnf("恢复告警状态失败: %v", err)
+		return
+	}
+
+	now := time.Now()
+	suppressed := 0
+	e.statusMutex.Lock()
+	for i := range statuses {
+		status := statuses[i]
+		if status.Suppressed && now.After(status.SuppressUntil) {
+			status.Suppressed = false
 		}
+		if status.Suppressed || now.Before(status.SnoozedUntil) {
+			suppressed++
+		}
+		e.alertStatuses[status.RuleName] = &status
+	}
+	e.statusMutex.Unlock()
 
-		status.Suppressed = true
-		status.SuppressUntil = time.Now().Add(suppressDuration)
+	if len(statuses) > 0 {
+		e.logger.Infof("恢复了 %d 个规则的告警状态，其中 %d 个仍在抑制中", len(statuses), suppressed)
+	}
+}
+
+// suppressDuration 计算规则告警后的抑制时长，规则 realert（秒）优先于全局配置
+func (e *Engine) suppressDuration(rule types.AlertRule, alertCount int) time.Duration {
+	if rule.Realert > 0 {
+		return time.Duration(rule.Realert) * time.Second
+	}
+
+	if !e.config.AlertSuppression.Enabled {
+		return 0
+	}
+
+	// 指数级抑制
+	if e.config.AlertSuppression.ExponentialRealert.Enabled {
+		exponentialHours := e.config.AlertSuppression.ExponentialRealert.Hours
+		return time.Duration(exponentialHours) * time.Hour * time.Duration(alertCount)
 	}
+
+	return time.Duration(e.config.AlertSuppression.RealertMinutes) * time.Minute
 }
 
 // isSuppressed 检查规则是否被抑制
@@ -310,6 +972,12 @@ func (e *Engine) isSuppressed(ruleName string) bool {
 		return false
 	}
 
+	// 手动暂停独立于 realert 抑制，到期前始终生效
+	if time.Now().Before(status.SnoozedUntil) {
+		e.logger.Debugf("规则 %s 已暂停告警，暂停到 %s", ruleName, status.SnoozedUntil.Format("2006-01-02 15:04:05"))
+		return true
+	}
+
 	if !status.Suppressed {
 		e.logger.Debugf("规则 %s 未被抑制", ruleName)
 		return false
@@ -326,6 +994,59 @@ func (e *Engine) isSuppressed(ruleName string) bool {
 	return true
 }
 
+// sendMetaAlert 发送告警工具自身的监控告警（自监控），同类问题按 key 去重
+func (e *Engine) sendMetaAlert(key, message string) {
+	const metaRuleName = "OpenSearch 告警自监控"
+	const metaDedupeTTL = 3600
+
+	shouldSend, err := e.database.ShouldSendAndTouch(metaRuleName, "Critical", "", key, metaDedupeTTL)
+	if err != nil {
+		e.logger.Warnf("自监控告警去重检查失败（忽略错误继续）: %v", err)
+	}
+	if !shouldSend {
+		e.logger.Debugf("自监控告警 %s 去重命中，跳过发送", key)
+		return
+	}
+
+	alert := &types.Alert{
+		ID:        types.NewAlertID("meta-" + key),
+		RuleName:  metaRuleName,
+		Level:     "Critical",
+		Message:   message,
+		Timestamp: time.Now(),
+		Data:      map[string]interface{}{"meta": true, "key": key},
+		Count:     1,
+		Matches:   0,
+	}
+	if _, err := e.notifier.SendAlert(alert); err != nil {
+		e.logger.Errorf("发送自监控告警失败: %v", err)
+	}
+}
+
+// saveNotificationResults 保存告警各渠道的发送结果
+func (e *Engine) saveNotificationResults(alertID string, results map[string]error) {
+	if len(results) == 0 {
+		return
+	}
+
+	if err := e.database.SaveNotificationResults(notification.ResultRecords(alertID, results)); err != nil {
+		e.logger.Warnf("保存通知发送结果失败: %v", err)
+	}
+}
+
+// saveFailedAlert 所有渠道均发送失败时将告警写入死信表，便于之后在 Web 中重试
+func (e *Engine) saveFailedAlert(alert *types.Alert, results map[string]error) {
+	errs := notification.FailedChannels(results)
+	if errs == nil {
+		return
+	}
+	if err := e.database.SaveFailedAlert(alert, errs); err != nil {
+		e.logger.Errorf("保存死信告警 %s 失败: %v", alert.ID, err)
+		return
+	}
+	e.logger.Warnf("告警 %s 所有通知渠道均发送失败，已写入死信表", alert.ID)
+}
+
 // recordAlert 记录告警到 OpenSearch
 func (e *Engine) recordAlert(alert *types.Alert) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
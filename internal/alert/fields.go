@@ -0,0 +1,70 @@
+package alert
+
+import (
+	"strconv"
+	"strings"
+)
+
+// flattenSource 将文档展开为点路径键：嵌套对象为 a.b，数组元素为 a.0.b；空对象与空数组保留原值
+func flattenSource(source map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	for key, value := range source {
+		flattenValue(flat, key, value)
+	}
+	return flat
+}
+
+// flattenValue 递归展开单个值
+func flattenValue(flat map[string]interface{}, prefix string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			flat[prefix] = v
+			return
+		}
+		for key, child := range v {
+			flattenValue(flat, prefix+"."+key, child)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			flat[prefix] = v
+			return
+		}
+		for i, child := range v {
+			flattenValue(flat, prefix+"."+strconv.Itoa(i), child)
+		}
+	default:
+		flat[prefix] = v
+	}
+}
+
+// lookupPath 按点路径取值，数字段可访问数组元素（items.0.name）；
+// 字段名本身带点的文档（如 "kubernetes.labels"）也能匹配，逐层查找优先
+func lookupPath(root map[string]interface{}, path string) (interface{}, bool) {
+	return walkPath(root, strings.Split(path, "."))
+}
+
+// walkPath 逐层查找路径：对象中先匹配单个段，找不到时依次尝试由多个段拼成的带点字段名
+func walkPath(cur interface{}, parts []string) (interface{}, bool) {
+	if len(parts) == 0 {
+		return cur, true
+	}
+	switch v := cur.(type) {
+	case map[string]interface{}:
+		for n := 1; n <= len(parts); n++ {
+			next, ok := v[strings.Join(parts[:n], ".")]
+			if !ok {
+				continue
+			}
+			if value, ok := walkPath(next, parts[n:]); ok {
+				return value, true
+			}
+		}
+	case []interface{}:
+		i, err := strconv.Atoi(parts[0])
+		if err == nil && i >= 0 && i < len(v) {
+			return walkPath(v[i], parts[1:])
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,79 @@
+package alert
+
+import (
+	"reflect"
+	"testing"
+)
+
+// nestedSource 混合嵌套对象、数组与带点字段名的示例文档
+func nestedSource() map[string]interface{} {
+	return map[string]interface{}{
+		"level": "error",
+		"kubernetes": map[string]interface{}{
+			"pod_name": "api-7d9f",
+			"labels":   []interface{}{"app=api", "tier=backend"},
+		},
+		"items": []interface{}{
+			map[string]interface{}{"name": "first"},
+			map[string]interface{}{"name": "second", "ports": []interface{}{float64(80), float64(443)}},
+		},
+		"matrix":            []interface{}{[]interface{}{"a", "b"}, []interface{}{"c"}},
+		"kubernetes.labels": map[string]interface{}{"app": "dotted"},
+		"empty_obj":         map[string]interface{}{},
+		"empty_list":        []interface{}{},
+	}
+}
+
+func TestFlattenSource(t *testing.T) {
+	flat := flattenSource(nestedSource())
+
+	want := map[string]interface{}{
+		"level":                 "error",
+		"kubernetes.pod_name":   "api-7d9f",
+		"kubernetes.labels.0":   "app=api",
+		"kubernetes.labels.1":   "tier=backend",
+		"items.0.name":          "first",
+		"items.1.name":          "second",
+		"items.1.ports.0":       float64(80),
+		"items.1.ports.1":       float64(443),
+		"matrix.0.0":            "a",
+		"matrix.0.1":            "b",
+		"matrix.1.0":            "c",
+		"kubernetes.labels.app": "dotted",
+		"empty_obj":             map[string]interface{}{},
+		"empty_list":            []interface{}{},
+	}
+	if !reflect.DeepEqual(flat, want) {
+		t.Errorf("展开结果不符:\n实际 %v\n期望 %v", flat, want)
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	source := nestedSource()
+	tests := []struct {
+		path   string
+		want   interface{}
+		wantOK bool
+	}{
+		{"level", "error", true},
+		{"kubernetes.pod_name", "api-7d9f", true},
+		{"kubernetes.labels.1", "tier=backend", true},
+		{"items.0.name", "first", true},
+		{"items.1.ports.1", float64(443), true},
+		{"matrix.1.0", "c", true},
+		// 逐层查找失败时匹配带点的字段名
+		{"kubernetes.labels.app", "dotted", true},
+		{"items.1", map[string]interface{}{"name": "second", "ports": []interface{}{float64(80), float64(443)}}, true},
+		{"items.2.name", nil, false},
+		{"items.-1.name", nil, false},
+		{"items.name", nil, false},
+		{"level.0", nil, false},
+		{"missing", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := lookupPath(source, tt.path)
+		if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lookupPath(%q) = %v, %v，期望 %v, %v", tt.path, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
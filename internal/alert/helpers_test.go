@@ -0,0 +1,78 @@
+package alert
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"opensearch-alert/internal/database"
+	"opensearch-alert/internal/notification"
+	"opensearch-alert/internal/opensearch"
+	"opensearch-alert/pkg/types"
+)
+
+// newTestLogger 返回丢弃输出的日志器
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// newTestEngine 创建连接到测试 OpenSearch 服务的引擎（不含数据库与通知渠道）
+func newTestEngine(t *testing.T, handler http.Handler) *Engine {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := opensearch.NewClient(types.OpenSearchConfig{Host: server.URL, Timeout: 5})
+	if err != nil {
+		t.Fatalf("创建 OpenSearch 客户端失败: %v", err)
+	}
+	return NewEngine(&types.Config{}, client, nil, nil, newTestLogger())
+}
+
+// newDBTestEngine 创建带数据库与空通知器（无渠道）的引擎，OpenSearch 桩服务接受所有请求
+func newDBTestEngine(t *testing.T, config *types.Config) *Engine {
+	t.Helper()
+	return newStubDBEngine(t, config, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":"created"}`))
+	}))
+}
+
+// newStubDBEngine 创建带数据库与通知器的引擎，OpenSearch 请求由 handler 应答
+func newStubDBEngine(t *testing.T, config *types.Config, handler http.Handler) *Engine {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := opensearch.NewClient(types.OpenSearchConfig{Host: server.URL, Timeout: 5})
+	if err != nil {
+		t.Fatalf("创建 OpenSearch 客户端失败: %v", err)
+	}
+	return NewEngine(config, client, notification.NewNotifier(config, newTestLogger()), newTestDatabase(t), newTestLogger())
+}
+
+// hitsResponse 构造包含指定 _source 的查询响应
+func hitsResponse(sources ...map[string]interface{}) *types.OpenSearchResponse {
+	response := &types.OpenSearchResponse{}
+	response.Hits.Total.Value = len(sources)
+	for _, source := range sources {
+		response.Hits.Hits = append(response.Hits.Hits, types.OpenSearchHit{Source: source})
+	}
+	return response
+}
+
+// newTestDatabase 在临时目录创建 SQLite 数据库
+func newTestDatabase(t *testing.T) *database.Database {
+	t.Helper()
+	db, err := database.NewDatabase(types.DatabaseConfig{Type: "sqlite", Path: filepath.Join(t.TempDir(), "alert.db")}, newTestLogger())
+	if err != nil {
+		t.Fatalf("创建测试数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
@@ -0,0 +1,90 @@
+package alert
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"opensearch-alert/internal/config"
+	"opensearch-alert/pkg/types"
+)
+
+// waitForRules 等待引擎规则满足条件，超时返回最后一次读取的规则
+func waitForRules(e *Engine, ok func([]types.AlertRule) bool) ([]types.AlertRule, bool) {
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		rules := e.Rules()
+		if ok(rules) {
+			return rules, true
+		}
+		if time.Now().After(deadline) {
+			return rules, false
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestRulesWatcherReloadsEngine(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("errors.yaml", "name: errors\ntype: any\nindex: logs-*\nenabled: true\n")
+
+	defaults := types.RulesConfig{DefaultTimeframe: 300, DefaultThreshold: 7}
+	e := newTestEngine(t, http.NotFoundHandler())
+	initial, err := config.LoadRules(dir)
+	if err != nil {
+		t.Fatalf("加载规则失败: %v", err)
+	}
+	config.ApplyRuleDefaults(initial, defaults)
+	e.LoadRules(initial)
+
+	// 与 main.go 相同的接线方式：回填默认值后交给引擎
+	watcher, err := config.NewRulesWatcher(dir, 50*time.Millisecond, func(rules []types.AlertRule) {
+		config.ApplyRuleDefaults(rules, defaults)
+		e.LoadRules(rules)
+	}, newTestLogger())
+	if err != nil {
+		t.Fatalf("创建规则监听器失败: %v", err)
+	}
+	watcher.Start()
+	t.Cleanup(watcher.Stop)
+
+	// 禁用已有规则并新增一个规则文件：禁用的规则不再加载
+	write("errors.yaml", "name: errors\ntype: any\nindex: logs-*\nenabled: false\n")
+	write("latency.yml", "name: latency\ntype: frequency\nindex: nginx-*\nenabled: true\n")
+
+	rules, ok := waitForRules(e, func(rules []types.AlertRule) bool {
+		return len(rules) == 1 && rules[0].Name == "latency"
+	})
+	if !ok {
+		t.Fatalf("引擎规则未随文件变化更新（errors 应移除、latency 应新增）: %+v", rules)
+	}
+	if rules[0].Timeframe != 300 || rules[0].Threshold != 7 {
+		t.Errorf("热加载的规则应回填默认值，timeframe=%d threshold=%d", rules[0].Timeframe, rules[0].Threshold)
+	}
+
+	// 修改规则内容
+	write("latency.yml", "name: latency\ntype: frequency\nindex: nginx-access-*\nthreshold: 20\nenabled: true\n")
+	if rules, ok := waitForRules(e, func(rules []types.AlertRule) bool {
+		return len(rules) == 1 && rules[0].Index == "nginx-access-*"
+	}); !ok {
+		t.Fatalf("修改规则文件后引擎规则未更新: %+v", rules)
+	} else if rules[0].Threshold != 20 {
+		t.Errorf("threshold = %d, 期望 20", rules[0].Threshold)
+	}
+
+	// 删除规则文件后引擎中的规则随之移除
+	if err := os.Remove(filepath.Join(dir, "latency.yml")); err != nil {
+		t.Fatal(err)
+	}
+	if rules, ok := waitForRules(e, func(rules []types.AlertRule) bool { return len(rules) == 0 }); !ok {
+		t.Errorf("删除规则文件后引擎规则未更新: %+v", rules)
+	}
+}
@@ -0,0 +1,60 @@
+package alert
+
+import (
+	"context"
+	"time"
+
+	"opensearch-alert/internal/opensearch"
+	"opensearch-alert/pkg/types"
+)
+
+// indexCheckInterval 规则没有命中时重新检查索引是否存在的最短间隔
+const indexCheckInterval = 10 * time.Minute
+
+// indexCheck 规则索引的检查结果
+type indexCheck struct {
+	missing   bool
+	checkedAt time.Time
+}
+
+// checkRuleIndex 规则查询返回 404 或没有命中时检查索引是否存在；索引不存在时输出明确的告警日志。
+// 没有命中的规则按 indexCheckInterval 限制检查频率（force 为 true 时立即检查），
+// 同一规则只在首次发现与恢复时各输出一次日志，避免每轮刷屏
+func (e *Engine) checkRuleIndex(ctx context.Context, rule types.AlertRule, force bool) {
+	e.indexMutex.Lock()
+	last, checked := e.indexChecks[rule.Name]
+	e.indexMutex.Unlock()
+	if checked && !force && time.Since(last.checkedAt) < indexCheckInterval {
+		return
+	}
+
+	index := opensearch.RuleIndex(rule)
+	exists, err := e.opensearchClient.IndexExists(ctx, index)
+	if err != nil {
+		e.logger.Debugf("检查规则 %s 的索引 %s 失败: %v", rule.Name, index, err)
+		return
+	}
+
+	e.indexMutex.Lock()
+	e.indexChecks[rule.Name] = indexCheck{missing: !exists, checkedAt: time.Now()}
+	e.indexMutex.Unlock()
+
+	switch {
+	case !exists && !last.missing:
+		e.logger.Warnf("⚠️  规则 %s 的索引模式 %s 未匹配任何索引，规则不会命中任何数据；请检查 index/indices 配置或索引是否已创建", rule.Name, index)
+	case exists && last.missing:
+		e.logger.Infof("规则 %s 的索引模式 %s 已匹配到索引", rule.Name, index)
+	}
+}
+
+// markIndexFound 规则有命中时清除索引检查状态
+func (e *Engine) markIndexFound(rule types.AlertRule) {
+	e.indexMutex.Lock()
+	last := e.indexChecks[rule.Name]
+	delete(e.indexChecks, rule.Name)
+	e.indexMutex.Unlock()
+
+	if last.missing {
+		e.logger.Infof("规则 %s 的索引模式 %s 已匹配到索引", rule.Name, opensearch.RuleIndex(rule))
+	}
+}
@@ -0,0 +1,87 @@
+package alert
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	"opensearch-alert/pkg/types"
+)
+
+// indexStub 应答 HEAD 索引检查的桩服务，exists 控制索引是否存在
+type indexStub struct {
+	exists int32
+	checks int32
+}
+
+func (s *indexStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&s.checks, 1)
+	if atomic.LoadInt32(&s.exists) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// countLevel 统计指定级别的日志条数
+func countLevel(hook *test.Hook, level logrus.Level) int {
+	count := 0
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == level {
+			count++
+		}
+	}
+	return count
+}
+
+func TestCheckRuleIndexLogsOncePerStateChange(t *testing.T) {
+	stub := &indexStub{}
+	e := newTestEngine(t, stub)
+	logger, hook := test.NewNullLogger()
+	e.logger = logger
+	rule := types.AlertRule{Name: "missing", Index: "nope-*"}
+	ctx := context.Background()
+
+	e.checkRuleIndex(ctx, rule, true)
+	e.checkRuleIndex(ctx, rule, true)
+	if got := countLevel(hook, logrus.WarnLevel); got != 1 {
+		t.Fatalf("索引持续缺失时只应警告一次，实际 %d 次", got)
+	}
+
+	// 没有命中的规则在检查间隔内不重复检查
+	e.checkRuleIndex(ctx, rule, false)
+	if got := atomic.LoadInt32(&stub.checks); got != 2 {
+		t.Errorf("检查间隔内不应再次检查，实际检查 %d 次", got)
+	}
+
+	// 超过检查间隔后重新检查，索引出现时记录恢复日志
+	e.indexChecks[rule.Name] = indexCheck{missing: true, checkedAt: time.Now().Add(-indexCheckInterval)}
+	atomic.StoreInt32(&stub.exists, 1)
+	e.checkRuleIndex(ctx, rule, false)
+	if got := atomic.LoadInt32(&stub.checks); got != 3 {
+		t.Errorf("超过检查间隔应重新检查，实际检查 %d 次", got)
+	}
+	if got := countLevel(hook, logrus.InfoLevel); got != 1 {
+		t.Errorf("索引恢复时应记录一次日志，实际 %d 次", got)
+	}
+}
+
+func TestMarkIndexFound(t *testing.T) {
+	e := newTestEngine(t, &indexStub{})
+	logger, hook := test.NewNullLogger()
+	e.logger = logger
+	rule := types.AlertRule{Name: "missing", Index: "nope-*"}
+
+	e.checkRuleIndex(context.Background(), rule, true)
+	e.markIndexFound(rule)
+	e.markIndexFound(rule)
+	if _, ok := e.indexChecks[rule.Name]; ok {
+		t.Error("规则有命中时应清除索引检查状态")
+	}
+	if got := countLevel(hook, logrus.InfoLevel); got != 1 {
+		t.Errorf("只应在从缺失恢复时记录一次日志，实际 %d 次", got)
+	}
+}
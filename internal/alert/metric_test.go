@@ -0,0 +1,79 @@
+package alert
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestCompareMetric(t *testing.T) {
+	tests := []struct {
+		operator string
+		value    float64
+		want     bool
+	}{
+		{"gt", 501, true},
+		{"gt", 500, false},
+		{"gte", 500, true},
+		{"gte", 499.9, false},
+		{"lt", 499, true},
+		{"lt", 500, false},
+		{"lte", 500, true},
+		{"lte", 500.1, false},
+		{"eq", 500, false},
+	}
+	for _, tt := range tests {
+		if got := compareMetric(tt.value, tt.operator, 500); got != tt.want {
+			t.Errorf("compareMetric(%v, %q, 500) = %v, 期望 %v", tt.value, tt.operator, got, tt.want)
+		}
+	}
+}
+
+// aggregationHandler 返回固定指标聚合值的 OpenSearch 桩；value 为 "null" 表示窗口内无文档
+func aggregationHandler(value string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"hits": {"total": {"value": 4, "relation": "eq"}, "hits": [{"_id": "1", "_source": {"message": "slow"}}]},
+			"aggregations": {"metric_value": {"value": %s}}}`, value)
+	})
+}
+
+func TestMetricRuleFiresOnStubbedAggregation(t *testing.T) {
+	rule := types.AlertRule{
+		Name: "latency", Type: "metric", Index: "nginx-*", Timeframe: 300, Enabled: true,
+		MetricAgg: "avg", MetricField: "response_ms", MetricOperator: "gt", MetricThreshold: 500,
+	}
+	tests := []struct {
+		name      string
+		value     string
+		wantFired bool
+	}{
+		{name: "超过阈值", value: "812.5", wantFired: true},
+		{name: "未超过阈值", value: "120", wantFired: false},
+		{name: "窗口内无文档", value: "null", wantFired: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newStubDBEngine(t, &types.Config{}, aggregationHandler(tt.value))
+			result := e.executeRule(rule, false)
+			if result.Error != "" {
+				t.Fatalf("执行规则失败: %s", result.Error)
+			}
+			if result.Fired != tt.wantFired {
+				t.Fatalf("Fired = %v, 期望 %v", result.Fired, tt.wantFired)
+			}
+			if !tt.wantFired {
+				return
+			}
+			data := result.Alert.Data
+			if data["metric_value"] != 812.5 {
+				t.Errorf("metric_value = %v, 期望 812.5", data["metric_value"])
+			}
+			if data["metric_agg"] != "avg" || data["metric_field"] != "response_ms" || data["metric_operator"] != "gt" || data["metric_threshold"] != 500.0 {
+				t.Errorf("告警数据缺少指标规则信息: %v", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package alert
+
+import (
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// peakHitsWindow flatline 规则命中峰值的有效期：超过该时长没有窗口达到 min_hits 时视为数据源已下线，不再告警
+const peakHitsWindow = 24 * time.Hour
+
+// minHitsMet 判断规则是否满足 min_hits：flatline 规则本身就在命中变少时告警，
+// 因此要求最近 peakHitsWindow 内曾有窗口达到 min_hits（从有数据跌到 0 仍会告警，从未有过数据的空索引
+// 以及长期没有数据的已下线数据源不告警）；其他规则要求本轮窗口命中数达到 min_hits
+func (e *Engine) minHitsMet(rule types.AlertRule, count int) bool {
+	if rule.MinHits <= 0 {
+		return true
+	}
+	if rule.Type != "flatline" {
+		return count >= rule.MinHits
+	}
+
+	peak, err := e.database.GetRulePeakHits(rule.Name, time.Now().Add(-peakHitsWindow))
+	if err != nil {
+		e.logger.Warnf("读取规则 %s 命中峰值失败（按未达到 min_hits 处理）: %v", rule.Name, err)
+		return false
+	}
+	return peak >= rule.MinHits
+}
+
+// recordPeakHits 记录 flatline 规则的窗口命中峰值，供 min_hits 判断
+//
+// 峰值按 min_hits 封顶记录：只需判断是否达到 min_hits，封顶后每一轮达到 min_hits 的窗口都会刷新记录时间，
+// 峰值在最后一次达到 min_hits 后 peakHitsWindow 才过期
+func (e *Engine) recordPeakHits(rule types.AlertRule, count int) {
+	if rule.MinHits <= 0 || rule.Type != "flatline" {
+		return
+	}
+	if count > rule.MinHits {
+		count = rule.MinHits
+	}
+	if err := e.database.RecordRulePeakHits(rule.Name, count, time.Now().Add(-peakHitsWindow)); err != nil {
+		e.logger.Warnf("记录规则 %s 命中峰值失败: %v", rule.Name, err)
+	}
+}
@@ -0,0 +1,62 @@
+package alert
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestMinHitsMet(t *testing.T) {
+	e := NewEngine(&types.Config{}, nil, nil, newTestDatabase(t), newTestLogger())
+
+	frequency := types.AlertRule{Name: "frequency", Type: "frequency", MinHits: 5}
+	if e.minHitsMet(frequency, 4) || !e.minHitsMet(frequency, 5) {
+		t.Error("非 flatline 规则应按本轮命中数判断 min_hits")
+	}
+
+	flatline := types.AlertRule{Name: "flatline", Type: "flatline", MinHits: 5}
+	if e.minHitsMet(flatline, 0) {
+		t.Error("从未达到 min_hits 的 flatline 规则不应告警")
+	}
+	e.recordPeakHits(flatline, 50)
+	e.recordPeakHits(flatline, 0)
+	if !e.minHitsMet(flatline, 0) {
+		t.Error("有效期内曾达到 min_hits 的 flatline 规则跌到 0 应告警")
+	}
+
+	// 峰值按 min_hits 封顶，之后每次达到 min_hits 都刷新记录时间
+	since := time.Now().Add(-peakHitsWindow)
+	if peak, _ := e.database.GetRulePeakHits(flatline.Name, since); peak != flatline.MinHits {
+		t.Errorf("峰值应按 min_hits 封顶记录，实际 %d", peak)
+	}
+	if peak, _ := e.database.GetRulePeakHits(flatline.Name, time.Now().Add(time.Second)); peak != 0 {
+		t.Errorf("峰值记录早于有效期起点时应视为过期，实际 %d", peak)
+	}
+}
+
+func TestUnmetMinHitsLogsAtDebug(t *testing.T) {
+	e := newTestEngine(t, &countStub{count: 2})
+	e.database = newTestDatabase(t)
+	logger, hook := test.NewNullLogger()
+	e.logger = logger
+	rule := types.AlertRule{Name: "min-hits", Type: "frequency", Index: "logs-*", Timeframe: 300, Threshold: 5, MinHits: 3, Enabled: true}
+	e.LoadRules([]types.AlertRule{rule})
+	hook.Reset()
+
+	for i := 0; i < 3; i++ {
+		if result := e.executeRule(rule, false); result.Skipped != "未达到 min_hits" {
+			t.Fatalf("执行结果不符: %+v", result)
+		}
+	}
+	// 每轮都未达到 min_hits 是常态，不应在 info 级别刷屏
+	for _, entry := range hook.AllEntries() {
+		if entry.Level <= logrus.InfoLevel && strings.Contains(entry.Message, "min_hits") {
+			t.Errorf("未达到 min_hits 不应以 %s 级别记录: %s", entry.Level, entry.Message)
+		}
+	}
+}
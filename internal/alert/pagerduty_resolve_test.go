@@ -0,0 +1,61 @@
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+// pagerDutyEvent PagerDuty 桩服务收到的事件
+type pagerDutyEvent struct {
+	Action   string `json:"event_action"`
+	DedupKey string `json:"dedup_key"`
+}
+
+func TestPagerDutyResolveClosesTriggeredIncident(t *testing.T) {
+	var mu sync.Mutex
+	var events []pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("解析事件失败: %v", err)
+		}
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	config := &types.Config{}
+	config.Notifications.PagerDuty = types.PagerDutyConfig{Enabled: true, RoutingKey: "key", EventsURL: server.URL}
+	e := newDBTestEngine(t, config)
+	rule := types.AlertRule{Name: "per-host", Type: "any", Index: "logs-*", Level: "High", QueryKey: []string{"host"}, AutoResolve: true}
+
+	// 不同 query_key 对象的告警各自触发
+	for _, host := range []string{"a", "b"} {
+		if e.triggerAlert(rule, hitsResponse(map[string]interface{}{"host": host}), false) == nil {
+			t.Fatalf("主机 %s 应生成告警", host)
+		}
+	}
+	e.resolveRule(rule, hitsResponse())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 3 {
+		t.Fatalf("应发送 2 个 trigger 与 1 个 resolve，实际 %+v", events)
+	}
+	if events[2].Action != "resolve" {
+		t.Fatalf("最后一个事件应为 resolve，实际 %+v", events[2])
+	}
+	// resolve 必须使用与全部 trigger 相同的 dedup_key，否则会遗留未关闭的 incident
+	for _, event := range events {
+		if event.DedupKey != "opensearch-alert/per-host" {
+			t.Errorf("dedup_key 应按规则生成，实际 %+v", event)
+		}
+	}
+}
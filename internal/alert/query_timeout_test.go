@@ -0,0 +1,76 @@
+package alert
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestQueryTimeout(t *testing.T) {
+	tests := []struct {
+		name          string
+		engineTimeout int
+		ruleTimeout   int
+		clientTimeout int
+		want          time.Duration
+	}{
+		{name: "默认值", want: defaultQueryTimeout * time.Second},
+		{name: "全局配置", engineTimeout: 90, want: 90 * time.Second},
+		{name: "规则覆盖全局", engineTimeout: 90, ruleTimeout: 120, want: 120 * time.Second},
+		{name: "不超过客户端超时", ruleTimeout: 120, clientTimeout: 60, want: 60 * time.Second},
+		{name: "默认值不超过客户端超时", clientTimeout: 10, want: 10 * time.Second},
+		{name: "低于客户端超时不受影响", engineTimeout: 45, clientTimeout: 60, want: 45 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &types.Config{}
+			config.AlertEngine.QueryTimeout = tt.engineTimeout
+			config.OpenSearch.Timeout = tt.clientTimeout
+			e := &Engine{config: config, logger: newTestLogger()}
+			if got := e.queryTimeout(types.AlertRule{Name: "r", QueryTimeout: tt.ruleTimeout}); got != tt.want {
+				t.Errorf("queryTimeout = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteRuleHonorsQueryTimeout(t *testing.T) {
+	canceledAt := make(chan time.Time, 1)
+	// 阻塞直到请求被取消，记录首次取消的时刻；读完请求体后服务端才能感知客户端断开
+	stub := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+		select {
+		case canceledAt <- time.Now():
+		default:
+		}
+	})
+	e := newStubDBEngine(t, &types.Config{}, stub)
+	rule := types.AlertRule{Name: "slow", Type: "frequency", Index: "big-*", Timeframe: 300, Threshold: 1, Enabled: true, QueryTimeout: 1}
+
+	start := time.Now()
+	result := e.executeRule(rule, false)
+	elapsed := time.Since(start)
+
+	if result.Error == "" {
+		t.Fatalf("查询超时应返回错误: %+v", result)
+	}
+	// opensearch.timeout 为 5 秒，规则 query_timeout 为 1 秒，应在约 1 秒时取消
+	if elapsed < 900*time.Millisecond || elapsed > 3*time.Second {
+		t.Errorf("执行耗时 %v，期望约 1 秒（规则 query_timeout）", elapsed)
+	}
+	select {
+	case canceled := <-canceledAt:
+		if d := canceled.Sub(start); d > 3*time.Second {
+			t.Errorf("请求在 %v 后才被取消", d)
+		}
+	case <-time.After(time.Second):
+		t.Error("OpenSearch 请求应随上下文超时被取消")
+	}
+}
@@ -0,0 +1,73 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestSuppressDuration(t *testing.T) {
+	tests := []struct {
+		name       string
+		suppress   types.AlertSuppressionConfig
+		realert    int
+		alertCount int
+		want       time.Duration
+	}{
+		{"未开启抑制", types.AlertSuppressionConfig{RealertMinutes: 10}, 0, 1, 0},
+		{"全局 realert_minutes", types.AlertSuppressionConfig{Enabled: true, RealertMinutes: 10}, 0, 1, 10 * time.Minute},
+		{"指数抑制随告警次数增长", types.AlertSuppressionConfig{Enabled: true, RealertMinutes: 10, ExponentialRealert: types.ExponentialRealertConfig{Enabled: true, Hours: 1}}, 0, 3, 3 * time.Hour},
+		{"规则 realert 优先于全局", types.AlertSuppressionConfig{Enabled: true, RealertMinutes: 10}, 30, 1, 30 * time.Second},
+		{"全局未开启时规则 realert 仍生效", types.AlertSuppressionConfig{}, 90, 5, 90 * time.Second},
+		{"规则 realert 不受指数抑制影响", types.AlertSuppressionConfig{Enabled: true, ExponentialRealert: types.ExponentialRealertConfig{Enabled: true, Hours: 1}}, 60, 4, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &types.Config{AlertSuppression: tt.suppress}
+			e := NewEngine(config, nil, nil, nil, newTestLogger())
+			if got := e.suppressDuration(types.AlertRule{Realert: tt.realert}, tt.alertCount); got != tt.want {
+				t.Errorf("suppressDuration = %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleRealertSuppressesUntilElapsed(t *testing.T) {
+	config := &types.Config{AlertSuppression: types.AlertSuppressionConfig{Enabled: true, RealertMinutes: 60}}
+	e := NewEngine(config, nil, nil, newTestDatabase(t), newTestLogger())
+	rule := types.AlertRule{Name: "realert", Realert: 120}
+
+	before := time.Now()
+	e.updateAlertStatus(rule, &types.Alert{RuleName: rule.Name, Timestamp: before})
+	if !e.isSuppressed(rule.Name) {
+		t.Fatal("告警后应在 realert 时间内抑制")
+	}
+
+	e.statusMutex.RLock()
+	status := *e.alertStatuses[rule.Name]
+	e.statusMutex.RUnlock()
+	// 抑制时长取规则的 120 秒而不是全局的 60 分钟
+	if until := status.SuppressUntil.Sub(before); until < 119*time.Second || until > 121*time.Second {
+		t.Errorf("抑制时长 = %s, 期望约 2 分钟", until)
+	}
+
+	// 模拟 realert 到期
+	e.statusMutex.Lock()
+	e.alertStatuses[rule.Name].SuppressUntil = time.Now().Add(-time.Second)
+	e.statusMutex.Unlock()
+	if e.isSuppressed(rule.Name) {
+		t.Error("realert 到期后应解除抑制")
+	}
+}
+
+func TestRuleWithoutRealertUsesGlobalSuppression(t *testing.T) {
+	config := &types.Config{}
+	e := NewEngine(config, nil, nil, newTestDatabase(t), newTestLogger())
+	rule := types.AlertRule{Name: "no-realert"}
+
+	e.updateAlertStatus(rule, &types.Alert{RuleName: rule.Name, Timestamp: time.Now()})
+	if e.isSuppressed(rule.Name) {
+		t.Error("规则未设置 realert 且全局未开启抑制时不应抑制")
+	}
+}
@@ -0,0 +1,77 @@
+package alert
+
+import (
+	"fmt"
+	"time"
+)
+
+// RuleErrorState 规则连续查询失败状态
+type RuleErrorState struct {
+	// Failures 以相同错误连续失败的次数
+	Failures int `json:"failures"`
+	// LastError 最近一次错误
+	LastError string `json:"last_error"`
+	// Errored 达到阈值后标记为出错，规则暂停执行直至重新启用
+	Errored bool `json:"errored"`
+	// Since 首次出现该错误的时间
+	Since time.Time `json:"since"`
+}
+
+// recordRuleFailure 记录规则查询错误，相同错误连续达到阈值时标记为出错并发送自监控告警
+func (e *Engine) recordRuleFailure(ruleName string, err error) {
+	msg := err.Error()
+
+	e.ruleErrorMutex.Lock()
+	state, ok := e.ruleErrors[ruleName]
+	if !ok || state.LastError != msg {
+		state = &RuleErrorState{LastError: msg, Since: time.Now()}
+		e.ruleErrors[ruleName] = state
+	}
+	state.Failures++
+	justErrored := !state.Errored && state.Failures >= e.config.AlertEngine.MaxRuleFailures
+	if justErrored {
+		state.Errored = true
+	}
+	failures := state.Failures
+	e.ruleErrorMutex.Unlock()
+
+	if !justErrored {
+		return
+	}
+
+	e.logger.Errorf("规则 %s 连续 %d 次查询失败（相同错误），已标记为出错并暂停执行: %v", ruleName, failures, err)
+	e.sendMetaAlert("rule-errored-"+ruleName,
+		fmt.Sprintf("规则 **%s** 连续 %d 次查询失败，已暂停执行。修复规则后请在 Web 中重新启用。\n\n错误: %s", ruleName, failures, msg))
+}
+
+// clearRuleFailure 查询成功后清除失败计数
+func (e *Engine) clearRuleFailure(ruleName string) {
+	e.ruleErrorMutex.Lock()
+	delete(e.ruleErrors, ruleName)
+	e.ruleErrorMutex.Unlock()
+}
+
+// isRuleErrored 判断规则是否已被标记为出错
+func (e *Engine) isRuleErrored(ruleName string) bool {
+	e.ruleErrorMutex.RLock()
+	defer e.ruleErrorMutex.RUnlock()
+	state, ok := e.ruleErrors[ruleName]
+	return ok && state.Errored
+}
+
+// RuleErrorStates 返回存在查询失败记录的规则状态
+func (e *Engine) RuleErrorStates() map[string]RuleErrorState {
+	e.ruleErrorMutex.RLock()
+	defer e.ruleErrorMutex.RUnlock()
+
+	states := make(map[string]RuleErrorState, len(e.ruleErrors))
+	for name, state := range e.ruleErrors {
+		states[name] = *state
+	}
+	return states
+}
+
+// ResetRuleError 清除规则的出错状态（规则修复或重新启用后调用）
+func (e *Engine) ResetRuleError(ruleName string) {
+	e.clearRuleFailure(ruleName)
+}
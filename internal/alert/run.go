@@ -0,0 +1,56 @@
+package alert
+
+import (
+	"errors"
+	"opensearch-alert/pkg/types"
+)
+
+// RuleRunResult 单次执行规则的结果
+type RuleRunResult struct {
+	Rule  string `json:"rule"`
+	Fired bool   `json:"fired"`
+	Hits  int    `json:"hits"`
+	// Reference spike 规则参考窗口（上一窗口）的命中数
+	Reference int          `json:"reference,omitempty"`
+	Skipped   string       `json:"skipped,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Alert     *types.Alert `json:"alert,omitempty"`
+}
+
+// ErrRuleNotFound 引擎中未加载该规则
+var ErrRuleNotFound = errors.New("规则未加载")
+
+// Rules 返回已加载规则的副本
+func (e *Engine) Rules() []types.AlertRule {
+	e.rulesMutex.RLock()
+	defer e.rulesMutex.RUnlock()
+	rules := make([]types.AlertRule, len(e.rules))
+	copy(rules, e.rules)
+	return rules
+}
+
+// Rule 按名称查找已加载的规则
+func (e *Engine) Rule(name string) (types.AlertRule, bool) {
+	e.rulesMutex.RLock()
+	defer e.rulesMutex.RUnlock()
+	for _, rule := range e.rules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return types.AlertRule{}, false
+}
+
+// RunRuleNow 立即同步执行一次规则（不受调度周期限制，仍遵循锁与静默）；force 为 true 时跳过告警抑制与去重
+func (e *Engine) RunRuleNow(name string, force bool) (*RuleRunResult, error) {
+	rule, ok := e.Rule(name)
+	if !ok {
+		return nil, ErrRuleNotFound
+	}
+	if !rule.Enabled {
+		return &RuleRunResult{Rule: name, Skipped: "规则已禁用"}, nil
+	}
+
+	e.logger.Infof("手动执行规则: %s (force=%v)", name, force)
+	return e.executeRule(rule, force), nil
+}
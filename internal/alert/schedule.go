@@ -0,0 +1,54 @@
+package alert
+
+import (
+	"opensearch-alert/pkg/types"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser 规则调度表达式解析器：支持标准 5 段、带秒的 6 段以及 @every 等描述符
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// scheduleRules 为配置了 schedule 的规则注册独立的定时任务（规则重新加载时先移除旧任务）
+func (e *Engine) scheduleRules(rules []types.AlertRule) {
+	e.scheduleMutex.Lock()
+	defer e.scheduleMutex.Unlock()
+
+	for name, id := range e.ruleEntries {
+		e.cron.Remove(id)
+		delete(e.ruleEntries, name)
+	}
+
+	for _, rule := range rules {
+		if rule.Schedule == "" || !rule.Enabled {
+			continue
+		}
+		rule := rule
+		id, err := e.cron.AddFunc(rule.Schedule, func() { e.runRule(rule) })
+		if err != nil {
+			e.logger.Errorf("规则 %s 的调度表达式 %q 无效，该规则不会执行: %v", rule.Name, rule.Schedule, err)
+			continue
+		}
+		e.ruleEntries[rule.Name] = id
+		e.logger.Infof("规则 %s 使用独立调度: %s", rule.Name, rule.Schedule)
+	}
+}
+
+// tryStartRule 标记规则开始执行，同一实例内上一轮尚未结束时返回 false
+func (e *Engine) tryStartRule(ruleName string) bool {
+	e.scheduleMutex.Lock()
+	defer e.scheduleMutex.Unlock()
+
+	if e.runningRules[ruleName] {
+		return false
+	}
+	e.runningRules[ruleName] = true
+	return true
+}
+
+// finishRule 标记规则执行结束
+func (e *Engine) finishRule(ruleName string) {
+	e.scheduleMutex.Lock()
+	delete(e.runningRules, ruleName)
+	e.scheduleMutex.Unlock()
+}
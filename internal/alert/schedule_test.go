@@ -0,0 +1,109 @@
+package alert
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// indexCounter 按索引统计查询次数的 OpenSearch 桩，查询均无命中（HEAD 索引存在性检查不计数）
+type indexCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *indexCounter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodHead {
+		index := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")[0]
+		c.mu.Lock()
+		c.counts[index]++
+		c.mu.Unlock()
+	}
+	w.Write([]byte(`{"hits": {"total": {"value": 0, "relation": "eq"}, "hits": []}}`))
+}
+
+// count 返回索引被查询的次数
+func (c *indexCounter) count(index string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[index]
+}
+
+func TestRuleScheduleFiresOnItsCadence(t *testing.T) {
+	stub := &indexCounter{counts: make(map[string]int)}
+	e := newStubDBEngine(t, &types.Config{}, stub)
+	e.LoadRules([]types.AlertRule{
+		{Name: "fast", Type: "any", Index: "fast-logs", Timeframe: 60, Enabled: true, Schedule: "@every 1s"},
+		{Name: "global", Type: "any", Index: "global-logs", Timeframe: 60, Enabled: true},
+		{Name: "disabled", Type: "any", Index: "disabled-logs", Timeframe: 60, Schedule: "@every 1s"},
+	})
+	if got := len(e.cron.Entries()); got != 1 {
+		t.Fatalf("只有启用且配置了 schedule 的规则应注册独立任务，实际 %d 个", got)
+	}
+
+	e.cron.Start()
+	defer e.cron.Stop()
+	time.Sleep(2500 * time.Millisecond)
+
+	if got := stub.count("fast-logs"); got < 2 || got > 3 {
+		t.Errorf("@every 1s 的规则 2.5 秒内应执行 2 次左右，实际 %d 次", got)
+	}
+	if got := stub.count("global-logs"); got != 0 {
+		t.Errorf("未配置 schedule 的规则只随全局周期执行，实际独立执行 %d 次", got)
+	}
+	if got := stub.count("disabled-logs"); got != 0 {
+		t.Errorf("禁用的规则不应执行，实际 %d 次", got)
+	}
+}
+
+func TestRunRulesSkipsScheduledRules(t *testing.T) {
+	stub := &indexCounter{counts: make(map[string]int)}
+	e := newStubDBEngine(t, &types.Config{}, stub)
+	e.LoadRules([]types.AlertRule{
+		{Name: "hourly", Type: "any", Index: "hourly-logs", Timeframe: 60, Enabled: true, Schedule: "0 0 * * * *"},
+		{Name: "global", Type: "any", Index: "global-logs", Timeframe: 60, Enabled: true},
+	})
+
+	e.runRules()
+	deadline := time.Now().Add(2 * time.Second)
+	for stub.count("global-logs") == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := stub.count("global-logs"); got != 1 {
+		t.Errorf("全局周期应执行未配置 schedule 的规则，实际 %d 次", got)
+	}
+	if got := stub.count("hourly-logs"); got != 0 {
+		t.Errorf("全局周期不应执行配置了 schedule 的规则，实际 %d 次", got)
+	}
+}
+
+func TestReloadReplacesRuleSchedules(t *testing.T) {
+	e := newTestEngine(t, &indexCounter{counts: make(map[string]int)})
+	e.LoadRules([]types.AlertRule{{Name: "a", Enabled: true, Schedule: "@every 1m"}, {Name: "b", Enabled: true, Schedule: "@every 1m"}})
+	e.LoadRules([]types.AlertRule{{Name: "a", Enabled: true, Schedule: "@every 1m"}, {Name: "b", Enabled: true}})
+
+	if got := len(e.cron.Entries()); got != 1 {
+		t.Errorf("重新加载后应只保留 1 个独立任务，实际 %d 个", got)
+	}
+	if _, ok := e.ruleEntries["b"]; ok {
+		t.Error("移除 schedule 的规则不应保留独立任务")
+	}
+}
+
+func TestTryStartRulePreventsOverlap(t *testing.T) {
+	e := NewEngine(&types.Config{}, nil, nil, nil, newTestLogger())
+	if !e.tryStartRule("r") {
+		t.Fatal("首次执行应成功")
+	}
+	if e.tryStartRule("r") {
+		t.Error("上一轮未结束时不应重复执行")
+	}
+	e.finishRule("r")
+	if !e.tryStartRule("r") {
+		t.Error("上一轮结束后应可再次执行")
+	}
+}
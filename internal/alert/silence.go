@@ -0,0 +1,70 @@
+package alert
+
+import (
+	"opensearch-alert/pkg/types"
+	"path"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// silenceActive 判断静默窗口在 now 时刻是否生效
+func silenceActive(silence types.Silence, now time.Time, location *time.Location) bool {
+	if silence.Cron != "" {
+		schedule, err := cron.ParseStandard(silence.Cron)
+		if err != nil || silence.Duration <= 0 {
+			return false
+		}
+		// 若 (now-duration, now] 内存在一次窗口开始时间，则窗口仍在进行中
+		duration := time.Duration(silence.Duration) * time.Minute
+		next := schedule.Next(now.Add(-duration).In(location))
+		return !next.After(now)
+	}
+
+	if silence.StartsAt.IsZero() || silence.EndsAt.IsZero() {
+		return false
+	}
+	return !now.Before(silence.StartsAt) && now.Before(silence.EndsAt)
+}
+
+// silenceMatches 判断静默窗口是否作用于该规则
+func silenceMatches(silence types.Silence, ruleName string) bool {
+	if silence.Rule == "" || silence.Rule == "*" {
+		return true
+	}
+	matched, err := path.Match(silence.Rule, ruleName)
+	return err == nil && matched
+}
+
+// Silences 返回配置文件与数据库中的全部静默窗口
+func (e *Engine) Silences() ([]types.Silence, error) {
+	silences := append([]types.Silence{}, e.config.Silences...)
+	stored, err := e.database.ListSilences()
+	if err != nil {
+		return silences, err
+	}
+	return append(silences, stored...), nil
+}
+
+// IsSilenceActive 判断静默窗口当前是否生效
+func (e *Engine) IsSilenceActive(silence types.Silence) bool {
+	return silenceActive(silence, time.Now(), e.config.Location())
+}
+
+// activeSilence 返回当前作用于该规则的静默窗口，没有则返回 nil
+func (e *Engine) activeSilence(ruleName string) *types.Silence {
+	silences, err := e.Silences()
+	if err != nil {
+		// 数据库异常时仍使用配置文件中的静默
+		e.logger.Warnf("读取静默窗口失败: %v", err)
+	}
+
+	now := time.Now()
+	location := e.config.Location()
+	for i := range silences {
+		if silenceMatches(silences[i], ruleName) && silenceActive(silences[i], now, location) {
+			return &silences[i]
+		}
+	}
+	return nil
+}
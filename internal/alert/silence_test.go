@@ -0,0 +1,142 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestSilenceActive(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 2, hour, minute, 0, 0, shanghai)
+	}
+
+	oneShot := types.Silence{StartsAt: at(2, 0), EndsAt: at(3, 0)}
+	nightly := types.Silence{Cron: "0 2 * * *", Duration: 60}
+	midnight := types.Silence{Cron: "30 23 * * *", Duration: 60}
+
+	tests := []struct {
+		name    string
+		silence types.Silence
+		now     time.Time
+		want    bool
+	}{
+		{"一次性窗口开始时刻", oneShot, at(2, 0), true},
+		{"一次性窗口内", oneShot, at(2, 59), true},
+		{"一次性窗口结束时刻不包含", oneShot, at(3, 0), false},
+		{"一次性窗口开始前", oneShot, at(1, 59), false},
+		{"缺少结束时间", types.Silence{StartsAt: at(2, 0)}, at(2, 30), false},
+		{"周期窗口内（按配置时区）", nightly, at(2, 30), true},
+		{"周期窗口结束后", nightly, at(3, 1), false},
+		{"周期窗口开始前", nightly, at(1, 59), false},
+		{"跨零点的周期窗口", midnight, time.Date(2024, 1, 3, 0, 15, 0, 0, shanghai), true},
+		{"跨零点窗口结束后", midnight, time.Date(2024, 1, 3, 0, 31, 0, 0, shanghai), false},
+		{"周期窗口时长为 0", types.Silence{Cron: "0 2 * * *"}, at(2, 0), false},
+		{"无效 cron", types.Silence{Cron: "bogus", Duration: 60}, at(2, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := silenceActive(tt.silence, tt.now, shanghai); got != tt.want {
+				t.Errorf("silenceActive = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	// 同一时刻在 UTC 下不处于 02:00-03:00 窗口
+	if silenceActive(nightly, at(2, 30), time.UTC) {
+		t.Error("周期窗口应按配置时区计算")
+	}
+}
+
+func TestSilenceMatches(t *testing.T) {
+	tests := []struct {
+		pattern, rule string
+		want          bool
+	}{
+		{"", "any", true},
+		{"*", "any", true},
+		{"k8s-*", "k8s-pod", true},
+		{"k8s-*", "app-error", false},
+		{"app-error", "app-error", true},
+		{"[", "[", false},
+	}
+	for _, tt := range tests {
+		if got := silenceMatches(types.Silence{Rule: tt.pattern}, tt.rule); got != tt.want {
+			t.Errorf("silenceMatches(%q, %q) = %v, 期望 %v", tt.pattern, tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestOverlappingSilences(t *testing.T) {
+	now := time.Now()
+	config := &types.Config{}
+	config.Silences = []types.Silence{
+		{Rule: "k8s-*", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), Comment: "集群维护"},
+	}
+	e := NewEngine(config, nil, nil, newTestDatabase(t), newTestLogger())
+
+	// 数据库中两个互相重叠、且与配置窗口重叠的静默
+	podID, err := e.database.SaveSilence(&types.Silence{Rule: "k8s-pod", StartsAt: now.Add(-10 * time.Minute), EndsAt: now.Add(2 * time.Hour), Comment: "Pod 迁移"})
+	if err != nil {
+		t.Fatalf("保存静默失败: %v", err)
+	}
+	if _, err := e.database.SaveSilence(&types.Silence{Rule: "*", StartsAt: now.Add(-5 * time.Minute), EndsAt: now.Add(30 * time.Minute), Comment: "全局冻结"}); err != nil {
+		t.Fatalf("保存静默失败: %v", err)
+	}
+
+	silence := e.activeSilence("k8s-pod")
+	if silence == nil || silence.Comment != "集群维护" {
+		t.Fatalf("多个静默同时生效时应返回首个匹配（配置文件优先），实际 %+v", silence)
+	}
+
+	// 配置窗口结束后，仍由重叠的数据库窗口覆盖
+	config.Silences[0].EndsAt = now.Add(-time.Minute)
+	if silence := e.activeSilence("k8s-pod"); silence == nil || silence.Comment != "Pod 迁移" {
+		t.Fatalf("配置窗口结束后应由重叠窗口继续静默，实际 %+v", silence)
+	}
+
+	// 删除其中一个重叠窗口，剩余的全局窗口仍生效
+	if ok, err := e.database.DeleteSilence(podID); err != nil || !ok {
+		t.Fatalf("删除静默失败: %v", err)
+	}
+	if silence := e.activeSilence("k8s-pod"); silence == nil || silence.Comment != "全局冻结" {
+		t.Fatalf("删除一个窗口后其余重叠窗口应继续生效，实际 %+v", silence)
+	}
+	if silence := e.activeSilence("app-error"); silence == nil || silence.Comment != "全局冻结" {
+		t.Fatalf("通配窗口应作用于所有规则，实际 %+v", silence)
+	}
+}
+
+func TestCleanExpiredSilencesKeepsOverlappingActive(t *testing.T) {
+	e := NewEngine(&types.Config{}, nil, nil, newTestDatabase(t), newTestLogger())
+	now := time.Now()
+	for _, silence := range []types.Silence{
+		{Rule: "r", StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour), Comment: "已结束"},
+		{Rule: "r", StartsAt: now.Add(-90 * time.Minute), EndsAt: now.Add(time.Hour), Comment: "进行中"},
+		{Rule: "r", Cron: "0 2 * * *", Duration: 60, Comment: "周期"},
+	} {
+		silence := silence
+		if _, err := e.database.SaveSilence(&silence); err != nil {
+			t.Fatalf("保存静默失败: %v", err)
+		}
+	}
+
+	e.cleanExpiredSilences()
+
+	silences, err := e.Silences()
+	if err != nil {
+		t.Fatalf("读取静默失败: %v", err)
+	}
+	comments := map[string]bool{}
+	for _, silence := range silences {
+		comments[silence.Comment] = true
+	}
+	if len(silences) != 2 || !comments["进行中"] || !comments["周期"] {
+		t.Errorf("只应清理已结束的一次性窗口，剩余 %v", comments)
+	}
+	if silence := e.activeSilence("r"); silence == nil || silence.Comment != "进行中" {
+		t.Errorf("进行中的窗口应继续生效，实际 %+v", silence)
+	}
+}
@@ -0,0 +1,49 @@
+package alert
+
+import (
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// SnoozeRule 暂停规则告警 duration 时长：设置告警状态的暂停截止时间并持久化，重启后仍然生效
+func (e *Engine) SnoozeRule(name string, duration time.Duration) (types.AlertStatus, error) {
+	if _, ok := e.Rule(name); !ok {
+		return types.AlertStatus{}, ErrRuleNotFound
+	}
+
+	e.statusMutex.Lock()
+	status := e.alertStatuses[name]
+	if status == nil {
+		status = &types.AlertStatus{RuleName: name}
+		e.alertStatuses[name] = status
+	}
+	status.SnoozedUntil = time.Now().Add(duration)
+	snapshot := *status
+	e.statusMutex.Unlock()
+
+	e.persistAlertStatus(snapshot)
+	e.logger.Infof("规则 %s 已暂停告警至 %s", name, snapshot.SnoozedUntil.Format("2006-01-02 15:04:05"))
+	return snapshot, nil
+}
+
+// UnsnoozeRule 立即解除规则的告警暂停，realert 产生的抑制不受影响
+func (e *Engine) UnsnoozeRule(name string) (types.AlertStatus, error) {
+	if _, ok := e.Rule(name); !ok {
+		return types.AlertStatus{}, ErrRuleNotFound
+	}
+
+	e.statusMutex.Lock()
+	status := e.alertStatuses[name]
+	if status == nil {
+		e.statusMutex.Unlock()
+		return types.AlertStatus{RuleName: name}, nil
+	}
+	status.SnoozedUntil = time.Time{}
+	snapshot := *status
+	e.statusMutex.Unlock()
+
+	e.persistAlertStatus(snapshot)
+	e.logger.Infof("规则 %s 已解除告警暂停", name)
+	return snapshot, nil
+}
@@ -0,0 +1,88 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"opensearch-alert/internal/notification"
+	"opensearch-alert/pkg/types"
+)
+
+// newSnoozeTestEngine 创建带数据库并加载了规则 r 的引擎
+func newSnoozeTestEngine(t *testing.T) (*Engine, types.AlertRule) {
+	t.Helper()
+	rule := types.AlertRule{Name: "r", Type: "any", Index: "logs-*", Realert: 1, AutoResolve: true}
+	config := &types.Config{}
+	e := NewEngine(config, nil, notification.NewNotifier(config, newTestLogger()), newTestDatabase(t), newTestLogger())
+	e.LoadRules([]types.AlertRule{rule})
+	return e, rule
+}
+
+func TestSnoozeRuleSuppressesUntilElapsed(t *testing.T) {
+	e, _ := newSnoozeTestEngine(t)
+
+	if _, err := e.SnoozeRule("missing", time.Minute); err != ErrRuleNotFound {
+		t.Fatalf("未加载的规则应返回 ErrRuleNotFound，实际 %v", err)
+	}
+
+	if _, err := e.SnoozeRule("r", 100*time.Millisecond); err != nil {
+		t.Fatalf("SnoozeRule 失败: %v", err)
+	}
+	if !e.isSuppressed("r") {
+		t.Fatal("暂停期间规则应处于抑制状态")
+	}
+	time.Sleep(150 * time.Millisecond)
+	if e.isSuppressed("r") {
+		t.Fatal("暂停到期后规则不应再被抑制")
+	}
+}
+
+func TestSnoozeSurvivesRealertAndResolve(t *testing.T) {
+	e, rule := newSnoozeTestEngine(t)
+	status, err := e.SnoozeRule("r", time.Hour)
+	if err != nil {
+		t.Fatalf("SnoozeRule 失败: %v", err)
+	}
+
+	// 强制执行触发告警时按 realert（1 秒）更新抑制时间，不应缩短暂停
+	e.updateAlertStatus(rule, &types.Alert{RuleName: "r", Timestamp: time.Now()})
+	// 自动恢复只解除 realert 抑制
+	if err := e.database.SetOpenAlert("r", "r-1"); err != nil {
+		t.Fatalf("SetOpenAlert 失败: %v", err)
+	}
+	e.resolveRule(rule, &types.OpenSearchResponse{})
+	if open, _ := e.database.GetOpenAlert("r"); open != "" {
+		t.Fatalf("告警应已恢复，仍有未恢复告警 %s", open)
+	}
+
+	if !e.isSuppressed("r") {
+		t.Fatal("realert 与自动恢复不应解除暂停")
+	}
+	e.statusMutex.RLock()
+	snoozedUntil := e.alertStatuses["r"].SnoozedUntil
+	e.statusMutex.RUnlock()
+	if !snoozedUntil.Equal(status.SnoozedUntil) {
+		t.Fatalf("暂停截止时间被修改: %v -> %v", status.SnoozedUntil, snoozedUntil)
+	}
+}
+
+func TestSnoozePersistsAndUnsnooze(t *testing.T) {
+	e, _ := newSnoozeTestEngine(t)
+	if _, err := e.SnoozeRule("r", time.Hour); err != nil {
+		t.Fatalf("SnoozeRule 失败: %v", err)
+	}
+
+	// 模拟重启：新引擎从数据库恢复状态
+	restarted := NewEngine(&types.Config{}, nil, nil, e.database, newTestLogger())
+	restarted.restoreAlertStatuses()
+	if !restarted.isSuppressed("r") {
+		t.Fatal("重启后暂停应仍然生效")
+	}
+
+	if _, err := e.UnsnoozeRule("r"); err != nil {
+		t.Fatalf("UnsnoozeRule 失败: %v", err)
+	}
+	if e.isSuppressed("r") {
+		t.Fatal("解除暂停后规则不应被抑制")
+	}
+}
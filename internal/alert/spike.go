@@ -0,0 +1,128 @@
+package alert
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"opensearch-alert/internal/opensearch"
+	"opensearch-alert/pkg/types"
+)
+
+// defaultSpikeHeight spike 规则未设置 spike_height 时的突变倍数
+const defaultSpikeHeight = 2.0
+
+// spikeHeight 返回规则的突变倍数
+func spikeHeight(rule types.AlertRule) float64 {
+	if rule.SpikeHeight > 0 {
+		return rule.SpikeHeight
+	}
+	return defaultSpikeHeight
+}
+
+// spikeDetected 比较当前窗口与参考窗口（紧邻的上一窗口）的命中数判断是否突变
+//
+// 突增（up）要求当前命中数不低于 threshold 且达到参考窗口的 spike_height 倍；
+// 突降（down）要求参考窗口命中数不低于 threshold 且当前命中数不高于其 1/spike_height。
+func spikeDetected(rule types.AlertRule, current, reference int) bool {
+	height := spikeHeight(rule)
+	up := current >= rule.Threshold && current > reference && float64(current) >= float64(reference)*height
+	down := reference >= rule.Threshold && current < reference && float64(current)*height <= float64(reference)
+
+	switch rule.SpikeType {
+	case "down":
+		return down
+	case "both":
+		return up || down
+	default:
+		return up
+	}
+}
+
+// referenceWindow 返回当前窗口的参考窗口：紧邻其前、长度为 timeframe 的窗口
+func referenceWindow(rule types.AlertRule, window opensearch.TimeWindow) opensearch.TimeWindow {
+	return opensearch.SlidingWindow(rule, window.Start)
+}
+
+// referenceCount 返回 spike 规则参考窗口的命中数：参考窗口恰好是之前某轮的当前窗口时直接复用其计数，
+// 否则执行一次 _count 查询
+func (e *Engine) referenceCount(ctx context.Context, rule types.AlertRule, window opensearch.TimeWindow) (int, error) {
+	ref := referenceWindow(rule, window)
+	if count, ok := e.windowCounts.get(rule.Name, ref.End); ok {
+		e.logger.Debugf("规则 %s 参考窗口命中缓存: %d", rule.Name, count)
+		return count, nil
+	}
+
+	query := e.opensearchClient.BuildWindowQuery(rule, ref)
+	return e.opensearchClient.Count(ctx, opensearch.RuleIndex(rule), map[string]interface{}{"query": query["query"]})
+}
+
+// recordWindowCount 记录 spike 规则当前窗口的命中数，供后续窗口作为参考窗口复用
+func (e *Engine) recordWindowCount(rule types.AlertRule, window opensearch.TimeWindow, count int) {
+	if rule.Type != "spike" {
+		return
+	}
+	e.windowCounts.put(rule.Name, window.End, time.Duration(rule.Timeframe)*time.Second, count)
+}
+
+// invalidateWindowCounts 规则重新加载后丢弃定义有变化（含删除）的规则的窗口计数
+func (e *Engine) invalidateWindowCounts(oldRules, newRules []types.AlertRule) {
+	newByName := make(map[string]types.AlertRule, len(newRules))
+	for _, rule := range newRules {
+		newByName[rule.Name] = rule
+	}
+	for _, old := range oldRules {
+		if rule, ok := newByName[old.Name]; !ok || !reflect.DeepEqual(old, rule) {
+			e.windowCounts.invalidate(old.Name)
+		}
+	}
+}
+
+// windowCountCache spike 规则的窗口计数缓存：规则名 + 窗口终点（秒）→ 命中数
+//
+// 窗口终点按秒对齐（与查询中 RFC3339 的精度一致），调度间隔能整除 timeframe 时，
+// 当前窗口的参考窗口就是 timeframe 之前那一轮的当前窗口，可省去一次 _count 查询。
+type windowCountCache struct {
+	mu     sync.Mutex
+	counts map[string]map[int64]int
+}
+
+// newWindowCountCache 创建窗口计数缓存
+func newWindowCountCache() *windowCountCache {
+	return &windowCountCache{counts: make(map[string]map[int64]int)}
+}
+
+// get 查询规则在指定窗口终点的命中数
+func (c *windowCountCache) get(rule string, end time.Time) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count, ok := c.counts[rule][end.Unix()]
+	return count, ok
+}
+
+// put 记录窗口命中数，并清理终点早于 end - timeframe 的记录（之后的窗口不会再引用）
+func (c *windowCountCache) put(rule string, end time.Time, timeframe time.Duration, count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := c.counts[rule]
+	if entries == nil {
+		entries = make(map[int64]int)
+		c.counts[rule] = entries
+	}
+	entries[end.Unix()] = count
+
+	oldest := end.Add(-timeframe).Unix()
+	for key := range entries {
+		if key < oldest {
+			delete(entries, key)
+		}
+	}
+}
+
+// invalidate 丢弃规则的全部窗口计数
+func (c *windowCountCache) invalidate(rule string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.counts, rule)
+}
@@ -0,0 +1,137 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"opensearch-alert/internal/opensearch"
+	"opensearch-alert/pkg/types"
+)
+
+func TestSpikeDetected(t *testing.T) {
+	tests := []struct {
+		name      string
+		spikeType string
+		height    float64
+		current   int
+		reference int
+		want      bool
+	}{
+		{"突增达到默认倍数", "", 0, 20, 10, true},
+		{"突增未达到倍数", "", 0, 19, 10, false},
+		{"参考窗口为零", "up", 0, 10, 0, true},
+		{"低于阈值", "up", 0, 4, 0, false},
+		{"自定义倍数", "up", 3, 25, 10, false},
+		{"突降", "down", 0, 5, 10, true},
+		{"突降参考窗口低于阈值", "down", 0, 0, 4, false},
+		{"突增规则不报突降", "up", 0, 1, 10, false},
+		{"双向", "both", 0, 1, 10, true},
+		{"持平", "both", 0, 10, 10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := types.AlertRule{Type: "spike", Threshold: 5, SpikeType: tt.spikeType, SpikeHeight: tt.height}
+			if got := spikeDetected(rule, tt.current, tt.reference); got != tt.want {
+				t.Errorf("spikeDetected(%d, %d) = %v, 期望 %v", tt.current, tt.reference, got, tt.want)
+			}
+		})
+	}
+}
+
+// countingHandler 统计 _count 请求次数，每次返回 count
+func countingHandler(calls *int32, count int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/_count") {
+			atomic.AddInt32(calls, 1)
+			fmt.Fprintf(w, `{"count": %d}`, count)
+			return
+		}
+		w.Write([]byte(`{"hits": {"total": {"value": 30, "relation": "eq"}, "hits": []}}`))
+	})
+}
+
+func TestReferenceCountReusesContiguousWindow(t *testing.T) {
+	var calls int32
+	e := newTestEngine(t, countingHandler(&calls, 7))
+	rule := types.AlertRule{Name: "spike", Type: "spike", Index: "logs-*", Timeframe: 300}
+	ctx := context.Background()
+	t0 := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	// 上一轮的当前窗口恰好是本轮的参考窗口：复用计数，不发送 _count
+	previous := opensearch.SlidingWindow(rule, t0)
+	e.recordWindowCount(rule, previous, 5)
+	current := opensearch.SlidingWindow(rule, t0.Add(5*time.Minute).Add(300*time.Millisecond))
+	got, err := e.referenceCount(ctx, rule, current)
+	if err != nil {
+		t.Fatalf("referenceCount 失败: %v", err)
+	}
+	if got != 5 || atomic.LoadInt32(&calls) != 0 {
+		t.Fatalf("连续窗口应命中缓存: count=%d, _count 调用 %d 次", got, calls)
+	}
+
+	// 窗口不连续时查询参考窗口
+	gap := opensearch.SlidingWindow(rule, t0.Add(7*time.Minute))
+	got, err = e.referenceCount(ctx, rule, gap)
+	if err != nil {
+		t.Fatalf("referenceCount 失败: %v", err)
+	}
+	if got != 7 || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("不连续窗口应查询 _count: count=%d, _count 调用 %d 次", got, calls)
+	}
+}
+
+func TestReferenceCountInvalidatedOnRuleChange(t *testing.T) {
+	var calls int32
+	e := newTestEngine(t, countingHandler(&calls, 7))
+	rule := types.AlertRule{Name: "spike", Type: "spike", Index: "logs-*", Timeframe: 300}
+	e.LoadRules([]types.AlertRule{rule})
+
+	t0 := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	e.recordWindowCount(rule, opensearch.SlidingWindow(rule, t0), 5)
+
+	changed := rule
+	changed.Query = map[string]interface{}{"match": map[string]interface{}{"level": "error"}}
+	e.LoadRules([]types.AlertRule{changed})
+
+	got, err := e.referenceCount(context.Background(), changed, opensearch.SlidingWindow(changed, t0.Add(5*time.Minute)))
+	if err != nil {
+		t.Fatalf("referenceCount 失败: %v", err)
+	}
+	if got != 7 || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("规则修改后应重新查询: count=%d, _count 调用 %d 次", got, calls)
+	}
+}
+
+func TestWindowCountCachePrunesOldWindows(t *testing.T) {
+	cache := newWindowCountCache()
+	t0 := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+	cache.put("r", t0, 5*time.Minute, 1)
+	cache.put("r", t0.Add(5*time.Minute), 5*time.Minute, 2)
+	cache.put("r", t0.Add(6*time.Minute), 5*time.Minute, 3)
+
+	if _, ok := cache.get("r", t0); ok {
+		t.Error("早于 end - timeframe 的窗口应被清理")
+	}
+	if count, ok := cache.get("r", t0.Add(5*time.Minute)); !ok || count != 2 {
+		t.Errorf("get = %d, %v，期望 2, true", count, ok)
+	}
+}
+
+func TestTestRuleSpikeUsesReferenceWindow(t *testing.T) {
+	var calls int32
+	e := newTestEngine(t, countingHandler(&calls, 7))
+	rule := types.AlertRule{Name: "spike", Type: "spike", Index: "logs-*", Timeframe: 300, Threshold: 10}
+
+	result, err := e.TestRule(context.Background(), rule)
+	if err != nil {
+		t.Fatalf("TestRule 失败: %v", err)
+	}
+	if !result.WouldTrigger || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("当前 30 条、参考窗口 7 条应判定为突增: would_trigger=%v, _count 调用 %d 次", result.WouldTrigger, calls)
+	}
+}
@@ -0,0 +1,55 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestSuppressionSurvivesRestart(t *testing.T) {
+	db := newTestDatabase(t)
+	config := &types.Config{}
+	e := NewEngine(config, nil, nil, db, newTestLogger())
+
+	active := types.AlertRule{Name: "active", Realert: 3600}
+	expired := types.AlertRule{Name: "expired", Realert: 3600}
+	e.updateAlertStatus(active, &types.Alert{RuleName: active.Name, Timestamp: time.Now()})
+	e.updateAlertStatus(active, &types.Alert{RuleName: active.Name, Timestamp: time.Now()})
+	e.updateAlertStatus(expired, &types.Alert{RuleName: expired.Name, Timestamp: time.Now()})
+
+	// 模拟抑制已在停机期间到期
+	e.statusMutex.Lock()
+	status := *e.alertStatuses[expired.Name]
+	e.statusMutex.Unlock()
+	status.SuppressUntil = time.Now().Add(-time.Minute)
+	e.persistAlertStatus(status)
+
+	// 模拟重启：新引擎从数据库恢复状态
+	restarted := NewEngine(config, nil, nil, db, newTestLogger())
+	restarted.restoreAlertStatuses()
+
+	if !restarted.isSuppressed(active.Name) {
+		t.Error("重启后未到期的抑制应继续生效")
+	}
+	if restarted.isSuppressed(expired.Name) {
+		t.Error("停机期间已到期的抑制应在恢复时解除")
+	}
+
+	restarted.statusMutex.RLock()
+	defer restarted.statusMutex.RUnlock()
+	if got := restarted.alertStatuses[active.Name].AlertCount; got != 2 {
+		t.Errorf("告警次数 = %d, 期望 2（指数抑制依赖该计数）", got)
+	}
+	if restarted.alertStatuses[expired.Name].Suppressed {
+		t.Error("已到期的抑制恢复后应标记为未抑制")
+	}
+}
+
+func TestRestoreAlertStatusesWithoutHistory(t *testing.T) {
+	e := NewEngine(&types.Config{}, nil, nil, newTestDatabase(t), newTestLogger())
+	e.restoreAlertStatuses()
+	if e.isSuppressed("any") {
+		t.Error("没有历史状态时不应抑制")
+	}
+}
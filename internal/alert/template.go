@@ -3,60 +3,122 @@ package alert
 import (
 	"encoding/json"
 	"fmt"
+	"opensearch-alert/internal/opensearch"
 	"opensearch-alert/pkg/types"
 	"regexp"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
+	"unicode/utf8"
 )
 
 // TemplateEngine 模板引擎
-type TemplateEngine struct{}
+type TemplateEngine struct {
+	// now 当前时间来源，便于测试注入固定时钟
+	now func() time.Time
+	// location 消息中时间的显示时区
+	location *time.Location
+	// levels 级别图标等元数据
+	levels types.LevelTable
+	// logSnippetLength 日志内容截取长度（字符），0 表示使用默认值
+	logSnippetLength int
+}
+
+// defaultLogSnippetLength 日志内容默认截取长度（字符）
+const defaultLogSnippetLength = 500
+
+// NewTemplateEngine 创建模板引擎，location 为空时使用本地时区，levels 为空时使用内置级别表
+func NewTemplateEngine(location *time.Location, levels types.LevelTable) *TemplateEngine {
+	if location == nil {
+		location = time.Local
+	}
+	return &TemplateEngine{
+		now:      time.Now,
+		location: location,
+		levels:   levels,
+	}
+}
+
+// snippetLength 日志内容截取长度
+func (te *TemplateEngine) snippetLength() int {
+	if te.logSnippetLength > 0 {
+		return te.logSnippetLength
+	}
+	return defaultLogSnippetLength
+}
+
+// levelEmoji 消息标题图标，规则未设置级别时沿用 🚨
+func (te *TemplateEngine) levelEmoji(level string) string {
+	if level == "" {
+		return "🚨"
+	}
+	return te.levels.Lookup(level).Emoji
+}
+
+// SetClock 设置时间来源
+func (te *TemplateEngine) SetClock(now func() time.Time) {
+	if now != nil {
+		te.now = now
+	}
+}
 
-// NewTemplateEngine 创建模板引擎
-func NewTemplateEngine() *TemplateEngine {
-	return &TemplateEngine{}
+// SetLocation 设置消息中时间的显示时区
+func (te *TemplateEngine) SetLocation(loc *time.Location) {
+	if loc != nil {
+		te.location = loc
+	}
 }
 
-// BuildAlertMessage 根据事件类型构建告警消息
+// placeholderPattern 自定义模板占位符 ${path.to.field}
+var placeholderPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// BuildAlertMessage 构建告警消息，指标规则在消息前附加聚合结果
 func (te *TemplateEngine) BuildAlertMessage(rule types.AlertRule, response *types.OpenSearchResponse) string {
-	// 根据索引类型确定事件类型
-	eventType := te.detectEventType(rule.Index)
-
-	// 若设置了自定义模板，则在自定义文本后追加系统默认详情，避免信息过少
-	if rule.AlertText != "" {
-		custom := te.buildCustomAlertMessage(rule, response)
-		var details string
-		switch eventType {
-		case "events":
-			details = te.buildEventAlertMessage(rule, response)
-		case "logging":
-			if strings.Contains(rule.Name, "系统组件") {
-				details = te.buildSystemComponentLoggingAlertMessage(rule, response)
-			} else {
-				details = te.buildLoggingAlertMessage(rule, response)
-			}
-		case "auditing":
-			details = te.buildAuditingAlertMessage(rule, response)
-		default:
-			details = te.buildDefaultAlertMessage(rule, response)
-		}
-		// 合并（自定义在上，详情在下）
-		if custom == "" {
-			return details
-		}
-		return custom + "\n\n" + details
+	message := te.buildMessage(rule, response)
+	// replace 模式只使用自定义文本，不附加指标摘要
+	if rule.Type != "metric" || (rule.TemplateMode == "replace" && rule.AlertText != "") {
+		return message
 	}
+	value, ok := opensearch.MetricValue(response)
+	if !ok {
+		return message
+	}
+	summary := fmt.Sprintf("📊 **指标:** %s(%s) = %s（条件: %s %s）",
+		rule.MetricAgg, rule.MetricField, strconv.FormatFloat(value, 'f', -1, 64),
+		rule.MetricOperator, strconv.FormatFloat(rule.MetricThreshold, 'f', -1, 64))
+	return summary + "\n\n" + message
+}
 
+// buildMessage 根据事件类型构建告警消息
+func (te *TemplateEngine) buildMessage(rule types.AlertRule, response *types.OpenSearchResponse) string {
 	// 未设置自定义模板时，走系统默认详情
-	// 根据索引类型确定事件类型
-	// eventType 已在上面计算
+	if rule.AlertText == "" {
+		return te.buildDetailMessage(rule, response)
+	}
+
+	// 自定义文本与系统默认详情按 template_mode 组合：append（默认，自定义在上）、prepend（详情在上）、replace（仅自定义）
+	custom := te.buildCustomAlertMessage(rule, response)
+	if custom == "" {
+		return te.buildDetailMessage(rule, response)
+	}
+	switch rule.TemplateMode {
+	case "replace":
+		return custom
+	case "prepend":
+		return te.buildDetailMessage(rule, response) + "\n\n" + custom
+	default:
+		return custom + "\n\n" + te.buildDetailMessage(rule, response)
+	}
+}
 
-	switch eventType {
+// buildDetailMessage 按事件类型选择系统默认详情模板
+func (te *TemplateEngine) buildDetailMessage(rule types.AlertRule, response *types.OpenSearchResponse) string {
+	switch te.ruleEventType(rule) {
 	case "events":
 		return te.buildEventAlertMessage(rule, response)
 	case "logging":
-		// 根据规则名称选择不同的日志模板
-		if strings.Contains(rule.Name, "系统组件") {
+		if te.isSystemComponent(rule) {
 			return te.buildSystemComponentLoggingAlertMessage(rule, response)
 		}
 		return te.buildLoggingAlertMessage(rule, response)
@@ -67,8 +129,28 @@ func (te *TemplateEngine) BuildAlertMessage(rule types.AlertRule, response *type
 	}
 }
 
+// ruleEventType 规则的事件类型：显式配置的 event_type 优先，否则按索引名推断
+func (te *TemplateEngine) ruleEventType(rule types.AlertRule) string {
+	if rule.EventType != "" {
+		return rule.EventType
+	}
+	return te.detectEventType(opensearch.RuleIndex(rule))
+}
+
+// isSystemComponent 是否使用系统组件日志模板：显式配置的 event_subtype 优先，未配置时沿用规则名包含“系统组件”的约定
+func (te *TemplateEngine) isSystemComponent(rule types.AlertRule) bool {
+	if rule.EventSubtype != "" {
+		return rule.EventSubtype == "system_component"
+	}
+	return strings.Contains(rule.Name, "系统组件")
+}
+
 // buildCustomAlertMessage 使用 AlertText/AlertTextArgs 构建自定义告警文本
 func (te *TemplateEngine) buildCustomAlertMessage(rule types.AlertRule, response *types.OpenSearchResponse) string {
+	if rule.AlertTextType == "go_template" {
+		return te.buildGoTemplateMessage(rule, response)
+	}
+
 	text := rule.AlertText
 	var source map[string]interface{}
 	if len(response.Hits.Hits) > 0 {
@@ -78,9 +160,8 @@ func (te *TemplateEngine) buildCustomAlertMessage(rule types.AlertRule, response
 	}
 
 	// 占位符替换：支持 ${path.to.field}
-	placeholder := regexp.MustCompile(`\$\{([^}]+)\}`)
-	text = placeholder.ReplaceAllStringFunc(text, func(m string) string {
-		sub := placeholder.FindStringSubmatch(m)
+	text = placeholderPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := placeholderPattern.FindStringSubmatch(m)
 		if len(sub) < 2 {
 			return ""
 		}
@@ -108,8 +189,88 @@ func (te *TemplateEngine) buildCustomAlertMessage(rule types.AlertRule, response
 	return b.String()
 }
 
-// detectEventType 检测事件类型
+// buildGoTemplateMessage 以 Go 模板渲染 alert_text
+// 模板数据：.Source 首条命中的 _source，.Hits 全部命中，.Rule 规则，.Total 匹配总数
+func (te *TemplateEngine) buildGoTemplateMessage(rule types.AlertRule, response *types.OpenSearchResponse) string {
+	source := make(map[string]interface{})
+	if len(response.Hits.Hits) > 0 && response.Hits.Hits[0].Source != nil {
+		source = response.Hits.Hits[0].Source
+	}
+
+	funcs := template.FuncMap{
+		// default 值为空时返回默认值：{{ default "-" .Source.user }}
+		"default": func(def, val interface{}) interface{} {
+			if isEmptyValue(val) {
+				return def
+			}
+			return val
+		},
+		// levelEmoji 级别图标：{{ levelEmoji .Rule.Level }}
+		"levelEmoji": te.levelEmoji,
+		// get 按点路径取值：{{ get .Source "kubernetes.pod_name" }}
+		"get": func(data interface{}, path string) string {
+			m, ok := data.(map[string]interface{})
+			if !ok {
+				return ""
+			}
+			return te.getValueByPath(m, path)
+		},
+	}
+
+	tmpl, err := template.New(rule.Name).Funcs(funcs).Option("missingkey=zero").Parse(rule.AlertText)
+	if err != nil {
+		return fmt.Sprintf("alert_text 模板解析失败: %v", err)
+	}
+
+	data := map[string]interface{}{
+		"Source": source,
+		"Hits":   response.Hits.Hits,
+		"Rule":   rule,
+		"Total":  response.Hits.Total.Value,
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return fmt.Sprintf("alert_text 模板渲染失败: %v", err)
+	}
+	return b.String()
+}
+
+// isEmptyValue 判断模板值是否为空
+func isEmptyValue(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case int:
+		return v == 0
+	case float64:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// detectEventType 检测事件类型；多个索引时各索引类型一致才使用对应模板，混合类型使用默认模板
 func (te *TemplateEngine) detectEventType(index string) string {
+	if strings.Contains(index, ",") {
+		eventType := ""
+		for _, part := range strings.Split(index, ",") {
+			partType := te.detectEventType(part)
+			if eventType != "" && partType != eventType {
+				return "default"
+			}
+			eventType = partType
+		}
+		return eventType
+	}
+
 	if strings.Contains(index, "events") {
 		return "events"
 	} else if strings.Contains(index, "logging") {
@@ -145,7 +306,7 @@ func (te *TemplateEngine) buildEventAlertMessage(rule types.AlertRule, response
 	lastTimestamp := te.getTimeValue(hit, "lastTimestamp")
 	count := te.getIntValue(hit, "count")
 
-	return fmt.Sprintf(`🚨 **Kubernetes 事件告警**
+	return fmt.Sprintf(`%s **Kubernetes 事件告警**
 
 **规则名称:** %s
 **事件类型:** %s
@@ -158,7 +319,7 @@ func (te *TemplateEngine) buildEventAlertMessage(rule types.AlertRule, response
 **最后发生:** %s
 **发生次数:** %d
 **匹配记录数:** %d`,
-		rule.Name, eventType, reason, objectKind, objectName, objectNamespace,
+		te.levelEmoji(rule.Level), rule.Name, eventType, reason, objectKind, objectName, objectNamespace,
 		message, firstTimestamp, lastTimestamp, count, response.Hits.Total.Value)
 }
 
@@ -183,24 +344,22 @@ func (te *TemplateEngine) buildLoggingAlertMessage(rule types.AlertRule, respons
 	containerImage := te.getStringValue(kubernetes, "container_image")
 
 	// 截取日志内容（避免过长）
-	if len(log) > 500 {
-		log = log[:500] + "..."
-	}
+	log = truncateRunes(log, te.snippetLength())
 
 	// 根据规则名称确定告警类型
 	alertType := "应用日志告警"
-	if strings.Contains(rule.Name, "系统组件") {
+	if te.isSystemComponent(rule) {
 		alertType = "系统组件日志告警"
 	} else if strings.Contains(rule.Name, "Pod") {
 		alertType = "Pod日志告警"
 	}
 
 	// 构建基础信息
-	baseInfo := fmt.Sprintf("🚨 **%s**\n\n"+
+	baseInfo := fmt.Sprintf("%s **%s**\n\n"+
 		"**时间窗口:** 最近%d分钟\n"+
 		"**阈值:** %d条\n"+
 		"**实际匹配:** %d条",
-		alertType, rule.Timeframe/60, rule.Threshold, response.Hits.Total.Value)
+		te.levelEmoji(rule.Level), alertType, rule.Timeframe/60, rule.Threshold, response.Hits.Total.Value)
 
 	// 构建Pod信息（如果存在）
 	podInfo := ""
@@ -258,16 +417,14 @@ func (te *TemplateEngine) buildSystemComponentLoggingAlertMessage(rule types.Ale
 	containerImage := te.getStringValue(kubernetes, "container_image")
 
 	// 截取日志内容（避免过长）
-	if len(log) > 500 {
-		log = log[:500] + "..."
-	}
+	log = truncateRunes(log, te.snippetLength())
 
 	// 构建基础信息
-	baseInfo := fmt.Sprintf("🚨 **系统组件日志告警**\n\n"+
+	baseInfo := fmt.Sprintf("%s **系统组件日志告警**\n\n"+
 		"**时间窗口:** 最近%d分钟\n"+
 		"**阈值:** %d条\n"+
 		"**实际匹配:** %d条",
-		rule.Timeframe/60, rule.Threshold, response.Hits.Total.Value)
+		te.levelEmoji(rule.Level), rule.Timeframe/60, rule.Threshold, response.Hits.Total.Value)
 
 	// 构建系统组件信息
 	componentInfo := ""
@@ -342,7 +499,7 @@ func (te *TemplateEngine) buildAuditingAlertMessage(rule types.AlertRule, respon
 	responseStatus := te.getMapValue(hit, "ResponseStatus")
 	statusCode := te.getIntValue(responseStatus, "code")
 
-	return fmt.Sprintf(`🚨 **安全审计告警**
+	return fmt.Sprintf(`%s **安全审计告警**
 
 **规则名称:** %s
 **审计级别:** %s
@@ -355,23 +512,33 @@ func (te *TemplateEngine) buildAuditingAlertMessage(rule types.AlertRule, respon
 **审计消息:** %s
 **操作时间:** %s
 **匹配记录数:** %d`,
-		rule.Name, level, verb, resource, objectName, objectNamespace,
+		te.levelEmoji(rule.Level), rule.Name, level, verb, resource, objectName, objectNamespace,
 		username, userUID, statusCode, message, timestamp, response.Hits.Total.Value)
 }
 
 // buildDefaultAlertMessage 构建默认告警消息
 func (te *TemplateEngine) buildDefaultAlertMessage(rule types.AlertRule, response *types.OpenSearchResponse) string {
-	return fmt.Sprintf(`🚨 **OpenSearch 告警**
+	return fmt.Sprintf(`%s **OpenSearch 告警**
 
 **规则名称:** %s
 **匹配记录数:** %d
 **告警时间:** %s
 **索引模式:** %s`,
-		rule.Name, response.Hits.Total.Value,
-		time.Now().Format("2006-01-02 15:04:05"), rule.Index)
+		te.levelEmoji(rule.Level), rule.Name, response.Hits.Total.Value,
+		te.now().In(te.location).Format("2006-01-02 15:04:05"), opensearch.RuleIndex(rule))
 }
 
 // 辅助方法
+
+// truncateRunes 按字符（rune）截断文本，避免截断多字节 UTF-8 字符产生乱码
+func truncateRunes(text string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:max]) + "..."
+}
+
 func (te *TemplateEngine) getStringValue(data map[string]interface{}, key string) string {
 	if val, ok := data[key]; ok {
 		if str, ok := val.(string); ok {
@@ -381,19 +548,14 @@ func (te *TemplateEngine) getStringValue(data map[string]interface{}, key string
 	return ""
 }
 
-// getValueByPath 从嵌套 map 中按点路径获取值，并返回字符串
+// getValueByPath 按点路径获取值（支持数组下标，如 items.0.name），并返回字符串
 func (te *TemplateEngine) getValueByPath(root map[string]interface{}, path string) string {
 	if path == "" {
 		return ""
 	}
-	parts := strings.Split(path, ".")
-	var cur interface{} = root
-	for _, part := range parts {
-		if m, ok := cur.(map[string]interface{}); ok {
-			cur = m[part]
-		} else {
-			return ""
-		}
+	cur, ok := lookupPath(root, path)
+	if !ok {
+		return ""
 	}
 	switch v := cur.(type) {
 	case string:
@@ -418,13 +580,25 @@ func (te *TemplateEngine) getValueByPath(root map[string]interface{}, path strin
 	}
 }
 
+// getIntValue 获取整数值，兼容 JSON 数字、json.Number 与数字字符串
 func (te *TemplateEngine) getIntValue(data map[string]interface{}, key string) int {
-	if val, ok := data[key]; ok {
-		if intVal, ok := val.(int); ok {
-			return intVal
+	switch v := data[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return int(n)
 		}
-		if floatVal, ok := val.(float64); ok {
-			return int(floatVal)
+		if f, err := v.Float64(); err == nil {
+			return int(f)
+		}
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n
 		}
 	}
 	return 0
@@ -442,11 +616,9 @@ func (te *TemplateEngine) getMapValue(data map[string]interface{}, key string) m
 func (te *TemplateEngine) getTimeValue(data map[string]interface{}, key string) string {
 	if val, ok := data[key]; ok {
 		if str, ok := val.(string); ok {
-			// 尝试解析时间格式
+			// 尝试解析时间格式，并转换为配置的显示时区
 			if t, err := time.Parse(time.RFC3339, str); err == nil {
-				// 转换为本地时间（CST，UTC+8）
-				localTime := t.In(time.FixedZone("CST", 8*60*60))
-				return localTime.Format("2006-01-02 15:04:05")
+				return t.In(te.location).Format("2006-01-02 15:04:05")
 			}
 			return str
 		}
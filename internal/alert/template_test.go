@@ -0,0 +1,412 @@
+package alert
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"opensearch-alert/pkg/types"
+)
+
+// updateGolden 为 true 时用当前输出覆盖 golden 文件：go test ./internal/alert -run TestTemplateGolden -update
+var updateGolden = flag.Bool("update", false, "更新 testdata/golden 下的期望消息")
+
+// testLocation 测试使用的显示时区（UTC+8，不依赖系统时区数据）
+var testLocation = time.FixedZone("CST", 8*3600)
+
+// testNow 测试使用的固定时钟
+func testNow() time.Time {
+	return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+}
+
+// newTestTemplateEngine 创建固定时钟与时区的模板引擎
+func newTestTemplateEngine() *TemplateEngine {
+	te := NewTemplateEngine(testLocation, types.DefaultLevels())
+	te.SetClock(testNow)
+	return te
+}
+
+// loadResponse 读取 testdata/responses 下的 OpenSearch 响应
+func loadResponse(t *testing.T, name string) *types.OpenSearchResponse {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "responses", name+".json"))
+	if err != nil {
+		t.Fatalf("读取响应 %s 失败: %v", name, err)
+	}
+	var response types.OpenSearchResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatalf("解析响应 %s 失败: %v", name, err)
+	}
+	return &response
+}
+
+// assertGolden 比较消息与 testdata/golden/<name>.golden
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("写入 golden 文件失败: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取 golden 文件失败（可用 -update 生成）: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("消息与 %s 不一致\n--- 实际 ---\n%s\n--- 期望 ---\n%s", path, got, want)
+	}
+}
+
+func TestTemplateGolden(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		rule     types.AlertRule
+	}{
+		{
+			name:     "events",
+			response: "events",
+			rule:     types.AlertRule{Name: "Pod 重启事件", Index: "ks-whizard-events-*", Level: "High", Threshold: 1, Timeframe: 300},
+		},
+		{
+			name:     "events_no_hits",
+			response: "empty",
+			rule:     types.AlertRule{Name: "Pod 重启事件", Index: "ks-whizard-events-*", Level: "High"},
+		},
+		{
+			name:     "logging",
+			response: "logging",
+			rule:     types.AlertRule{Name: "应用Pod警告日志告警", Index: "ks-whizard-logging-*", Level: "Medium", Threshold: 5, Timeframe: 600},
+		},
+		{
+			name:     "system_component",
+			response: "system_component",
+			rule:     types.AlertRule{Name: "系统组件错误日志", Index: "ks-whizard-logging-*", Level: "Critical", Threshold: 1, Timeframe: 300},
+		},
+		{
+			name:     "system_component_by_subtype",
+			response: "system_component",
+			rule:     types.AlertRule{Name: "kubelet errors", Index: "ks-whizard-logging-*", EventSubtype: "system_component", Level: "Critical", Threshold: 1, Timeframe: 300},
+		},
+		{
+			name:     "auditing",
+			response: "auditing",
+			rule:     types.AlertRule{Name: "删除 Secret 审计", Index: "ks-whizard-auditing-*", Level: "Critical"},
+		},
+		{
+			name:     "default",
+			response: "logging",
+			rule:     types.AlertRule{Name: "应用错误", Index: "app-*,nginx-*"},
+		},
+		{
+			name:     "custom_placeholders",
+			response: "logging",
+			rule: types.AlertRule{
+				Name:      "自定义占位符",
+				Index:     "app-*",
+				AlertText: "Pod ${kubernetes.pod_name} 出现错误（用户 ${user.id}，VIP ${user.vip}，缺失字段 [${missing.field}]）",
+				AlertTextArgs: []string{
+					"kubernetes.namespace_name",
+					"items.1.name",
+					"kubernetes.labels",
+					"",
+					"missing",
+				},
+			},
+		},
+		{
+			name:     "custom_prepend",
+			response: "logging",
+			rule: types.AlertRule{
+				Name:         "应用Pod警告日志告警",
+				Index:        "ks-whizard-logging-*",
+				AlertText:    "请联系 ${kubernetes.labels.app} 负责人",
+				TemplateMode: "prepend",
+				Threshold:    5,
+				Timeframe:    600,
+			},
+		},
+		{
+			name:     "custom_replace",
+			response: "logging",
+			rule: types.AlertRule{
+				Name:         "应用Pod警告日志告警",
+				Index:        "ks-whizard-logging-*",
+				AlertText:    "仅自定义：${log}",
+				TemplateMode: "replace",
+			},
+		},
+		{
+			name:     "go_template",
+			response: "logging",
+			rule: types.AlertRule{
+				Name:          "Go 模板",
+				Index:         "app-*",
+				Level:         "High",
+				AlertTextType: "go_template",
+				AlertText:     `{{ levelEmoji .Rule.Level }} {{ .Rule.Name }} 共 {{ .Total }} 条，Pod {{ get .Source "kubernetes.pod_name" }}，负责人 {{ get .Source "owner" | default "-" }}`,
+				TemplateMode:  "replace",
+			},
+		},
+		{
+			name:     "metric",
+			response: "metric",
+			rule: types.AlertRule{
+				Name:            "响应时间",
+				Type:            "metric",
+				Index:           "nginx-*",
+				MetricAgg:       "avg",
+				MetricField:     "response_time_ms",
+				MetricOperator:  "gt",
+				MetricThreshold: 500,
+			},
+		},
+	}
+
+	te := newTestTemplateEngine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := te.BuildAlertMessage(tt.rule, loadResponse(t, tt.response))
+			assertGolden(t, tt.name, got)
+		})
+	}
+}
+
+func TestGetTimeValueUsesConfiguredLocation(t *testing.T) {
+	data := map[string]interface{}{"@timestamp": "2024-01-02T02:59:30Z", "raw": "yesterday"}
+	tests := []struct {
+		loc  *time.Location
+		want string
+	}{
+		{time.UTC, "2024-01-02 02:59:30"},
+		{testLocation, "2024-01-02 10:59:30"},
+		{time.FixedZone("PST", -8*3600), "2024-01-01 18:59:30"},
+	}
+	for _, tt := range tests {
+		te := NewTemplateEngine(tt.loc, nil)
+		if got := te.getTimeValue(data, "@timestamp"); got != tt.want {
+			t.Errorf("%s: getTimeValue = %q, 期望 %q", tt.loc, got, tt.want)
+		}
+	}
+
+	te := newTestTemplateEngine()
+	if got := te.getTimeValue(data, "raw"); got != "yesterday" {
+		t.Errorf("无法解析的时间应原样返回，实际 %q", got)
+	}
+	if got := te.getTimeValue(data, "missing"); got != "" {
+		t.Errorf("缺失字段应返回空，实际 %q", got)
+	}
+}
+
+func TestGetIntValue(t *testing.T) {
+	data := map[string]interface{}{
+		"float":   float64(7),
+		"int":     3,
+		"number":  json.Number("12"),
+		"decimal": json.Number("4.0"),
+		"string":  " 9 ",
+		"bad":     "many",
+	}
+	want := map[string]int{"float": 7, "int": 3, "number": 12, "decimal": 4, "string": 9, "bad": 0, "missing": 0}
+
+	te := newTestTemplateEngine()
+	for key, expected := range want {
+		if got := te.getIntValue(data, key); got != expected {
+			t.Errorf("getIntValue(%q) = %d, 期望 %d", key, got, expected)
+		}
+	}
+}
+
+func TestTruncateRunesMultiByteBoundary(t *testing.T) {
+	// 前缀 1 字节 + 汉字 3 字节：第 500 个字节落在第 167 个汉字中间
+	text := "E" + strings.Repeat("中", 600)
+	if utf8.ValidString(text[:500]) {
+		t.Fatal("测试数据应让第 500 字节落在多字节字符中间")
+	}
+
+	got := truncateRunes(text, 500)
+	if !utf8.ValidString(got) {
+		t.Fatalf("截断结果不是合法 UTF-8: %q", got)
+	}
+	if want := "E" + strings.Repeat("中", 499) + "..."; got != want {
+		t.Errorf("截断后应保留 500 个字符，实际 %d 个", utf8.RuneCountInString(got))
+	}
+
+	// 超过 500 字节但不足 500 个字符的文本不截断
+	short := "E" + strings.Repeat("中", 200)
+	if got := truncateRunes(short, 500); got != short {
+		t.Errorf("不足 500 个字符不应截断，实际 %q", got)
+	}
+	if got := truncateRunes(text, 0); got != text {
+		t.Error("max <= 0 时应返回原文")
+	}
+}
+
+func TestLoggingMessageTruncatesLogByRune(t *testing.T) {
+	log := "E" + strings.Repeat("错", 600)
+	response := &types.OpenSearchResponse{}
+	response.Hits.Total.Value = 1
+	response.Hits.Hits = []types.OpenSearchHit{{Source: map[string]interface{}{
+		"log":        log,
+		"kubernetes": map[string]interface{}{"pod_name": "p"},
+	}}}
+
+	message := newTestTemplateEngine().BuildAlertMessage(types.AlertRule{Name: "Pod 日志", Index: "ks-whizard-logging-*"}, response)
+	if !utf8.ValidString(message) {
+		t.Fatal("告警消息包含被截断的多字节字符")
+	}
+	if !strings.Contains(message, "E"+strings.Repeat("错", 499)+"...\n") {
+		t.Error("日志内容应按字符截断为 500 个字符")
+	}
+}
+
+func TestLoggingMessageUsesConfiguredSnippetLength(t *testing.T) {
+	response := &types.OpenSearchResponse{}
+	response.Hits.Total.Value = 1
+	response.Hits.Hits = []types.OpenSearchHit{{Source: map[string]interface{}{
+		"log":        strings.Repeat("错", 600),
+		"kubernetes": map[string]interface{}{"pod_name": "p"},
+	}}}
+
+	te := newTestTemplateEngine()
+	te.logSnippetLength = 100
+	message := te.BuildAlertMessage(types.AlertRule{Name: "Pod 日志", Index: "ks-whizard-logging-*"}, response)
+	if !strings.Contains(message, strings.Repeat("错", 100)+"...\n") || strings.Contains(message, strings.Repeat("错", 101)) {
+		t.Error("日志内容应按 log_snippet_length 截断为 100 个字符")
+	}
+}
+
+func TestGoTemplateIfAndRange(t *testing.T) {
+	rule := types.AlertRule{
+		Name:          "Go 模板控制结构",
+		Level:         "Critical",
+		AlertTextType: "go_template",
+		TemplateMode:  "replace",
+		AlertText: `{{ if gt .Total 10 }}大量{{ else }}少量{{ end }}错误 {{ .Total }} 条
+{{ range $i, $hit := .Hits }}{{ $i }}. {{ get $hit.Source "kubernetes.pod_name" }} [{{ default "无用户" $hit.Source.user }}]
+{{ end }}{{ if .Source.missing }}不应出现{{ end }}{{ range .Source.items }}- {{ .name }}
+{{ end }}`,
+	}
+
+	response := loadResponse(t, "logging")
+	response.Hits.Hits = append(response.Hits.Hits, types.OpenSearchHit{Source: map[string]interface{}{
+		"kubernetes": map[string]interface{}{"pod_name": "worker-0"},
+	}})
+
+	got := newTestTemplateEngine().BuildAlertMessage(rule, response)
+	want := "大量错误 12 条\n" +
+		"0. payment-7c9f8d-x2k4z [map[id:42 vip:true]]\n" +
+		"1. worker-0 [无用户]\n" +
+		"- first\n" +
+		"- second\n"
+	if got != want {
+		t.Errorf("渲染结果不符\n--- 实际 ---\n%s\n--- 期望 ---\n%s", got, want)
+	}
+
+	response.Hits.Total.Value = 3
+	if got := newTestTemplateEngine().BuildAlertMessage(rule, response); !strings.HasPrefix(got, "少量错误 3 条\n") {
+		t.Errorf("if/else 分支未生效: %q", got)
+	}
+}
+
+func TestGoTemplateErrors(t *testing.T) {
+	te := newTestTemplateEngine()
+	response := loadResponse(t, "logging")
+
+	rule := types.AlertRule{Name: "坏模板", AlertTextType: "go_template", TemplateMode: "replace", AlertText: "{{ if .Total }}未闭合"}
+	if got := te.BuildAlertMessage(rule, response); !strings.HasPrefix(got, "alert_text 模板解析失败") {
+		t.Errorf("解析错误应体现在消息中，实际 %q", got)
+	}
+
+	rule.AlertText = `{{ index .Source.items 10 }}`
+	if got := te.BuildAlertMessage(rule, response); !strings.HasPrefix(got, "alert_text 模板渲染失败") {
+		t.Errorf("渲染错误应体现在消息中，实际 %q", got)
+	}
+}
+
+func TestRuleEventType(t *testing.T) {
+	tests := []struct {
+		name string
+		rule types.AlertRule
+		want string
+	}{
+		{"按索引推断 events", types.AlertRule{Index: "ks-whizard-events-*"}, "events"},
+		{"按索引推断 logging", types.AlertRule{Index: "ks-whizard-logging-*"}, "logging"},
+		{"按索引推断 auditing", types.AlertRule{Index: "ks-whizard-auditing-*"}, "auditing"},
+		{"无法推断时使用默认", types.AlertRule{Index: "app-*"}, "default"},
+		{"多索引类型一致", types.AlertRule{Index: "a-logging-*", Indices: []string{"b-logging-*"}}, "logging"},
+		{"多索引类型混合", types.AlertRule{Index: "a-logging-*", Indices: []string{"k8s-events-*"}}, "default"},
+		{"显式配置优先于索引名", types.AlertRule{Index: "ks-whizard-logging-*", EventType: "auditing"}, "auditing"},
+		{"自定义索引显式配置", types.AlertRule{Index: "cluster-a-k8s-*", EventType: "events"}, "events"},
+		{"显式 default 不推断", types.AlertRule{Index: "ks-whizard-events-*", EventType: "default"}, "default"},
+	}
+	te := newTestTemplateEngine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := te.ruleEventType(tt.rule); got != tt.want {
+				t.Errorf("ruleEventType = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventTypeSelectsTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		rule     types.AlertRule
+		header   string
+	}{
+		{"自定义索引显式 events", "events", types.AlertRule{Name: "事件", Index: "cluster-a-k8s-*", EventType: "events"}, "Kubernetes 事件告警"},
+		{"自定义索引显式 logging", "logging", types.AlertRule{Name: "日志", Index: "app-*", EventType: "logging"}, "应用日志告警"},
+		{"自定义索引回退默认", "logging", types.AlertRule{Name: "日志", Index: "app-*"}, "OpenSearch 告警"},
+		{"子类型显式指定系统组件", "system_component", types.AlertRule{Name: "kubelet", Index: "app-*", EventType: "logging", EventSubtype: "system_component"}, "系统组件日志告警"},
+		{"子类型显式指定时不按规则名推断", "logging", types.AlertRule{Name: "系统组件日志", Index: "ks-whizard-logging-*", EventSubtype: "application"}, "应用日志告警"},
+	}
+	te := newTestTemplateEngine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := te.BuildAlertMessage(tt.rule, loadResponse(t, tt.response))
+			if firstLine := strings.SplitN(message, "\n", 2)[0]; !strings.Contains(firstLine, tt.header) {
+				t.Errorf("消息标题 = %q，期望包含 %q", firstLine, tt.header)
+			}
+		})
+	}
+}
+
+func TestTemplateModes(t *testing.T) {
+	te := newTestTemplateEngine()
+	response := loadResponse(t, "logging")
+	base := types.AlertRule{Name: "应用Pod警告日志告警", Index: "ks-whizard-logging-*", Threshold: 5, Timeframe: 600}
+	detail := te.buildDetailMessage(base, response)
+	custom := "请联系 payment 负责人"
+
+	tests := []struct {
+		mode      string
+		alertText string
+		want      string
+	}{
+		{"", custom, custom + "\n\n" + detail},
+		{"append", custom, custom + "\n\n" + detail},
+		{"prepend", custom, detail + "\n\n" + custom},
+		{"replace", custom, custom},
+		// 未配置自定义文本时各模式均只有默认详情
+		{"replace", "", detail},
+		{"prepend", "", detail},
+	}
+	for _, tt := range tests {
+		rule := base
+		rule.TemplateMode = tt.mode
+		rule.AlertText = tt.alertText
+		if got := te.BuildAlertMessage(rule, response); got != tt.want {
+			t.Errorf("template_mode=%q alert_text=%q:\n实际 %q\n期望 %q", tt.mode, tt.alertText, got, tt.want)
+		}
+	}
+}
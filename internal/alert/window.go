@@ -0,0 +1,128 @@
+package alert
+
+import (
+	"context"
+	"time"
+
+	"opensearch-alert/internal/opensearch"
+	"opensearch-alert/pkg/types"
+)
+
+// overlapTail 增量规则上一窗口尾部 [start, end] 内已统计过的文档，start 为 end - overlap（不早于该窗口的起点）
+type overlapTail struct {
+	start time.Time
+	end   time.Time
+	ids   []string
+}
+
+// queryWindow 计算规则本轮的查询窗口，窗口为空（距上次查询过近）时返回 false
+//
+// 窗口终点均为 now - buffer_time（为写入延迟预留时间）。普通规则使用滑动窗口
+// [终点 - timeframe, 终点]；增量规则（incremental: true）的起点接续上次成功查询的终点：
+// 配置了 incremental_overlap 时起点回退到 上次终点 - overlap，补查晚到（写入延迟超过 buffer_time）的文档，
+// 重叠部分中上一轮已统计过的文档按 _id 排除，每条文档只计入一次阈值；重叠不超过 overlap。
+// 未配置重叠或重启后没有上一窗口的尾部记录时，起点为上次终点（不含），相邻窗口既不重叠也无遗漏。
+// 首次执行或中断超过 timeframe 时，起点退回到终点前 timeframe。
+func (e *Engine) queryWindow(rule types.AlertRule, now time.Time) (opensearch.TimeWindow, bool) {
+	end := opensearch.BufferedEnd(now, e.config.AlertEngine.BufferTime)
+	if !rule.Incremental {
+		return opensearch.SlidingWindow(rule, end), true
+	}
+
+	// 按秒对齐，与查询中 RFC3339 的精度一致，保证下一窗口能精确接续
+	end = end.Truncate(time.Second)
+	window := opensearch.SlidingWindow(rule, end)
+
+	lastEnd, err := e.database.GetRuleQueryEnd(rule.Name)
+	if err != nil {
+		e.logger.Warnf("读取规则 %s 的增量窗口失败（按滑动窗口查询）: %v", rule.Name, err)
+		return window, true
+	}
+	if lastEnd.IsZero() {
+		return window, true
+	}
+	if lastEnd.Before(window.Start) {
+		e.logger.Warnf("规则 %s 距上次查询已超过 timeframe，跳过 %s 至 %s 的数据", rule.Name,
+			lastEnd.Format(time.RFC3339), window.Start.Format(time.RFC3339))
+		return window, true
+	}
+	if !lastEnd.Before(end) {
+		return window, false
+	}
+
+	if tail, ok := e.lastOverlapTail(rule, lastEnd); ok {
+		window.Start = tail.start
+		window.ExcludeIDs = tail.ids
+		return window, true
+	}
+	window.Start = lastEnd
+	window.StartExclusive = true
+	return window, true
+}
+
+// incrementalOverlap 返回增量窗口的重叠时长
+func (e *Engine) incrementalOverlap() time.Duration {
+	if e.config.AlertEngine.IncrementalOverlap <= 0 {
+		return 0
+	}
+	return time.Duration(e.config.AlertEngine.IncrementalOverlap) * time.Second
+}
+
+// lastOverlapTail 返回上一窗口（终点为 lastEnd）尾部已统计的文档；未开启重叠或记录不属于该窗口时返回 false
+func (e *Engine) lastOverlapTail(rule types.AlertRule, lastEnd time.Time) (overlapTail, bool) {
+	if e.incrementalOverlap() == 0 {
+		return overlapTail{}, false
+	}
+	e.overlapMutex.Lock()
+	defer e.overlapMutex.Unlock()
+	tail, ok := e.overlapTails[rule.Name]
+	return tail, ok && tail.end.Equal(lastEnd)
+}
+
+// recordQueryWindow 增量规则查询成功后记录窗口终点，供下一轮接续；开启重叠时同时记录窗口尾部已统计的文档
+func (e *Engine) recordQueryWindow(ctx context.Context, rule types.AlertRule, window opensearch.TimeWindow) {
+	if !rule.Incremental {
+		return
+	}
+	if err := e.database.SetRuleQueryEnd(rule.Name, window.End); err != nil {
+		e.logger.Warnf("记录规则 %s 的增量窗口失败: %v", rule.Name, err)
+	}
+	if overlap := e.incrementalOverlap(); overlap > 0 {
+		e.recordOverlapTail(ctx, rule, window, overlap)
+	}
+}
+
+// recordOverlapTail 查询窗口尾部 [end - overlap, end] 内匹配规则的文档 _id（均已在本轮或此前各轮统计过），
+// 下一轮重叠查询时排除；尾部不早于本轮窗口的起点，更早的文档不一定统计过。
+// 查询失败或文档数超过 max_fetch_hits 时不记录，下一轮退回无重叠的接续窗口
+func (e *Engine) recordOverlapTail(ctx context.Context, rule types.AlertRule, window opensearch.TimeWindow, overlap time.Duration) {
+	e.overlapMutex.Lock()
+	delete(e.overlapTails, rule.Name)
+	e.overlapMutex.Unlock()
+
+	tail := opensearch.TimeWindow{Start: window.End.Add(-overlap), End: window.End}
+	if tail.Start.Before(window.Start) {
+		tail.Start = window.Start
+	}
+	query := e.opensearchClient.BuildWindowQuery(rule, tail)
+	query["_source"] = false
+	delete(query, "aggs")
+	maxIDs := e.config.AlertEngine.MaxFetchHits
+	response, err := e.opensearchClient.SearchAll(ctx, opensearch.RuleIndex(rule), query, maxIDs)
+	if err != nil {
+		e.logger.Warnf("查询规则 %s 增量窗口重叠部分失败（下一轮不重叠）: %v", rule.Name, err)
+		return
+	}
+	if response.Hits.Total.Value > len(response.Hits.Hits) {
+		e.logger.Warnf("规则 %s 增量窗口重叠部分文档数 %d 超过 max_fetch_hits %d（下一轮不重叠）", rule.Name, response.Hits.Total.Value, maxIDs)
+		return
+	}
+
+	ids := make([]string, 0, len(response.Hits.Hits))
+	for _, hit := range response.Hits.Hits {
+		ids = append(ids, hit.ID)
+	}
+	e.overlapMutex.Lock()
+	e.overlapTails[rule.Name] = overlapTail{start: tail.Start, end: tail.End, ids: ids}
+	e.overlapMutex.Unlock()
+}
@@ -0,0 +1,208 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// windowDoc 桩服务中的文档：timestamp 为事件时间，visibleAt 为写入（可被查询到）的时间
+type windowDoc struct {
+	id        string
+	timestamp time.Time
+	visibleAt time.Time
+}
+
+// windowStub 按查询中的时间范围与 must_not ids 过滤文档的 OpenSearch 桩服务，now 为当前的模拟时间
+type windowStub struct {
+	mu   sync.Mutex
+	now  time.Time
+	docs []windowDoc
+}
+
+func (s *windowStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Query struct {
+			Bool struct {
+				Must []struct {
+					Range map[string]map[string]string `json:"range"`
+				} `json:"must"`
+				MustNot []struct {
+					IDs *struct {
+						Values []string `json:"values"`
+					} `json:"ids"`
+				} `json:"must_not"`
+			} `json:"bool"`
+		} `json:"query"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	bounds := body.Query.Bool.Must[0].Range["@timestamp"]
+	parse := func(key string) (time.Time, bool) {
+		t, err := time.Parse(time.RFC3339, bounds[key])
+		return t, err == nil
+	}
+	excluded := make(map[string]bool)
+	for _, clause := range body.Query.Bool.MustNot {
+		if clause.IDs != nil {
+			for _, id := range clause.IDs.Values {
+				excluded[id] = true
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	response := types.OpenSearchResponse{}
+	for _, doc := range s.docs {
+		if doc.visibleAt.After(s.now) || excluded[doc.id] {
+			continue
+		}
+		if gte, ok := parse("gte"); ok && doc.timestamp.Before(gte) {
+			continue
+		}
+		if gt, ok := parse("gt"); ok && !doc.timestamp.After(gt) {
+			continue
+		}
+		if lte, ok := parse("lte"); ok && doc.timestamp.After(lte) {
+			continue
+		}
+		response.Hits.Hits = append(response.Hits.Hits, types.OpenSearchHit{ID: doc.id})
+	}
+	response.Hits.Total.Value = len(response.Hits.Hits)
+	json.NewEncoder(w).Encode(response)
+}
+
+// runIncremental 模拟增量规则在 runs 个时刻依次执行，返回各轮窗口与每条文档被统计的次数
+func runIncremental(t *testing.T, overlap int, stub *windowStub, runs []time.Time) ([]time.Time, []time.Time, map[string]int) {
+	t.Helper()
+	e := newTestEngine(t, stub)
+	e.database = newTestDatabase(t)
+	e.config.AlertEngine.IncrementalOverlap = overlap
+	rule := types.AlertRule{Name: "incremental", Type: "frequency", Index: "logs-*", Timeframe: 600, Incremental: true}
+	ctx := context.Background()
+
+	var starts, ends []time.Time
+	counted := make(map[string]int)
+	for _, now := range runs {
+		stub.mu.Lock()
+		stub.now = now
+		stub.mu.Unlock()
+
+		window, ok := e.queryWindow(rule, now)
+		if !ok {
+			t.Fatalf("%s 的窗口不应为空", now.Format(time.RFC3339))
+		}
+		response, err := e.opensearchClient.Search(ctx, "logs-*", e.opensearchClient.BuildWindowQuery(rule, window))
+		if err != nil {
+			t.Fatalf("查询失败: %v", err)
+		}
+		for _, hit := range response.Hits.Hits {
+			counted[hit.ID]++
+		}
+		e.recordQueryWindow(ctx, rule, window)
+		starts, ends = append(starts, window.Start), append(ends, window.End)
+	}
+	return starts, ends, counted
+}
+
+func TestIncrementalOverlapNoGapsNoDoubleCount(t *testing.T) {
+	base := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return base.Add(time.Duration(seconds) * time.Second) }
+	stub := &windowStub{docs: []windowDoc{
+		{id: "first", timestamp: at(-30), visibleAt: at(-30)},
+		// 恰好落在窗口边界上
+		{id: "boundary", timestamp: at(60), visibleAt: at(60)},
+		// 写入延迟 40 秒：事件时间在第二轮窗口内，第三轮才可见
+		{id: "late", timestamp: at(100), visibleAt: at(140)},
+		// 写入延迟超过 overlap，无法补统计
+		{id: "too-late", timestamp: at(110), visibleAt: at(300)},
+		{id: "steady", timestamp: at(150), visibleAt: at(150)},
+	}}
+	runs := []time.Time{at(0), at(60), at(120), at(180), at(240), at(300)}
+
+	starts, ends, counted := runIncremental(t, 60, stub, runs)
+
+	for i := 1; i < len(runs); i++ {
+		if starts[i].After(ends[i-1]) {
+			t.Errorf("第 %d 轮窗口起点 %s 晚于上一轮终点 %s，存在遗漏", i+1, starts[i], ends[i-1])
+		}
+		if overlap := ends[i-1].Sub(starts[i]); overlap > time.Minute {
+			t.Errorf("第 %d 轮与上一轮重叠 %s，超过 incremental_overlap", i+1, overlap)
+		}
+	}
+	for _, id := range []string{"first", "boundary", "late", "steady"} {
+		if counted[id] != 1 {
+			t.Errorf("文档 %s 应恰好统计 1 次，实际 %d 次", id, counted[id])
+		}
+	}
+	if counted["too-late"] != 0 {
+		t.Errorf("写入延迟超过 overlap 的文档不在任何窗口内，实际统计 %d 次", counted["too-late"])
+	}
+}
+
+func TestIncrementalWithoutOverlapIsContiguous(t *testing.T) {
+	base := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return base.Add(time.Duration(seconds) * time.Second) }
+	stub := &windowStub{docs: []windowDoc{
+		{id: "boundary", timestamp: at(60), visibleAt: at(60)},
+		{id: "late", timestamp: at(100), visibleAt: at(140)},
+	}}
+
+	starts, ends, counted := runIncremental(t, 0, stub, []time.Time{at(0), at(60), at(120), at(180)})
+
+	for i := 1; i < len(starts); i++ {
+		if !starts[i].Equal(ends[i-1]) {
+			t.Errorf("未配置重叠时第 %d 轮应从上一轮终点接续: %s != %s", i+1, starts[i], ends[i-1])
+		}
+	}
+	if counted["boundary"] != 1 {
+		t.Errorf("边界文档应恰好统计 1 次，实际 %d 次", counted["boundary"])
+	}
+	// 未配置重叠时，写入晚于窗口终点的文档被跳过
+	if counted["late"] != 0 {
+		t.Errorf("未配置重叠时晚到文档不会被补统计，实际 %d 次", counted["late"])
+	}
+}
+
+func TestIncrementalOverlapFallsBackAfterRestart(t *testing.T) {
+	base := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	stub := &windowStub{}
+	e := newTestEngine(t, stub)
+	e.database = newTestDatabase(t)
+	e.config.AlertEngine.IncrementalOverlap = 60
+	rule := types.AlertRule{Name: "incremental", Timeframe: 600, Incremental: true}
+	if err := e.database.SetRuleQueryEnd(rule.Name, base); err != nil {
+		t.Fatalf("写入窗口终点失败: %v", err)
+	}
+
+	// 重启后内存中没有上一窗口尾部的 _id，不能排除已统计的文档，按上次终点接续
+	window, ok := e.queryWindow(rule, base.Add(time.Minute))
+	if !ok || !window.Start.Equal(base) || !window.StartExclusive || len(window.ExcludeIDs) != 0 {
+		t.Errorf("重启后首轮应从上次终点接续: %+v", window)
+	}
+}
+
+func TestQueryWindowAppliesBufferTime(t *testing.T) {
+	e := newTestEngine(t, http.NotFoundHandler())
+	e.config.AlertEngine.BufferTime = 90
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	window, ok := e.queryWindow(types.AlertRule{Name: "sliding", Timeframe: 300}, now)
+	if !ok {
+		t.Fatal("滑动窗口不应为空")
+	}
+	if want := now.Add(-90 * time.Second); !window.End.Equal(want) {
+		t.Errorf("窗口终点 = %s，期望 now - buffer_time = %s", window.End, want)
+	}
+	if want := now.Add(-390 * time.Second); !window.Start.Equal(want) {
+		t.Errorf("窗口起点 = %s，期望 %s", window.Start, want)
+	}
+}
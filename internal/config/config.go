@@ -1,11 +1,17 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"opensearch-alert/internal/opensearch"
 	"opensearch-alert/pkg/types"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/robfig/cron/v3"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
@@ -17,14 +23,38 @@ func LoadConfig(configPath string) (*types.Config, error) {
 		return nil, fmt.Errorf("读取配置文件失败: %w", err)
 	}
 
-	var config types.Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	// 先解析为节点树，展开 ${VAR} 占位符后再映射到配置结构
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
 		return nil, fmt.Errorf("解析配置文件失败: %w", err)
 	}
+	placeholders := expandEnvNode(&root, "")
+
+	var config types.Config
+	if len(root.Content) > 0 {
+		if err := root.Decode(&config); err != nil {
+			return nil, fmt.Errorf("解析配置文件失败: %w", err)
+		}
+	}
+	config.EnvPlaceholders = placeholders
 
 	// 设置默认值
 	setDefaults(&config)
 
+	if _, err := config.LoadLocation(); err != nil {
+		return nil, err
+	}
+
+	if err := validateOpenSearchAuth(config.OpenSearch); err != nil {
+		return nil, err
+	}
+
+	for i, silence := range config.Silences {
+		if err := ValidateSilence(silence); err != nil {
+			return nil, fmt.Errorf("静默配置 #%d 无效: %w", i+1, err)
+		}
+	}
+
 	return &config, nil
 }
 
@@ -32,15 +62,12 @@ func LoadConfig(configPath string) (*types.Config, error) {
 func LoadRules(rulesFolder string) ([]types.AlertRule, error) {
 	var rules []types.AlertRule
 
-	// 创建日志器
-	logger := logrus.New()
-	logger.SetLevel(logrus.InfoLevel)
-	// 与主程序保持一致的时间格式，避免出现 INFO[0000] 相对时间样式
-	logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	// 使用全局日志器，与主程序的格式、级别和输出保持一致
+	logger := logrus.StandardLogger()
 
 	logger.Debugf("开始加载规则文件，目录: %s", rulesFolder)
 
-	files, err := filepath.Glob(filepath.Join(rulesFolder, "*.yaml"))
+	files, err := RuleFiles(rulesFolder)
 	if err != nil {
 		logger.Errorf("读取规则文件失败: %v", err)
 		return nil, fmt.Errorf("读取规则文件失败: %w", err)
@@ -48,6 +75,10 @@ func LoadRules(rulesFolder string) ([]types.AlertRule, error) {
 
 	logger.Debugf("找到 %d 个规则文件", len(files))
 
+	// 按规则名称去重：不同子目录中的同名规则仅保留最近修改的文件（与 Web 规则列表一致）
+	ruleIndex := make(map[string]int)
+	ruleMtime := make(map[string]time.Time)
+
 	for _, file := range files {
 		logger.Debugf("加载规则文件: %s", file)
 
@@ -63,19 +94,253 @@ func LoadRules(rulesFolder string) ([]types.AlertRule, error) {
 			return nil, fmt.Errorf("解析规则文件 %s 失败: %w", file, err)
 		}
 
+		// 规则校验不通过时仅告警，保持原有加载行为
+		if err := ValidateRule(rule); err != nil {
+			logger.Warnf("规则文件 %s 校验未通过: %v", file, err)
+		}
+
 		// 只加载启用的规则
-		if rule.Enabled {
-			logger.Debugf("加载启用规则: %s (级别: %s)", rule.Name, rule.Level)
-			rules = append(rules, rule)
-		} else {
+		if !rule.Enabled {
 			logger.Debugf("跳过禁用规则: %s", rule.Name)
+			continue
+		}
+
+		var mtime time.Time
+		if fi, err := os.Stat(file); err == nil {
+			mtime = fi.ModTime()
 		}
+		if i, ok := ruleIndex[rule.Name]; ok {
+			logger.Warnf("规则名称重复: %s（%s），保留最近修改的文件", rule.Name, file)
+			if mtime.After(ruleMtime[rule.Name]) {
+				rules[i] = rule
+				ruleMtime[rule.Name] = mtime
+			}
+			continue
+		}
+		logger.Debugf("加载启用规则: %s (级别: %s)", rule.Name, rule.Level)
+		ruleIndex[rule.Name] = len(rules)
+		ruleMtime[rule.Name] = mtime
+		rules = append(rules, rule)
 	}
 
 	logger.Debugf("规则加载完成，共加载 %d 个启用规则", len(rules))
 	return rules, nil
 }
 
+// RuleFiles 递归列出规则目录（含子目录，如 rules/prod/）中的规则文件，按路径排序；目录不存在时返回空
+// 以 . 开头的目录会被跳过（如 Kubernetes ConfigMap 挂载产生的 ..data 及带时间戳的快照目录），避免重复加载
+func RuleFiles(rulesFolder string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(rulesFolder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == rulesFolder && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			if path != rulesFolder && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if IsRuleFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// ruleTypes 支持的规则类型
+var ruleTypes = []string{"frequency", "any", "spike", "flatline", "change", "metric"}
+
+// validRuleTypes 支持的规则类型（集合形式）
+var validRuleTypes = stringSet(ruleTypes)
+
+// ruleEventTypes 规则 event_type 可选值
+var ruleEventTypes = []string{"events", "logging", "auditing", "default"}
+
+// templateModes 规则 template_mode 可选值
+var templateModes = []string{"append", "prepend", "replace"}
+
+// sortOrders 规则 sort_order 可选值
+var sortOrders = []string{"asc", "desc"}
+
+// reservedRuleNames 保留的规则名称：与 /api/rules/ 下的固定路由同名的规则无法通过 /api/rules/{name} 访问
+var reservedRuleNames = []string{"test", "validate-yaml", "bulk", "export", "schema", "import"}
+
+// ruleEventSubtypes 规则 event_subtype 可选值
+var ruleEventSubtypes = []string{"system_component", "none"}
+
+// stringSet 将字符串列表转换为集合
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// spikeTypes 规则 spike_type 可选值
+var spikeTypes = []string{"up", "down", "both"}
+
+// validateSpikeRule 校验 spike 规则的突变倍数、方向与时间窗口
+func validateSpikeRule(rule types.AlertRule) error {
+	if rule.SpikeHeight < 0 || (rule.SpikeHeight > 0 && rule.SpikeHeight <= 1) {
+		return fmt.Errorf("spike_height 必须大于 1: %g", rule.SpikeHeight)
+	}
+	if rule.SpikeType != "" && !stringSet(spikeTypes)[rule.SpikeType] {
+		return fmt.Errorf("不支持的 spike_type: %q（可选 %s）", rule.SpikeType, strings.Join(spikeTypes, "/"))
+	}
+	if rule.Timeframe <= 0 {
+		return fmt.Errorf("spike 规则必须设置 timeframe")
+	}
+	if rule.Incremental {
+		return fmt.Errorf("spike 规则不支持 incremental（当前窗口与上一窗口需等长）")
+	}
+	return nil
+}
+
+// validRuleLevels 支持的告警级别
+var validRuleLevels = map[string]bool{
+	"critical": true,
+	"high":     true,
+	"medium":   true,
+	"low":      true,
+	"info":     true,
+}
+
+// NormalizeLevel 将级别规范为标准写法（如 critical → Critical），未知级别返回 false
+func NormalizeLevel(level string) (string, bool) {
+	return types.NormalizeLevelName(level)
+}
+
+// ruleScheduleParser 规则调度表达式解析器（与告警引擎一致，秒字段可选）
+var ruleScheduleParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ValidateRule 校验规则的类型、索引、时间窗口、阈值与级别
+func ValidateRule(rule types.AlertRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("规则名称不能为空")
+	}
+	if stringSet(reservedRuleNames)[rule.Name] {
+		return fmt.Errorf("规则名称 %q 为保留名称（不可使用 %s）", rule.Name, strings.Join(reservedRuleNames, "/"))
+	}
+	if !validRuleTypes[rule.Type] {
+		return fmt.Errorf("不支持的规则类型: %q（可选 %s）", rule.Type, strings.Join(ruleTypes, "/"))
+	}
+	if opensearch.RuleIndex(rule) == "" {
+		return fmt.Errorf("规则索引不能为空（index 或 indices）")
+	}
+	if rule.MaxHits != nil && *rule.MaxHits < 0 {
+		return fmt.Errorf("max_hits 不能为负数: %d", *rule.MaxHits)
+	}
+	if rule.MinHits < 0 {
+		return fmt.Errorf("min_hits 不能为负数: %d", rule.MinHits)
+	}
+	if rule.TemplateMode != "" && !stringSet(templateModes)[rule.TemplateMode] {
+		return fmt.Errorf("不支持的 template_mode: %q（可选 %s）", rule.TemplateMode, strings.Join(templateModes, "/"))
+	}
+	if rule.TemplateMode == "replace" && strings.TrimSpace(rule.AlertText) == "" {
+		return fmt.Errorf("template_mode 为 replace 时 alert_text 不能为空")
+	}
+	if rule.SortOrder != "" && !stringSet(sortOrders)[strings.ToLower(rule.SortOrder)] {
+		return fmt.Errorf("不支持的 sort_order: %q（可选 %s）", rule.SortOrder, strings.Join(sortOrders, "/"))
+	}
+	if rule.EventType != "" && !stringSet(ruleEventTypes)[rule.EventType] {
+		return fmt.Errorf("不支持的 event_type: %q（可选 %s）", rule.EventType, strings.Join(ruleEventTypes, "/"))
+	}
+	if rule.EventSubtype != "" && !stringSet(ruleEventSubtypes)[rule.EventSubtype] {
+		return fmt.Errorf("不支持的 event_subtype: %q（可选 %s）", rule.EventSubtype, strings.Join(ruleEventSubtypes, "/"))
+	}
+	if rule.DigestSeconds < 0 {
+		return fmt.Errorf("digest_seconds 不能为负数: %d", rule.DigestSeconds)
+	}
+	if rule.Timeframe < 0 {
+		return fmt.Errorf("时间窗口不能为负数: %d", rule.Timeframe)
+	}
+	if rule.Threshold < 0 {
+		return fmt.Errorf("阈值不能为负数: %d", rule.Threshold)
+	}
+	if rule.QueryTimeout < 0 {
+		return fmt.Errorf("query_timeout 不能为负数: %d", rule.QueryTimeout)
+	}
+	if rule.Type == "metric" {
+		if err := validateMetricRule(rule); err != nil {
+			return err
+		}
+	}
+	if rule.Type == "spike" {
+		if err := validateSpikeRule(rule); err != nil {
+			return err
+		}
+	}
+	if rule.Schedule != "" {
+		if _, err := ruleScheduleParser.Parse(rule.Schedule); err != nil {
+			return fmt.Errorf("无效的调度表达式 %q: %w", rule.Schedule, err)
+		}
+	}
+	if rule.Level != "" && !validRuleLevels[strings.ToLower(rule.Level)] {
+		return fmt.Errorf("未知的告警级别: %q（可选 Critical/High/Medium/Low/Info）", rule.Level)
+	}
+	return nil
+}
+
+// validateOpenSearchAuth 校验 OpenSearch 认证方式及对应凭据
+func validateOpenSearchAuth(cfg types.OpenSearchConfig) error {
+	switch strings.ToLower(cfg.AuthType) {
+	case "", "basic":
+		return nil
+	case "apikey":
+		if cfg.APIKey == "" {
+			return fmt.Errorf("opensearch.auth_type 为 apikey 时需配置 api_key")
+		}
+	case "bearer":
+		if cfg.Token == "" {
+			return fmt.Errorf("opensearch.auth_type 为 bearer 时需配置 token")
+		}
+	default:
+		return fmt.Errorf("不支持的 opensearch.auth_type: %q（可选 basic/apikey/bearer）", cfg.AuthType)
+	}
+	return nil
+}
+
+// metricOperators 指标规则支持的比较方式
+var metricOperators = []string{"gt", "gte", "lt", "lte"}
+
+// validMetricOperators 指标规则支持的比较方式（集合形式）
+var validMetricOperators = stringSet(metricOperators)
+
+// validateMetricRule 校验指标规则的聚合方式、字段与比较方式
+func validateMetricRule(rule types.AlertRule) error {
+	if !opensearch.IsMetricAgg(rule.MetricAgg) {
+		return fmt.Errorf("不支持的指标聚合: %q（可选 avg/max/min/sum）", rule.MetricAgg)
+	}
+	if strings.TrimSpace(rule.MetricField) == "" {
+		return fmt.Errorf("指标规则需配置 metric_field")
+	}
+	if !validMetricOperators[rule.MetricOperator] {
+		return fmt.Errorf("不支持的比较方式: %q（可选 gt/gte/lt/lte）", rule.MetricOperator)
+	}
+	return nil
+}
+
+// ApplyRuleDefaults 使用配置默认值回填规则缺失的 timeframe 与 threshold
+func ApplyRuleDefaults(rules []types.AlertRule, rulesConfig types.RulesConfig) {
+	for i := range rules {
+		if rules[i].Timeframe == 0 {
+			rules[i].Timeframe = rulesConfig.DefaultTimeframe
+		}
+		if rules[i].Threshold == 0 {
+			rules[i].Threshold = rulesConfig.DefaultThreshold
+		}
+	}
+}
+
 // setDefaults 设置默认值
 func setDefaults(config *types.Config) {
 	if config.AlertEngine.RunInterval == 0 {
@@ -93,11 +358,34 @@ func setDefaults(config *types.Config) {
 	if config.AlertEngine.AlertTimeLimit == 0 {
 		config.AlertEngine.AlertTimeLimit = 172800 // 2天
 	}
+	if config.AlertEngine.MaxRuleFailures == 0 {
+		config.AlertEngine.MaxRuleFailures = 5
+	}
+	if config.AlertEngine.MaxFetchHits == 0 {
+		config.AlertEngine.MaxFetchHits = 10000
+	}
+	if config.AlertEngine.QueryTimeout == 0 {
+		config.AlertEngine.QueryTimeout = 30
+		// 默认值不超过 HTTP 客户端超时
+		if config.OpenSearch.Timeout > 0 && config.OpenSearch.Timeout < 30 {
+			config.AlertEngine.QueryTimeout = config.OpenSearch.Timeout
+		}
+	}
 
 	if config.AlertSuppression.RealertMinutes == 0 {
 		config.AlertSuppression.RealertMinutes = 5
 	}
 
+	if config.Notifications.Email.AttachFormat == "" {
+		config.Notifications.Email.AttachFormat = "csv"
+	}
+	if config.Notifications.Email.AttachMaxRows == 0 {
+		config.Notifications.Email.AttachMaxRows = 1000
+	}
+	if config.Notifications.Email.AttachMaxBytes == 0 {
+		config.Notifications.Email.AttachMaxBytes = 5 * 1024 * 1024
+	}
+
 	if config.Rules.DefaultTimeframe == 0 {
 		config.Rules.DefaultTimeframe = 300
 	}
@@ -121,7 +409,8 @@ func setDefaults(config *types.Config) {
 	if config.Web.TemplatePath == "" {
 		config.Web.TemplatePath = "web/templates"
 	}
-	if config.Web.SessionSecret == "" {
+	// 开启鉴权时必须显式配置会话密钥（由 ValidateConfig 检查）
+	if config.Web.SessionSecret == "" && !config.Auth.Enabled {
 		config.Web.SessionSecret = "opensearch-alert-secret-key-2024"
 	}
 
@@ -143,4 +432,16 @@ func setDefaults(config *types.Config) {
 	if config.Auth.SessionTimeout == 0 {
 		config.Auth.SessionTimeout = 3600
 	}
+	if config.Auth.MaxLoginAttempts == 0 {
+		config.Auth.MaxLoginAttempts = 5
+	}
+	if config.Auth.MaxLoginAttemptsPerIP == 0 {
+		config.Auth.MaxLoginAttemptsPerIP = 4 * config.Auth.MaxLoginAttempts
+	}
+	if config.Auth.LockoutMinutes == 0 {
+		config.Auth.LockoutMinutes = 15
+	}
+	if config.Auth.HtpasswdRole == "" {
+		config.Auth.HtpasswdRole = "viewer"
+	}
 }
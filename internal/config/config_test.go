@@ -0,0 +1,280 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"opensearch-alert/internal/opensearch"
+	"opensearch-alert/pkg/types"
+)
+
+func TestLoadConfigMaxRetries(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want int
+	}{
+		{"未设置", "opensearch:\n  host: localhost\n", opensearch.DefaultMaxRetries},
+		{"显式为 0", "opensearch:\n  host: localhost\n  max_retries: 0\n", 0},
+		{"自定义", "opensearch:\n  host: localhost\n  max_retries: 5\n", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("加载配置失败: %v", err)
+			}
+			if got := opensearch.MaxRetries(cfg.OpenSearch); got != tt.want {
+				t.Errorf("MaxRetries = %d, 期望 %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	valid := types.AlertRule{Name: "r", Type: "frequency", Index: "app-*", Timeframe: 300, Threshold: 1, Level: "High"}
+	intPtr := func(v int) *int { return &v }
+	metric := func(agg, field, operator string) func(*types.AlertRule) {
+		return func(r *types.AlertRule) {
+			r.Type = "metric"
+			r.MetricAgg = agg
+			r.MetricField = field
+			r.MetricOperator = operator
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*types.AlertRule)
+		want   string
+	}{
+		{"合法规则", func(r *types.AlertRule) {}, ""},
+		{"级别大小写不敏感", func(r *types.AlertRule) { r.Level = "critical" }, ""},
+		{"indices 代替 index", func(r *types.AlertRule) { r.Index = ""; r.Indices = []string{"a-*", "b-*"} }, ""},
+		{"名称为空", func(r *types.AlertRule) { r.Name = "" }, "规则名称不能为空"},
+		{"保留名称 export", func(r *types.AlertRule) { r.Name = "export" }, "保留名称"},
+		{"保留名称 schema", func(r *types.AlertRule) { r.Name = "schema" }, "保留名称"},
+		{"保留名称 validate-yaml", func(r *types.AlertRule) { r.Name = "validate-yaml" }, "保留名称"},
+		{"包含保留名称的名称", func(r *types.AlertRule) { r.Name = "export-jobs" }, ""},
+		{"未知类型", func(r *types.AlertRule) { r.Type = "bogus" }, "不支持的规则类型"},
+		{"类型为空", func(r *types.AlertRule) { r.Type = "" }, "不支持的规则类型"},
+		{"索引为空", func(r *types.AlertRule) { r.Index = " , " }, "规则索引不能为空"},
+		{"max_hits 为负", func(r *types.AlertRule) { r.MaxHits = intPtr(-1) }, "max_hits 不能为负数"},
+		{"max_hits 为 0", func(r *types.AlertRule) { r.MaxHits = intPtr(0) }, ""},
+		{"min_hits 为负", func(r *types.AlertRule) { r.MinHits = -1 }, "min_hits 不能为负数"},
+		{"未知 template_mode", func(r *types.AlertRule) { r.TemplateMode = "merge" }, "不支持的 template_mode"},
+		{"replace 缺少 alert_text", func(r *types.AlertRule) { r.TemplateMode = "replace"; r.AlertText = "  " }, "alert_text 不能为空"},
+		{"未知 sort_order", func(r *types.AlertRule) { r.SortOrder = "random" }, "不支持的 sort_order"},
+		{"sort_order 大小写不敏感", func(r *types.AlertRule) { r.SortOrder = "ASC" }, ""},
+		{"未知 event_type", func(r *types.AlertRule) { r.EventType = "metrics" }, "不支持的 event_type"},
+		{"未知 event_subtype", func(r *types.AlertRule) { r.EventSubtype = "kernel" }, "不支持的 event_subtype"},
+		{"digest_seconds 为负", func(r *types.AlertRule) { r.DigestSeconds = -1 }, "digest_seconds 不能为负数"},
+		{"时间窗口为负", func(r *types.AlertRule) { r.Timeframe = -60 }, "时间窗口不能为负数"},
+		{"阈值为负", func(r *types.AlertRule) { r.Threshold = -1 }, "阈值不能为负数"},
+		{"query_timeout 为负", func(r *types.AlertRule) { r.QueryTimeout = -1 }, "query_timeout 不能为负数"},
+		{"无效调度表达式", func(r *types.AlertRule) { r.Schedule = "every minute" }, "无效的调度表达式"},
+		{"带秒字段的调度", func(r *types.AlertRule) { r.Schedule = "0 */5 * * * *" }, ""},
+		{"调度描述符", func(r *types.AlertRule) { r.Schedule = "@hourly" }, ""},
+		{"未知级别", func(r *types.AlertRule) { r.Level = "Urgent" }, "未知的告警级别"},
+		{"指标缺少聚合", metric("", "f", "gt"), "不支持的指标聚合"},
+		{"指标缺少字段", metric("avg", " ", "gt"), "metric_field"},
+		{"指标比较方式无效", metric("avg", "f", "eq"), "不支持的比较方式"},
+		{"合法指标规则", metric("max", "f", "gte"), ""},
+		{"spike 倍数不大于 1", func(r *types.AlertRule) { r.Type = "spike"; r.SpikeHeight = 1 }, "spike_height 必须大于 1"},
+		{"spike 方向无效", func(r *types.AlertRule) { r.Type = "spike"; r.SpikeType = "sideways" }, "不支持的 spike_type"},
+		{"spike 缺少时间窗口", func(r *types.AlertRule) { r.Type = "spike"; r.Timeframe = 0 }, "必须设置 timeframe"},
+		{"spike 不支持增量", func(r *types.AlertRule) { r.Type = "spike"; r.Incremental = true }, "不支持 incremental"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := valid
+			tt.modify(&rule)
+			err := ValidateRule(rule)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("期望通过校验，实际: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("期望包含 %q 的错误，实际: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadRulesKeepsInvalidRules(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"valid.yaml":    "name: valid\ntype: frequency\nindex: app-*\nenabled: true\n",
+		"invalid.yaml":  "name: invalid\ntype: bogus\nindex: app-*\nenabled: true\n",
+		"disabled.yaml": "name: disabled\ntype: any\nindex: app-*\nenabled: false\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules, err := LoadRules(dir)
+	if err != nil {
+		t.Fatalf("加载规则失败: %v", err)
+	}
+	// 校验未通过的规则仅告警，仍按原有行为加载
+	names := map[string]bool{}
+	for _, rule := range rules {
+		names[rule.Name] = true
+	}
+	if len(rules) != 2 || !names["valid"] || !names["invalid"] {
+		t.Errorf("加载结果不符: %v", names)
+	}
+}
+
+func TestLoadRulesRecursesSubdirectories(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("top.yaml", "name: top\ntype: any\nindex: app-*\nenabled: true\n")
+	write("prod/errors.yaml", "name: prod-errors\ntype: any\nindex: prod-*\nenabled: true\n")
+	write("staging/deep/latency.yml", "name: staging-latency\ntype: any\nindex: staging-*\nenabled: true\n")
+	write(".git/ignored.yaml", "name: hidden\ntype: any\nindex: app-*\nenabled: true\n")
+	write("prod/notes.txt", "name: not-a-rule\n")
+	// 不同子目录中的同名规则只保留最近修改的文件
+	older := write("prod/dup.yaml", "name: dup\ntype: any\nindex: old-*\nenabled: true\n")
+	write("staging/dup.yaml", "name: dup\ntype: any\nindex: new-*\nenabled: true\n")
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(older, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadRules(dir)
+	if err != nil {
+		t.Fatalf("加载规则失败: %v", err)
+	}
+	byName := map[string]types.AlertRule{}
+	for _, rule := range rules {
+		byName[rule.Name] = rule
+	}
+	for _, name := range []string{"top", "prod-errors", "staging-latency", "dup"} {
+		if _, ok := byName[name]; !ok {
+			t.Errorf("缺少规则 %s，实际 %v", name, byName)
+		}
+	}
+	if _, ok := byName["hidden"]; ok {
+		t.Error("以 . 开头的目录应被跳过")
+	}
+	if len(rules) != 4 {
+		t.Errorf("规则数 = %d, 期望 4", len(rules))
+	}
+	if byName["dup"].Index != "new-*" {
+		t.Errorf("同名规则应保留最近修改的文件，实际 index %q", byName["dup"].Index)
+	}
+}
+
+func TestValidateOpenSearchAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		config types.OpenSearchConfig
+		want   string
+	}{
+		{"默认 basic", types.OpenSearchConfig{}, ""},
+		{"basic", types.OpenSearchConfig{AuthType: "basic"}, ""},
+		{"apikey", types.OpenSearchConfig{AuthType: "apikey", APIKey: "aWQ6a2V5"}, ""},
+		{"apikey 缺少 api_key", types.OpenSearchConfig{AuthType: "apikey"}, "需配置 api_key"},
+		{"bearer 大小写不敏感", types.OpenSearchConfig{AuthType: "BEARER", Token: "t"}, ""},
+		{"bearer 缺少 token", types.OpenSearchConfig{AuthType: "bearer"}, "需配置 token"},
+		{"未知方式", types.OpenSearchConfig{AuthType: "kerberos"}, "不支持的 opensearch.auth_type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOpenSearchAuth(tt.config)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("期望通过校验，实际: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("期望包含 %q 的错误，实际: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRuleFileExtensions(t *testing.T) {
+	for name, want := range map[string]bool{
+		"errors.yaml": true, "errors.yml": true, "ERRORS.YML": true, "prod/errors.Yaml": true,
+		"errors.json": false, "errors.yaml.bak": false, "yml": false,
+	} {
+		if got := IsRuleFile(name); got != want {
+			t.Errorf("IsRuleFile(%q) = %v, 期望 %v", name, got, want)
+		}
+	}
+
+	dir := t.TempDir()
+	if got := RuleFilePath(dir, "errors"); got != filepath.Join(dir, "errors.yaml") {
+		t.Errorf("无现有文件时应默认 .yaml，实际 %s", got)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "errors.yml"), []byte("name: errors\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := RuleFilePath(dir, "errors"); got != filepath.Join(dir, "errors.yml") {
+		t.Errorf("已有 .yml 文件时应沿用，实际 %s", got)
+	}
+}
+
+func TestLoadRulesAcceptsYml(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "latency.yml"), []byte("name: latency\ntype: any\nindex: app-*\nenabled: true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := LoadRules(dir)
+	if err != nil {
+		t.Fatalf("加载规则失败: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Name != "latency" {
+		t.Errorf(".yml 规则应被加载，实际 %+v", rules)
+	}
+}
+
+func TestBootstrapEmbeddedRulesKeepsExistingYml(t *testing.T) {
+	dir := t.TempDir()
+	custom := []byte("name: customized\n")
+	if err := os.WriteFile(filepath.Join(dir, "error_logs.yml"), custom, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := BootstrapEmbeddedRules(dir, false, nil)
+	if err != nil {
+		t.Fatalf("生成内置规则失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "error_logs.yaml")); !os.IsNotExist(err) {
+		t.Error("已有同名 .yml 文件时不应生成 .yaml 副本")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "error_logs.yml")); string(data) != string(custom) {
+		t.Error("overwrite=false 时不应修改已有的 .yml 文件")
+	}
+	files, err := RuleFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != written+1 {
+		t.Errorf("规则文件数 = %d, 期望新写入 %d 个加已有 1 个", len(files), written)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
@@ -35,11 +36,12 @@ func BootstrapEmbeddedRules(targetDir string, overwrite bool, logger *logrus.Log
 			continue
 		}
 		name := entry.Name()
-		if filepath.Ext(name) != ".yaml" {
+		if !IsRuleFile(name) {
 			continue
 		}
 
-		destPath := filepath.Join(targetDir, name)
+		// 目标目录中已有同名 .yml 文件时视为已存在，不再生成 .yaml 副本
+		destPath := RuleFilePath(targetDir, strings.TrimSuffix(name, filepath.Ext(name)))
 		if _, statErr := os.Stat(destPath); statErr == nil && !overwrite {
 			if logger != nil {
 				logger.Debugf("规则已存在，跳过: %s", destPath)
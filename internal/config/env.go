@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"opensearch-alert/pkg/types"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// envPattern 匹配 ${VAR} 与 ${VAR:-default}；不处理 $VAR 形式，避免误改 bcrypt 哈希等含 $ 的值
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// ExpandEnv 展开字符串中的环境变量占位符；未设置且无默认值的占位符保持原样并返回其变量名
+func ExpandEnv(value string) (string, []string) {
+	var missing []string
+	expanded := envPattern.ReplaceAllStringFunc(value, func(match string) string {
+		groups := envPattern.FindStringSubmatch(match)
+		if v, ok := os.LookupEnv(groups[1]); ok && (v != "" || groups[2] == "") {
+			return v
+		}
+		if groups[2] != "" {
+			return groups[3]
+		}
+		missing = append(missing, groups[1])
+		return match
+	})
+	return expanded, missing
+}
+
+// expandEnvNode 递归展开节点树中的标量值，返回发生展开的配置项（路径 → 占位符）
+func expandEnvNode(node *yaml.Node, path string) map[string]types.EnvPlaceholder {
+	placeholders := make(map[string]types.EnvPlaceholder)
+	walkScalars(node, path, func(n *yaml.Node, path string) {
+		if !envPattern.MatchString(n.Value) {
+			return
+		}
+		expanded, missing := ExpandEnv(n.Value)
+		for _, name := range missing {
+			logrus.Warnf("配置项 %s 引用的环境变量 %s 未设置，保留原始占位符", path, name)
+		}
+		if expanded == n.Value {
+			return
+		}
+		placeholders[path] = types.EnvPlaceholder{Raw: n.Value, Value: expanded}
+		n.Value = expanded
+		// 展开后按普通标量重新推断类型（如端口号 ${SMTP_PORT}）
+		n.Tag = ""
+		n.Style = 0
+	})
+	return placeholders
+}
+
+// walkScalars 遍历节点树中的标量值（映射的键除外）
+func walkScalars(node *yaml.Node, path string, fn func(n *yaml.Node, path string)) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkScalars(child, path, fn)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+			walkScalars(node.Content[i+1], key, fn)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			walkScalars(child, path+"["+strconv.Itoa(i)+"]", fn)
+		}
+	case yaml.ScalarNode:
+		fn(node, path)
+	}
+}
+
+// MarshalConfig 序列化配置用于写回文件；值未被修改的环境变量配置项写回原始占位符
+func MarshalConfig(config *types.Config) ([]byte, error) {
+	var root yaml.Node
+	if err := root.Encode(config); err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+
+	if len(config.EnvPlaceholders) > 0 {
+		walkScalars(&root, "", func(n *yaml.Node, path string) {
+			placeholder, ok := config.EnvPlaceholders[path]
+			if !ok || n.Value != placeholder.Value {
+				return
+			}
+			n.Value = placeholder.Raw
+			n.Tag = "!!str"
+			n.Style = 0
+		})
+	}
+
+	data, err := yaml.Marshal(&root)
+	if err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("OS_PASSWORD", "s3cret")
+	t.Setenv("EMPTY_VAR", "")
+
+	tests := []struct {
+		name        string
+		value       string
+		want        string
+		wantMissing []string
+	}{
+		{"已设置", "${OS_PASSWORD}", "s3cret", nil},
+		{"已设置时忽略默认值", "${OS_PASSWORD:-fallback}", "s3cret", nil},
+		{"未设置使用默认值", "${SMTP_PORT_UNSET:-587}", "587", nil},
+		{"空值使用默认值", "${EMPTY_VAR:-fallback}", "fallback", nil},
+		{"空值无默认值", "${EMPTY_VAR}", "", nil},
+		{"空默认值", "${MISSING_VAR:-}", "", nil},
+		{"未设置且无默认值保留原样", "${MISSING_VAR}", "${MISSING_VAR}", []string{"MISSING_VAR"}},
+		{"混合文本", "user:${OS_PASSWORD}@${MISSING_HOST}", "user:s3cret@${MISSING_HOST}", []string{"MISSING_HOST"}},
+		{"不处理 $VAR 形式", "$2a$10$abc$OS_PASSWORD", "$2a$10$abc$OS_PASSWORD", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing := ExpandEnv(tt.value)
+			if got != tt.want {
+				t.Errorf("ExpandEnv(%q) = %q, 期望 %q", tt.value, got, tt.want)
+			}
+			if !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Errorf("未设置的变量 = %v, 期望 %v", missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestLoadConfigExpandsEnvAndKeepsPlaceholdersOnSave(t *testing.T) {
+	t.Setenv("OS_PASSWORD", "s3cret")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yamlText := `opensearch:
+  host: localhost
+  username: ${OS_USER:-admin}
+  password: ${OS_PASSWORD}
+notifications:
+  email:
+    smtp_server: smtp.example.com
+    smtp_port: ${SMTP_PORT_UNSET:-2525}
+    password: ${SMTP_PASSWORD_UNSET}
+`
+	if err := os.WriteFile(path, []byte(yamlText), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	if cfg.OpenSearch.Password != "s3cret" {
+		t.Errorf("opensearch.password = %q, 期望从环境变量展开", cfg.OpenSearch.Password)
+	}
+	if cfg.OpenSearch.Username != "admin" {
+		t.Errorf("opensearch.username = %q, 期望默认值 admin", cfg.OpenSearch.Username)
+	}
+	if cfg.Notifications.Email.SMTPPort != 2525 {
+		t.Errorf("smtp_port = %d, 期望默认值按整数解析为 2525", cfg.Notifications.Email.SMTPPort)
+	}
+	if cfg.Notifications.Email.Password != "${SMTP_PASSWORD_UNSET}" {
+		t.Errorf("未设置的变量应保留占位符，实际 %q", cfg.Notifications.Email.Password)
+	}
+
+	// 写回文件时恢复占位符，密钥不落盘
+	data, err := MarshalConfig(cfg)
+	if err != nil {
+		t.Fatalf("序列化配置失败: %v", err)
+	}
+	saved := string(data)
+	if strings.Contains(saved, "s3cret") {
+		t.Errorf("保存的配置不应包含展开后的密钥:\n%s", saved)
+	}
+	for _, want := range []string{"${OS_PASSWORD}", "${OS_USER:-admin}", "${SMTP_PORT_UNSET:-2525}"} {
+		if !strings.Contains(saved, want) {
+			t.Errorf("保存的配置缺少占位符 %s:\n%s", want, saved)
+		}
+	}
+
+	// 通过界面修改过的值按新值保存
+	cfg.OpenSearch.Password = "rotated"
+	data, err = MarshalConfig(cfg)
+	if err != nil {
+		t.Fatalf("序列化配置失败: %v", err)
+	}
+	if saved := string(data); !strings.Contains(saved, "password: rotated") || strings.Contains(saved, "${OS_PASSWORD}") {
+		t.Errorf("修改后的密码应按新值保存:\n%s", saved)
+	}
+}
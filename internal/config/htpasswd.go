@@ -0,0 +1,244 @@
+package config
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Htpasswd htpasswd 用户文件，文件修改后在下次校验时自动重新加载
+type Htpasswd struct {
+	path           string
+	allowPlaintext bool
+	mu             sync.Mutex
+	modTime        time.Time
+	entries        map[string]string
+}
+
+// htpasswd 条目格式
+const (
+	htpasswdBcrypt      = "bcrypt"
+	htpasswdAPR1        = "apr1"
+	htpasswdSHA         = "sha"
+	htpasswdPlaintext   = "plaintext"
+	htpasswdUnsupported = "unsupported"
+)
+
+// NewHtpasswd 加载 htpasswd 文件；allowPlaintext 为 false 时明文条目一律校验失败
+func NewHtpasswd(path string, allowPlaintext bool) (*Htpasswd, error) {
+	h := &Htpasswd{path: path, allowPlaintext: allowPlaintext}
+	if err := h.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Verify 校验用户名与密码，支持 bcrypt、apr1、{SHA}，明文条目需显式开启；
+// 条目格式不受支持（DES crypt、$5$/$6$ 等）时返回错误，避免把哈希本身当作密码比较
+func (h *Htpasswd) Verify(username, password string) (bool, error) {
+	if err := h.reloadIfChanged(); err != nil {
+		return false, err
+	}
+
+	h.mu.Lock()
+	hash, ok := h.entries[username]
+	h.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	switch format := htpasswdFormat(hash); format {
+	case htpasswdUnsupported:
+		return false, fmt.Errorf("用户 %s 的 htpasswd 条目格式不受支持（仅支持 bcrypt/apr1/{SHA}），已拒绝登录", username)
+	case htpasswdPlaintext:
+		if !h.allowPlaintext {
+			return false, fmt.Errorf("用户 %s 的 htpasswd 条目为明文密码，未开启 htpasswd_allow_plaintext，已拒绝登录", username)
+		}
+	}
+	return verifyHtpasswdHash(hash, password), nil
+}
+
+// Unsupported 返回条目格式无法用于登录的用户（不受支持的哈希，或未开启明文时的明文条目）
+func (h *Htpasswd) Unsupported() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var users []string
+	for user, hash := range h.entries {
+		switch htpasswdFormat(hash) {
+		case htpasswdUnsupported:
+			users = append(users, user)
+		case htpasswdPlaintext:
+			if !h.allowPlaintext {
+				users = append(users, user)
+			}
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+// reloadIfChanged 文件修改时间变化时重新加载
+func (h *Htpasswd) reloadIfChanged() error {
+	info, err := os.Stat(h.path)
+	if err != nil {
+		return fmt.Errorf("读取 htpasswd 文件失败: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.entries != nil && info.ModTime().Equal(h.modTime) {
+		return nil
+	}
+
+	entries, err := parseHtpasswd(h.path)
+	if err != nil {
+		return err
+	}
+	h.entries = entries
+	h.modTime = info.ModTime()
+	return nil
+}
+
+// parseHtpasswd 解析 htpasswd 文件（user:hash，忽略空行与 # 注释）
+func parseHtpasswd(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 htpasswd 文件失败: %w", err)
+	}
+	defer f.Close()
+
+	entries := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" {
+			continue
+		}
+		entries[user] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("解析 htpasswd 文件失败: %w", err)
+	}
+	return entries, nil
+}
+
+// desCryptPattern 传统 DES crypt 哈希（htpasswd -d）：2 位盐 + 11 位哈希
+var desCryptPattern = regexp.MustCompile(`^[./0-9A-Za-z]{13}$`)
+
+// htpasswdFormat 识别 htpasswd 条目格式：以 $ 或 { 开头的未知方案及形如 DES crypt 的条目视为不受支持，
+// 其余为明文（htpasswd -p）
+func htpasswdFormat(hash string) string {
+	switch {
+	case IsHashedPassword(hash):
+		return htpasswdBcrypt
+	case strings.HasPrefix(hash, "$apr1$"):
+		return htpasswdAPR1
+	case strings.HasPrefix(hash, "{SHA}"):
+		return htpasswdSHA
+	case strings.HasPrefix(hash, "$"), strings.HasPrefix(hash, "{"), desCryptPattern.MatchString(hash):
+		return htpasswdUnsupported
+	default:
+		return htpasswdPlaintext
+	}
+}
+
+// verifyHtpasswdHash 按 htpasswd 条目格式校验密码，不受支持的格式一律校验失败
+func verifyHtpasswdHash(hash, password string) bool {
+	switch htpasswdFormat(hash) {
+	case htpasswdBcrypt:
+		return VerifyPassword(hash, password)
+	case htpasswdAPR1:
+		salt := strings.SplitN(strings.TrimPrefix(hash, "$apr1$"), "$", 2)[0]
+		return subtle.ConstantTimeCompare([]byte(apr1Crypt(password, salt)), []byte(hash)) == 1
+	case htpasswdSHA:
+		sum := sha1.Sum([]byte(password))
+		expected := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(expected), []byte(hash)) == 1
+	case htpasswdPlaintext:
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(password)) == 1
+	default:
+		return false
+	}
+}
+
+// apr1Crypt Apache MD5（$apr1$）密码哈希
+func apr1Crypt(password, salt string) string {
+	const magic = "$apr1$"
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+	pw := []byte(password)
+
+	alt := md5.Sum([]byte(password + salt + password))
+
+	h := md5.New()
+	h.Write([]byte(password + magic + salt))
+	for i := len(pw); i > 0; i -= 16 {
+		if i > 16 {
+			h.Write(alt[:])
+		} else {
+			h.Write(alt[:i])
+		}
+	}
+	for i := len(pw); i > 0; i >>= 1 {
+		if i&1 == 1 {
+			h.Write([]byte{0})
+		} else {
+			h.Write(pw[:1])
+		}
+	}
+	final := h.Sum(nil)
+
+	for i := 0; i < 1000; i++ {
+		r := md5.New()
+		if i&1 == 1 {
+			r.Write(pw)
+		} else {
+			r.Write(final)
+		}
+		if i%3 != 0 {
+			r.Write([]byte(salt))
+		}
+		if i%7 != 0 {
+			r.Write(pw)
+		}
+		if i&1 == 1 {
+			r.Write(final)
+		} else {
+			r.Write(pw)
+		}
+		final = r.Sum(nil)
+	}
+
+	const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	var out strings.Builder
+	to64 := func(v uint32, n int) {
+		for ; n > 0; n-- {
+			out.WriteByte(itoa64[v&0x3f])
+			v >>= 6
+		}
+	}
+	to64(uint32(final[0])<<16|uint32(final[6])<<8|uint32(final[12]), 4)
+	to64(uint32(final[1])<<16|uint32(final[7])<<8|uint32(final[13]), 4)
+	to64(uint32(final[2])<<16|uint32(final[8])<<8|uint32(final[14]), 4)
+	to64(uint32(final[3])<<16|uint32(final[9])<<8|uint32(final[15]), 4)
+	to64(uint32(final[4])<<16|uint32(final[10])<<8|uint32(final[5]), 4)
+	to64(uint32(final[11]), 2)
+
+	return magic + salt + "$" + out.String()
+}
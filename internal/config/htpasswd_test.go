@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeHtpasswd 将条目写入临时 htpasswd 文件
+func writeHtpasswd(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatalf("写入 htpasswd 文件失败: %v", err)
+	}
+	return path
+}
+
+func TestHtpasswdVerifySupportedFormats(t *testing.T) {
+	bcryptHash, err := HashPassword("password")
+	if err != nil {
+		t.Fatalf("HashPassword 失败: %v", err)
+	}
+	path := writeHtpasswd(t,
+		"# comment",
+		"bcrypt:"+bcryptHash,
+		// openssl passwd -apr1 -salt saltsalt password
+		"apr1:$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/",
+		"sha:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=",
+	)
+	h, err := NewHtpasswd(path, false)
+	if err != nil {
+		t.Fatalf("NewHtpasswd 失败: %v", err)
+	}
+
+	for _, user := range []string{"bcrypt", "apr1", "sha"} {
+		t.Run(user, func(t *testing.T) {
+			ok, err := h.Verify(user, "password")
+			if err != nil || !ok {
+				t.Fatalf("正确密码应校验通过: ok=%v err=%v", ok, err)
+			}
+			ok, err = h.Verify(user, "wrong")
+			if err != nil || ok {
+				t.Fatalf("错误密码应校验失败: ok=%v err=%v", ok, err)
+			}
+		})
+	}
+
+	if ok, err := h.Verify("nobody", "password"); ok || err != nil {
+		t.Fatalf("不存在的用户应校验失败: ok=%v err=%v", ok, err)
+	}
+}
+
+func TestHtpasswdRejectsUnsupportedFormats(t *testing.T) {
+	entries := map[string]string{
+		"des":  "saEmUZ4ftYr6o",
+		"md5":  "$1$saltsalt$qjXMvbEw8oaL.CzflDtaK/",
+		"sha5": "$5$saltsalt$gOjOtoMpVhru2uyjeJSEc/JaLQWOXMNmlOnj6T4AtC.",
+		"sha6": "$6$saltsalt$qFmFH.bQmmtXzyBY0s9v7Oicd2z4XSIecDzlB5KiA2/jctKu9YterLp8wwnSq.qc.eoxqOmSuNp2xS0ktL3nh/",
+		"ssha": "{SSHA}c2FsdHNhbHRzYWx0c2FsdA==",
+	}
+	var lines []string
+	for user, hash := range entries {
+		lines = append(lines, user+":"+hash)
+	}
+	// 开启明文也不能让不受支持的哈希按明文比较
+	h, err := NewHtpasswd(writeHtpasswd(t, lines...), true)
+	if err != nil {
+		t.Fatalf("NewHtpasswd 失败: %v", err)
+	}
+
+	for user, hash := range entries {
+		t.Run(user, func(t *testing.T) {
+			ok, err := h.Verify(user, hash)
+			if ok || err == nil {
+				t.Fatalf("输入哈希本身不应登录成功: ok=%v err=%v", ok, err)
+			}
+		})
+	}
+
+	want := []string{"des", "md5", "sha5", "sha6", "ssha"}
+	if got := h.Unsupported(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unsupported() = %v, 期望 %v", got, want)
+	}
+}
+
+func TestHtpasswdPlaintextRequiresOptIn(t *testing.T) {
+	path := writeHtpasswd(t, "plain:secret")
+
+	h, err := NewHtpasswd(path, false)
+	if err != nil {
+		t.Fatalf("NewHtpasswd 失败: %v", err)
+	}
+	if ok, err := h.Verify("plain", "secret"); ok || err == nil {
+		t.Fatalf("未开启明文时应拒绝: ok=%v err=%v", ok, err)
+	}
+	if got := h.Unsupported(); !reflect.DeepEqual(got, []string{"plain"}) {
+		t.Fatalf("Unsupported() = %v", got)
+	}
+
+	h, err = NewHtpasswd(path, true)
+	if err != nil {
+		t.Fatalf("NewHtpasswd 失败: %v", err)
+	}
+	if ok, err := h.Verify("plain", "secret"); !ok || err != nil {
+		t.Fatalf("开启明文后应校验通过: ok=%v err=%v", ok, err)
+	}
+	if ok, _ := h.Verify("plain", "wrong"); ok {
+		t.Fatal("错误密码应校验失败")
+	}
+	if got := h.Unsupported(); len(got) != 0 {
+		t.Fatalf("开启明文后不应报告不受支持的用户: %v", got)
+	}
+}
+
+func TestAPR1CryptMatchesOpenSSL(t *testing.T) {
+	// openssl passwd -apr1 -salt <salt> <password>
+	tests := []struct{ password, salt, want string }{
+		{"password", "saltsalt", "$apr1$saltsalt$yAAkm4libquA.ZWLHbSBq/"},
+		{"p@ss wörd", "abcd", "$apr1$abcd$KOP8dtoWhoMstDuGoLT221"},
+	}
+	for _, tt := range tests {
+		if got := apr1Crypt(tt.password, tt.salt); got != tt.want {
+			t.Errorf("apr1Crypt(%q, %q) = %q, 期望 %q", tt.password, tt.salt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import "testing"
+
+func TestVerifyPassword(t *testing.T) {
+	hash, err := HashPassword("s3cret")
+	if err != nil {
+		t.Fatalf("HashPassword 失败: %v", err)
+	}
+	if !IsHashedPassword(hash) {
+		t.Fatalf("HashPassword 应生成 bcrypt 哈希，实际 %q", hash)
+	}
+	// $2y$ 前缀（htpasswd -B 生成）同样按 bcrypt 校验
+	hash2y := "$2y$" + hash[len("$2a$"):]
+
+	tests := []struct {
+		name     string
+		stored   string
+		password string
+		want     bool
+	}{
+		{"bcrypt 正确", hash, "s3cret", true},
+		{"bcrypt 错误", hash, "wrong", false},
+		{"bcrypt 不接受哈希原文", hash, hash, false},
+		{"$2y$ 前缀", hash2y, "s3cret", true},
+		{"明文正确", "s3cret", "s3cret", true},
+		{"明文错误", "s3cret", "S3cret", false},
+		{"明文前缀不匹配", "s3cret", "s3cre", false},
+		{"空密码不接受非空输入", "", "x", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyPassword(tt.stored, tt.password); got != tt.want {
+				t.Errorf("VerifyPassword(%q, %q) = %v, 期望 %v", tt.stored, tt.password, got, tt.want)
+			}
+		})
+	}
+}
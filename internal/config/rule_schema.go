@@ -0,0 +1,103 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"opensearch-alert/internal/notification"
+	"opensearch-alert/internal/opensearch"
+	"opensearch-alert/pkg/types"
+)
+
+// RuleField 规则字段描述，供前端动态渲染规则表单
+type RuleField struct {
+	// Name YAML 字段名
+	Name string `json:"name"`
+	// Type 字段类型：string/integer/number/boolean/array/object
+	Type string `json:"type"`
+	// Items 数组元素类型
+	Items string `json:"items,omitempty"`
+	// Required 是否必填
+	Required bool `json:"required"`
+	// Enum 可选值（为空表示不限）
+	Enum []string `json:"enum,omitempty"`
+}
+
+// RuleSchemaInfo 规则结构描述
+type RuleSchemaInfo struct {
+	Fields []RuleField `json:"fields"`
+	// OneOf 每组字段至少需要填写一个（如 index 与 indices）
+	OneOf [][]string `json:"one_of,omitempty"`
+}
+
+// requiredRuleFields 必填的规则字段
+var requiredRuleFields = map[string]bool{"name": true, "type": true}
+
+// ruleFieldEnums 有固定可选值的规则字段，与 ValidateRule 的校验保持一致
+func ruleFieldEnums() map[string][]string {
+	return map[string][]string{
+		"type":            ruleTypes,
+		"level":           types.AlertLevels,
+		"alert":           notification.ChannelNames,
+		"metric_agg":      opensearch.MetricAggs,
+		"metric_operator": metricOperators,
+		"event_type":      ruleEventTypes,
+		"event_subtype":   ruleEventSubtypes,
+		"sort_order":      sortOrders,
+		"spike_type":      spikeTypes,
+		"template_mode":   templateModes,
+		"alert_text_type": {"go_template"},
+	}
+}
+
+// RuleSchema 通过反射 AlertRule 的 yaml 标签生成规则字段描述
+func RuleSchema() RuleSchemaInfo {
+	enums := ruleFieldEnums()
+	ruleType := reflect.TypeOf(types.AlertRule{})
+
+	fields := make([]RuleField, 0, ruleType.NumField())
+	for i := 0; i < ruleType.NumField(); i++ {
+		f := ruleType.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		field := RuleField{
+			Name:     name,
+			Type:     schemaType(f.Type),
+			Required: requiredRuleFields[name],
+			Enum:     enums[name],
+		}
+		if field.Type == "array" {
+			field.Items = schemaType(f.Type.Elem())
+		}
+		fields = append(fields, field)
+	}
+
+	return RuleSchemaInfo{
+		Fields: fields,
+		OneOf:  [][]string{{"index", "indices"}},
+	}
+}
+
+// schemaType Go 类型对应的字段类型
+func schemaType(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
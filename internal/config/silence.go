@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"opensearch-alert/pkg/types"
+	"path"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ValidateSilence 校验静默窗口：需指定起止时间或 cron+duration 之一
+func ValidateSilence(silence types.Silence) error {
+	if silence.Rule != "" {
+		if _, err := path.Match(silence.Rule, ""); err != nil {
+			return fmt.Errorf("无效的规则匹配模式 %q: %w", silence.Rule, err)
+		}
+	}
+
+	if silence.Cron != "" {
+		if _, err := cron.ParseStandard(silence.Cron); err != nil {
+			return fmt.Errorf("无效的 cron 表达式 %q: %w", silence.Cron, err)
+		}
+		if silence.Duration <= 0 {
+			return fmt.Errorf("周期性静默需设置 duration（分钟）")
+		}
+		return nil
+	}
+
+	if silence.StartsAt.IsZero() || silence.EndsAt.IsZero() {
+		return fmt.Errorf("静默需设置 starts_at/ends_at 或 cron/duration")
+	}
+	if !silence.EndsAt.After(silence.StartsAt) {
+		return fmt.Errorf("静默结束时间必须晚于开始时间")
+	}
+	return nil
+}
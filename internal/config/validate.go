@@ -0,0 +1,270 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/url"
+	"opensearch-alert/internal/notification"
+	"opensearch-alert/pkg/types"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// maxQueryCacheTTL 查询结果缓存时间上限（秒）
+const maxQueryCacheTTL = 60
+
+// ValidateConfig 校验配置的必填项与取值范围，一次性返回全部问题
+func ValidateConfig(cfg *types.Config) error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	// OpenSearch
+	if len(cfg.OpenSearch.Hosts) == 0 {
+		if cfg.OpenSearch.Host == "" {
+			add("opensearch.host 不能为空")
+		}
+		if !validPort(cfg.OpenSearch.Port) {
+			add("opensearch.port 必须在 1-65535 之间（当前 %d）", cfg.OpenSearch.Port)
+		}
+	} else {
+		needPort := false
+		for i, host := range cfg.OpenSearch.Hosts {
+			host = strings.TrimSpace(host)
+			if host == "" {
+				add("opensearch.hosts[%d] 不能为空", i)
+				continue
+			}
+			if !strings.Contains(host, ":") {
+				needPort = true
+			}
+		}
+		if needPort && !validPort(cfg.OpenSearch.Port) {
+			add("opensearch.hosts 中存在未指定端口的主机，opensearch.port 必须在 1-65535 之间（当前 %d）", cfg.OpenSearch.Port)
+		}
+	}
+
+	// 告警引擎：规则执行超时超过 HTTP 客户端超时时，请求会先被客户端中断，报错与超时设置不符
+	if cfg.OpenSearch.QueryCacheTTL < 0 || cfg.OpenSearch.QueryCacheTTL > maxQueryCacheTTL {
+		add("opensearch.query_cache_ttl 必须在 0-%d 秒之间（当前 %d），过长会读到过期结果", maxQueryCacheTTL, cfg.OpenSearch.QueryCacheTTL)
+	}
+	if cfg.AlertEngine.BufferTime < 0 {
+		add("alert_engine.buffer_time 不能为负数")
+	}
+	if cfg.AlertEngine.IncrementalOverlap < 0 {
+		add("alert_engine.incremental_overlap 不能为负数")
+	}
+	if cfg.AlertEngine.LogSnippetLength < 0 {
+		add("alert_engine.log_snippet_length 不能为负数")
+	}
+	if cfg.AlertEngine.QueryTimeout < 0 {
+		add("alert_engine.query_timeout 不能为负数")
+	}
+	if cfg.OpenSearch.Timeout > 0 && cfg.AlertEngine.QueryTimeout > cfg.OpenSearch.Timeout {
+		add("alert_engine.query_timeout（%d 秒）不能超过 opensearch.timeout（%d 秒）", cfg.AlertEngine.QueryTimeout, cfg.OpenSearch.Timeout)
+	}
+
+	// 通知渠道
+	email := cfg.Notifications.Email
+	if email.Enabled {
+		if email.SMTPServer == "" {
+			add("notifications.email.smtp_server 不能为空")
+		}
+		if !validPort(email.SMTPPort) {
+			add("notifications.email.smtp_port 必须在 1-65535 之间（当前 %d）", email.SMTPPort)
+		}
+		if email.Username == "" {
+			add("notifications.email.username 不能为空")
+		}
+		if email.Password == "" {
+			add("notifications.email.password 不能为空")
+		}
+		if email.FromEmail == "" {
+			add("notifications.email.from_email 不能为空")
+		}
+		if len(email.ToEmails) == 0 {
+			add("notifications.email.to_emails 不能为空")
+		}
+		if email.AttachMatches && email.AttachFormat != "csv" && email.AttachFormat != "json" {
+			add("notifications.email.attach_format 不支持 %q（可选 csv/json）", email.AttachFormat)
+		}
+	}
+	webhooks := []struct {
+		name    string
+		enabled bool
+		url     string
+	}{
+		{"dingtalk", cfg.Notifications.DingTalk.Enabled, cfg.Notifications.DingTalk.WebhookURL},
+		{"wechat", cfg.Notifications.WeChat.Enabled, cfg.Notifications.WeChat.WebhookURL},
+		{"feishu", cfg.Notifications.Feishu.Enabled, cfg.Notifications.Feishu.WebhookURL},
+	}
+	for _, hook := range webhooks {
+		if !hook.enabled {
+			continue
+		}
+		if err := validateURL(hook.url); err != nil {
+			add("notifications.%s.webhook_url %v", hook.name, err)
+		}
+	}
+
+	ntfy := cfg.Notifications.Ntfy
+	if ntfy.Enabled {
+		if err := validateURL(ntfy.ServerURL); err != nil {
+			add("notifications.ntfy.server_url %v", err)
+		}
+		if strings.TrimSpace(ntfy.Topic) == "" {
+			add("notifications.ntfy.topic 不能为空")
+		}
+	}
+	pagerduty := cfg.Notifications.PagerDuty
+	if pagerduty.Enabled && strings.TrimSpace(pagerduty.RoutingKey) == "" {
+		add("notifications.pagerduty.routing_key 不能为空")
+	}
+	if pagerduty.EventsURL != "" {
+		if err := validateURL(pagerduty.EventsURL); err != nil {
+			add("notifications.pagerduty.events_url %v", err)
+		}
+	}
+	if cfg.Notifications.MaxMessageLength < 0 {
+		add("notifications.max_message_length 不能为负数（当前 %d，0 表示按各平台限制使用默认值）", cfg.Notifications.MaxMessageLength)
+	}
+	if cfg.Notifications.HTTPTimeout < 0 {
+		add("notifications.http_timeout 不能为负数（当前 %d，0 表示默认 %d 秒）", cfg.Notifications.HTTPTimeout, notification.DefaultHTTPTimeout)
+	}
+	if ntfy.Priority < 0 || ntfy.Priority > 5 {
+		add("notifications.ntfy.priority 必须在 1-5 之间（0 表示按级别映射，当前 %d）", ntfy.Priority)
+	}
+
+	minLevels := []struct {
+		name  string
+		level string
+	}{
+		{"email", cfg.Notifications.Email.MinLevel},
+		{"dingtalk", cfg.Notifications.DingTalk.MinLevel},
+		{"wechat", cfg.Notifications.WeChat.MinLevel},
+		{"feishu", cfg.Notifications.Feishu.MinLevel},
+		{"ntfy", cfg.Notifications.Ntfy.MinLevel},
+		{"pagerduty", cfg.Notifications.PagerDuty.MinLevel},
+	}
+	for _, c := range minLevels {
+		if c.level != "" && types.LevelRank(c.level) == 0 {
+			add("notifications.%s.min_level 不支持 %q（可选 %s）", c.name, c.level, strings.Join(types.AlertLevels, "/"))
+		}
+	}
+
+	// 级别展示元数据覆盖
+	for level, meta := range cfg.Levels {
+		if _, ok := types.NormalizeLevelName(level); !ok {
+			add("levels.%s 不是有效的告警级别（可选 %s）", level, strings.Join(types.AlertLevels, "/"))
+		}
+		switch meta.WeChatColor {
+		case "", "info", "comment", "warning":
+		default:
+			add("levels.%s.wechat_color 不支持 %q（可选 info/comment/warning）", level, meta.WeChatColor)
+		}
+	}
+
+	if text := cfg.Notifications.Email.SubjectTemplate; text != "" {
+		if _, err := notification.ParseSubjectTemplate(text); err != nil {
+			add("notifications.email.subject_template %v", err)
+		}
+	}
+
+	dingtalk := cfg.Notifications.DingTalk
+	if dingtalk.Enabled && dingtalk.UseActionCard {
+		if err := validateURL(dingtalk.DashboardBaseURL); err != nil {
+			add("开启 use_action_card 时 notifications.dingtalk.dashboard_base_url %v", err)
+		}
+	}
+
+	// 数据库：配置了 dsn 时不再要求分项配置
+	dsn := strings.TrimSpace(cfg.Database.DSN)
+	switch {
+	case dsn != "" && cfg.Database.Type == "mysql":
+		if dsnCfg, err := mysql.ParseDSN(dsn); err != nil {
+			add("database.dsn 格式错误: %v", err)
+		} else if !dsnCfg.ParseTime {
+			add("database.dsn 需包含 parseTime=true")
+		}
+	case dsn != "" && cfg.Database.Type == "sqlite":
+		// SQLite DSN（如 file:data/alert.db?_busy_timeout=5000）由驱动在连接时校验
+	case cfg.Database.Type == "sqlite":
+		if cfg.Database.Path == "" {
+			add("database.path 不能为空")
+		}
+	case cfg.Database.Type == "mysql":
+		if cfg.Database.Host == "" {
+			add("database.host 不能为空")
+		}
+		if !validPort(cfg.Database.Port) {
+			add("database.port 必须在 1-65535 之间（当前 %d）", cfg.Database.Port)
+		}
+		if cfg.Database.DBName == "" {
+			add("database.dbname 不能为空")
+		}
+	default:
+		add("database.type 不支持 %q（可选 sqlite/mysql）", cfg.Database.Type)
+	}
+
+	// 日志
+	if cfg.Logging.MaxSize != "" {
+		if _, err := ParseSize(cfg.Logging.MaxSize); err != nil {
+			add("logging.max_size %v", err)
+		}
+	}
+	if cfg.Logging.BackupCount < 0 {
+		add("logging.backup_count 不能为负数")
+	}
+
+	// Web 与鉴权
+	if cfg.Web.Enabled && !validPort(cfg.Web.Port) {
+		add("web.port 必须在 1-65535 之间（当前 %d）", cfg.Web.Port)
+	}
+	if cfg.Auth.Enabled && strings.TrimSpace(cfg.Web.SessionSecret) == "" {
+		add("开启鉴权时 web.session_secret 不能为空")
+	}
+	if (cfg.Web.TLSCertFile == "") != (cfg.Web.TLSKeyFile == "") {
+		add("web.tls_cert_file 与 web.tls_key_file 需同时配置")
+	} else if cfg.Web.Enabled && cfg.Web.TLSEnabled() {
+		if _, err := tls.LoadX509KeyPair(cfg.Web.TLSCertFile, cfg.Web.TLSKeyFile); err != nil {
+			add("web.tls_cert_file/tls_key_file 加载失败: %v", err)
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.Web.CookieSameSite)) {
+	case "", "lax", "strict":
+	case "none":
+		if cfg.Web.CookieSecure != nil && !*cfg.Web.CookieSecure {
+			add("web.cookie_same_site 为 none 时 web.cookie_secure 不能为 false（浏览器会拒绝该 Cookie）")
+		}
+	default:
+		add("web.cookie_same_site 不支持 %q（可选 lax/strict/none）", cfg.Web.CookieSameSite)
+	}
+	if _, err := ParseTrustedProxies(cfg.Web.TrustedProxies); err != nil {
+		add("web.trusted_proxies: %v", err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validPort 判断端口是否在有效范围内
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// validateURL 校验 http(s) 地址
+func validateURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("不能为空")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("格式错误: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("必须是 http(s) 地址")
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+// validTestConfig 返回可通过 ValidateConfig 的最小配置
+func validTestConfig() types.Config {
+	var cfg types.Config
+	cfg.OpenSearch.Host = "localhost"
+	cfg.OpenSearch.Port = 9200
+	cfg.OpenSearch.Timeout = 30
+	cfg.Database.Type = "sqlite"
+	cfg.Database.Path = "data/alert.db"
+	cfg.Web.Enabled = true
+	cfg.Web.Port = 8080
+	return cfg
+}
+
+func TestValidateConfig(t *testing.T) {
+	boolPtr := func(v bool) *bool { return &v }
+	email := func(c *types.Config) {
+		c.Notifications.Email = types.EmailConfig{
+			Enabled: true, SMTPServer: "smtp.local", SMTPPort: 465,
+			Username: "u", Password: "p", FromEmail: "alert@local", ToEmails: []string{"ops@local"},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*types.Config)
+		want   []string
+	}{
+		{"合法配置", func(c *types.Config) {}, nil},
+		{"合法的邮件渠道", email, nil},
+		{"多主机带端口时不要求 port", func(c *types.Config) {
+			c.OpenSearch.Host, c.OpenSearch.Port = "", 0
+			c.OpenSearch.Hosts = []string{"a:9200", "b:9200"}
+		}, nil},
+		{"OpenSearch 主机与端口缺失", func(c *types.Config) { c.OpenSearch.Host, c.OpenSearch.Port = "", 0 },
+			[]string{"opensearch.host 不能为空", "opensearch.port 必须在 1-65535 之间"}},
+		{"多主机缺少端口", func(c *types.Config) {
+			c.OpenSearch.Port = 0
+			c.OpenSearch.Hosts = []string{"a:9200", " ", "b"}
+		}, []string{"opensearch.hosts[1] 不能为空", "存在未指定端口的主机"}},
+		{"查询缓存过长", func(c *types.Config) { c.OpenSearch.QueryCacheTTL = 600 }, []string{"opensearch.query_cache_ttl"}},
+		{"query_timeout 超过 opensearch.timeout", func(c *types.Config) { c.AlertEngine.QueryTimeout = 60 },
+			[]string{"不能超过 opensearch.timeout"}},
+		{"引擎参数为负", func(c *types.Config) {
+			c.AlertEngine.BufferTime = -1
+			c.AlertEngine.IncrementalOverlap = -1
+			c.AlertEngine.LogSnippetLength = -1
+		}, []string{"buffer_time 不能为负数", "incremental_overlap 不能为负数", "log_snippet_length 不能为负数"}},
+		{"邮件渠道缺少 SMTP 参数", func(c *types.Config) {
+			email(c)
+			c.Notifications.Email.SMTPServer = ""
+			c.Notifications.Email.SMTPPort = 0
+		}, []string{"smtp_server 不能为空", "smtp_port 必须在 1-65535 之间"}},
+		{"未启用的渠道不校验", func(c *types.Config) { c.Notifications.DingTalk.WebhookURL = "not a url" }, nil},
+		{"Webhook 地址无效", func(c *types.Config) {
+			c.Notifications.DingTalk = types.DingTalkConfig{Enabled: true, WebhookURL: "ftp://example.com"}
+			c.Notifications.Feishu = types.FeishuConfig{Enabled: true}
+		}, []string{"notifications.dingtalk.webhook_url 必须是 http(s) 地址", "notifications.feishu.webhook_url 不能为空"}},
+		{"ntfy 缺少 topic", func(c *types.Config) {
+			c.Notifications.Ntfy = types.NtfyConfig{Enabled: true, ServerURL: "https://ntfy.sh"}
+		}, []string{"notifications.ntfy.topic 不能为空"}},
+		{"PagerDuty 缺少 routing_key", func(c *types.Config) { c.Notifications.PagerDuty.Enabled = true },
+			[]string{"notifications.pagerduty.routing_key 不能为空"}},
+		{"min_level 无效", func(c *types.Config) { c.Notifications.WeChat.MinLevel = "Urgent" }, []string{"notifications.wechat.min_level"}},
+		{"MySQL 缺少连接参数", func(c *types.Config) { c.Database = types.DatabaseConfig{Type: "mysql"} },
+			[]string{"database.host 不能为空", "database.port", "database.dbname 不能为空"}},
+		{"MySQL DSN 缺少 parseTime", func(c *types.Config) {
+			c.Database = types.DatabaseConfig{Type: "mysql", DSN: "user:pass@tcp(db:3306)/alerts"}
+		}, []string{"parseTime=true"}},
+		{"未知数据库类型", func(c *types.Config) { c.Database.Type = "postgres" }, []string{"database.type 不支持"}},
+		{"日志大小格式错误", func(c *types.Config) { c.Logging.MaxSize = "lots" }, []string{"logging.max_size"}},
+		{"Web 端口无效", func(c *types.Config) { c.Web.Port = 70000 }, []string{"web.port 必须在 1-65535 之间"}},
+		{"未启用 Web 时不校验端口", func(c *types.Config) { c.Web.Enabled = false; c.Web.Port = 0 }, nil},
+		{"开启鉴权缺少会话密钥", func(c *types.Config) { c.Auth.Enabled = true }, []string{"web.session_secret 不能为空"}},
+		{"证书与私钥只配置其一", func(c *types.Config) { c.Web.TLSCertFile = "cert.pem" }, []string{"需同时配置"}},
+		{"SameSite=None 且 Secure=false", func(c *types.Config) {
+			c.Web.CookieSameSite = "None"
+			c.Web.CookieSecure = boolPtr(false)
+		}, []string{"cookie_secure 不能为 false"}},
+		{"SameSite 无效", func(c *types.Config) { c.Web.CookieSameSite = "loose" }, []string{"web.cookie_same_site 不支持"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			tt.modify(&cfg)
+			err := ValidateConfig(&cfg)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("期望通过校验，实际: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("期望校验失败（%v），实际通过", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("错误中应包含 %q，实际:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateConfigReportsAllProblems(t *testing.T) {
+	cfg := validTestConfig()
+	cfg.OpenSearch.Host = ""
+	cfg.Database.Type = "postgres"
+	cfg.Web.Port = 0
+
+	err := ValidateConfig(&cfg)
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("应一次性返回全部问题，实际: %v", err)
+	}
+	if got := len(joined.Unwrap()); got != 3 {
+		t.Errorf("期望 3 个问题，实际 %d 个:\n%v", got, err)
+	}
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"opensearch-alert/pkg/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// RulesWatcher 监听规则目录，文件变化后（防抖）重新加载规则并回调
+type RulesWatcher struct {
+	watcher  *fsnotify.Watcher
+	folder   string
+	debounce time.Duration
+	onChange func(rules []types.AlertRule)
+	logger   *logrus.Logger
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRulesWatcher 创建规则目录监听器
+func NewRulesWatcher(folder string, debounce time.Duration, onChange func(rules []types.AlertRule), logger *logrus.Logger) (*RulesWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("创建文件监听器失败: %w", err)
+	}
+	w := &RulesWatcher{
+		watcher:  watcher,
+		folder:   folder,
+		debounce: debounce,
+		onChange: onChange,
+		logger:   logger,
+		stopCh:   make(chan struct{}),
+	}
+	// fsnotify 不递归监听，逐个添加子目录
+	if err := w.addDirs(folder); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("监听规则目录失败: %w", err)
+	}
+	return w, nil
+}
+
+// addDirs 监听 root 及其下所有子目录（跳过以 . 开头的目录，与 RuleFiles 一致）
+func (w *RulesWatcher) addDirs(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return fs.SkipDir
+		}
+		return w.watcher.Add(path)
+	})
+}
+
+// Start 开始监听（后台运行）
+func (w *RulesWatcher) Start() {
+	go w.run()
+	w.logger.Infof("规则热加载已开启，监听目录: %s", w.folder)
+}
+
+// Stop 停止监听
+func (w *RulesWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+		w.watcher.Close()
+	})
+}
+
+// run 事件循环：编辑器保存时往往产生多次事件，合并为一次重新加载
+func (w *RulesWatcher) run() {
+	var timer *time.Timer
+	var timerC <-chan time.Time
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			// 新建的子目录需加入监听，其中可能已有规则文件（如整体移入），同样触发重新加载
+			if event.Op&fsnotify.Create != 0 && isRuleDir(event.Name) {
+				if err := w.addDirs(event.Name); err != nil {
+					w.logger.Warnf("监听规则子目录 %s 失败: %v", event.Name, err)
+				}
+			} else if !IsRuleFile(event.Name) {
+				continue
+			}
+			w.logger.Debugf("规则文件变化: %s (%s)", event.Name, event.Op)
+			if timer == nil {
+				timer = time.NewTimer(w.debounce)
+			} else {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(w.debounce)
+			}
+			timerC = timer.C
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Warnf("规则目录监听错误: %v", err)
+		case <-timerC:
+			timerC = nil
+			w.reload()
+		case <-w.stopCh:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+	}
+}
+
+// reload 重新加载规则目录
+func (w *RulesWatcher) reload() {
+	rules, err := LoadRules(w.folder)
+	if err != nil {
+		w.logger.Errorf("规则热加载失败（保留当前规则）: %v", err)
+		return
+	}
+	w.onChange(rules)
+}
+
+// IsRuleFile 判断是否为规则文件（.yaml 或 .yml）
+func IsRuleFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// RuleFilePath 返回目录中 base 对应的规则文件路径：已存在 base.yaml 或 base.yml 时返回该文件，否则默认 base.yaml
+func RuleFilePath(dir, base string) string {
+	for _, ext := range []string{".yaml", ".yml"} {
+		path := filepath.Join(dir, base+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, base+".yaml")
+}
+
+// isRuleDir 判断是否为需要监听的规则子目录
+func isRuleDir(path string) bool {
+	if strings.HasPrefix(filepath.Base(path), ".") {
+		return false
+	}
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
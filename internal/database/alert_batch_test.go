@@ -0,0 +1,82 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// newTestAlerts 构造 n 条测试告警，ID 以 prefix 区分
+func newTestAlerts(prefix string, n int) []*types.Alert {
+	now := time.Now()
+	alerts := make([]*types.Alert, 0, n)
+	for i := 0; i < n; i++ {
+		alerts = append(alerts, &types.Alert{
+			ID:        fmt.Sprintf("%s-%d", prefix, i),
+			RuleName:  "batch",
+			Level:     "High",
+			Message:   "message",
+			Timestamp: now,
+			Data:      map[string]interface{}{"seq": i},
+			Count:     1,
+			Matches:   1,
+		})
+	}
+	return alerts
+}
+
+func TestSaveAlertBatch(t *testing.T) {
+	db := newTestDatabase(t)
+	// 超过单条 INSERT 的行数上限，跨多个分块写入
+	alerts := newTestAlerts("alert", alertBatchChunk*2+5)
+	if err := db.SaveAlertBatch(alerts); err != nil {
+		t.Fatalf("批量保存失败: %v", err)
+	}
+	if err := db.SaveAlertBatch(nil); err != nil {
+		t.Errorf("空批次应直接返回: %v", err)
+	}
+
+	stats, err := db.GetAlertStats(24, false)
+	if err != nil {
+		t.Fatalf("获取统计失败: %v", err)
+	}
+	if stats.TotalAlerts != int64(len(alerts)) {
+		t.Errorf("写入 %d 条，实际 %d 条", len(alerts), stats.TotalAlerts)
+	}
+
+	history, err := db.GetAlertsByRule("batch", 1000)
+	if err != nil {
+		t.Fatalf("查询告警失败: %v", err)
+	}
+	seen := make(map[string]bool, len(history))
+	for _, h := range history {
+		seen[h.AlertID] = true
+	}
+	for _, alert := range alerts {
+		if !seen[alert.ID] {
+			t.Fatalf("告警 %s 未写入", alert.ID)
+		}
+	}
+}
+
+func BenchmarkSaveAlert(b *testing.B) {
+	db := newTestDatabase(b)
+	alerts := newTestAlerts("single", b.N)
+	b.ResetTimer()
+	for _, alert := range alerts {
+		if err := db.SaveAlert(alert); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSaveAlertBatch(b *testing.B) {
+	db := newTestDatabase(b)
+	alerts := newTestAlerts("batch", b.N)
+	b.ResetTimer()
+	if err := db.SaveAlertBatch(alerts); err != nil {
+		b.Fatal(err)
+	}
+}
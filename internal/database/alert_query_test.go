@@ -0,0 +1,136 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestGetAlertsByTimeRange(t *testing.T) {
+	db := newTestDatabase(t)
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	// alert-0 .. alert-5 间隔 1 小时
+	for i := 0; i < 6; i++ {
+		seedAlert(t, db, fmt.Sprintf("alert-%d", i), base.Add(time.Duration(i)*time.Hour))
+	}
+
+	tests := []struct {
+		name      string
+		start     time.Time
+		end       time.Time
+		page      int
+		pageSize  int
+		wantTotal int64
+		wantIDs   []string
+	}{
+		{"闭区间包含边界", base.Add(time.Hour), base.Add(3 * time.Hour), 1, 10, 3, []string{"alert-3", "alert-2", "alert-1"}},
+		{"其他时区的边界", base.Add(time.Hour).In(time.FixedZone("UTC+8", 8*3600)), base.Add(time.Hour).In(time.FixedZone("UTC-5", -5*3600)), 1, 10, 1, []string{"alert-1"}},
+		{"分页", base, base.Add(5 * time.Hour), 2, 4, 6, []string{"alert-1", "alert-0"}},
+		{"范围外无结果", base.Add(-2 * time.Hour), base.Add(-time.Hour), 1, 10, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts, total, err := db.GetAlertsByTimeRange(tt.start, tt.end, tt.page, tt.pageSize)
+			if err != nil {
+				t.Fatalf("查询失败: %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, 期望 %d", total, tt.wantTotal)
+			}
+			var ids []string
+			for _, a := range alerts {
+				ids = append(ids, a.AlertID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("告警 = %v, 期望 %v（按时间倒序）", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestQueryAlertsFilterCombinations(t *testing.T) {
+	db := newTestDatabase(t)
+	now := time.Now()
+	recent, old := now.Add(-time.Hour), now.Add(-48*time.Hour)
+
+	// 规则 × 级别 × 时间 × 确认状态，每种属性组合各一条告警，共 16 条
+	for _, rule := range []string{"a", "b"} {
+		for _, level := range []string{"High", "Low"} {
+			for _, at := range []time.Time{recent, old} {
+				for _, ack := range []bool{true, false} {
+					id := fmt.Sprintf("%s-%s-%d-%v", rule, level, at.Unix(), ack)
+					alert := &types.Alert{ID: id, RuleName: rule, Level: level, Message: "msg", Timestamp: at}
+					if err := db.SaveAlert(alert); err != nil {
+						t.Fatal(err)
+					}
+					if ack {
+						if _, err := db.AcknowledgeAlert(id, "ops"); err != nil {
+							t.Fatal(err)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	acked := true
+	start := now.Add(-24 * time.Hour)
+	// mask 的每一位对应一个过滤条件：rule、level、时间范围、确认状态
+	for mask := 0; mask < 16; mask++ {
+		filter := types.AlertFilter{Page: 1, PageSize: 100}
+		var names []string
+		want := int64(16)
+		if mask&1 != 0 {
+			filter.Rule = "a"
+			names, want = append(names, "rule"), want/2
+		}
+		if mask&2 != 0 {
+			filter.Level = "High"
+			names, want = append(names, "level"), want/2
+		}
+		if mask&4 != 0 {
+			filter.Start, filter.End = start, now
+			names, want = append(names, "time"), want/2
+		}
+		if mask&8 != 0 {
+			filter.Acknowledged = &acked
+			names, want = append(names, "acknowledged"), want/2
+		}
+
+		t.Run(fmt.Sprintf("%v", names), func(t *testing.T) {
+			alerts, total, err := db.QueryAlerts(filter)
+			if err != nil {
+				t.Fatalf("查询失败: %v", err)
+			}
+			if total != want || int64(len(alerts)) != want {
+				t.Fatalf("total = %d, 返回 %d 条，期望 %d", total, len(alerts), want)
+			}
+			for _, a := range alerts {
+				if (filter.Rule != "" && a.RuleName != filter.Rule) ||
+					(filter.Level != "" && a.Level != filter.Level) ||
+					(!filter.Start.IsZero() && a.Timestamp.Before(filter.Start)) ||
+					(filter.Acknowledged != nil && a.Acknowledged != *filter.Acknowledged) {
+					t.Errorf("告警 %s 不满足过滤条件 %v", a.AlertID, names)
+				}
+			}
+		})
+	}
+
+	// 分页：总数不受页大小影响，各页不重叠
+	first, total, err := db.QueryAlerts(types.AlertFilter{Rule: "b", Page: 1, PageSize: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := db.QueryAlerts(types.AlertFilter{Rule: "b", Page: 2, PageSize: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 8 || len(first) != 5 || len(second) != 3 {
+		t.Errorf("分页结果 total=%d, 第一页 %d 条, 第二页 %d 条，期望 8, 5, 3", total, len(first), len(second))
+	}
+	if len(first) > 0 && len(second) > 0 && first[len(first)-1].Timestamp.Before(second[0].Timestamp) {
+		t.Error("分页结果应按时间倒序")
+	}
+}
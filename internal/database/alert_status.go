@@ -0,0 +1,48 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"opensearch-alert/pkg/types"
+)
+
+// SaveAlertStatus 保存规则的告警抑制状态，重启后据此恢复
+func (d *Database) SaveAlertStatus(status types.AlertStatus) error {
+	upsert := " ON CONFLICT(rule_name) DO UPDATE SET last_alert = excluded.last_alert, alert_count = excluded.alert_count, " +
+		"suppressed = excluded.suppressed, suppress_until = excluded.suppress_until, snoozed_until = excluded.snoozed_until"
+	if d.dbType == "mysql" {
+		upsert = " ON DUPLICATE KEY UPDATE last_alert = VALUES(last_alert), alert_count = VALUES(alert_count), " +
+			"suppressed = VALUES(suppressed), suppress_until = VALUES(suppress_until), snoozed_until = VALUES(snoozed_until)"
+	}
+
+	_, err := d.db.Exec(`INSERT INTO alert_status (rule_name, last_alert, alert_count, suppressed, suppress_until, snoozed_until)
+        VALUES (?, ?, ?, ?, ?, ?)`+upsert,
+		status.RuleName, nullTime(status.LastAlert), status.AlertCount, status.Suppressed, nullTime(status.SuppressUntil), nullTime(status.SnoozedUntil))
+	if err != nil {
+		return fmt.Errorf("保存告警状态失败: %w", err)
+	}
+	return nil
+}
+
+// ListAlertStatuses 获取全部规则的告警抑制状态
+func (d *Database) ListAlertStatuses() ([]types.AlertStatus, error) {
+	rows, err := d.db.Query("SELECT rule_name, last_alert, alert_count, suppressed, suppress_until, snoozed_until FROM alert_status")
+	if err != nil {
+		return nil, fmt.Errorf("查询告警状态失败: %w", err)
+	}
+	defer rows.Close()
+
+	var statuses []types.AlertStatus
+	for rows.Next() {
+		var status types.AlertStatus
+		var lastAlert, suppressUntil, snoozedUntil sql.NullTime
+		if err := rows.Scan(&status.RuleName, &lastAlert, &status.AlertCount, &status.Suppressed, &suppressUntil, &snoozedUntil); err != nil {
+			return nil, fmt.Errorf("读取告警状态失败: %w", err)
+		}
+		status.LastAlert = lastAlert.Time
+		status.SuppressUntil = suppressUntil.Time
+		status.SnoozedUntil = snoozedUntil.Time
+		statuses = append(statuses, status)
+	}
+	return statuses, rows.Err()
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestAlertStatusRoundTrip(t *testing.T) {
+	db := newTestDatabase(t)
+	lastAlert := time.Now().Add(-time.Minute).Truncate(time.Second)
+	until := lastAlert.Add(time.Hour)
+
+	if err := db.SaveAlertStatus(types.AlertStatus{RuleName: "a", LastAlert: lastAlert, AlertCount: 1, Suppressed: true, SuppressUntil: until}); err != nil {
+		t.Fatalf("保存状态失败: %v", err)
+	}
+	// 同一规则再次保存为更新而不是新增
+	if err := db.SaveAlertStatus(types.AlertStatus{RuleName: "a", LastAlert: lastAlert, AlertCount: 2, Suppressed: true, SuppressUntil: until}); err != nil {
+		t.Fatalf("更新状态失败: %v", err)
+	}
+	// 未设置的时间以 NULL 保存，读取后仍为零值
+	if err := db.SaveAlertStatus(types.AlertStatus{RuleName: "b", AlertCount: 0}); err != nil {
+		t.Fatalf("保存状态失败: %v", err)
+	}
+
+	statuses, err := db.ListAlertStatuses()
+	if err != nil {
+		t.Fatalf("读取状态失败: %v", err)
+	}
+	byRule := map[string]types.AlertStatus{}
+	for _, status := range statuses {
+		byRule[status.RuleName] = status
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("状态数 = %d, 期望 2", len(statuses))
+	}
+
+	a := byRule["a"]
+	if a.AlertCount != 2 || !a.Suppressed || !a.SuppressUntil.Equal(until) || !a.LastAlert.Equal(lastAlert) {
+		t.Errorf("规则 a 状态不符: %+v", a)
+	}
+	b := byRule["b"]
+	if b.Suppressed || !b.LastAlert.IsZero() || !b.SuppressUntil.IsZero() || !b.SnoozedUntil.IsZero() {
+		t.Errorf("规则 b 的空时间应读回零值: %+v", b)
+	}
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"opensearch-alert/pkg/types"
+	"time"
+)
+
+// SaveAudit 写入一条审计记录
+func (d *Database) SaveAudit(entry *types.AuditEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	res, err := d.db.Exec(`INSERT INTO config_audit (username, action, target, summary, created_at) VALUES (?, ?, ?, ?, ?)`,
+		entry.Username, entry.Action, entry.Target, entry.Summary, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("保存审计记录失败: %w", err)
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		entry.ID = id
+	}
+	return nil
+}
+
+// ListAudit 分页获取审计记录（时间倒序），返回当前页与总数
+func (d *Database) ListAudit(page, pageSize int) ([]types.AuditEntry, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	var total int64
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM config_audit").Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("统计审计记录失败: %w", err)
+	}
+
+	rows, err := d.db.Query(`SELECT id, username, action, target, summary, created_at FROM config_audit
+        ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询审计记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []types.AuditEntry{}
+	for rows.Next() {
+		var entry types.AuditEntry
+		var summary sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.Username, &entry.Action, &entry.Target, &summary, &entry.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("读取审计记录失败: %w", err)
+		}
+		entry.Summary = summary.String
+		entries = append(entries, entry)
+	}
+	return entries, total, rows.Err()
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"crypto/sha1"
 	"database/sql"
 	"encoding/json"
@@ -26,22 +27,14 @@ type Database struct {
 
 // NewDatabase 创建数据库连接
 func NewDatabase(config types.DatabaseConfig, logger *logrus.Logger) (*Database, error) {
-	// 确保数据库目录存在
-	var dsn string
-	driver := "sqlite3"
-	if config.Type == "mysql" {
-		// MySQL 8.0+ DSN
-		if config.Params == "" {
-			config.Params = "charset=utf8mb4&parseTime=true&loc=Local"
-		}
-		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s", config.Username, config.Password, config.Host, config.Port, config.DBName, config.Params)
-		driver = "mysql"
-	} else {
+	driver, dsn := dataSource(config)
+
+	// 未指定 DSN 的 SQLite 确保数据库目录存在
+	if driver == "sqlite3" && strings.TrimSpace(config.DSN) == "" {
 		dbDir := filepath.Dir(config.Path)
 		if err := os.MkdirAll(dbDir, 0755); err != nil {
 			return nil, fmt.Errorf("创建数据库目录失败: %w", err)
 		}
-		dsn = config.Path
 	}
 
 	// 连接数据库
@@ -75,6 +68,27 @@ func NewDatabase(config types.DatabaseConfig, logger *logrus.Logger) (*Database,
 	return database, nil
 }
 
+// dataSource 返回驱动名与连接串：配置了 DSN 时原样使用，否则由分项配置拼接
+func dataSource(config types.DatabaseConfig) (string, string) {
+	driver := "sqlite3"
+	if config.Type == "mysql" {
+		driver = "mysql"
+	}
+	if dsn := strings.TrimSpace(config.DSN); dsn != "" {
+		return driver, dsn
+	}
+
+	if driver == "mysql" {
+		// MySQL 8.0+ DSN
+		params := config.Params
+		if params == "" {
+			params = "charset=utf8mb4&parseTime=true&loc=Local"
+		}
+		return driver, fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s", config.Username, config.Password, config.Host, config.Port, config.DBName, params)
+	}
+	return driver, config.Path
+}
+
 // initTables 初始化数据库表
 func (d *Database) initTables() error {
 	if d.dbType == "mysql" {
@@ -136,6 +150,91 @@ func (d *Database) initTables() error {
 			return fmt.Errorf("创建去重表失败: %w", err)
 		}
 
+		// 规则运行状态表：记录预热进度等跨重启的状态
+		createRuleStateTable := `
+        CREATE TABLE IF NOT EXISTS rule_state (
+            rule_name VARCHAR(255) PRIMARY KEY,
+            windows_seen INT NOT NULL DEFAULT 0,
+            updated_at DATETIME NULL
+        )`
+		if _, err := d.db.Exec(createRuleStateTable); err != nil {
+			return fmt.Errorf("创建规则状态表失败: %w", err)
+		}
+
+		// 告警抑制状态表：重启后恢复抑制，避免已抑制的规则重复告警
+		createAlertStatusTable := `
+        CREATE TABLE IF NOT EXISTS alert_status (
+            rule_name VARCHAR(255) PRIMARY KEY,
+            last_alert DATETIME NULL,
+            alert_count INT NOT NULL DEFAULT 0,
+            suppressed BOOLEAN NOT NULL DEFAULT FALSE,
+            suppress_until DATETIME NULL
+        )`
+		if _, err := d.db.Exec(createAlertStatusTable); err != nil {
+			return fmt.Errorf("创建告警状态表失败: %w", err)
+		}
+
+		// 死信表：所有渠道均发送失败的告警
+		createFailedAlertTable := `
+        CREATE TABLE IF NOT EXISTS failed_alerts (
+            id BIGINT AUTO_INCREMENT PRIMARY KEY,
+            alert_id VARCHAR(255) NOT NULL,
+            rule_name VARCHAR(255) NOT NULL DEFAULT '',
+            level VARCHAR(32) NOT NULL DEFAULT '',
+            alert_json LONGTEXT,
+            errors TEXT,
+            attempts INT NOT NULL DEFAULT 1,
+            created_at DATETIME NOT NULL,
+            last_attempt_at DATETIME NOT NULL
+        )`
+		if _, err := d.db.Exec(createFailedAlertTable); err != nil {
+			return fmt.Errorf("创建死信表失败: %w", err)
+		}
+
+		// 审计表：记录配置与规则的变更
+		createAuditTable := `
+        CREATE TABLE IF NOT EXISTS config_audit (
+            id BIGINT AUTO_INCREMENT PRIMARY KEY,
+            username VARCHAR(255) NOT NULL DEFAULT '',
+            action VARCHAR(64) NOT NULL,
+            target VARCHAR(255) NOT NULL DEFAULT '',
+            summary TEXT,
+            created_at DATETIME NOT NULL
+        )`
+		if _, err := d.db.Exec(createAuditTable); err != nil {
+			return fmt.Errorf("创建审计表失败: %w", err)
+		}
+
+		// 静默窗口表：运行时通过 Web 新增的维护窗口
+		createSilenceTable := `
+        CREATE TABLE IF NOT EXISTS silences (
+            id BIGINT AUTO_INCREMENT PRIMARY KEY,
+            rule_pattern VARCHAR(255) NOT NULL DEFAULT '',
+            starts_at DATETIME NULL,
+            ends_at DATETIME NULL,
+            cron_expr VARCHAR(255) NOT NULL DEFAULT '',
+            duration_minutes INT NOT NULL DEFAULT 0,
+            comment TEXT,
+            created_by VARCHAR(255) NOT NULL DEFAULT '',
+            created_at DATETIME NOT NULL
+        )`
+		if _, err := d.db.Exec(createSilenceTable); err != nil {
+			return fmt.Errorf("创建静默表失败: %w", err)
+		}
+
+		createNotificationTable := `
+        CREATE TABLE IF NOT EXISTS alert_notifications (
+            id BIGINT AUTO_INCREMENT PRIMARY KEY,
+            alert_id VARCHAR(255) NOT NULL,
+            channel VARCHAR(64) NOT NULL,
+            success BOOLEAN NOT NULL DEFAULT FALSE,
+            error TEXT,
+            sent_at DATETIME NOT NULL
+        )`
+		if _, err := d.db.Exec(createNotificationTable); err != nil {
+			return fmt.Errorf("创建通知记录表失败: %w", err)
+		}
+
 		// MySQL 不支持 CREATE INDEX IF NOT EXISTS，这里直接创建并忽略已存在错误(1061)
 		indexes := []string{
 			"CREATE INDEX idx_alert_id ON alert_history(alert_id)",
@@ -144,6 +243,8 @@ func (d *Database) initTables() error {
 			"CREATE INDEX idx_timestamp ON alert_history(timestamp)",
 			"CREATE INDEX idx_session_id ON user_sessions(session_id)",
 			"CREATE INDEX idx_username ON user_sessions(username)",
+			"CREATE INDEX idx_notification_alert_id ON alert_notifications(alert_id)",
+			"CREATE INDEX idx_audit_created_at ON config_audit(created_at)",
 		}
 		for _, indexSQL := range indexes {
 			if _, err := d.db.Exec(indexSQL); err != nil {
@@ -213,6 +314,91 @@ func (d *Database) initTables() error {
 			return fmt.Errorf("创建去重表失败: %w", err)
 		}
 
+		// 规则运行状态表
+		createRuleStateTable := `
+        CREATE TABLE IF NOT EXISTS rule_state (
+            rule_name TEXT PRIMARY KEY,
+            windows_seen INTEGER NOT NULL DEFAULT 0,
+            updated_at DATETIME
+        )`
+		if _, err := d.db.Exec(createRuleStateTable); err != nil {
+			return fmt.Errorf("创建规则状态表失败: %w", err)
+		}
+
+		// 告警抑制状态表
+		createAlertStatusTable := `
+        CREATE TABLE IF NOT EXISTS alert_status (
+            rule_name TEXT PRIMARY KEY,
+            last_alert DATETIME,
+            alert_count INTEGER NOT NULL DEFAULT 0,
+            suppressed BOOLEAN NOT NULL DEFAULT 0,
+            suppress_until DATETIME
+        )`
+		if _, err := d.db.Exec(createAlertStatusTable); err != nil {
+			return fmt.Errorf("创建告警状态表失败: %w", err)
+		}
+
+		// 死信表
+		createFailedAlertTable := `
+        CREATE TABLE IF NOT EXISTS failed_alerts (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            alert_id TEXT NOT NULL,
+            rule_name TEXT NOT NULL DEFAULT '',
+            level TEXT NOT NULL DEFAULT '',
+            alert_json TEXT,
+            errors TEXT,
+            attempts INTEGER NOT NULL DEFAULT 1,
+            created_at DATETIME NOT NULL,
+            last_attempt_at DATETIME NOT NULL
+        )`
+		if _, err := d.db.Exec(createFailedAlertTable); err != nil {
+			return fmt.Errorf("创建死信表失败: %w", err)
+		}
+
+		// 审计表
+		createAuditTable := `
+        CREATE TABLE IF NOT EXISTS config_audit (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            username TEXT NOT NULL DEFAULT '',
+            action TEXT NOT NULL,
+            target TEXT NOT NULL DEFAULT '',
+            summary TEXT,
+            created_at DATETIME NOT NULL
+        )`
+		if _, err := d.db.Exec(createAuditTable); err != nil {
+			return fmt.Errorf("创建审计表失败: %w", err)
+		}
+
+		// 静默窗口表
+		createSilenceTable := `
+        CREATE TABLE IF NOT EXISTS silences (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            rule_pattern TEXT NOT NULL DEFAULT '',
+            starts_at DATETIME,
+            ends_at DATETIME,
+            cron_expr TEXT NOT NULL DEFAULT '',
+            duration_minutes INTEGER NOT NULL DEFAULT 0,
+            comment TEXT,
+            created_by TEXT NOT NULL DEFAULT '',
+            created_at DATETIME NOT NULL
+        )`
+		if _, err := d.db.Exec(createSilenceTable); err != nil {
+			return fmt.Errorf("创建静默表失败: %w", err)
+		}
+
+		createNotificationTable := `
+        CREATE TABLE IF NOT EXISTS alert_notifications (
+            id INTEGER PRIMARY KEY AUTOINCREMENT,
+            alert_id TEXT NOT NULL,
+            channel TEXT NOT NULL,
+            success BOOLEAN NOT NULL DEFAULT 0,
+            error TEXT,
+            sent_at DATETIME NOT NULL
+        )`
+		if _, err := d.db.Exec(createNotificationTable); err != nil {
+			return fmt.Errorf("创建通知记录表失败: %w", err)
+		}
+
 		indexes := []string{
 			"CREATE INDEX IF NOT EXISTS idx_alert_id ON alert_history(alert_id)",
 			"CREATE INDEX IF NOT EXISTS idx_rule_name ON alert_history(rule_name)",
@@ -220,6 +406,8 @@ func (d *Database) initTables() error {
 			"CREATE INDEX IF NOT EXISTS idx_timestamp ON alert_history(timestamp)",
 			"CREATE INDEX IF NOT EXISTS idx_session_id ON user_sessions(session_id)",
 			"CREATE INDEX IF NOT EXISTS idx_username ON user_sessions(username)",
+			"CREATE INDEX IF NOT EXISTS idx_notification_alert_id ON alert_notifications(alert_id)",
+			"CREATE INDEX IF NOT EXISTS idx_audit_created_at ON config_audit(created_at)",
 		}
 		for _, indexSQL := range indexes {
 			if _, err := d.db.Exec(indexSQL); err != nil {
@@ -227,10 +415,145 @@ func (d *Database) initTables() error {
 			}
 		}
 	}
+
+	if err := d.migrateTables(); err != nil {
+		return err
+	}
+
 	d.logger.Info("数据库表初始化完成")
 	return nil
 }
 
+// migrateTables 为旧版数据库补齐后续新增的列
+func (d *Database) migrateTables() error {
+	columns := []struct {
+		table  string
+		column string
+		mysql  string
+		sqlite string
+	}{
+		{"alert_history", "acknowledged", "BOOLEAN NOT NULL DEFAULT FALSE", "BOOLEAN NOT NULL DEFAULT 0"},
+		{"alert_history", "acknowledged_by", "VARCHAR(255) NOT NULL DEFAULT ''", "TEXT NOT NULL DEFAULT ''"},
+		{"alert_history", "acknowledged_at", "DATETIME NULL", "DATETIME"},
+		{"alert_history", "resolved", "BOOLEAN NOT NULL DEFAULT FALSE", "BOOLEAN NOT NULL DEFAULT 0"},
+		{"alert_history", "resolved_at", "DATETIME NULL", "DATETIME"},
+		{"alert_dedupe", "dedupe_context", "VARCHAR(512) NOT NULL DEFAULT ''", "TEXT NOT NULL DEFAULT ''"},
+		{"rule_state", "open_alert_id", "VARCHAR(191) NOT NULL DEFAULT ''", "TEXT NOT NULL DEFAULT ''"},
+		{"rule_state", "last_query_end", "DATETIME NULL", "DATETIME"},
+		{"rule_state", "peak_hits", "INT NOT NULL DEFAULT 0", "INTEGER NOT NULL DEFAULT 0"},
+		{"rule_state", "peak_hits_at", "DATETIME NULL", "DATETIME"},
+		{"alert_status", "snoozed_until", "DATETIME NULL", "DATETIME"},
+	}
+
+	for _, c := range columns {
+		definition := c.sqlite
+		if d.dbType == "mysql" {
+			definition = c.mysql
+		}
+		if err := d.ensureColumn(c.table, c.column, definition); err != nil {
+			return err
+		}
+	}
+
+	return d.ensureUniqueAlertID()
+}
+
+// ensureUniqueAlertID 为 alert_history.alert_id 建立唯一索引，旧库存在重复记录时先去重（保留最早一条）
+func (d *Database) ensureUniqueAlertID() error {
+	createSQL := "CREATE UNIQUE INDEX IF NOT EXISTS uniq_alert_id ON alert_history(alert_id)"
+	dedupeSQL := "DELETE FROM alert_history WHERE id NOT IN (SELECT MIN(id) FROM alert_history GROUP BY alert_id)"
+	if d.dbType == "mysql" {
+		createSQL = "CREATE UNIQUE INDEX uniq_alert_id ON alert_history(alert_id)"
+		dedupeSQL = "DELETE a FROM alert_history a JOIN alert_history b ON a.alert_id = b.alert_id AND a.id > b.id"
+	}
+
+	err := d.execIgnoreExists(createSQL)
+	if err == nil {
+		return nil
+	}
+
+	res, dedupeErr := d.db.Exec(dedupeSQL)
+	if dedupeErr != nil {
+		return fmt.Errorf("清理重复告警记录失败: %w", dedupeErr)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		d.logger.Warnf("已清理 %d 条 alert_id 重复的告警记录", n)
+	}
+
+	if err := d.execIgnoreExists(createSQL); err != nil {
+		return fmt.Errorf("创建 alert_id 唯一索引失败: %w", err)
+	}
+	return nil
+}
+
+// execIgnoreExists 执行 DDL，忽略对象已存在错误（MySQL 1061）
+func (d *Database) execIgnoreExists(ddl string) error {
+	if _, err := d.db.Exec(ddl); err != nil {
+		if strings.Contains(err.Error(), "1061") || strings.Contains(strings.ToLower(err.Error()), "exists") {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// alertUpsertClause 按 alert_id 冲突时覆盖告警内容，保证重试写入幂等
+func (d *Database) alertUpsertClause() string {
+	if d.dbType == "mysql" {
+		return " ON DUPLICATE KEY UPDATE rule_name = VALUES(rule_name), level = VALUES(level), message = VALUES(message), " +
+			"timestamp = VALUES(timestamp), data = VALUES(data), count = VALUES(count), matches = VALUES(matches)"
+	}
+	return " ON CONFLICT(alert_id) DO UPDATE SET rule_name = excluded.rule_name, level = excluded.level, message = excluded.message, " +
+		"timestamp = excluded.timestamp, data = excluded.data, count = excluded.count, matches = excluded.matches"
+}
+
+// ensureColumn 列不存在时通过 ALTER TABLE 追加
+func (d *Database) ensureColumn(table, column, definition string) error {
+	rows, err := d.db.Query(fmt.Sprintf("SELECT %s FROM %s LIMIT 0", column, table))
+	if err == nil {
+		rows.Close()
+		return nil
+	}
+
+	if _, err := d.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("为表 %s 添加列 %s 失败: %w", table, column, err)
+	}
+	d.logger.Infof("已为表 %s 添加列 %s", table, column)
+	return nil
+}
+
+// alertHistoryColumns alert_history 查询列（与 scanAlertHistory 顺序一致）
+const alertHistoryColumns = "id, alert_id, rule_name, level, message, timestamp, data, count, matches, created_at, acknowledged, acknowledged_by, acknowledged_at, resolved, resolved_at"
+
+// rowScanner 兼容 *sql.Row 与 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAlertHistory 扫描一行告警历史
+func scanAlertHistory(row rowScanner) (types.AlertHistory, error) {
+	var alert types.AlertHistory
+	var ackAt, resolvedAt sql.NullTime
+	if err := row.Scan(&alert.ID, &alert.AlertID, &alert.RuleName, &alert.Level, &alert.Message, &alert.Timestamp, &alert.Data, &alert.Count, &alert.Matches, &alert.CreatedAt,
+		&alert.Acknowledged, &alert.AcknowledgedBy, &ackAt, &alert.Resolved, &resolvedAt); err != nil {
+		return alert, err
+	}
+	if ackAt.Valid {
+		t := ackAt.Time
+		alert.AcknowledgedAt = &t
+	}
+	if resolvedAt.Valid {
+		t := resolvedAt.Time
+		alert.ResolvedAt = &t
+	}
+	return alert, nil
+}
+
+// Ping 检查数据库连接是否可用
+func (d *Database) Ping(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
 // Close 关闭数据库连接
 func (d *Database) Close() error {
 	return d.db.Close()
@@ -245,7 +568,7 @@ func (d *Database) SaveAlert(alert *types.Alert) error {
 
 	query := `
 	INSERT INTO alert_history (alert_id, rule_name, level, message, timestamp, data, count, matches)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)` + d.alertUpsertClause()
 
 	_, err = d.db.Exec(query,
 		alert.ID,
@@ -266,11 +589,63 @@ func (d *Database) SaveAlert(alert *types.Alert) error {
 	return nil
 }
 
-// GetAlertStats 获取告警统计
-func (d *Database) GetAlertStats(hours int) (*types.AlertStats, error) {
+// alertBatchChunk 单条 INSERT 语句包含的最大行数（避免超出 SQLite 参数上限）
+const alertBatchChunk = 100
+
+// SaveAlertBatch 批量保存告警记录：单事务内多行 INSERT，减少告警风暴时的写放大
+func (d *Database) SaveAlertBatch(alerts []*types.Alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+
+	for start := 0; start < len(alerts); start += alertBatchChunk {
+		end := start + alertBatchChunk
+		if end > len(alerts) {
+			end = len(alerts)
+		}
+
+		placeholders := make([]string, 0, end-start)
+		args := make([]interface{}, 0, (end-start)*8)
+		for _, alert := range alerts[start:end] {
+			dataJSON, err := json.Marshal(alert.Data)
+			if err != nil {
+				tx.Rollback()
+				return fmt.Errorf("序列化告警数据失败: %w", err)
+			}
+			placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?, ?)")
+			args = append(args, alert.ID, alert.RuleName, alert.Level, alert.Message, alert.Timestamp, string(dataJSON), alert.Count, alert.Matches)
+		}
+
+		query := "INSERT INTO alert_history (alert_id, rule_name, level, message, timestamp, data, count, matches) VALUES " + strings.Join(placeholders, ", ") + d.alertUpsertClause()
+		if _, err := tx.Exec(query, args...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("批量保存告警记录失败: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	d.logger.Debugf("批量保存告警记录: %d 条", len(alerts))
+	return nil
+}
+
+// topRuleStatsLimit 告警统计中按规则统计返回的规则数
+const topRuleStatsLimit = 10
+
+// GetAlertStats 获取告警统计，hourlyByLevel 为 true 时每小时统计按级别细分
+func (d *Database) GetAlertStats(hours int, hourlyByLevel bool) (*types.AlertStats, error) {
 	// 初始化统计结构
 	stats := &types.AlertStats{
 		LevelStats:   make(map[string]int64),
+		RuleStats:    make(map[string]int64),
+		TopRules:     []types.RuleStat{},
 		RecentAlerts: []types.AlertHistory{},
 	}
 
@@ -284,6 +659,13 @@ func (d *Database) GetAlertStats(hours int) (*types.AlertStats, error) {
 		return nil, err
 	}
 
+	// 获取未确认告警数
+	err = d.db.QueryRow("SELECT COUNT(*) FROM alert_history WHERE timestamp >= ? AND acknowledged = ?", startTime, false).Scan(&stats.UnacknowledgedAlerts)
+	if err != nil && err != sql.ErrNoRows {
+		d.logger.Errorf("获取未确认告警数失败: %v", err)
+		return nil, err
+	}
+
 	// 2. 获取各级别告警数
 	levelQuery := "SELECT level, COUNT(*) as count FROM alert_history WHERE timestamp >= ? GROUP BY level"
 	rows, err := d.db.Query(levelQuery, startTime)
@@ -302,45 +684,35 @@ func (d *Database) GetAlertStats(hours int) (*types.AlertStats, error) {
 		stats.LevelStats[level] = count
 	}
 
-	// 3. 获取每小时告警统计（使用本地时区）
-	var hourlyStatsQuery string
-	if d.dbType == "mysql" {
-		hourlyStatsQuery = `
-            SELECT DATE_FORMAT(timestamp, '%H') as hour, COUNT(*) as count
-            FROM alert_history
-            WHERE timestamp >= ?
-            GROUP BY hour
-            ORDER BY hour`
-	} else {
-		hourlyStatsQuery = `
-            SELECT strftime('%H', timestamp, 'localtime') as hour, COUNT(*) as count
-            FROM alert_history
-            WHERE timestamp >= ?
-            GROUP BY hour
-            ORDER BY hour`
-	}
-	rows, err = d.db.Query(hourlyStatsQuery, startTime)
+	// 获取告警数最多的规则
+	ruleQuery := "SELECT rule_name, COUNT(*) as count FROM alert_history WHERE timestamp >= ? GROUP BY rule_name ORDER BY count DESC, rule_name LIMIT ?"
+	rows, err = d.db.Query(ruleQuery, startTime, topRuleStatsLimit)
 	if err != nil {
-		d.logger.Errorf("获取每小时告警统计失败: %v", err)
+		d.logger.Errorf("获取各规则告警数失败: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
-
-	var hourlyStats []types.HourlyStat
 	for rows.Next() {
-		var hs types.HourlyStat
-		var hourStr string
-		if err := rows.Scan(&hourStr, &hs.Count); err != nil {
-			d.logger.Errorf("扫描每小时告警统计失败: %v", err)
+		var ruleName string
+		var count int64
+		if err := rows.Scan(&ruleName, &count); err != nil {
+			d.logger.Errorf("扫描规则告警统计失败: %v", err)
 			continue
 		}
-		hs.Hour, _ = strconv.Atoi(hourStr)
-		hourlyStats = append(hourlyStats, hs)
+		stats.RuleStats[ruleName] = count
+		stats.TopRules = append(stats.TopRules, types.RuleStat{Rule: ruleName, Count: count})
+	}
+
+	// 3. 获取每小时告警统计（使用本地时区）
+	hourlyStats, err := d.getHourlyStats(startTime, hourlyByLevel)
+	if err != nil {
+		d.logger.Errorf("获取每小时告警统计失败: %v", err)
+		return nil, err
 	}
 	stats.HourlyStats = hourlyStats
 
 	// 4. 获取最近的告警
-	recentAlertsQuery := "SELECT * FROM alert_history ORDER BY timestamp DESC LIMIT 10"
+	recentAlertsQuery := "SELECT " + alertHistoryColumns + " FROM alert_history ORDER BY timestamp DESC LIMIT 10"
 	rows, err = d.db.Query(recentAlertsQuery)
 	if err != nil {
 		d.logger.Errorf("获取最近告警失败: %v", err)
@@ -350,8 +722,8 @@ func (d *Database) GetAlertStats(hours int) (*types.AlertStats, error) {
 
 	var recentAlerts []types.AlertHistory
 	for rows.Next() {
-		var alert types.AlertHistory
-		if err := rows.Scan(&alert.ID, &alert.AlertID, &alert.RuleName, &alert.Level, &alert.Message, &alert.Timestamp, &alert.Data, &alert.Count, &alert.Matches, &alert.CreatedAt); err != nil {
+		alert, err := scanAlertHistory(rows)
+		if err != nil {
 			d.logger.Errorf("扫描最近告警失败: %v", err)
 			continue
 		}
@@ -362,9 +734,47 @@ func (d *Database) GetAlertStats(hours int) (*types.AlertStats, error) {
 	return stats, nil
 }
 
+// getHourlyStats 按小时（本地时区）统计 startTime 之后的告警数，byLevel 为 true 时同时按级别分组
+func (d *Database) getHourlyStats(startTime time.Time, byLevel bool) ([]types.HourlyStat, error) {
+	hourExpr := "strftime('%H', timestamp, 'localtime')"
+	if d.dbType == "mysql" {
+		hourExpr = "DATE_FORMAT(timestamp, '%H')"
+	}
+	columns, groupBy := hourExpr+" as hour", "hour"
+	if byLevel {
+		columns += ", level"
+		groupBy += ", level"
+	}
+	query := "SELECT " + columns + ", COUNT(*) as count FROM alert_history WHERE timestamp >= ? GROUP BY " + groupBy + " ORDER BY " + groupBy
+
+	rows, err := d.db.Query(query, startTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hourlyStats []types.HourlyStat
+	for rows.Next() {
+		var hs types.HourlyStat
+		var hourStr string
+		dest := []interface{}{&hourStr}
+		if byLevel {
+			dest = append(dest, &hs.Level)
+		}
+		dest = append(dest, &hs.Count)
+		if err := rows.Scan(dest...); err != nil {
+			d.logger.Errorf("扫描每小时告警统计失败: %v", err)
+			continue
+		}
+		hs.Hour, _ = strconv.Atoi(hourStr)
+		hourlyStats = append(hourlyStats, hs)
+	}
+	return hourlyStats, rows.Err()
+}
+
 // GetAlertsByRule 从数据库获取指定规则的告警历史
 func (d *Database) GetAlertsByRule(ruleName string, limit int) ([]types.AlertHistory, error) {
-	query := "SELECT * FROM alert_history WHERE rule_name = ? ORDER BY timestamp DESC LIMIT ?"
+	query := "SELECT " + alertHistoryColumns + " FROM alert_history WHERE rule_name = ? ORDER BY timestamp DESC LIMIT ?"
 	rows, err := d.db.Query(query, ruleName, limit)
 	if err != nil {
 		return nil, err
@@ -373,8 +783,8 @@ func (d *Database) GetAlertsByRule(ruleName string, limit int) ([]types.AlertHis
 
 	var alerts []types.AlertHistory
 	for rows.Next() {
-		var alert types.AlertHistory
-		if err := rows.Scan(&alert.ID, &alert.AlertID, &alert.RuleName, &alert.Level, &alert.Message, &alert.Timestamp, &alert.Data, &alert.Count, &alert.Matches, &alert.CreatedAt); err != nil {
+		alert, err := scanAlertHistory(rows)
+		if err != nil {
 			return nil, err
 		}
 		alerts = append(alerts, alert)
@@ -384,7 +794,7 @@ func (d *Database) GetAlertsByRule(ruleName string, limit int) ([]types.AlertHis
 
 // GetAlertsByLevel 从数据库获取指定级别的告警历史
 func (d *Database) GetAlertsByLevel(level string, limit int) ([]types.AlertHistory, error) {
-	query := "SELECT * FROM alert_history WHERE level = ? ORDER BY timestamp DESC LIMIT ?"
+	query := "SELECT " + alertHistoryColumns + " FROM alert_history WHERE level = ? ORDER BY timestamp DESC LIMIT ?"
 	rows, err := d.db.Query(query, level, limit)
 	if err != nil {
 		return nil, err
@@ -393,8 +803,8 @@ func (d *Database) GetAlertsByLevel(level string, limit int) ([]types.AlertHisto
 
 	var alerts []types.AlertHistory
 	for rows.Next() {
-		var alert types.AlertHistory
-		if err := rows.Scan(&alert.ID, &alert.AlertID, &alert.RuleName, &alert.Level, &alert.Message, &alert.Timestamp, &alert.Data, &alert.Count, &alert.Matches, &alert.CreatedAt); err != nil {
+		alert, err := scanAlertHistory(rows)
+		if err != nil {
 			return nil, err
 		}
 		alerts = append(alerts, alert)
@@ -404,6 +814,53 @@ func (d *Database) GetAlertsByLevel(level string, limit int) ([]types.AlertHisto
 
 // GetAlertsPaged 分页查询（可选：按小时范围筛选）
 func (d *Database) GetAlertsPaged(hours, page, pageSize int) ([]types.AlertHistory, int64, error) {
+	filter := types.AlertFilter{Page: page, PageSize: pageSize}
+	if hours > 0 {
+		filter.Start = time.Now().Add(-time.Duration(hours) * time.Hour)
+	}
+	return d.QueryAlerts(filter)
+}
+
+// GetAlertsByTimeRange 分页获取指定时间范围内（含首尾）的告警
+func (d *Database) GetAlertsByTimeRange(start, end time.Time, page, pageSize int) ([]types.AlertHistory, int64, error) {
+	return d.QueryAlerts(types.AlertFilter{Start: start, End: end, Page: page, PageSize: pageSize})
+}
+
+// QueryAlerts 按规则、级别、时间范围与确认状态组合过滤并分页查询告警
+func (d *Database) QueryAlerts(filter types.AlertFilter) ([]types.AlertHistory, int64, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Rule != "" {
+		conditions = append(conditions, "rule_name = ?")
+		args = append(args, filter.Rule)
+	}
+	if filter.Level != "" {
+		conditions = append(conditions, "level = ?")
+		args = append(args, filter.Level)
+	}
+	// 时间统一为本地时区，与写入时的格式一致（SQLite 按字符串比较时间）
+	if !filter.Start.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, filter.Start.Local())
+	}
+	if !filter.End.IsZero() {
+		conditions = append(conditions, "timestamp <= ?")
+		args = append(args, filter.End.Local())
+	}
+	if filter.Acknowledged != nil {
+		conditions = append(conditions, "acknowledged = ?")
+		args = append(args, *filter.Acknowledged)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	return d.queryAlertsPaged(where, args, filter.Page, filter.PageSize)
+}
+
+// queryAlertsPaged 按条件分页查询告警历史（时间倒序），返回当前页与总数
+func (d *Database) queryAlertsPaged(baseWhere string, args []interface{}, page, pageSize int) ([]types.AlertHistory, int64, error) {
 	if page <= 0 {
 		page = 1
 	}
@@ -413,22 +870,11 @@ func (d *Database) GetAlertsPaged(hours, page, pageSize int) ([]types.AlertHisto
 	offset := (page - 1) * pageSize
 
 	var total int64
-	baseWhere := ""
-	args := []interface{}{}
-	if hours > 0 {
-		startTime := time.Now().Add(-time.Duration(hours) * time.Hour)
-		baseWhere = "WHERE timestamp >= ?"
-		args = append(args, startTime)
-		if err := d.db.QueryRow("SELECT COUNT(*) FROM alert_history "+baseWhere, args...).Scan(&total); err != nil {
-			return nil, 0, err
-		}
-	} else {
-		if err := d.db.QueryRow("SELECT COUNT(*) FROM alert_history").Scan(&total); err != nil {
-			return nil, 0, err
-		}
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM alert_history "+baseWhere, args...).Scan(&total); err != nil {
+		return nil, 0, err
 	}
 
-	query := "SELECT * FROM alert_history " + baseWhere + " ORDER BY timestamp DESC LIMIT ? OFFSET ?"
+	query := "SELECT " + alertHistoryColumns + " FROM alert_history " + baseWhere + " ORDER BY timestamp DESC LIMIT ? OFFSET ?"
 	args = append(args, pageSize, offset)
 	rows, err := d.db.Query(query, args...)
 	if err != nil {
@@ -438,8 +884,8 @@ func (d *Database) GetAlertsPaged(hours, page, pageSize int) ([]types.AlertHisto
 
 	var alerts []types.AlertHistory
 	for rows.Next() {
-		var alert types.AlertHistory
-		if err := rows.Scan(&alert.ID, &alert.AlertID, &alert.RuleName, &alert.Level, &alert.Message, &alert.Timestamp, &alert.Data, &alert.Count, &alert.Matches, &alert.CreatedAt); err != nil {
+		alert, err := scanAlertHistory(rows)
+		if err != nil {
 			return nil, 0, err
 		}
 		alerts = append(alerts, alert)
@@ -451,20 +897,25 @@ func (d *Database) GetAlertsPaged(hours, page, pageSize int) ([]types.AlertHisto
 
 // GetAlertByID 根据 alert_id 获取单条告警详情
 func (d *Database) GetAlertByID(alertID string) (*types.AlertDetail, error) {
-	query := "SELECT alert_id, rule_name, level, message, timestamp, data, count, matches FROM alert_history WHERE alert_id = ? LIMIT 1"
+	query := "SELECT alert_id, rule_name, level, message, timestamp, data, count, matches, acknowledged, acknowledged_by, acknowledged_at, resolved, resolved_at FROM alert_history WHERE alert_id = ? LIMIT 1"
 
 	var (
-		id        string
-		ruleName  string
-		level     string
-		message   string
-		timestamp time.Time
-		dataJSON  string
-		count     int64
-		matches   int64
+		id             string
+		ruleName       string
+		level          string
+		message        string
+		timestamp      time.Time
+		dataJSON       string
+		count          int64
+		matches        int64
+		acknowledged   bool
+		acknowledgedBy string
+		acknowledgedAt sql.NullTime
+		resolved       bool
+		resolvedAt     sql.NullTime
 	)
 
-	err := d.db.QueryRow(query, alertID).Scan(&id, &ruleName, &level, &message, &timestamp, &dataJSON, &count, &matches)
+	err := d.db.QueryRow(query, alertID).Scan(&id, &ruleName, &level, &message, &timestamp, &dataJSON, &count, &matches, &acknowledged, &acknowledgedBy, &acknowledgedAt, &resolved, &resolvedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -480,16 +931,38 @@ func (d *Database) GetAlertByID(alertID string) (*types.AlertDetail, error) {
 		}
 	}
 
-	return &types.AlertDetail{
-		ID:        id,
-		RuleName:  ruleName,
-		Level:     level,
-		Message:   message,
-		Timestamp: timestamp,
-		Count:     count,
-		Matches:   matches,
-		Data:      data,
-	}, nil
+	detail := &types.AlertDetail{
+		ID:             id,
+		RuleName:       ruleName,
+		Level:          level,
+		Message:        message,
+		Timestamp:      timestamp,
+		Count:          count,
+		Matches:        matches,
+		Data:           data,
+		Acknowledged:   acknowledged,
+		AcknowledgedBy: acknowledgedBy,
+		Resolved:       resolved,
+	}
+	if acknowledgedAt.Valid {
+		t := acknowledgedAt.Time
+		detail.AcknowledgedAt = &t
+	}
+	if resolvedAt.Valid {
+		t := resolvedAt.Time
+		detail.ResolvedAt = &t
+	}
+	return detail, nil
+}
+
+// AcknowledgeAlert 将告警标记为已确认，返回更新后的详情；告警不存在时返回 nil
+func (d *Database) AcknowledgeAlert(alertID, username string) (*types.AlertDetail, error) {
+	_, err := d.db.Exec("UPDATE alert_history SET acknowledged = ?, acknowledged_by = ?, acknowledged_at = ? WHERE alert_id = ?",
+		true, username, time.Now(), alertID)
+	if err != nil {
+		return nil, fmt.Errorf("确认告警失败: %w", err)
+	}
+	return d.GetAlertByID(alertID)
 }
 
 // SaveSession 保存用户会话
@@ -552,11 +1025,121 @@ func (d *Database) ReleaseRuleLock(ruleName, instanceID string) error {
             WHERE rule_name=? AND locked_by=?`, ruleName, instanceID)
 		return err
 	}
-	_, err := d.db.Exec(`UPDATE rule_locks SET locked_by='', locked_at = datetime('now','-1 second')
+	// 置空 locked_at 使锁立即可被获取（原先的 now-1s 仍处于租约期内，释放后 ttl 内无法再次执行）
+	_, err := d.db.Exec(`UPDATE rule_locks SET locked_by='', locked_at = NULL
         WHERE rule_name=? AND locked_by=?`, ruleName, instanceID)
 	return err
 }
 
+// ensureRuleState 确保规则状态占位行存在
+func (d *Database) ensureRuleState(ruleName string) {
+	if d.dbType == "mysql" {
+		_, _ = d.db.Exec("INSERT IGNORE INTO rule_state(rule_name) VALUES(?)", ruleName)
+		return
+	}
+	_, _ = d.db.Exec("INSERT OR IGNORE INTO rule_state(rule_name) VALUES(?)", ruleName)
+}
+
+// GetRuleWindowsSeen 获取规则已完成的查询窗口数
+func (d *Database) GetRuleWindowsSeen(ruleName string) (int, error) {
+	var n int
+	err := d.db.QueryRow("SELECT windows_seen FROM rule_state WHERE rule_name = ?", ruleName).Scan(&n)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return n, err
+}
+
+// IncrementRuleWindows 规则完成一个查询窗口，返回累计窗口数
+func (d *Database) IncrementRuleWindows(ruleName string) (int, error) {
+	d.ensureRuleState(ruleName)
+	if _, err := d.db.Exec("UPDATE rule_state SET windows_seen = windows_seen + 1, updated_at = ? WHERE rule_name = ?", time.Now(), ruleName); err != nil {
+		return 0, fmt.Errorf("更新规则状态失败: %w", err)
+	}
+	return d.GetRuleWindowsSeen(ruleName)
+}
+
+// GetOpenAlert 获取规则当前未恢复的告警 ID，无则返回空串
+func (d *Database) GetOpenAlert(ruleName string) (string, error) {
+	var alertID string
+	err := d.db.QueryRow("SELECT open_alert_id FROM rule_state WHERE rule_name = ?", ruleName).Scan(&alertID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return alertID, err
+}
+
+// SetOpenAlert 记录规则当前未恢复的告警
+func (d *Database) SetOpenAlert(ruleName, alertID string) error {
+	d.ensureRuleState(ruleName)
+	if _, err := d.db.Exec("UPDATE rule_state SET open_alert_id = ?, updated_at = ? WHERE rule_name = ?", alertID, time.Now(), ruleName); err != nil {
+		return fmt.Errorf("更新规则状态失败: %w", err)
+	}
+	return nil
+}
+
+// GetRuleQueryEnd 获取增量规则上次成功查询的窗口结束时间，无记录时返回零值
+func (d *Database) GetRuleQueryEnd(ruleName string) (time.Time, error) {
+	var end sql.NullTime
+	err := d.db.QueryRow("SELECT last_query_end FROM rule_state WHERE rule_name = ?", ruleName).Scan(&end)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return end.Time, err
+}
+
+// SetRuleQueryEnd 记录增量规则本次成功查询的窗口结束时间
+func (d *Database) SetRuleQueryEnd(ruleName string, end time.Time) error {
+	d.ensureRuleState(ruleName)
+	if _, err := d.db.Exec("UPDATE rule_state SET last_query_end = ?, updated_at = ? WHERE rule_name = ?", end, time.Now(), ruleName); err != nil {
+		return fmt.Errorf("更新规则状态失败: %w", err)
+	}
+	return nil
+}
+
+// GetRulePeakHits 获取规则在 since 之后记录的单个窗口最大命中数，峰值记录早于 since（已过期）时返回 0
+func (d *Database) GetRulePeakHits(ruleName string, since time.Time) (int, error) {
+	var n int
+	var at sql.NullTime
+	err := d.db.QueryRow("SELECT peak_hits, peak_hits_at FROM rule_state WHERE rule_name = ?", ruleName).Scan(&n, &at)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !at.Valid || at.Time.Before(since) {
+		return 0, nil
+	}
+	return n, nil
+}
+
+// RecordRulePeakHits 本轮命中数不低于当前峰值、或峰值记录早于 since（已过期）时，以本轮命中数更新峰值及记录时间
+func (d *Database) RecordRulePeakHits(ruleName string, hits int, since time.Time) error {
+	d.ensureRuleState(ruleName)
+	now := time.Now()
+	if _, err := d.db.Exec("UPDATE rule_state SET peak_hits = ?, peak_hits_at = ?, updated_at = ? WHERE rule_name = ? AND (peak_hits <= ? OR peak_hits_at IS NULL OR peak_hits_at < ?)",
+		hits, now, now, ruleName, hits, since); err != nil {
+		return fmt.Errorf("更新规则状态失败: %w", err)
+	}
+	return nil
+}
+
+// ResolveAlert 将告警标记为已恢复，清除规则的未恢复告警记录及去重记录（问题再次出现时立即告警）
+func (d *Database) ResolveAlert(ruleName, alertID string) error {
+	now := time.Now()
+	if _, err := d.db.Exec("UPDATE alert_history SET resolved = ?, resolved_at = ? WHERE alert_id = ?", true, now, alertID); err != nil {
+		return fmt.Errorf("标记告警恢复失败: %w", err)
+	}
+	if _, err := d.db.Exec("UPDATE rule_state SET open_alert_id = '', updated_at = ? WHERE rule_name = ? AND open_alert_id = ?", now, ruleName, alertID); err != nil {
+		return fmt.Errorf("更新规则状态失败: %w", err)
+	}
+	if _, err := d.db.Exec("DELETE FROM alert_dedupe WHERE rule_name = ?", ruleName); err != nil {
+		return fmt.Errorf("清除去重记录失败: %w", err)
+	}
+	return nil
+}
+
 // GetSession 获取用户会话
 func (d *Database) GetSession(sessionID string) (*types.User, error) {
 	query := `
@@ -594,15 +1177,20 @@ func (d *Database) DeleteSession(sessionID string) error {
 }
 
 // ShouldSendAndTouch 发送前去重：
-// - 以 (rule_name, level, message_hash) 作为去重签名（可加上聚合键等）
+// - 以 (rule_name, level, message_hash) 作为去重签名
+// - dedupeContext 非空时（如 query_key 键值）改用 (rule_name, level, dedupeContext)，同一对象的告警即使消息不同也会被去重
 // - 若在 TTL 内已发送，则返回 false
 // - 否则更新/插入最近发送时间并返回 true
-func (d *Database) ShouldSendAndTouch(ruleName, level, message string, ttlSeconds int) (bool, error) {
+func (d *Database) ShouldSendAndTouch(ruleName, level, dedupeContext, message string, ttlSeconds int) (bool, error) {
 	if ttlSeconds <= 0 {
 		ttlSeconds = 120
 	}
-	// 计算消息哈希（避免长文本索引）
-	h := sha1.Sum([]byte(message))
+	// 计算签名哈希（避免长文本索引）
+	signature := message
+	if dedupeContext != "" {
+		signature = "ctx:" + dedupeContext
+	}
+	h := sha1.Sum([]byte(signature))
 	messageHash := fmt.Sprintf("%x", h[:])
 	dedupeKey := fmt.Sprintf("%s|%s|%s", ruleName, level, messageHash)
 
@@ -610,7 +1198,7 @@ func (d *Database) ShouldSendAndTouch(ruleName, level, message string, ttlSecond
 	// MySQL 与 SQLite 写法分支
 	if d.dbType == "mysql" {
 		// 占位
-		_, _ = d.db.Exec("INSERT IGNORE INTO alert_dedupe(dedupe_key, alert_id, rule_name, level, message_hash, last_sent, ttl_seconds) VALUES(?, '', ?, ?, ?, DATE_SUB(?, INTERVAL ttl_seconds SECOND), ?)", dedupeKey, ruleName, level, messageHash, now, ttlSeconds)
+		_, _ = d.db.Exec("INSERT IGNORE INTO alert_dedupe(dedupe_key, alert_id, rule_name, level, message_hash, dedupe_context, last_sent, ttl_seconds) VALUES(?, '', ?, ?, ?, ?, DATE_SUB(?, INTERVAL ? SECOND), ?)", dedupeKey, ruleName, level, messageHash, dedupeContext, now, ttlSeconds, ttlSeconds)
 		// 检查是否过期
 		var lastSent time.Time
 		err := d.db.QueryRow("SELECT last_sent FROM alert_dedupe WHERE dedupe_key=?", dedupeKey).Scan(&lastSent)
@@ -628,21 +1216,15 @@ func (d *Database) ShouldSendAndTouch(ruleName, level, message string, ttlSecond
 		}
 		return true, nil
 	}
-	// SQLite
-	_, _ = d.db.Exec("INSERT OR IGNORE INTO alert_dedupe(dedupe_key, alert_id, rule_name, level, message_hash, last_sent, ttl_seconds) VALUES(?, '', ?, ?, ?, datetime(?, '-' || ttl_seconds || ' seconds'), ?)", dedupeKey, ruleName, level, messageHash, now, ttlSeconds)
-	var lastSentStr string
-	err := d.db.QueryRow("SELECT last_sent FROM alert_dedupe WHERE dedupe_key=?", dedupeKey).Scan(&lastSentStr)
+	// SQLite（VALUES 中不能引用 ttl_seconds 列，TTL 以参数传入，否则插入失败导致去重永不生效）
+	_, _ = d.db.Exec("INSERT OR IGNORE INTO alert_dedupe(dedupe_key, alert_id, rule_name, level, message_hash, dedupe_context, last_sent, ttl_seconds) VALUES(?, '', ?, ?, ?, ?, datetime(?, '-' || ? || ' seconds'), ?)", dedupeKey, ruleName, level, messageHash, dedupeContext, now, ttlSeconds, ttlSeconds)
+	// 驱动将 DATETIME 列解析为 time.Time
+	var lastSentAt sql.NullTime
+	err := d.db.QueryRow("SELECT last_sent FROM alert_dedupe WHERE dedupe_key=?", dedupeKey).Scan(&lastSentAt)
 	if err != nil && err != sql.ErrNoRows {
 		return false, err
 	}
-	var lastSent time.Time
-	if lastSentStr != "" {
-		// SQLite parse
-		lst, perr := time.Parse("2006-01-02 15:04:05", lastSentStr)
-		if perr == nil {
-			lastSent = lst
-		}
-	}
+	lastSent := lastSentAt.Time
 	if !lastSent.IsZero() && lastSent.After(now.Add(-time.Duration(ttlSeconds)*time.Second)) {
 		return false, nil
 	}
@@ -653,6 +1235,25 @@ func (d *Database) ShouldSendAndTouch(ruleName, level, message string, ttlSecond
 	return true, nil
 }
 
+// CleanExpiredAlerts 清理超过保留时间的告警历史，返回删除的行数
+func (d *Database) CleanExpiredAlerts(retentionSeconds int) (int64, error) {
+	if retentionSeconds <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().Add(-time.Duration(retentionSeconds) * time.Second)
+
+	res, err := d.db.Exec(`DELETE FROM alert_history WHERE timestamp < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("清理过期告警失败: %w", err)
+	}
+	n, _ := res.RowsAffected()
+
+	if _, err := d.db.Exec(`DELETE FROM alert_notifications WHERE sent_at < ?`, cutoff); err != nil {
+		return n, fmt.Errorf("清理过期通知记录失败: %w", err)
+	}
+	return n, nil
+}
+
 // CleanExpiredSessions 清理过期会话
 func (d *Database) CleanExpiredSessions() error {
 	query := `DELETE FROM user_sessions WHERE expires_at <= ?`
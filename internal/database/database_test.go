@@ -0,0 +1,130 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"opensearch-alert/pkg/types"
+)
+
+// newTestDatabase 在临时目录创建 SQLite 数据库
+func newTestDatabase(t testing.TB) *Database {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	db, err := NewDatabase(types.DatabaseConfig{Type: "sqlite", Path: filepath.Join(t.TempDir(), "alert.db")}, logger)
+	if err != nil {
+		t.Fatalf("创建测试数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// seedAlert 写入一条指定时间的告警及其通知记录
+func seedAlert(t *testing.T, db *Database, id string, at time.Time) {
+	t.Helper()
+	alert := &types.Alert{ID: id, RuleName: "rule", Level: "High", Message: "msg " + id, Timestamp: at, Count: 1, Matches: 1}
+	if err := db.SaveAlert(alert); err != nil {
+		t.Fatalf("写入告警 %s 失败: %v", id, err)
+	}
+	if err := db.SaveNotificationResults([]types.NotificationResult{{AlertID: id, Channel: "webhook", Success: true, SentAt: at}}); err != nil {
+		t.Fatalf("写入通知记录 %s 失败: %v", id, err)
+	}
+}
+
+func TestCleanExpiredAlerts(t *testing.T) {
+	db := newTestDatabase(t)
+	now := time.Now()
+	for i, age := range []time.Duration{72 * time.Hour, 25 * time.Hour, 23 * time.Hour, time.Minute} {
+		seedAlert(t, db, fmt.Sprintf("alert-%d", i), now.Add(-age))
+	}
+
+	deleted, err := db.CleanExpiredAlerts(24 * 3600)
+	if err != nil {
+		t.Fatalf("清理失败: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("删除行数 = %d, 期望 2", deleted)
+	}
+
+	for i, wantKept := range []bool{false, false, true, true} {
+		id := fmt.Sprintf("alert-%d", i)
+		detail, err := db.GetAlertByID(id)
+		if err != nil {
+			t.Fatalf("查询告警 %s 失败: %v", id, err)
+		}
+		if (detail != nil) != wantKept {
+			t.Errorf("告警 %s 保留 = %v, 期望 %v", id, detail != nil, wantKept)
+		}
+		results, err := db.GetNotificationResults(id)
+		if err != nil {
+			t.Fatalf("查询通知记录 %s 失败: %v", id, err)
+		}
+		if (len(results) > 0) != wantKept {
+			t.Errorf("告警 %s 的通知记录保留 = %v, 期望 %v", id, len(results) > 0, wantKept)
+		}
+	}
+}
+
+func TestCleanExpiredAlertsDisabled(t *testing.T) {
+	db := newTestDatabase(t)
+	seedAlert(t, db, "old", time.Now().Add(-365*24*time.Hour))
+
+	deleted, err := db.CleanExpiredAlerts(0)
+	if err != nil || deleted != 0 {
+		t.Fatalf("保留时间为 0 时不应清理: deleted=%d err=%v", deleted, err)
+	}
+	if detail, _ := db.GetAlertByID("old"); detail == nil {
+		t.Error("保留时间为 0 时告警不应被删除")
+	}
+}
+
+func TestShouldSendAndTouch(t *testing.T) {
+	db := newTestDatabase(t)
+
+	send := func(context, message string, ttl int) bool {
+		t.Helper()
+		ok, err := db.ShouldSendAndTouch("rule", "High", context, message, ttl)
+		if err != nil {
+			t.Fatalf("ShouldSendAndTouch 失败: %v", err)
+		}
+		return ok
+	}
+
+	if !send("", "msg", 60) {
+		t.Fatal("首次发送应放行")
+	}
+	if send("", "msg", 60) {
+		t.Fatal("TTL 内相同消息应去重")
+	}
+	if !send("", "other", 60) {
+		t.Fatal("不同消息不应去重")
+	}
+
+	// 有 query_key 时按键值去重，与消息无关
+	if !send("host=web-1", "msg", 60) {
+		t.Fatal("首个 query_key 应放行")
+	}
+	if send("host=web-1", "changed", 60) {
+		t.Fatal("同一 query_key 在 TTL 内应去重")
+	}
+	if !send("host=web-2", "msg", 60) {
+		t.Fatal("不同 query_key 不应去重")
+	}
+
+	// 模拟时间流逝：上次发送早于 TTL 后再次放行
+	if _, err := db.db.Exec("UPDATE alert_dedupe SET last_sent = ?", time.Now().Add(-2*time.Minute)); err != nil {
+		t.Fatalf("更新发送时间失败: %v", err)
+	}
+	if !send("host=web-1", "msg", 60) {
+		t.Fatal("超过 TTL 后应再次放行")
+	}
+	if send("host=web-2", "msg", 600) {
+		t.Fatal("TTL 更长时仍应去重")
+	}
+}
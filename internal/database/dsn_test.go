@@ -0,0 +1,68 @@
+package database
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestDataSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     types.DatabaseConfig
+		wantDriver string
+		wantDSN    string
+	}{
+		{"SQLite 路径", types.DatabaseConfig{Type: "sqlite", Path: "data/alert.db"}, "sqlite3", "data/alert.db"},
+		{"SQLite DSN 优先于路径",
+			types.DatabaseConfig{Type: "sqlite", Path: "data/alert.db", DSN: "file:/var/lib/alert.db?_busy_timeout=5000&cache=shared"},
+			"sqlite3", "file:/var/lib/alert.db?_busy_timeout=5000&cache=shared"},
+		{"MySQL 分项配置",
+			types.DatabaseConfig{Type: "mysql", Host: "db", Port: 3306, Username: "alert", Password: "pw", DBName: "alerts"},
+			"mysql", "alert:pw@tcp(db:3306)/alerts?charset=utf8mb4&parseTime=true&loc=Local"},
+		{"MySQL 自定义参数",
+			types.DatabaseConfig{Type: "mysql", Host: "db", Port: 3306, Username: "alert", Password: "pw", DBName: "alerts", Params: "parseTime=true&tls=skip-verify"},
+			"mysql", "alert:pw@tcp(db:3306)/alerts?parseTime=true&tls=skip-verify"},
+		{"MySQL socket DSN 原样使用，忽略分项配置",
+			types.DatabaseConfig{Type: "mysql", Host: "db", Port: 3306, Username: "ignored", DBName: "ignored", DSN: "  alert:p@ss@unix(/var/run/mysqld/mysqld.sock)/alerts?parseTime=true&loc=Local\n"},
+			"mysql", "alert:p@ss@unix(/var/run/mysqld/mysqld.sock)/alerts?parseTime=true&loc=Local"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver, dsn := dataSource(tt.config)
+			if driver != tt.wantDriver || dsn != tt.wantDSN {
+				t.Errorf("dataSource = %q, %q，期望 %q, %q", driver, dsn, tt.wantDriver, tt.wantDSN)
+			}
+		})
+	}
+}
+
+func TestNewDatabaseUsesSQLiteDSN(t *testing.T) {
+	dir := t.TempDir()
+	dbFile := filepath.Join(dir, "from-dsn.db")
+	unusedDir := filepath.Join(dir, "unused")
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	db, err := NewDatabase(types.DatabaseConfig{
+		Type: "sqlite",
+		Path: filepath.Join(unusedDir, "alert.db"),
+		DSN:  "file:" + dbFile + "?_busy_timeout=5000",
+	}, logger)
+	if err != nil {
+		t.Fatalf("使用 DSN 创建数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := os.Stat(dbFile); err != nil {
+		t.Errorf("应按 DSN 创建数据库文件: %v", err)
+	}
+	if _, err := os.Stat(unusedDir); !os.IsNotExist(err) {
+		t.Errorf("配置 DSN 时不应使用 path: %v", err)
+	}
+}
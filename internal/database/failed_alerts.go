@@ -0,0 +1,118 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"opensearch-alert/pkg/types"
+	"time"
+)
+
+// SaveFailedAlert 保存所有渠道均发送失败的告警及各渠道错误
+func (d *Database) SaveFailedAlert(alert *types.Alert, errs map[string]string) error {
+	alertJSON, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("序列化告警失败: %w", err)
+	}
+	errsJSON, err := json.Marshal(errs)
+	if err != nil {
+		return fmt.Errorf("序列化发送错误失败: %w", err)
+	}
+
+	now := time.Now()
+	_, err = d.db.Exec(`INSERT INTO failed_alerts (alert_id, rule_name, level, alert_json, errors, attempts, created_at, last_attempt_at)
+        VALUES (?, ?, ?, ?, ?, 1, ?, ?)`,
+		alert.ID, alert.RuleName, alert.Level, string(alertJSON), string(errsJSON), now, now)
+	if err != nil {
+		return fmt.Errorf("保存死信告警失败: %w", err)
+	}
+	return nil
+}
+
+// ListFailedAlerts 分页获取死信告警（时间倒序），返回当前页与总数
+func (d *Database) ListFailedAlerts(page, pageSize int) ([]types.FailedAlert, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	var total int64
+	if err := d.db.QueryRow("SELECT COUNT(*) FROM failed_alerts").Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("统计死信告警失败: %w", err)
+	}
+
+	rows, err := d.db.Query(`SELECT id, alert_id, rule_name, level, alert_json, errors, attempts, created_at, last_attempt_at
+        FROM failed_alerts ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询死信告警失败: %w", err)
+	}
+	defer rows.Close()
+
+	failed := []types.FailedAlert{}
+	for rows.Next() {
+		item, err := scanFailedAlert(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		failed = append(failed, *item)
+	}
+	return failed, total, rows.Err()
+}
+
+// GetFailedAlert 获取单条死信告警，不存在时返回 nil
+func (d *Database) GetFailedAlert(id int64) (*types.FailedAlert, error) {
+	row := d.db.QueryRow(`SELECT id, alert_id, rule_name, level, alert_json, errors, attempts, created_at, last_attempt_at
+        FROM failed_alerts WHERE id = ?`, id)
+	item, err := scanFailedAlert(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return item, err
+}
+
+// UpdateFailedAlertAttempt 重试仍全部失败时更新错误信息与重试次数
+func (d *Database) UpdateFailedAlertAttempt(id int64, errs map[string]string) error {
+	errsJSON, err := json.Marshal(errs)
+	if err != nil {
+		return fmt.Errorf("序列化发送错误失败: %w", err)
+	}
+	if _, err := d.db.Exec("UPDATE failed_alerts SET errors = ?, attempts = attempts + 1, last_attempt_at = ? WHERE id = ?",
+		string(errsJSON), time.Now(), id); err != nil {
+		return fmt.Errorf("更新死信告警失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteFailedAlert 删除死信告警（重试成功后调用）
+func (d *Database) DeleteFailedAlert(id int64) error {
+	if _, err := d.db.Exec("DELETE FROM failed_alerts WHERE id = ?", id); err != nil {
+		return fmt.Errorf("删除死信告警失败: %w", err)
+	}
+	return nil
+}
+
+// scanFailedAlert 读取一行死信告警
+func scanFailedAlert(row rowScanner) (*types.FailedAlert, error) {
+	var item types.FailedAlert
+	var alertJSON, errsJSON sql.NullString
+	if err := row.Scan(&item.ID, &item.AlertID, &item.RuleName, &item.Level, &alertJSON, &errsJSON,
+		&item.Attempts, &item.CreatedAt, &item.LastAttemptAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("读取死信告警失败: %w", err)
+	}
+	if alertJSON.String != "" {
+		var alert types.Alert
+		if err := json.Unmarshal([]byte(alertJSON.String), &alert); err != nil {
+			return nil, fmt.Errorf("解析死信告警失败: %w", err)
+		}
+		item.Alert = &alert
+	}
+	if errsJSON.String != "" {
+		_ = json.Unmarshal([]byte(errsJSON.String), &item.Errors)
+	}
+	return &item, nil
+}
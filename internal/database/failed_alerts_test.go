@@ -0,0 +1,55 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestFailedAlertLifecycle(t *testing.T) {
+	db := newTestDatabase(t)
+	for _, id := range []string{"first", "second"} {
+		alert := &types.Alert{ID: id, RuleName: "rule", Level: "High", Message: "msg " + id, Timestamp: time.Now()}
+		if err := db.SaveFailedAlert(alert, map[string]string{"ntfy": "503"}); err != nil {
+			t.Fatalf("保存死信失败: %v", err)
+		}
+	}
+
+	failed, total, err := db.ListFailedAlerts(1, 1)
+	if err != nil {
+		t.Fatalf("列出死信失败: %v", err)
+	}
+	if total != 2 || len(failed) != 1 {
+		t.Fatalf("分页结果不符: total=%d len=%d", total, len(failed))
+	}
+	// 时间倒序，最新写入的在前
+	latest := failed[0]
+	if latest.AlertID != "second" || latest.Attempts != 1 || latest.Errors["ntfy"] != "503" {
+		t.Errorf("死信内容不符: %+v", latest)
+	}
+	if latest.Alert == nil || latest.Alert.Message != "msg second" {
+		t.Errorf("应保存完整告警以便重试: %+v", latest.Alert)
+	}
+
+	if err := db.UpdateFailedAlertAttempt(latest.ID, map[string]string{"ntfy": "timeout"}); err != nil {
+		t.Fatalf("更新重试次数失败: %v", err)
+	}
+	item, err := db.GetFailedAlert(latest.ID)
+	if err != nil || item == nil {
+		t.Fatalf("读取死信失败: %v", err)
+	}
+	if item.Attempts != 2 || item.Errors["ntfy"] != "timeout" || item.LastAttemptAt.Before(item.CreatedAt) {
+		t.Errorf("重试后状态不符: %+v", item)
+	}
+
+	if err := db.DeleteFailedAlert(latest.ID); err != nil {
+		t.Fatalf("删除死信失败: %v", err)
+	}
+	if item, err := db.GetFailedAlert(latest.ID); err != nil || item != nil {
+		t.Fatalf("删除后应不存在: %+v %v", item, err)
+	}
+	if _, total, _ := db.ListFailedAlerts(1, 20); total != 1 {
+		t.Errorf("删除后剩余 %d 条, 期望 1", total)
+	}
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"opensearch-alert/pkg/types"
+)
+
+// SaveNotificationResults 保存告警各渠道的发送结果
+func (d *Database) SaveNotificationResults(results []types.NotificationResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO alert_notifications (alert_id, channel, success, error, sent_at) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("准备语句失败: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, result := range results {
+		if _, err := stmt.Exec(result.AlertID, result.Channel, result.Success, result.Error, result.SentAt); err != nil {
+			return fmt.Errorf("保存通知记录失败: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+// GetNotificationResults 获取告警各渠道的发送结果
+func (d *Database) GetNotificationResults(alertID string) ([]types.NotificationResult, error) {
+	rows, err := d.db.Query(`SELECT alert_id, channel, success, error, sent_at
+        FROM alert_notifications WHERE alert_id = ? ORDER BY sent_at, id`, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("查询通知记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	results := []types.NotificationResult{}
+	for rows.Next() {
+		var result types.NotificationResult
+		var errMsg sql.NullString
+		if err := rows.Scan(&result.AlertID, &result.Channel, &result.Success, &errMsg, &result.SentAt); err != nil {
+			return nil, fmt.Errorf("读取通知记录失败: %w", err)
+		}
+		result.Error = errMsg.String
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestNotificationResultsRoundTrip(t *testing.T) {
+	db := newTestDatabase(t)
+	sentAt := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	err := db.SaveNotificationResults([]types.NotificationResult{
+		{AlertID: "a1", Channel: "dingtalk", Success: true, SentAt: sentAt},
+		{AlertID: "a1", Channel: "wechat", Success: false, Error: "企业微信消息发送失败，状态码: 500", SentAt: sentAt},
+		{AlertID: "a2", Channel: "email", Success: true, SentAt: sentAt},
+	})
+	if err != nil {
+		t.Fatalf("保存通知记录失败: %v", err)
+	}
+
+	results, err := db.GetNotificationResults("a1")
+	if err != nil {
+		t.Fatalf("查询通知记录失败: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("应只返回该告警的 2 条记录，实际 %d 条", len(results))
+	}
+	if results[0].Channel != "dingtalk" || !results[0].Success || results[0].Error != "" {
+		t.Errorf("成功记录不符: %+v", results[0])
+	}
+	if results[1].Channel != "wechat" || results[1].Success || results[1].Error == "" {
+		t.Errorf("失败记录不符: %+v", results[1])
+	}
+
+	none, err := db.GetNotificationResults("missing")
+	if err != nil || none == nil || len(none) != 0 {
+		t.Errorf("没有记录时应返回空切片，实际 %v (err=%v)", none, err)
+	}
+}
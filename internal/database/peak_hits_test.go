@@ -0,0 +1,40 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRulePeakHitsExpire(t *testing.T) {
+	db := newTestDatabase(t)
+	since := time.Now().Add(-time.Hour)
+
+	if peak, err := db.GetRulePeakHits("flat", since); err != nil || peak != 0 {
+		t.Fatalf("没有记录时峰值应为 0: %d %v", peak, err)
+	}
+
+	for _, hits := range []int{3, 10, 4} {
+		if err := db.RecordRulePeakHits("flat", hits, since); err != nil {
+			t.Fatalf("记录峰值失败: %v", err)
+		}
+	}
+	if peak, _ := db.GetRulePeakHits("flat", since); peak != 10 {
+		t.Errorf("有效期内应保留最大值 10，实际 %d", peak)
+	}
+
+	// 峰值记录早于有效期起点时视为过期
+	if _, err := db.db.Exec("UPDATE rule_state SET peak_hits_at = ? WHERE rule_name = ?", time.Now().Add(-2*time.Hour), "flat"); err != nil {
+		t.Fatalf("修改峰值记录时间失败: %v", err)
+	}
+	if peak, _ := db.GetRulePeakHits("flat", since); peak != 0 {
+		t.Errorf("过期的峰值应返回 0，实际 %d", peak)
+	}
+
+	// 过期后以本轮命中数重新开始记录，即使低于旧峰值
+	if err := db.RecordRulePeakHits("flat", 2, since); err != nil {
+		t.Fatalf("记录峰值失败: %v", err)
+	}
+	if peak, _ := db.GetRulePeakHits("flat", since); peak != 2 {
+		t.Errorf("过期后应以本轮命中数重新记录，实际 %d", peak)
+	}
+}
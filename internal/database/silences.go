@@ -0,0 +1,83 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"opensearch-alert/pkg/types"
+	"time"
+)
+
+// SaveSilence 保存静默窗口，返回新记录 ID
+func (d *Database) SaveSilence(silence *types.Silence) (int64, error) {
+	if silence.CreatedAt.IsZero() {
+		silence.CreatedAt = time.Now()
+	}
+
+	res, err := d.db.Exec(`INSERT INTO silences (rule_pattern, starts_at, ends_at, cron_expr, duration_minutes, comment, created_by, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		silence.Rule, nullTime(silence.StartsAt), nullTime(silence.EndsAt), silence.Cron, silence.Duration,
+		silence.Comment, silence.CreatedBy, silence.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("保存静默失败: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("获取静默 ID 失败: %w", err)
+	}
+	silence.ID = id
+	return id, nil
+}
+
+// ListSilences 获取数据库中的全部静默窗口
+func (d *Database) ListSilences() ([]types.Silence, error) {
+	rows, err := d.db.Query(`SELECT id, rule_pattern, starts_at, ends_at, cron_expr, duration_minutes, comment, created_by, created_at
+        FROM silences ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("查询静默失败: %w", err)
+	}
+	defer rows.Close()
+
+	var silences []types.Silence
+	for rows.Next() {
+		var silence types.Silence
+		var startsAt, endsAt sql.NullTime
+		var comment sql.NullString
+		if err := rows.Scan(&silence.ID, &silence.Rule, &startsAt, &endsAt, &silence.Cron, &silence.Duration,
+			&comment, &silence.CreatedBy, &silence.CreatedAt); err != nil {
+			return nil, fmt.Errorf("读取静默失败: %w", err)
+		}
+		silence.StartsAt = startsAt.Time
+		silence.EndsAt = endsAt.Time
+		silence.Comment = comment.String
+		silences = append(silences, silence)
+	}
+	return silences, rows.Err()
+}
+
+// DeleteSilence 删除静默窗口，返回是否存在该记录
+func (d *Database) DeleteSilence(id int64) (bool, error) {
+	res, err := d.db.Exec("DELETE FROM silences WHERE id = ?", id)
+	if err != nil {
+		return false, fmt.Errorf("删除静默失败: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// CleanExpiredSilences 清理已结束的一次性静默窗口
+func (d *Database) CleanExpiredSilences() (int64, error) {
+	res, err := d.db.Exec("DELETE FROM silences WHERE cron_expr = '' AND ends_at IS NOT NULL AND ends_at < ?", time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("清理过期静默失败: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// nullTime 零值时间写入 NULL
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
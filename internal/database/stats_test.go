@@ -0,0 +1,119 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// sortedHourly 按小时、级别排序（与查询的 ORDER BY 一致，跨零点时 0 点排在前面）
+func sortedHourly(stats []types.HourlyStat) []types.HourlyStat {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Hour != stats[j].Hour {
+			return stats[i].Hour < stats[j].Hour
+		}
+		return stats[i].Level < stats[j].Level
+	})
+	return stats
+}
+
+func TestGetAlertStatsHourlyByLevel(t *testing.T) {
+	db := newTestDatabase(t)
+	first := time.Now().Add(-3 * time.Hour).Truncate(time.Hour).Add(5 * time.Minute)
+	second := first.Add(time.Hour)
+	seeds := []struct {
+		at    time.Time
+		level string
+	}{
+		{first, "High"},
+		{first.Add(time.Minute), "Low"},
+		{first.Add(2 * time.Minute), "High"},
+		{second, "High"},
+		// 超出统计窗口，不计入
+		{time.Now().Add(-48 * time.Hour), "Critical"},
+	}
+	for i, seed := range seeds {
+		alert := &types.Alert{ID: fmt.Sprintf("alert-%d", i), RuleName: "rule", Level: seed.level, Message: "msg", Timestamp: seed.at, Count: 1}
+		if err := db.SaveAlert(alert); err != nil {
+			t.Fatalf("写入告警失败: %v", err)
+		}
+	}
+	h1, h2 := first.Local().Hour(), second.Local().Hour()
+
+	stats, err := db.GetAlertStats(24, false)
+	if err != nil {
+		t.Fatalf("获取统计失败: %v", err)
+	}
+	want := sortedHourly([]types.HourlyStat{{Hour: h1, Count: 3}, {Hour: h2, Count: 1}})
+	if !reflect.DeepEqual(stats.HourlyStats, want) {
+		t.Errorf("全级别汇总 = %+v, 期望 %+v", stats.HourlyStats, want)
+	}
+
+	stats, err = db.GetAlertStats(24, true)
+	if err != nil {
+		t.Fatalf("获取统计失败: %v", err)
+	}
+	want = sortedHourly([]types.HourlyStat{
+		{Hour: h1, Level: "High", Count: 2},
+		{Hour: h1, Level: "Low", Count: 1},
+		{Hour: h2, Level: "High", Count: 1},
+	})
+	if !reflect.DeepEqual(stats.HourlyStats, want) {
+		t.Errorf("按级别细分 = %+v, 期望 %+v", stats.HourlyStats, want)
+	}
+	if stats.TotalAlerts != 4 || stats.LevelStats["High"] != 3 || stats.LevelStats["Low"] != 1 {
+		t.Errorf("总数/级别统计不正确: total=%d levels=%v", stats.TotalAlerts, stats.LevelStats)
+	}
+}
+
+func TestGetAlertStatsTopRules(t *testing.T) {
+	db := newTestDatabase(t)
+	now := time.Now().Add(-time.Minute)
+	// rule-00..rule-11 分别有 1..12 条告警，另有与 rule-11 同数的 alpha
+	counts := map[string]int{"alpha": 12}
+	for i := 0; i < topRuleStatsLimit+2; i++ {
+		counts[fmt.Sprintf("rule-%02d", i)] = i + 1
+	}
+	for rule, n := range counts {
+		for i := 0; i < n; i++ {
+			alert := &types.Alert{ID: fmt.Sprintf("%s-%d", rule, i), RuleName: rule, Level: "High", Message: "msg", Timestamp: now, Count: 1}
+			if err := db.SaveAlert(alert); err != nil {
+				t.Fatalf("写入告警失败: %v", err)
+			}
+		}
+	}
+
+	stats, err := db.GetAlertStats(24, false)
+	if err != nil {
+		t.Fatalf("获取统计失败: %v", err)
+	}
+	if len(stats.TopRules) != topRuleStatsLimit || len(stats.RuleStats) != topRuleStatsLimit {
+		t.Fatalf("应返回前 %d 条规则: top_rules=%d rule_stats=%d", topRuleStatsLimit, len(stats.TopRules), len(stats.RuleStats))
+	}
+	want := []types.RuleStat{{Rule: "alpha", Count: 12}, {Rule: "rule-11", Count: 12}, {Rule: "rule-10", Count: 11}}
+	if !reflect.DeepEqual(stats.TopRules[:3], want) {
+		t.Errorf("top_rules 应按告警数降序、同数按规则名排列: %+v", stats.TopRules[:3])
+	}
+	for i, stat := range stats.TopRules {
+		if i > 0 && stat.Count > stats.TopRules[i-1].Count {
+			t.Errorf("top_rules 未按告警数降序: %+v", stats.TopRules)
+		}
+		if stats.RuleStats[stat.Rule] != stat.Count {
+			t.Errorf("rule_stats 与 top_rules 不一致: %s %d != %d", stat.Rule, stats.RuleStats[stat.Rule], stat.Count)
+		}
+	}
+
+	// JSON 中 top_rules 保持顺序
+	data, _ := json.Marshal(stats)
+	var decoded struct {
+		TopRules []types.RuleStat `json:"top_rules"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(decoded.TopRules, stats.TopRules) {
+		t.Errorf("top_rules 序列化后顺序应保持不变: %v %+v", err, decoded.TopRules)
+	}
+}
@@ -0,0 +1,192 @@
+package notification
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"opensearch-alert/pkg/types"
+)
+
+// channelGuard 渠道配置校验状态，配置错误的渠道在进程内自动停用
+type channelGuard struct {
+	mu     sync.RWMutex
+	reason string
+}
+
+// disable 停用渠道并记录原因
+func (g *channelGuard) disable(reason string) {
+	g.mu.Lock()
+	g.reason = reason
+	g.mu.Unlock()
+}
+
+// clear 清除停用状态
+func (g *channelGuard) clear() {
+	g.mu.Lock()
+	g.reason = ""
+	g.mu.Unlock()
+}
+
+// disabledReason 返回停用原因，未停用返回空
+func (g *channelGuard) disabledReason() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.reason
+}
+
+// 各渠道告警详情的默认长度上限（字节），按平台消息大小限制预留标题与 @ 等内容的余量
+const (
+	dingTalkMaxDetailBytes       = 15000 // markdown 正文上限约 20000 字节，换行会被扩展为 "  \n  "
+	weChatTextMaxDetailBytes     = 1500  // text 消息上限 2048 字节
+	weChatMarkdownMaxDetailBytes = 3500  // markdown 消息上限 4096 字节
+	feishuMaxDetailBytes         = 25000 // 卡片消息上限约 30KB
+	ntfyMaxDetailBytes           = 3500  // 超过 4096 字节的消息会被 ntfy 转为附件
+)
+
+// truncatedMarker 详情被截断时追加的提示
+const truncatedMarker = "\n…（内容过长，已截断）"
+
+// messageLimit 详情长度上限：配置了 max_message_length 时使用配置，否则使用渠道默认值
+func messageLimit(configured, channelDefault int) int {
+	if configured > 0 {
+		return configured
+	}
+	return channelDefault
+}
+
+// truncateMessage 将详情截断到 maxBytes 字节以内（含截断提示，按字符边界截断不产生乱码），maxBytes 不大于 0 时不截断
+func truncateMessage(message string, maxBytes int) string {
+	if maxBytes <= 0 || len(message) <= maxBytes {
+		return message
+	}
+	cut := maxBytes - len(truncatedMarker)
+	if cut < 0 {
+		cut = 0
+	}
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+	return strings.TrimRight(message[:cut], " \n") + truncatedMarker
+}
+
+// alertField 读取告警示例文档的字段：优先使用引擎展开的 alert.Data.fields，
+// 没有 fields 的旧告警回退到逐层查找 sample_hit
+func alertField(data map[string]interface{}, path string) string {
+	if fields, ok := data["fields"].(map[string]interface{}); ok {
+		if v, ok := fields[path].(string); ok {
+			return v
+		}
+	}
+	var cur interface{} = data["sample_hit"]
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		cur = m[part]
+	}
+	v, _ := cur.(string)
+	return v
+}
+
+// k8sInfo 从告警数据提取 K8s 相关信息
+func k8sInfo(data map[string]interface{}) (podName, namespace, containerName, containerImage string) {
+	return alertField(data, "kubernetes.pod_name"),
+		alertField(data, "kubernetes.namespace_name"),
+		alertField(data, "kubernetes.container_name"),
+		alertField(data, "kubernetes.container_image")
+}
+
+// withPrefix 在通知标题前加上环境前缀（如 "[PROD]"）
+func withPrefix(prefix, title string) string {
+	if prefix == "" {
+		return title
+	}
+	return prefix + " " + title
+}
+
+// resolvedEmoji 恢复通知的图标
+const resolvedEmoji = "✅"
+
+// alertTitle 通知标题，恢复通知使用“告警恢复”
+func alertTitle(prefix string, alert *types.Alert) string {
+	if alert.Resolved {
+		return withPrefix(prefix, "KubeSphere-OpenSearch 告警恢复")
+	}
+	return withPrefix(prefix, "KubeSphere-OpenSearch 告警通知")
+}
+
+// titleEmoji 标题图标，恢复通知统一使用绿色对勾
+func titleEmoji(alert *types.Alert, levelEmoji string) string {
+	if alert.Resolved {
+		return resolvedEmoji
+	}
+	return levelEmoji
+}
+
+// timeLabel 时间字段名称，恢复通知为恢复时间
+func timeLabel(alert *types.Alert) string {
+	if alert.Resolved {
+		return "恢复时间"
+	}
+	return "触发时间"
+}
+
+// meetsMinLevel 告警级别是否达到渠道的 min_level；未配置时及测试告警始终发送
+func meetsMinLevel(alert *types.Alert, minLevel string) bool {
+	return minLevel == "" || isTestAlert(alert) || types.LevelAtLeast(alert.Level, minLevel)
+}
+
+// ChannelStatus 通知渠道状态
+type ChannelStatus struct {
+	// Configured 配置文件中是否启用
+	Configured bool `json:"configured"`
+	// Effective 实际是否生效（配置错误时自动停用）
+	Effective bool `json:"effective"`
+	// Error 自动停用的原因
+	Error string `json:"error,omitempty"`
+}
+
+// validateWebhookURL 校验 Webhook 地址
+func validateWebhookURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("webhook_url 不能为空")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("webhook_url 格式错误: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook_url 必须是 http(s) 地址")
+	}
+	return nil
+}
+
+// markdownToPlain 将告警消息中的Markdown标记转换为纯文本
+func markdownToPlain(message string) string {
+	formatted := message
+
+	// 移除粗体标记 **text** -> text
+	formatted = strings.ReplaceAll(formatted, "**", "")
+
+	// 移除代码块标记 ``` -> 空行
+	formatted = strings.ReplaceAll(formatted, "```", "")
+
+	// 移除分隔线标记 '---' 以及日志中仅由横线组成的分割线
+	formatted = strings.ReplaceAll(formatted, "---", "")
+	hyphenDivider := regexp.MustCompile(`(?m)^\s*-{6,}\s*$`)
+	formatted = hyphenDivider.ReplaceAllString(formatted, "")
+
+	// 清理多余的空行（将3个及以上连续换行压缩为2个）
+	multiEmptyLines := regexp.MustCompile(`\n{3,}`)
+	formatted = multiEmptyLines.ReplaceAllString(formatted, "\n\n")
+
+	// 确保开头和结尾没有多余的空行
+	formatted = strings.TrimSpace(formatted)
+
+	return formatted
+}
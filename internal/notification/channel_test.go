@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestTruncateMessage(t *testing.T) {
+	if got := truncateMessage("short", 100); got != "short" {
+		t.Errorf("未超出上限时不应截断，实际 %q", got)
+	}
+	if got := truncateMessage(strings.Repeat("x", 100), 0); len(got) != 100 {
+		t.Errorf("上限为 0 时不应截断，实际 %d 字节", len(got))
+	}
+
+	got := truncateMessage(strings.Repeat("错", 100), 50)
+	if len(got) > 50 || !utf8.ValidString(got) || !strings.HasSuffix(got, truncatedMarker) {
+		t.Errorf("应按字符边界截断到 50 字节以内并追加提示，实际 %d 字节 %q", len(got), got)
+	}
+}
+
+// channelContent 各渠道按告警生成的消息正文，maxLength 为 max_message_length 配置
+type channelContent func(alert *types.Alert, maxLength int) string
+
+func TestOversizedMessagePerChannel(t *testing.T) {
+	channels := []struct {
+		name string
+		// platformLimit 平台对正文的大小限制（字节）
+		platformLimit int
+		content       channelContent
+	}{
+		{"dingtalk", 20000, func(alert *types.Alert, maxLength int) string {
+			d := NewDingTalkNotifier(&types.DingTalkConfig{}, time.UTC, newTestLogger())
+			d.maxLength = maxLength
+			return d.buildDingTalkMessage(alert)["markdown"].(map[string]string)["text"]
+		}},
+		{"wechat text", 2048, func(alert *types.Alert, maxLength int) string {
+			w := NewWeChatNotifier(&types.WeChatConfig{}, time.UTC, newTestLogger())
+			w.maxLength = maxLength
+			return w.buildWeChatMessage(alert)["text"].(map[string]interface{})["content"].(string)
+		}},
+		{"wechat markdown", 4096, func(alert *types.Alert, maxLength int) string {
+			w := NewWeChatNotifier(&types.WeChatConfig{}, time.UTC, newTestLogger())
+			w.maxLength = maxLength
+			return w.buildWeChatMarkdown(alert)["markdown"].(map[string]interface{})["content"].(string)
+		}},
+		{"feishu", 30000, func(alert *types.Alert, maxLength int) string {
+			f := NewFeishuNotifier(&types.FeishuConfig{}, time.UTC, newTestLogger())
+			f.maxLength = maxLength
+			elements := f.buildFeishuMessage(alert)["card"].(map[string]interface{})["elements"].([]map[string]interface{})
+			var b strings.Builder
+			for _, element := range elements {
+				if text, ok := element["text"].(map[string]interface{}); ok {
+					b.WriteString(text["content"].(string))
+				}
+			}
+			return b.String()
+		}},
+		{"ntfy", 4096, func(alert *types.Alert, maxLength int) string {
+			n := NewNtfyNotifier(&types.NtfyConfig{}, time.UTC, newTestLogger())
+			n.maxLength = maxLength
+			return n.buildMessage(alert)
+		}},
+	}
+
+	huge := testAlert("High")
+	huge.Message = "**日志**\n" + strings.Repeat("错误日志内容 ", 10000)
+	empty := testAlert("High")
+	empty.Message = ""
+
+	for _, ch := range channels {
+		t.Run(ch.name, func(t *testing.T) {
+			// 默认上限保证不超过平台限制
+			content := ch.content(huge, 0)
+			if len(content) > ch.platformLimit {
+				t.Errorf("默认上限下正文 %d 字节，超出平台限制 %d", len(content), ch.platformLimit)
+			}
+			if !utf8.ValidString(content) {
+				t.Error("截断产生了不完整的多字节字符")
+			}
+			if !strings.Contains(content, "内容过长，已截断") {
+				t.Error("截断后应包含提示")
+			}
+
+			// 配置的 max_message_length 只限制详情，其余字段保持不变
+			const maxLength = 300
+			base := len(ch.content(empty, maxLength))
+			content = ch.content(huge, maxLength)
+			if extra := len(content) - base; extra > maxLength+8 {
+				t.Errorf("max_message_length=%d 时详情占用 %d 字节", maxLength, extra)
+			}
+
+			// 未超出上限的消息原样保留
+			short := testAlert("High")
+			if content := ch.content(short, maxLength); strings.Contains(content, "内容过长") || !strings.Contains(content, "panic: boom") {
+				t.Errorf("短消息不应被截断:\n%s", content)
+			}
+		})
+	}
+}
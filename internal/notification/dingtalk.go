@@ -21,26 +21,48 @@ import (
 
 // DingTalkNotifier 钉钉通知器
 type DingTalkNotifier struct {
-	config *types.DingTalkConfig
-	logger *logrus.Logger
+	config   *types.DingTalkConfig
+	logger   *logrus.Logger
+	guard    channelGuard
+	location *time.Location
+	// client 共用的 HTTP 客户端（带超时），由 Notifier 统一设置
+	client *http.Client
+	// prefix 环境前缀，由 Notifier 统一设置
+	prefix string
+	// maxLength 详情长度上限（字节），由 Notifier 统一设置；0 表示使用渠道默认值
+	maxLength int
+	// levels 级别图标与颜色，由 Notifier 统一设置（为空时使用内置表）
+	levels types.LevelTable
 }
 
 // NewDingTalkNotifier 创建钉钉通知器
-func NewDingTalkNotifier(config *types.DingTalkConfig, logger *logrus.Logger) *DingTalkNotifier {
+func NewDingTalkNotifier(config *types.DingTalkConfig, location *time.Location, logger *logrus.Logger) *DingTalkNotifier {
 	return &DingTalkNotifier{
-		config: config,
-		logger: logger,
+		config:   config,
+		logger:   logger,
+		location: location,
+		client:   newHTTPClient(DefaultHTTPTimeout),
 	}
 }
 
+// validateConfig 验证钉钉配置
+func (d *DingTalkNotifier) validateConfig() error {
+	return validateWebhookURL(d.config.WebhookURL)
+}
+
 // IsEnabled 检查是否启用
 func (d *DingTalkNotifier) IsEnabled() bool {
-	return d.config.Enabled
+	return d.config.Enabled && d.guard.disabledReason() == ""
+}
+
+// Accepts 告警级别是否达到渠道的 min_level
+func (d *DingTalkNotifier) Accepts(alert *types.Alert) bool {
+	return meetsMinLevel(alert, d.config.MinLevel)
 }
 
 // Send 发送钉钉消息
 func (d *DingTalkNotifier) Send(alert *types.Alert) error {
-	if !d.IsEnabled() {
+	if !d.IsEnabled() || !d.Accepts(alert) {
 		return nil
 	}
 
@@ -58,7 +80,7 @@ func (d *DingTalkNotifier) Send(alert *types.Alert) error {
 		return fmt.Errorf("序列化消息失败: %w", err)
 	}
 
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := d.client.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("发送钉钉消息失败: %w", err)
 	}
@@ -86,7 +108,7 @@ func (d *DingTalkNotifier) buildDingTalkMessage(alert *types.Alert) map[string]i
 
 	// 构建@文本 - 只有严重告警才@用户
 	atText := ""
-	if d.shouldAtUser(alert.Level) {
+	if !alert.Resolved && d.shouldAtUser(alert.Level) {
 		// 如果配置了@所有人，或者没有配置具体用户，则@所有人
 		if d.config.AtAll || len(d.config.AtMobiles) == 0 {
 			atText = "@所有人 "
@@ -99,18 +121,18 @@ func (d *DingTalkNotifier) buildDingTalkMessage(alert *types.Alert) map[string]i
 	}
 
 	// 构建Markdown内容（表情+标签），并追加详情
-	markdown := fmt.Sprintf("**%s KubeSphere-OpenSearch 告警通知**\n\n"+
+	markdown := fmt.Sprintf("**%s %s**\n\n"+
 		"🏷️ **规则名称:** %s\n"+
 		"%s **告警级别:** %s\n"+
-		"🕒 **触发时间:** %s\n"+
+		"🕒 **%s:** %s\n"+
 		"📈 **匹配数量:** %d\n\n"+
 		"📝 **详情:**\n%s",
-		d.getLevelEmoji(alert.Level),
+		titleEmoji(alert, d.getLevelEmoji(alert.Level)), alertTitle(d.prefix, alert),
 		alert.RuleName,
 		d.getLevelEmoji(alert.Level), alert.Level,
-		alert.Timestamp.Format("2006-01-02 15:04:05"),
+		timeLabel(alert), alert.Timestamp.In(d.location).Format("2006-01-02 15:04:05"),
 		alert.Count,
-		d.formatMessageContent(alert.Message))
+		truncateMessage(d.formatMessageContent(alert.Message), messageLimit(d.maxLength, dingTalkMaxDetailBytes)))
 
 	// 处理消息内容，确保在钉钉中正确显示
 	// 钉钉 Markdown 需要在换行符前后各添加两个空格才能正确换行
@@ -122,11 +144,25 @@ func (d *DingTalkNotifier) buildDingTalkMessage(alert *types.Alert) map[string]i
 		markdown += "\n\n" + atText
 	}
 
+	// 配置了管理台地址时使用带按钮的 actionCard（actionCard 不支持 at 字段，@ 仅体现在正文中）
+	if d.config.UseActionCard && d.config.DashboardBaseURL != "" {
+		return map[string]interface{}{
+			"msgtype": "actionCard",
+			"actionCard": map[string]interface{}{
+				"title":          alertTitle(d.prefix, alert),
+				"text":           markdown,
+				"btnOrientation": "0",
+				"singleTitle":    "在管理台中查看",
+				"singleURL":      d.dashboardURL(alert.RuleName),
+			},
+		}
+	}
+
 	// 构建消息体
 	message := map[string]interface{}{
 		"msgtype": "markdown",
 		"markdown": map[string]string{
-			"title": "KubeSphere-OpenSearch 告警通知",
+			"title": alertTitle(d.prefix, alert),
 			"text":  markdown,
 		},
 		"at": at,
@@ -135,20 +171,14 @@ func (d *DingTalkNotifier) buildDingTalkMessage(alert *types.Alert) map[string]i
 	return message
 }
 
+// dashboardURL 生成告警页面按规则筛选的链接
+func (d *DingTalkNotifier) dashboardURL(ruleName string) string {
+	return strings.TrimRight(d.config.DashboardBaseURL, "/") + "/alerts?rule=" + url.QueryEscape(ruleName)
+}
+
 // getLevelEmoji 不同级别对应的图标
 func (d *DingTalkNotifier) getLevelEmoji(level string) string {
-	switch level {
-	case "Critical":
-		return "🚨"
-	case "High":
-		return "🚩"
-	case "Medium":
-		return "🔔"
-	case "Low", "Info":
-		return "ℹ️"
-	default:
-		return "🔔"
-	}
+	return d.levels.Lookup(level).Emoji
 }
 
 // formatMessageContent 钉钉Markdown兼容处理：移除分隔线、代码块标记并压缩空行
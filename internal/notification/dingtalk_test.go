@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestDingTalkActionCard(t *testing.T) {
+	stub := newWebhookStub(t, 0)
+	d := NewDingTalkNotifier(&types.DingTalkConfig{
+		Enabled:          true,
+		WebhookURL:       stub.URL + "/robot/send?access_token=abc",
+		Secret:           "SEC123",
+		UseActionCard:    true,
+		DashboardBaseURL: "https://alert.example.com/",
+	}, time.UTC, newTestLogger())
+
+	alert := testAlert("High")
+	alert.RuleName = "error logs/prod"
+	if err := d.Send(alert); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+	requests := stub.received()
+	if len(requests) != 1 {
+		t.Fatalf("应发送 1 条消息，实际 %d 条", len(requests))
+	}
+
+	// 加签参数仍附加在原有查询参数之后
+	query, _ := url.ParseQuery(requests[0].Query)
+	if query.Get("access_token") != "abc" || query.Get("timestamp") == "" || query.Get("sign") == "" {
+		t.Errorf("actionCard 消息应保留加签参数，实际查询串 %q", requests[0].Query)
+	}
+
+	var message struct {
+		MsgType    string `json:"msgtype"`
+		ActionCard struct {
+			Title          string `json:"title"`
+			Text           string `json:"text"`
+			BtnOrientation string `json:"btnOrientation"`
+			SingleTitle    string `json:"singleTitle"`
+			SingleURL      string `json:"singleURL"`
+		} `json:"actionCard"`
+		Markdown json.RawMessage `json:"markdown"`
+		At       json.RawMessage `json:"at"`
+	}
+	if err := json.Unmarshal(requests[0].Body, &message); err != nil {
+		t.Fatalf("消息不是合法的 JSON: %v", err)
+	}
+	if message.MsgType != "actionCard" {
+		t.Fatalf("msgtype = %q, 期望 actionCard", message.MsgType)
+	}
+	card := message.ActionCard
+	if card.Title == "" || card.Text == "" || card.SingleTitle == "" {
+		t.Errorf("actionCard 缺少标题、正文或按钮: %+v", card)
+	}
+	if want := "https://alert.example.com/alerts?rule=error+logs%2Fprod"; card.SingleURL != want {
+		t.Errorf("singleURL = %q, 期望 %q", card.SingleURL, want)
+	}
+	if message.Markdown != nil || message.At != nil {
+		t.Error("actionCard 消息不应包含 markdown 或 at 字段")
+	}
+}
+
+func TestDingTalkMarkdownByDefault(t *testing.T) {
+	// 只配置管理台地址不开启 use_action_card 时仍为 markdown
+	d := NewDingTalkNotifier(&types.DingTalkConfig{Enabled: true, DashboardBaseURL: "https://alert.example.com"}, time.UTC, newTestLogger())
+	message := d.buildDingTalkMessage(testAlert("High"))
+	if message["msgtype"] != "markdown" {
+		t.Fatalf("msgtype = %v, 期望 markdown", message["msgtype"])
+	}
+	if _, ok := message["at"]; !ok {
+		t.Error("markdown 消息应包含 at 字段")
+	}
+}
@@ -6,6 +6,10 @@ import (
 	"fmt"
 	"opensearch-alert/pkg/types"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode/utf8"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/gomail.v2"
@@ -13,26 +17,50 @@ import (
 
 // EmailNotifier 邮件通知器
 type EmailNotifier struct {
-	config *types.EmailConfig
-	logger *logrus.Logger
+	config   *types.EmailConfig
+	logger   *logrus.Logger
+	guard    channelGuard
+	location *time.Location
+	// prefix 环境前缀，由 Notifier 统一设置
+	prefix string
+	// levels 级别图标与颜色，由 Notifier 统一设置（为空时使用内置表）
+	levels types.LevelTable
+
+	digestMutex   sync.Mutex
+	digestAlerts  []*types.Alert
+	digestRunning bool
+	stopCh        chan struct{}
 }
 
 // NewEmailNotifier 创建邮件通知器
-func NewEmailNotifier(config *types.EmailConfig, logger *logrus.Logger) *EmailNotifier {
+func NewEmailNotifier(config *types.EmailConfig, location *time.Location, logger *logrus.Logger) *EmailNotifier {
 	return &EmailNotifier{
-		config: config,
-		logger: logger,
+		config:   config,
+		logger:   logger,
+		location: location,
+		stopCh:   make(chan struct{}),
 	}
 }
 
 // IsEnabled 检查是否启用
 func (e *EmailNotifier) IsEnabled() bool {
-	return e.config.Enabled
+	return e.config.Enabled && e.guard.disabledReason() == ""
+}
+
+// Accepts 告警级别是否达到渠道的 min_level
+func (e *EmailNotifier) Accepts(alert *types.Alert) bool {
+	return meetsMinLevel(alert, e.config.MinLevel)
 }
 
 // Send 发送邮件
 func (e *EmailNotifier) Send(alert *types.Alert) error {
-	if !e.IsEnabled() {
+	if !e.IsEnabled() || !e.Accepts(alert) {
+		return nil
+	}
+
+	// 汇总模式下仅入队，测试告警仍立即发送
+	if e.config.Digest && !isTestAlert(alert) {
+		e.enqueueDigest(alert)
 		return nil
 	}
 
@@ -44,15 +72,37 @@ func (e *EmailNotifier) Send(alert *types.Alert) error {
 		return fmt.Errorf("邮件配置错误: %w", err)
 	}
 
+	if err := e.dialAndSend(e.buildMessage(alert)); err != nil {
+		return err
+	}
+
+	e.logger.Debugf("邮件消息发送成功，收件人: %v", e.config.ToEmails)
+	e.logger.Infof("邮件告警已发送: %s", alert.RuleName)
+	return nil
+}
+
+// buildMessage 构建告警邮件：纯文本与 HTML 两种正文，按配置附加匹配文档
+func (e *EmailNotifier) buildMessage(alert *types.Alert) *gomail.Message {
 	m := gomail.NewMessage()
 	m.SetHeader("From", e.config.FromEmail)
 	m.SetHeader("To", e.config.ToEmails...)
-	m.SetHeader("Subject", fmt.Sprintf("[%s] %s", alert.Level, alert.RuleName))
+	m.SetHeader("Subject", sanitizeSubject(withPrefix(e.prefix, e.subject(alert))))
+
+	// 构建邮件内容：纯文本在前、HTML 在后，客户端优先展示最后一个（HTML）部分
+	m.SetBody("text/plain", e.buildPlainBody(alert))
+	m.AddAlternative("text/html", e.buildEmailBody(alert))
 
-	// 构建邮件内容
-	body := e.buildEmailBody(alert)
-	m.SetBody("text/html", body)
+	// 附加全部匹配文档
+	if e.config.AttachMatches && len(alert.Hits) > 0 {
+		if err := e.attachMatches(m, alert); err != nil {
+			e.logger.Warnf("生成匹配文档附件失败（邮件照常发送）: %v", err)
+		}
+	}
+	return m
+}
 
+// dialAndSend 连接 SMTP 服务器并发送邮件
+func (e *EmailNotifier) dialAndSend(m *gomail.Message) error {
 	d := gomail.NewDialer(e.config.SMTPServer, e.config.SMTPPort, e.config.Username, e.config.Password)
 	if e.config.UseTLS {
 		d.TLSConfig = &tls.Config{ServerName: e.config.SMTPServer}
@@ -66,9 +116,6 @@ func (e *EmailNotifier) Send(alert *types.Alert) error {
 		}
 		return fmt.Errorf("发送邮件失败: %w", err)
 	}
-
-	e.logger.Debugf("邮件消息发送成功，收件人: %v", e.config.ToEmails)
-	e.logger.Infof("邮件告警已发送: %s", alert.RuleName)
 	return nil
 }
 
@@ -77,6 +124,9 @@ func (e *EmailNotifier) buildEmailBody(alert *types.Alert) string {
 	// 格式化告警消息，处理Markdown格式
 	formattedMessage := e.formatMessageContent(alert.Message)
 	headerBg, headerBorder := e.getHeaderColors(alert.Level)
+	if alert.Resolved {
+		headerBg, headerBorder = "#e8f5e9", "#a3e4b8"
+	}
 	levelEmoji := e.getLevelEmoji(alert.Level)
 	levelClass := e.getLevelClass(alert.Level)
 
@@ -128,7 +178,7 @@ func (e *EmailNotifier) buildEmailBody(alert *types.Alert) string {
 <html>
 <head>
     <meta charset="UTF-8">
-    <title>KubeSphere-OpenSearch 告警通知</title>
+    <title>%s</title>
     <style>
         body { 
             font-family: Arial, sans-serif; 
@@ -204,16 +254,11 @@ func (e *EmailNotifier) buildEmailBody(alert *types.Alert) string {
             overflow-x: auto;
             font-size: 12px;
         }
-        .level-critical { border-left-color: #dc3545; }
-        .level-high { border-left-color: #fd7e14; }
-        .level-medium { border-left-color: #ffc107; }
-        .level-low { border-left-color: #28a745; }
-        .level-info { border-left-color: #17a2b8; }
-    </style>
+%s    </style>
 </head>
 <body>
     <div class="header" style="background-color: %s; border: 1px solid %s;">
-        <h2>%s KubeSphere-OpenSearch 告警通知</h2>
+        <h2>%s %s</h2>
         <span class="level-badge">级别: %s</span>
     </div>
     
@@ -227,7 +272,7 @@ func (e *EmailNotifier) buildEmailBody(alert *types.Alert) string {
             <span class="value">%s</span>
         </div>
         <div class="field %s">
-            <span class="label">🕒 触发时间:</span>
+            <span class="label">🕒 %s:</span>
             <span class="value">%s</span>
         </div>
         <div class="field %s">
@@ -248,16 +293,44 @@ func (e *EmailNotifier) buildEmailBody(alert *types.Alert) string {
     </div>
 </body>
 </html>
-`, headerBg, headerBorder, levelEmoji, alert.Level,
+`, alertTitle(e.prefix, alert), e.levelCSS(),
+		headerBg, headerBorder, titleEmoji(alert, levelEmoji), alertTitle(e.prefix, alert), alert.Level,
 		levelClass, alert.RuleName,
 		levelClass, levelEmoji, alert.Level,
-		levelClass, alert.Timestamp.Format("2006-01-02 15:04:05"),
+		levelClass, timeLabel(alert), alert.Timestamp.In(e.location).Format("2006-01-02 15:04:05"),
 		levelClass, alert.Count,
 		levelClass, formattedMessage,
 		k8sSection,
 		e.formatData(alert.Data))
 }
 
+// buildPlainBody 构建纯文本邮件内容，供不支持 HTML 的客户端使用
+func (e *EmailNotifier) buildPlainBody(alert *types.Alert) string {
+	var b strings.Builder
+	b.WriteString(alertTitle(e.prefix, alert) + "\n\n")
+	fmt.Fprintf(&b, "规则名称: %s\n", alert.RuleName)
+	fmt.Fprintf(&b, "告警级别: %s\n", alert.Level)
+	fmt.Fprintf(&b, "%s: %s\n", timeLabel(alert), alert.Timestamp.In(e.location).Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "匹配数量: %d\n", alert.Count)
+
+	podName, namespace, containerName, containerImage := e.extractK8sInfo(alert.Data)
+	for _, field := range [][2]string{
+		{"Pod 名称", podName},
+		{"命名空间", namespace},
+		{"容器名称", containerName},
+		{"容器镜像", containerImage},
+	} {
+		if field[1] != "" {
+			fmt.Fprintf(&b, "%s: %s\n", field[0], field[1])
+		}
+	}
+
+	b.WriteString("\n告警消息:\n")
+	b.WriteString(markdownToPlain(alert.Message))
+	b.WriteString("\n")
+	return b.String()
+}
+
 // formatData 格式化数据
 func (e *EmailNotifier) formatData(data map[string]interface{}) string {
 	if data == nil {
@@ -355,82 +428,95 @@ func (e *EmailNotifier) processBoldText(text string) string {
 
 // getLevelClass 获取告警级别对应的CSS类名
 func (e *EmailNotifier) getLevelClass(level string) string {
-	switch strings.ToLower(level) {
-	case "critical":
-		return "critical"
-	case "high":
-		return "high"
-	case "medium":
-		return "medium"
-	case "low":
-		return "low"
-	case "info":
-		return "info"
-	default:
-		return "info"
-	}
+	return e.levels.Lookup(level).CSSClass
 }
 
 // getLevelEmoji 根据级别返回表情
 func (e *EmailNotifier) getLevelEmoji(level string) string {
-	switch strings.ToLower(level) {
-	case "critical":
-		return "🚨"
-	case "high":
-		return "🚩"
-	case "medium":
-		return "🔔"
-	case "low", "info":
-		return "ℹ️"
-	default:
-		return "🔔"
-	}
+	return e.levels.Lookup(level).Emoji
 }
 
-// getHeaderColors 根据级别返回标题背景色与边框色
-func (e *EmailNotifier) getHeaderColors(level string) (string, string) {
-	switch strings.ToLower(level) {
-	case "critical":
-		return "#fdecea", "#f5c6cb"
-	case "high":
-		return "#fff4e5", "#ffd7a8"
-	case "medium":
-		return "#fffbe6", "#ffe58f"
-	case "low":
-		return "#e8f5e9", "#a3e4b8"
-	case "info":
-		return "#e8f4fd", "#a3d0f7"
-	default:
-		return "#f8d7da", "#f5c6cb"
-	}
+// maxSubjectLength 邮件主题最大长度（字符）
+const maxSubjectLength = 255
+
+// subjectData 邮件主题模板数据：告警字段及从 sample_hit 提取的 K8s 信息
+type subjectData struct {
+	*types.Alert
+	PodName        string
+	Namespace      string
+	ContainerName  string
+	ContainerImage string
 }
 
-// extractK8sInfo 从 alert.Data.sample_hit 提取 K8s 相关信息
-func (e *EmailNotifier) extractK8sInfo(data map[string]interface{}) (podName, namespace, containerName, containerImage string) {
-	if data == nil {
-		return "", "", "", ""
+// ParseSubjectTemplate 解析邮件主题模板
+func ParseSubjectTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("subject").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析邮件主题模板失败: %w", err)
 	}
-	sample, ok := data["sample_hit"].(map[string]interface{})
-	if !ok {
-		return "", "", "", ""
+	return tmpl, nil
+}
+
+// subject 邮件主题，恢复通知加上 [已恢复] 标记
+func (e *EmailNotifier) subject(alert *types.Alert) string {
+	title := fmt.Sprintf("[%s] %s", alert.Level, alert.RuleName)
+	if e.config.SubjectTemplate != "" {
+		if rendered, err := e.renderSubject(alert); err != nil {
+			e.logger.Warnf("%v，使用默认主题", err)
+		} else {
+			title = rendered
+		}
 	}
-	kube, ok := sample["kubernetes"].(map[string]interface{})
-	if !ok {
-		return "", "", "", ""
+	if alert.Resolved {
+		return "[已恢复]" + title
 	}
-	if v, ok := kube["pod_name"].(string); ok {
-		podName = v
+	return title
+}
+
+// renderSubject 按 subject_template 渲染邮件主题
+func (e *EmailNotifier) renderSubject(alert *types.Alert) (string, error) {
+	tmpl, err := ParseSubjectTemplate(e.config.SubjectTemplate)
+	if err != nil {
+		return "", err
 	}
-	if v, ok := kube["namespace_name"].(string); ok {
-		namespace = v
+	data := subjectData{Alert: alert}
+	data.PodName, data.Namespace, data.ContainerName, data.ContainerImage = e.extractK8sInfo(alert.Data)
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("渲染邮件主题模板失败: %w", err)
 	}
-	if v, ok := kube["container_name"].(string); ok {
-		containerName = v
+	return b.String(), nil
+}
+
+// sanitizeSubject 去除主题中的换行并限制长度，避免破坏邮件头
+func sanitizeSubject(subject string) string {
+	subject = strings.Join(strings.Fields(subject), " ")
+	if utf8.RuneCountInString(subject) > maxSubjectLength {
+		subject = string([]rune(subject)[:maxSubjectLength])
 	}
-	if v, ok := kube["container_image"].(string); ok {
-		containerImage = v
+	return subject
+}
+
+// levelCSS 生成各级别字段左边框颜色的样式
+func (e *EmailNotifier) levelCSS() string {
+	var b strings.Builder
+	for _, level := range types.AlertLevels {
+		meta := e.levels.Lookup(level)
+		fmt.Fprintf(&b, "        .field.%s { border-left-color: %s; }\n", meta.CSSClass, meta.Color)
 	}
-	return
+	return b.String()
+}
+
+// getHeaderColors 根据级别返回标题背景色与边框色
+func (e *EmailNotifier) getHeaderColors(level string) (string, string) {
+	meta := e.levels.Lookup(level)
+	return meta.HeaderBg, meta.HeaderBorder
+}
+
+// extractK8sInfo 从告警数据（fields 或 sample_hit）提取 K8s 相关信息
+func (e *EmailNotifier) extractK8sInfo(data map[string]interface{}) (podName, namespace, containerName, containerImage string) {
+	return k8sInfo(data)
 }
 
 // validateConfig 验证邮件配置
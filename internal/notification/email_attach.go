@@ -0,0 +1,165 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"opensearch-alert/pkg/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/gomail.v2"
+)
+
+// attachMatches 将告警的全部匹配文档按配置格式作为附件加入邮件
+func (e *EmailNotifier) attachMatches(m *gomail.Message, alert *types.Alert) error {
+	hits := alert.Hits
+	if limit := e.config.AttachMaxRows; limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	var data []byte
+	var rows int
+	var err error
+	if e.config.AttachFormat == "json" {
+		data, rows, err = buildMatchesJSON(hits, e.config.AttachMaxBytes)
+	} else {
+		data, rows, err = buildMatchesCSV(hits, e.config.AttachMaxBytes)
+	}
+	if err != nil {
+		return err
+	}
+	if rows < len(alert.Hits) {
+		e.logger.Warnf("规则 %s 匹配 %d 条文档，附件仅包含前 %d 条（受 attach_max_rows/attach_max_bytes 限制）", alert.RuleName, len(alert.Hits), rows)
+	}
+
+	ext := "csv"
+	if e.config.AttachFormat == "json" {
+		ext = "json"
+	}
+	filename := fmt.Sprintf("%s-%s.%s", attachmentName(alert.RuleName), alert.Timestamp.In(e.location).Format("20060102-150405"), ext)
+	m.Attach(filename, gomail.SetCopyFunc(func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}))
+	return nil
+}
+
+// buildMatchesCSV 生成 CSV：列为 _index、_id 与所有文档展开后的字段，超出大小上限时截断
+func buildMatchesCSV(hits []types.OpenSearchHit, maxBytes int) ([]byte, int, error) {
+	flattened := make([]map[string]string, len(hits))
+	columnSet := make(map[string]bool)
+	for i, hit := range hits {
+		row := make(map[string]string)
+		flattenSource("", hit.Source, row)
+		for key := range row {
+			columnSet[key] = true
+		}
+		flattened[i] = row
+	}
+	columns := make([]string, 0, len(columnSet))
+	for key := range columnSet {
+		columns = append(columns, key)
+	}
+	sort.Strings(columns)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(append([]string{"_index", "_id"}, columns...)); err != nil {
+		return nil, 0, fmt.Errorf("写入CSV失败: %w", err)
+	}
+	w.Flush()
+
+	rows := 0
+	for i, hit := range hits {
+		record := []string{hit.Index, hit.ID}
+		for _, column := range columns {
+			record = append(record, flattened[i][column])
+		}
+		size := buf.Len()
+		if err := w.Write(record); err != nil {
+			return nil, 0, fmt.Errorf("写入CSV失败: %w", err)
+		}
+		w.Flush()
+		if maxBytes > 0 && buf.Len() > maxBytes {
+			buf.Truncate(size)
+			break
+		}
+		rows++
+	}
+	return buf.Bytes(), rows, w.Error()
+}
+
+// buildMatchesJSON 生成 JSON 数组，超出大小上限时截断
+func buildMatchesJSON(hits []types.OpenSearchHit, maxBytes int) ([]byte, int, error) {
+	items := make([]json.RawMessage, 0, len(hits))
+	size := 2
+	for _, hit := range hits {
+		item, err := json.Marshal(map[string]interface{}{
+			"_index":  hit.Index,
+			"_id":     hit.ID,
+			"_source": hit.Source,
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("序列化匹配文档失败: %w", err)
+		}
+		if maxBytes > 0 && size+len(item)+1 > maxBytes {
+			break
+		}
+		size += len(item) + 1
+		items = append(items, item)
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, 0, fmt.Errorf("序列化匹配文档失败: %w", err)
+	}
+	return data, len(items), nil
+}
+
+// flattenSource 将嵌套文档展开为以点号分隔的字段，数组按 JSON 输出
+func flattenSource(prefix string, source map[string]interface{}, out map[string]string) {
+	for key, value := range source {
+		name := key
+		if prefix != "" {
+			name = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenSource(name, v, out)
+		case string:
+			out[name] = v
+		case nil:
+			out[name] = ""
+		case float64:
+			out[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			out[name] = strconv.FormatBool(v)
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				out[name] = fmt.Sprint(v)
+				continue
+			}
+			out[name] = string(data)
+		}
+	}
+}
+
+// attachmentName 将规则名转换为安全的附件文件名
+func attachmentName(ruleName string) string {
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', ' ':
+			return '_'
+		}
+		return r
+	}, ruleName)
+	if name == "" {
+		name = "matches"
+	}
+	return name + "-matches"
+}
@@ -0,0 +1,176 @@
+package notification
+
+import (
+	"fmt"
+	"html"
+	"opensearch-alert/pkg/types"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// isTestAlert 判断是否为连接/启动测试告警
+func isTestAlert(alert *types.Alert) bool {
+	if alert.Data == nil {
+		return false
+	}
+	test, _ := alert.Data["test"].(bool)
+	return test
+}
+
+// enqueueDigest 将告警加入邮件汇总队列
+func (e *EmailNotifier) enqueueDigest(alert *types.Alert) {
+	e.digestMutex.Lock()
+	e.digestAlerts = append(e.digestAlerts, alert)
+	e.digestMutex.Unlock()
+	e.logger.Debugf("邮件汇总模式，告警已入队: %s (级别: %s)", alert.RuleName, alert.Level)
+}
+
+// StartDigest 按窗口定期发送汇总邮件
+func (e *EmailNotifier) StartDigest(interval time.Duration) {
+	if !e.config.Enabled || !e.config.Digest {
+		return
+	}
+	e.digestRunning = true
+
+	e.logger.Infof("邮件汇总模式已开启，汇总窗口: %s", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := e.FlushDigest(); err != nil {
+					e.logger.Errorf("发送汇总邮件失败: %v", err)
+				}
+			case <-e.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// StopDigest 停止汇总任务并发送剩余告警
+func (e *EmailNotifier) StopDigest() {
+	if !e.digestRunning {
+		return
+	}
+
+	close(e.stopCh)
+	if err := e.FlushDigest(); err != nil {
+		e.logger.Errorf("发送汇总邮件失败: %v", err)
+	}
+}
+
+// FlushDigest 将队列中的告警汇总为一封邮件发送
+func (e *EmailNotifier) FlushDigest() error {
+	e.digestMutex.Lock()
+	alerts := e.digestAlerts
+	e.digestAlerts = nil
+	e.digestMutex.Unlock()
+
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	if err := e.validateConfig(); err != nil {
+		return fmt.Errorf("邮件配置错误: %w", err)
+	}
+
+	sortDigestAlerts(alerts)
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", e.config.FromEmail)
+	m.SetHeader("To", e.config.ToEmails...)
+	m.SetHeader("Subject", withPrefix(e.prefix, fmt.Sprintf("[告警汇总] 共 %d 条告警", len(alerts))))
+	m.SetBody("text/html", e.buildDigestBody(alerts))
+
+	if err := e.dialAndSend(m); err != nil {
+		return err
+	}
+
+	e.logger.Infof("汇总邮件已发送，包含 %d 条告警", len(alerts))
+	return nil
+}
+
+// sortDigestAlerts 按规则、级别（从高到低）、时间排序
+func sortDigestAlerts(alerts []*types.Alert) {
+	sort.SliceStable(alerts, func(i, j int) bool {
+		a, b := alerts[i], alerts[j]
+		if a.RuleName != b.RuleName {
+			return a.RuleName < b.RuleName
+		}
+		if ra, rb := types.LevelRank(a.Level), types.LevelRank(b.Level); ra != rb {
+			return ra > rb
+		}
+		return a.Timestamp.Before(b.Timestamp)
+	})
+}
+
+// buildDigestBody 构建汇总邮件内容（按规则与级别分组的表格）
+func (e *EmailNotifier) buildDigestBody(alerts []*types.Alert) string {
+	var rows strings.Builder
+	for i := 0; i < len(alerts); {
+		// 同一规则、同一级别为一组
+		j := i
+		for j < len(alerts) && alerts[j].RuleName == alerts[i].RuleName && alerts[j].Level == alerts[i].Level {
+			j++
+		}
+
+		fmt.Fprintf(&rows, `
+        <tr class="group">
+            <td colspan="4">%s %s · %s（%d 条）</td>
+        </tr>`,
+			e.getLevelEmoji(alerts[i].Level), html.EscapeString(alerts[i].RuleName), html.EscapeString(alerts[i].Level), j-i)
+
+		for _, alert := range alerts[i:j] {
+			fmt.Fprintf(&rows, `
+        <tr>
+            <td>%s</td>
+            <td>%s</td>
+            <td>%d</td>
+            <td><pre>%s</pre></td>
+        </tr>`,
+				alert.Timestamp.In(e.location).Format("2006-01-02 15:04:05"),
+				html.EscapeString(alert.Level),
+				alert.Count,
+				html.EscapeString(alert.Message))
+		}
+		i = j
+	}
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>%s</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 20px; color: #333; }
+        table { border-collapse: collapse; width: 100%%; }
+        th, td { border: 1px solid #dee2e6; padding: 8px; text-align: left; vertical-align: top; font-size: 14px; }
+        th { background-color: #f8f9fa; }
+        tr.group td { background-color: #e9ecef; font-weight: bold; }
+        pre { margin: 0; white-space: pre-wrap; font-family: 'Courier New', monospace; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <h2>📬 %s</h2>
+    <p>汇总时间: %s，共 %d 条告警</p>
+    <table>
+        <tr>
+            <th>触发时间</th>
+            <th>级别</th>
+            <th>匹配数量</th>
+            <th>告警消息</th>
+        </tr>%s
+    </table>
+</body>
+</html>
+`, html.EscapeString(withPrefix(e.prefix, "KubeSphere-OpenSearch 告警汇总")),
+		html.EscapeString(withPrefix(e.prefix, "KubeSphere-OpenSearch 告警汇总")),
+		time.Now().In(e.location).Format("2006-01-02 15:04:05"), len(alerts), rows.String())
+}
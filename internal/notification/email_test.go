@@ -0,0 +1,294 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// mailPart 邮件中的叶子部分
+type mailPart struct {
+	ContentType string
+	Filename    string
+	Body        string
+}
+
+// newTestEmailNotifier 创建不连接 SMTP 的邮件通知器
+func newTestEmailNotifier(config types.EmailConfig) *EmailNotifier {
+	config.Enabled = true
+	config.FromEmail = "alert@example.com"
+	config.ToEmails = []string{"ops@example.com"}
+	return NewEmailNotifier(&config, time.UTC, newTestLogger())
+}
+
+// renderMail 生成邮件原文并展开所有叶子部分
+func renderMail(t *testing.T, e *EmailNotifier, alert *types.Alert) (*mail.Message, []mailPart) {
+	t.Helper()
+	var raw bytes.Buffer
+	if _, err := e.buildMessage(alert).WriteTo(&raw); err != nil {
+		t.Fatalf("生成邮件失败: %v", err)
+	}
+	msg, err := mail.ReadMessage(&raw)
+	if err != nil {
+		t.Fatalf("解析邮件失败: %v", err)
+	}
+	var parts []mailPart
+	collectParts(t, msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), "", msg.Body, &parts)
+	return msg, parts
+}
+
+// collectParts 递归展开 multipart 并按传输编码解码
+func collectParts(t *testing.T, contentType, encoding, disposition string, body io.Reader, parts *[]mailPart) {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("解析 Content-Type %q 失败: %v", contentType, err)
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatalf("读取 %s 失败: %v", mediaType, err)
+			}
+			// NextPart 已解码 quoted-printable 并移除对应的头，base64 仍需自行解码
+			collectParts(t, part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part.Header.Get("Content-Disposition"), part, parts)
+		}
+	}
+
+	switch strings.ToLower(encoding) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("读取 %s 正文失败: %v", mediaType, err)
+	}
+	var filename string
+	if disposition != "" {
+		if _, dispParams, err := mime.ParseMediaType(disposition); err == nil {
+			filename = dispParams["filename"]
+		}
+	}
+	*parts = append(*parts, mailPart{ContentType: mediaType, Filename: filename, Body: string(data)})
+}
+
+func TestEmailHasPlainAndHTMLParts(t *testing.T) {
+	e := newTestEmailNotifier(types.EmailConfig{})
+	msg, parts := renderMail(t, e, testAlert("High"))
+
+	if mediaType, _, _ := mime.ParseMediaType(msg.Header.Get("Content-Type")); mediaType != "multipart/alternative" {
+		t.Fatalf("邮件应为 multipart/alternative，实际 %s", mediaType)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("应包含 2 个正文部分，实际 %d 个", len(parts))
+	}
+
+	// 纯文本在前，HTML 作为首选展示的最后一个部分
+	plain, html := parts[0], parts[1]
+	if plain.ContentType != "text/plain" || html.ContentType != "text/html" {
+		t.Fatalf("部分顺序应为 text/plain、text/html，实际 %s、%s", plain.ContentType, html.ContentType)
+	}
+	for _, want := range []string{"规则名称: error-logs", "告警级别: High", "错误日志", "panic: boom"} {
+		if !strings.Contains(plain.Body, want) {
+			t.Errorf("纯文本正文缺少 %q:\n%s", want, plain.Body)
+		}
+	}
+	if strings.Contains(plain.Body, "**") || strings.Contains(plain.Body, "```") {
+		t.Errorf("纯文本正文不应保留 Markdown 标记:\n%s", plain.Body)
+	}
+	if !strings.Contains(html.Body, "<html") || !strings.Contains(html.Body, "error-logs") {
+		t.Errorf("HTML 正文不完整:\n%s", html.Body)
+	}
+}
+
+// hitsAlert 构造带全部匹配文档的告警
+func hitsAlert() *types.Alert {
+	alert := testAlert("High")
+	alert.RuleName = "audit/login"
+	alert.Hits = []types.OpenSearchHit{
+		{Index: "logs-1", ID: "a", Source: map[string]interface{}{"user": "alice", "event": map[string]interface{}{"action": "login"}}},
+		{Index: "logs-1", ID: "b", Source: map[string]interface{}{"user": "bob, jr", "count": float64(2)}},
+		{Index: "logs-2", ID: "c", Source: map[string]interface{}{"user": "carol"}},
+	}
+	return alert
+}
+
+// attachmentPart 返回邮件中唯一的附件
+func attachmentPart(t *testing.T, parts []mailPart) mailPart {
+	t.Helper()
+	var found []mailPart
+	for _, part := range parts {
+		if part.Filename != "" {
+			found = append(found, part)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("应包含 1 个附件，实际 %d 个", len(found))
+	}
+	return found[0]
+}
+
+func TestEmailAttachesMatchesAsCSV(t *testing.T) {
+	e := newTestEmailNotifier(types.EmailConfig{AttachMatches: true})
+	msg, parts := renderMail(t, e, hitsAlert())
+
+	if mediaType, _, _ := mime.ParseMediaType(msg.Header.Get("Content-Type")); mediaType != "multipart/mixed" {
+		t.Fatalf("带附件的邮件应为 multipart/mixed，实际 %s", mediaType)
+	}
+	attachment := attachmentPart(t, parts)
+	if attachment.Filename != "audit_login-matches-20240501-080000.csv" {
+		t.Errorf("附件名 = %q", attachment.Filename)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(attachment.Body)).ReadAll()
+	if err != nil {
+		t.Fatalf("附件不是合法的 CSV: %v", err)
+	}
+	want := [][]string{
+		{"_index", "_id", "count", "event.action", "user"},
+		{"logs-1", "a", "", "login", "alice"},
+		{"logs-1", "b", "2", "", "bob, jr"},
+		{"logs-2", "c", "", "", "carol"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("CSV 内容 = %v, 期望 %v", records, want)
+	}
+
+	// 正文仍为纯文本与 HTML 两部分
+	if bodies := len(parts) - 1; bodies != 2 {
+		t.Errorf("附件之外应有 2 个正文部分，实际 %d 个", bodies)
+	}
+}
+
+func TestEmailAttachesMatchesAsJSONWithRowLimit(t *testing.T) {
+	e := newTestEmailNotifier(types.EmailConfig{AttachMatches: true, AttachFormat: "json", AttachMaxRows: 2})
+	_, parts := renderMail(t, e, hitsAlert())
+
+	attachment := attachmentPart(t, parts)
+	if !strings.HasSuffix(attachment.Filename, ".json") {
+		t.Errorf("附件名 = %q, 期望 .json 后缀", attachment.Filename)
+	}
+	var docs []struct {
+		Index  string                 `json:"_index"`
+		ID     string                 `json:"_id"`
+		Source map[string]interface{} `json:"_source"`
+	}
+	if err := json.Unmarshal([]byte(attachment.Body), &docs); err != nil {
+		t.Fatalf("附件不是合法的 JSON: %v\n%s", err, attachment.Body)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("attach_max_rows=2 时应只包含 2 条文档，实际 %d 条", len(docs))
+	}
+	if docs[0].ID != "a" || docs[1].Source["user"] != "bob, jr" {
+		t.Errorf("JSON 内容不符: %+v", docs)
+	}
+}
+
+func TestEmailWithoutHitsHasNoAttachment(t *testing.T) {
+	e := newTestEmailNotifier(types.EmailConfig{AttachMatches: true})
+	_, parts := renderMail(t, e, testAlert("High"))
+	for _, part := range parts {
+		if part.Filename != "" {
+			t.Errorf("没有匹配文档时不应附加文件，实际附件 %q", part.Filename)
+		}
+	}
+}
+
+func TestBuildMatchesCSVRespectsMaxBytes(t *testing.T) {
+	hits := hitsAlert().Hits
+	full, rows, err := buildMatchesCSV(hits, 0)
+	if err != nil || rows != 3 {
+		t.Fatalf("不限大小时应包含全部 3 行，实际 %d 行 (err=%v)", rows, err)
+	}
+
+	data, rows, err := buildMatchesCSV(hits, len(full)-1)
+	if err != nil {
+		t.Fatalf("buildMatchesCSV 失败: %v", err)
+	}
+	if rows != 2 || len(data) > len(full)-1 {
+		t.Errorf("超出大小上限时应截断到 2 行，实际 %d 行 %d 字节", rows, len(data))
+	}
+	if _, err := csv.NewReader(bytes.NewReader(data)).ReadAll(); err != nil {
+		t.Errorf("截断后的 CSV 应仍然合法: %v", err)
+	}
+}
+
+// k8sAlert 构造 sample_hit 中带 K8s 信息的告警
+func k8sAlert() *types.Alert {
+	alert := testAlert("Critical")
+	alert.Data = map[string]interface{}{
+		"sample_hit": map[string]interface{}{
+			"kubernetes": map[string]interface{}{
+				"namespace_name": "payments",
+				"pod_name":       "api-7d9f",
+			},
+		},
+	}
+	return alert
+}
+
+func TestEmailSubjectTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   types.EmailConfig
+		alert    func() *types.Alert
+		expected string
+	}{
+		{"默认主题", types.EmailConfig{}, k8sAlert, "[Critical] error-logs"},
+		{"命名空间取自 sample_hit",
+			types.EmailConfig{SubjectTemplate: "[{{.Level}}] {{.Namespace}}/{{.PodName}} {{.RuleName}}"},
+			k8sAlert, "[Critical] payments/api-7d9f error-logs"},
+		{"缺少 K8s 字段时为空",
+			types.EmailConfig{SubjectTemplate: "{{.RuleName}} ns={{.Namespace}}"},
+			func() *types.Alert { return testAlert("High") }, "error-logs ns="},
+		{"去除换行",
+			types.EmailConfig{SubjectTemplate: "{{.RuleName}}\n{{.Namespace}}\r\n告警"},
+			k8sAlert, "error-logs payments 告警"},
+		{"模板错误时回退默认主题", types.EmailConfig{SubjectTemplate: "{{.RuleName"}, k8sAlert, "[Critical] error-logs"},
+		{"恢复通知加标记",
+			types.EmailConfig{SubjectTemplate: "{{.Namespace}} {{.RuleName}}"},
+			func() *types.Alert { a := k8sAlert(); a.Resolved = true; return a }, "[已恢复]payments error-logs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, _ := renderMail(t, newTestEmailNotifier(tt.config), tt.alert())
+			subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+			if err != nil {
+				t.Fatalf("解码主题失败: %v", err)
+			}
+			if subject != tt.expected {
+				t.Errorf("主题 = %q，期望 %q", subject, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEmailSubjectTruncated(t *testing.T) {
+	e := newTestEmailNotifier(types.EmailConfig{SubjectTemplate: strings.Repeat("告", 300) + "{{.RuleName}}"})
+	msg, _ := renderMail(t, e, testAlert("High"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("解码主题失败: %v", err)
+	}
+	if n := len([]rune(subject)); n != maxSubjectLength {
+		t.Errorf("主题长度 = %d，期望截断为 %d", n, maxSubjectLength)
+	}
+}
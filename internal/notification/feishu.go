@@ -19,29 +19,59 @@ import (
 
 // FeishuNotifier 飞书通知器
 type FeishuNotifier struct {
-	config *types.FeishuConfig
-	logger *logrus.Logger
+	config   *types.FeishuConfig
+	logger   *logrus.Logger
+	guard    channelGuard
+	location *time.Location
+	// client 共用的 HTTP 客户端（带超时），由 Notifier 统一设置
+	client *http.Client
+	// prefix 环境前缀，由 Notifier 统一设置
+	prefix string
+	// maxLength 详情长度上限（字节），由 Notifier 统一设置；0 表示使用渠道默认值
+	maxLength int
+	// levels 级别图标与颜色，由 Notifier 统一设置（为空时使用内置表）
+	levels types.LevelTable
+	// contacts 手机号到 open_id 的解析缓存
+	contacts feishuContacts
 }
 
 // NewFeishuNotifier 创建飞书通知器
-func NewFeishuNotifier(config *types.FeishuConfig, logger *logrus.Logger) *FeishuNotifier {
+func NewFeishuNotifier(config *types.FeishuConfig, location *time.Location, logger *logrus.Logger) *FeishuNotifier {
 	return &FeishuNotifier{
-		config: config,
-		logger: logger,
+		config:   config,
+		logger:   logger,
+		location: location,
+		client:   newHTTPClient(DefaultHTTPTimeout),
+		contacts: feishuContacts{apiBase: feishuOpenAPIBase, openIDs: make(map[string]string)},
 	}
 }
 
+// validateConfig 验证飞书配置
+func (f *FeishuNotifier) validateConfig() error {
+	return validateWebhookURL(f.config.WebhookURL)
+}
+
 // IsEnabled 检查是否启用
 func (f *FeishuNotifier) IsEnabled() bool {
-	return f.config.Enabled
+	return f.config.Enabled && f.guard.disabledReason() == ""
+}
+
+// Accepts 告警级别是否达到渠道的 min_level
+func (f *FeishuNotifier) Accepts(alert *types.Alert) bool {
+	return meetsMinLevel(alert, f.config.MinLevel)
 }
 
 // Send 发送飞书消息
 func (f *FeishuNotifier) Send(alert *types.Alert) error {
-	if !f.IsEnabled() {
+	if !f.IsEnabled() || !f.Accepts(alert) {
 		return nil
 	}
 
+	// 需要 @ 手机号用户时，先通过通讯录接口解析 open_id（结果缓存）
+	if f.shouldAtUser(alert.Level) && !f.config.AtAll {
+		f.resolveMobiles()
+	}
+
 	// 构建消息
 	message := f.buildFeishuMessage(alert)
 
@@ -56,7 +86,7 @@ func (f *FeishuNotifier) Send(alert *types.Alert) error {
 		return fmt.Errorf("序列化消息失败: %w", err)
 	}
 
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := f.client.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("发送飞书消息失败: %w", err)
 	}
@@ -80,13 +110,13 @@ func (f *FeishuNotifier) Send(alert *types.Alert) error {
 func (f *FeishuNotifier) buildFeishuMessage(alert *types.Alert) map[string]interface{} {
 	// 构建@文本 - 只有严重告警才@用户
 	atText := ""
-	if f.shouldAtUser(alert.Level) {
+	if !alert.Resolved && f.shouldAtUser(alert.Level) {
 		if f.config.AtAll {
 			atText = "<at id=\"all\"></at>"
-		} else if len(f.config.AtMobiles) > 0 {
-			// 注意：这里需要真实的用户Open ID，手机号码无法直接@
-			for _, mobile := range f.config.AtMobiles {
-				atText += fmt.Sprintf("<at id=\"%s\"></at>", mobile)
+		} else {
+			// 飞书只认 open_id：使用 at_user_ids 及由手机号解析出的 open_id，均无时不 @
+			for _, id := range f.mentionIDs() {
+				atText += fmt.Sprintf("<at id=\"%s\"></at>", id)
 			}
 		}
 	}
@@ -98,9 +128,9 @@ func (f *FeishuNotifier) buildFeishuMessage(alert *types.Alert) map[string]inter
 			"header": map[string]interface{}{
 				"title": map[string]interface{}{
 					"tag":     "plain_text",
-					"content": fmt.Sprintf("%s KubeSphere-OpenSearch 告警通知", f.getLevelEmoji(alert.Level)),
+					"content": fmt.Sprintf("%s %s", titleEmoji(alert, f.getLevelEmoji(alert.Level)), alertTitle(f.prefix, alert)),
 				},
-				"template": f.getTemplateByLevel(alert.Level),
+				"template": f.getTemplate(alert),
 			},
 			"elements": []map[string]interface{}{
 				{
@@ -121,7 +151,7 @@ func (f *FeishuNotifier) buildFeishuMessage(alert *types.Alert) map[string]inter
 					"tag": "div",
 					"text": map[string]interface{}{
 						"tag":     "lark_md",
-						"content": fmt.Sprintf("🕒 **触发时间:** %s", alert.Timestamp.Format("2006-01-02 15:04:05")),
+						"content": fmt.Sprintf("🕒 **%s:** %s", timeLabel(alert), alert.Timestamp.In(f.location).Format("2006-01-02 15:04:05")),
 					},
 				},
 				{
@@ -138,7 +168,7 @@ func (f *FeishuNotifier) buildFeishuMessage(alert *types.Alert) map[string]inter
 					"tag": "div",
 					"text": map[string]interface{}{
 						"tag":     "lark_md",
-						"content": f.formatMessageContent(alert.Message),
+						"content": truncateMessage(f.formatMessageContent(alert.Message), messageLimit(f.maxLength, feishuMaxDetailBytes)),
 					},
 				},
 				{
@@ -185,66 +215,30 @@ func (f *FeishuNotifier) formatMessageContent(message string) string {
 	return formatted
 }
 
-// getTemplateByLevel 根据级别返回卡片主题色
-func (f *FeishuNotifier) getTemplateByLevel(level string) string {
-	switch level {
-	case "Critical":
-		return "red"
-	case "High":
-		return "orange"
-	case "Medium":
-		return "yellow"
-	case "Low":
+// getTemplate 卡片主题色，恢复通知为绿色
+func (f *FeishuNotifier) getTemplate(alert *types.Alert) string {
+	if alert.Resolved {
 		return "green"
-	case "Info":
-		return "blue"
-	default:
-		return "red"
 	}
+	return f.getTemplateByLevel(alert.Level)
+}
+
+// getTemplateByLevel 根据级别返回卡片主题色
+func (f *FeishuNotifier) getTemplateByLevel(level string) string {
+	return f.levels.Lookup(level).FeishuTemplate
 }
 
 // getLevelEmoji 不同级别对应的图标
 func (f *FeishuNotifier) getLevelEmoji(level string) string {
-	switch level {
-	case "Critical":
-		return "🚨"
-	case "High":
-		return "🚩"
-	case "Medium":
-		return "🔔"
-	case "Low", "Info":
-		return "ℹ️"
-	default:
-		return "🔔"
-	}
+	return f.levels.Lookup(level).Emoji
 }
 
 // extractK8sInfo 提取K8s相关字段
 func (f *FeishuNotifier) extractK8sInfo(alert *types.Alert) (podName, namespace, containerName, containerImage string) {
-	if alert == nil || alert.Data == nil {
-		return "", "", "", ""
-	}
-	sample, ok := alert.Data["sample_hit"].(map[string]interface{})
-	if !ok {
+	if alert == nil {
 		return "", "", "", ""
 	}
-	kube, ok := sample["kubernetes"].(map[string]interface{})
-	if !ok {
-		return "", "", "", ""
-	}
-	if v, ok := kube["pod_name"].(string); ok {
-		podName = v
-	}
-	if v, ok := kube["namespace_name"].(string); ok {
-		namespace = v
-	}
-	if v, ok := kube["container_name"].(string); ok {
-		containerName = v
-	}
-	if v, ok := kube["container_image"].(string); ok {
-		containerImage = v
-	}
-	return
+	return k8sInfo(alert.Data)
 }
 
 // shouldAtUser 判断是否应该@用户
@@ -0,0 +1,178 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// feishuOpenAPIBase 飞书开放平台接口地址
+const feishuOpenAPIBase = "https://open.feishu.cn/open-apis"
+
+// feishuContacts 飞书通讯录查询：缓存 tenant_access_token 与手机号到 open_id 的映射
+type feishuContacts struct {
+	apiBase string
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	openIDs     map[string]string
+}
+
+// mentionIDs 返回需要 @ 的 open_id：at_user_ids 在前，其后为已解析的手机号
+func (f *FeishuNotifier) mentionIDs() []string {
+	ids := append([]string{}, f.config.AtUserIDs...)
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+
+	f.contacts.mu.Lock()
+	defer f.contacts.mu.Unlock()
+	for _, mobile := range f.config.AtMobiles {
+		if id := f.contacts.openIDs[mobile]; id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// resolveMobiles 将尚未解析的手机号解析为 open_id；未配置应用凭据或查询失败时忽略这些手机号
+func (f *FeishuNotifier) resolveMobiles() {
+	if len(f.config.AtMobiles) == 0 {
+		return
+	}
+	if f.config.AppID == "" || f.config.AppSecret == "" {
+		f.logger.Debugf("飞书未配置 app_id/app_secret，at_mobiles 无法解析为 open_id，已忽略")
+		return
+	}
+
+	f.contacts.mu.Lock()
+	var pending []string
+	for _, mobile := range f.config.AtMobiles {
+		if _, ok := f.contacts.openIDs[mobile]; !ok {
+			pending = append(pending, mobile)
+		}
+	}
+	f.contacts.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	resolved, err := f.lookupOpenIDs(pending)
+	if err != nil {
+		f.logger.Warnf("飞书手机号解析 open_id 失败（本次不 @ 这些用户）: %v", err)
+		return
+	}
+
+	f.contacts.mu.Lock()
+	defer f.contacts.mu.Unlock()
+	for _, mobile := range pending {
+		// 未找到的手机号也缓存为空，避免每次告警重复查询
+		f.contacts.openIDs[mobile] = resolved[mobile]
+		if resolved[mobile] == "" {
+			f.logger.Warnf("飞书通讯录中未找到手机号 %s 对应的用户", mobile)
+		}
+	}
+}
+
+// lookupOpenIDs 调用通讯录接口批量查询手机号对应的 open_id
+func (f *FeishuNotifier) lookupOpenIDs(mobiles []string) (map[string]string, error) {
+	token, err := f.tenantAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			UserList []struct {
+				Mobile string `json:"mobile"`
+				UserID string `json:"user_id"`
+			} `json:"user_list"`
+		} `json:"data"`
+	}
+	url := f.contacts.apiBase + "/contact/v3/users/batch_get_id?user_id_type=open_id"
+	if err := f.postOpenAPI(url, token, map[string]interface{}{"mobiles": mobiles}, &result); err != nil {
+		return nil, err
+	}
+	if result.Code != 0 {
+		return nil, fmt.Errorf("通讯录查询失败: code=%d, msg=%s", result.Code, result.Msg)
+	}
+
+	resolved := make(map[string]string, len(result.Data.UserList))
+	for _, user := range result.Data.UserList {
+		resolved[user.Mobile] = user.UserID
+	}
+	return resolved, nil
+}
+
+// tenantAccessToken 获取（并缓存）应用的 tenant_access_token
+func (f *FeishuNotifier) tenantAccessToken() (string, error) {
+	f.contacts.mu.Lock()
+	if f.contacts.token != "" && time.Now().Before(f.contacts.tokenExpiry) {
+		token := f.contacts.token
+		f.contacts.mu.Unlock()
+		return token, nil
+	}
+	f.contacts.mu.Unlock()
+
+	var result struct {
+		Code              int    `json:"code"`
+		Msg               string `json:"msg"`
+		TenantAccessToken string `json:"tenant_access_token"`
+		Expire            int    `json:"expire"`
+	}
+	url := f.contacts.apiBase + "/auth/v3/tenant_access_token/internal"
+	body := map[string]string{"app_id": f.config.AppID, "app_secret": f.config.AppSecret}
+	if err := f.postOpenAPI(url, "", body, &result); err != nil {
+		return "", err
+	}
+	if result.Code != 0 || result.TenantAccessToken == "" {
+		return "", fmt.Errorf("获取 tenant_access_token 失败: code=%d, msg=%s", result.Code, result.Msg)
+	}
+
+	// 提前一分钟过期，避免使用临界失效的令牌
+	f.contacts.mu.Lock()
+	f.contacts.token = result.TenantAccessToken
+	f.contacts.tokenExpiry = time.Now().Add(time.Duration(result.Expire)*time.Second - time.Minute)
+	f.contacts.mu.Unlock()
+	return result.TenantAccessToken, nil
+}
+
+// postOpenAPI 调用飞书开放平台接口并解析 JSON 响应
+func (f *FeishuNotifier) postOpenAPI(url, token string, payload interface{}, out interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求飞书开放平台失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("飞书开放平台返回状态码 %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析飞书开放平台响应失败: %w", err)
+	}
+	return nil
+}
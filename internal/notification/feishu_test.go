@@ -0,0 +1,101 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// feishuMention 返回飞书卡片末尾 @ 元素的内容
+func feishuMention(t *testing.T, message map[string]interface{}) string {
+	t.Helper()
+	elements := message["card"].(map[string]interface{})["elements"].([]map[string]interface{})
+	return elements[len(elements)-1]["text"].(map[string]interface{})["content"].(string)
+}
+
+func TestFeishuMentionsOpenIDsDirectly(t *testing.T) {
+	f := NewFeishuNotifier(&types.FeishuConfig{
+		Enabled:   true,
+		AtUserIDs: []string{"ou_alice", "ou_bob"},
+		// 未配置应用凭据，手机号无法解析，不应出现在 @ 中
+		AtMobiles: []string{"13800000000"},
+	}, time.UTC, newTestLogger())
+
+	f.resolveMobiles()
+	if got, want := feishuMention(t, f.buildFeishuMessage(testAlert("Critical"))), `<at id="ou_alice"></at><at id="ou_bob"></at>`; got != want {
+		t.Errorf("@ 内容 = %q, 期望 %q", got, want)
+	}
+
+	// 低级别告警与恢复通知不 @
+	if got := feishuMention(t, f.buildFeishuMessage(testAlert("Medium"))); got != "" {
+		t.Errorf("Medium 告警不应 @，实际 %q", got)
+	}
+	resolved := testAlert("Critical")
+	resolved.Resolved = true
+	if got := feishuMention(t, f.buildFeishuMessage(resolved)); got != "" {
+		t.Errorf("恢复通知不应 @，实际 %q", got)
+	}
+}
+
+func TestFeishuMentionsAll(t *testing.T) {
+	f := NewFeishuNotifier(&types.FeishuConfig{Enabled: true, AtAll: true, AtUserIDs: []string{"ou_alice"}}, time.UTC, newTestLogger())
+	if got, want := feishuMention(t, f.buildFeishuMessage(testAlert("High"))), `<at id="all"></at>`; got != want {
+		t.Errorf("@ 内容 = %q, 期望 %q", got, want)
+	}
+}
+
+func TestFeishuWithoutIDsMentionsNobody(t *testing.T) {
+	f := NewFeishuNotifier(&types.FeishuConfig{Enabled: true, AtMobiles: []string{"13800000000"}}, time.UTC, newTestLogger())
+	if got := feishuMention(t, f.buildFeishuMessage(testAlert("Critical"))); got != "" {
+		t.Errorf("没有可用的 open_id 时不应 @，实际 %q", got)
+	}
+}
+
+func TestFeishuResolvesMobilesViaContactsAPI(t *testing.T) {
+	var lookups int
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v3/tenant_access_token/internal":
+			w.Write([]byte(`{"code":0,"tenant_access_token":"t-123","expire":7200}`))
+		case "/contact/v3/users/batch_get_id":
+			lookups++
+			if r.Header.Get("Authorization") != "Bearer t-123" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			var req struct {
+				Mobiles []string `json:"mobiles"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			if len(req.Mobiles) != 2 {
+				t.Errorf("应批量查询 2 个手机号，实际 %v", req.Mobiles)
+			}
+			w.Write([]byte(`{"code":0,"data":{"user_list":[{"mobile":"13800000000","user_id":"ou_carol"},{"mobile":"13900000000"}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(api.Close)
+
+	f := NewFeishuNotifier(&types.FeishuConfig{
+		Enabled:   true,
+		AtUserIDs: []string{"ou_alice"},
+		AtMobiles: []string{"13800000000", "13900000000"},
+		AppID:     "cli_x",
+		AppSecret: "secret",
+	}, time.UTC, newTestLogger())
+	f.contacts.apiBase = api.URL
+
+	f.resolveMobiles()
+	f.resolveMobiles()
+	if lookups != 1 {
+		t.Errorf("解析结果（含未找到的手机号）应缓存，实际查询 %d 次", lookups)
+	}
+	if got, want := feishuMention(t, f.buildFeishuMessage(testAlert("High"))), `<at id="ou_alice"></at><at id="ou_carol"></at>`; got != want {
+		t.Errorf("@ 内容 = %q, 期望 %q", got, want)
+	}
+}
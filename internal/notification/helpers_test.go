@@ -0,0 +1,83 @@
+package notification
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"opensearch-alert/pkg/types"
+)
+
+// newTestLogger 返回丢弃输出的日志器
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// newTestNotifier 按配置创建通知器，测试结束时停止汇总任务
+func newTestNotifier(t *testing.T, config *types.Config) *Notifier {
+	t.Helper()
+	n := NewNotifier(config, newTestLogger())
+	t.Cleanup(n.Stop)
+	return n
+}
+
+// testAlert 构造指定级别的告警
+func testAlert(level string) *types.Alert {
+	return &types.Alert{
+		ID:        "alert-1",
+		RuleName:  "error-logs",
+		Level:     level,
+		Message:   "**错误日志**\n```\npanic: boom\n```",
+		Timestamp: time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+		Data:      map[string]interface{}{},
+		Count:     3,
+		Matches:   3,
+	}
+}
+
+// capturedRequest 桩服务收到的请求
+type capturedRequest struct {
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+}
+
+// webhookStub 记录收到的请求并以 status 应答的桩服务
+type webhookStub struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []capturedRequest
+}
+
+// newWebhookStub 启动桩服务，status 为 0 时返回 200
+func newWebhookStub(t *testing.T, status int) *webhookStub {
+	t.Helper()
+	stub := &webhookStub{}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		stub.mu.Lock()
+		stub.requests = append(stub.requests, capturedRequest{Path: r.URL.Path, Query: r.URL.RawQuery, Header: r.Header.Clone(), Body: body})
+		stub.mu.Unlock()
+		if status != 0 {
+			w.WriteHeader(status)
+		}
+		w.Write([]byte(`{"errcode":0}`))
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+// received 返回已收到的请求副本
+func (s *webhookStub) received() []capturedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]capturedRequest(nil), s.requests...)
+}
@@ -0,0 +1,36 @@
+package notification
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultHTTPTimeout Webhook 类渠道请求的默认超时时间（秒）
+const DefaultHTTPTimeout = 10
+
+// webhookTransport Webhook 类渠道共用的连接池，重新加载配置后仍复用已建立的连接
+var webhookTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          50,
+	MaxIdleConnsPerHost:   10,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   5 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
+// newHTTPClient 创建 Webhook 请求使用的 HTTP 客户端，timeout（秒）不大于 0 时使用默认值
+func newHTTPClient(timeout int) *http.Client {
+	if timeout <= 0 {
+		timeout = DefaultHTTPTimeout
+	}
+	return &http.Client{
+		Timeout:   time.Duration(timeout) * time.Second,
+		Transport: webhookTransport,
+	}
+}
@@ -3,6 +3,7 @@ package notification
 import (
 	"fmt"
 	"opensearch-alert/pkg/types"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,96 +12,319 @@ import (
 
 // Notifier 通知器
 type Notifier struct {
-	email    *EmailNotifier
-	dingtalk *DingTalkNotifier
-	wechat   *WeChatNotifier
-	feishu   *FeishuNotifier
-	logger   *logrus.Logger
+	email     *EmailNotifier
+	dingtalk  *DingTalkNotifier
+	wechat    *WeChatNotifier
+	feishu    *FeishuNotifier
+	ntfy      *NtfyNotifier
+	pagerduty *PagerDutyNotifier
+	logger    *logrus.Logger
+	// environment 实例环境，写入告警数据
+	environment string
+	// mu 保护各渠道通知器，重新加载时等待进行中的发送完成
+	mu sync.RWMutex
 }
 
 // NewNotifier 创建新的通知器
 func NewNotifier(config *types.Config, logger *logrus.Logger) *Notifier {
-	return &Notifier{
-		email:    NewEmailNotifier(&config.Notifications.Email, logger),
-		dingtalk: NewDingTalkNotifier(&config.Notifications.DingTalk, logger),
-		wechat:   NewWeChatNotifier(&config.Notifications.WeChat, logger),
-		feishu:   NewFeishuNotifier(&config.Notifications.Feishu, logger),
-		logger:   logger,
+	n := &Notifier{logger: logger}
+	n.buildChannels(config)
+	n.startDigest(config)
+	return n
+}
+
+// Reload 按新配置重建各渠道通知器，修改 Webhook 地址、SMTP 密码等无需重启
+func (n *Notifier) Reload(config *types.Config) {
+	n.mu.Lock()
+	oldEmail := n.email
+	n.buildChannels(config)
+	n.mu.Unlock()
+
+	// 旧的汇总任务按原配置发出剩余告警
+	oldEmail.StopDigest()
+	n.startDigest(config)
+	n.logger.Info("通知配置已重新加载")
+}
+
+// buildChannels 根据配置构建各渠道通知器（复制渠道配置，与外部配置互不影响）
+func (n *Notifier) buildChannels(config *types.Config) {
+	location := config.Location()
+	notifications := config.Notifications
+
+	n.email = NewEmailNotifier(&notifications.Email, location, n.logger)
+	n.dingtalk = NewDingTalkNotifier(&notifications.DingTalk, location, n.logger)
+	n.wechat = NewWeChatNotifier(&notifications.WeChat, location, n.logger)
+	n.feishu = NewFeishuNotifier(&notifications.Feishu, location, n.logger)
+	n.ntfy = NewNtfyNotifier(&notifications.Ntfy, location, n.logger)
+	n.pagerduty = NewPagerDutyNotifier(&notifications.PagerDuty, location, n.logger)
+	n.environment = config.Environment
+
+	// 各渠道统一使用相同的环境前缀
+	prefix := config.NotificationPrefix()
+	n.email.prefix = prefix
+	n.dingtalk.prefix = prefix
+	n.wechat.prefix = prefix
+	n.feishu.prefix = prefix
+	n.ntfy.prefix = prefix
+	n.pagerduty.prefix = prefix
+
+	// Webhook 类渠道共用一个带超时的 HTTP 客户端，避免接口无响应时发送协程一直阻塞
+	client := newHTTPClient(notifications.HTTPTimeout)
+	n.dingtalk.client = client
+	n.wechat.client = client
+	n.feishu.client = client
+	n.ntfy.client = client
+	n.pagerduty.client = client
+
+	// 详情超出平台消息大小限制时截断，避免发送被拒绝
+	maxLength := notifications.MaxMessageLength
+	n.dingtalk.maxLength = maxLength
+	n.wechat.maxLength = maxLength
+	n.feishu.maxLength = maxLength
+	n.ntfy.maxLength = maxLength
+
+	// 级别图标与颜色统一取自级别元数据表（含 levels 配置覆盖）
+	levels := config.LevelTable()
+	n.email.levels = levels
+	n.dingtalk.levels = levels
+	n.wechat.levels = levels
+	n.feishu.levels = levels
+	n.ntfy.levels = levels
+
+	// 校验已启用的渠道，配置错误的渠道自动停用
+	n.validateChannels()
+}
+
+// startDigest 启动邮件汇总任务，窗口默认与规则执行周期一致
+func (n *Notifier) startDigest(config *types.Config) {
+	digestInterval := config.Notifications.Email.DigestInterval
+	if digestInterval <= 0 {
+		digestInterval = config.AlertEngine.RunInterval
+	}
+	if digestInterval <= 0 {
+		digestInterval = 60
 	}
+
+	n.mu.RLock()
+	email := n.email
+	n.mu.RUnlock()
+	email.StartDigest(time.Duration(digestInterval) * time.Second)
 }
 
-// SendAlert 发送告警
-func (n *Notifier) SendAlert(alert *types.Alert) error {
-	n.logger.Debugf("开始发送告警: %s (级别: %s)", alert.RuleName, alert.Level)
+// Stop 停止通知器，发送尚未发出的汇总邮件
+func (n *Notifier) Stop() {
+	n.mu.RLock()
+	email := n.email
+	n.mu.RUnlock()
+	email.StopDigest()
+}
+
+// ValidateChannels 校验已启用渠道的配置，配置错误的渠道在进程内停用；配置修复后再次调用可恢复
+func (n *Notifier) ValidateChannels() {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	n.validateChannels()
+}
+
+// validateChannels 校验各渠道（调用方持有锁）
+func (n *Notifier) validateChannels() {
+	n.validateChannel("邮件", n.email.config.Enabled, &n.email.guard, n.email.validateConfig)
+	n.validateChannel("钉钉", n.dingtalk.config.Enabled, &n.dingtalk.guard, n.dingtalk.validateConfig)
+	n.validateChannel("企业微信", n.wechat.config.Enabled, &n.wechat.guard, n.wechat.validateConfig)
+	n.validateChannel("飞书", n.feishu.config.Enabled, &n.feishu.guard, n.feishu.validateConfig)
+	n.validateChannel("ntfy ", n.ntfy.config.Enabled, &n.ntfy.guard, n.ntfy.validateConfig)
+	n.validateChannel("PagerDuty ", n.pagerduty.config.Enabled, &n.pagerduty.guard, n.pagerduty.validateConfig)
+}
+
+// validateChannel 校验单个渠道并更新停用状态
+func (n *Notifier) validateChannel(label string, configured bool, guard *channelGuard, validate func() error) {
+	if !configured {
+		guard.clear()
+		return
+	}
+	if err := validate(); err != nil {
+		guard.disable(err.Error())
+		n.logger.Warnf("⚠️  %s通知配置错误，已自动停用该渠道: %v", label, err)
+		return
+	}
+	if guard.disabledReason() != "" {
+		n.logger.Infof("%s通知配置已修复，重新启用该渠道", label)
+	}
+	guard.clear()
+}
 
+// ChannelStatuses 返回各渠道的配置状态与实际生效状态
+func (n *Notifier) ChannelStatuses() map[string]ChannelStatus {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	status := func(configured bool, guard *channelGuard) ChannelStatus {
+		reason := guard.disabledReason()
+		return ChannelStatus{Configured: configured, Effective: configured && reason == "", Error: reason}
+	}
+	return map[string]ChannelStatus{
+		"email":     status(n.email.config.Enabled, &n.email.guard),
+		"dingtalk":  status(n.dingtalk.config.Enabled, &n.dingtalk.guard),
+		"wechat":    status(n.wechat.config.Enabled, &n.wechat.guard),
+		"feishu":    status(n.feishu.config.Enabled, &n.feishu.guard),
+		"ntfy":      status(n.ntfy.config.Enabled, &n.ntfy.guard),
+		"pagerduty": status(n.pagerduty.config.Enabled, &n.pagerduty.guard),
+	}
+}
+
+// channelSender 单个渠道的启用状态与发送方法
+type channelSender struct {
+	enabled func() bool
+	accepts func(alert *types.Alert) bool
+	send    func(alert *types.Alert) error
+}
+
+// ChannelNames 支持的通知渠道名称
+var ChannelNames = []string{"email", "dingtalk", "wechat", "feishu", "ntfy", "pagerduty"}
+
+// senders 按渠道名称返回发送器
+func (n *Notifier) senders() map[string]channelSender {
+	return map[string]channelSender{
+		"email":     {n.email.IsEnabled, n.email.Accepts, n.email.Send},
+		"dingtalk":  {n.dingtalk.IsEnabled, n.dingtalk.Accepts, n.dingtalk.Send},
+		"wechat":    {n.wechat.IsEnabled, n.wechat.Accepts, n.wechat.Send},
+		"feishu":    {n.feishu.IsEnabled, n.feishu.Accepts, n.feishu.Send},
+		"ntfy":      {n.ntfy.IsEnabled, n.ntfy.Accepts, n.ntfy.Send},
+		"pagerduty": {n.pagerduty.IsEnabled, n.pagerduty.Accepts, n.pagerduty.Send},
+	}
+}
+
+// SendAlertToChannels 将告警发送到指定渠道（为空表示全部启用的渠道），返回各渠道的发送结果
+func (n *Notifier) SendAlertToChannels(alert *types.Alert, channels []string) (map[string]error, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	senders := n.senders()
+	explicit := len(channels) > 0
+	if !explicit {
+		channels = ChannelNames
+	}
+	for _, name := range channels {
+		if _, ok := senders[name]; !ok {
+			return nil, fmt.Errorf("未知的通知渠道: %s（可选 %s）", name, strings.Join(ChannelNames, "/"))
+		}
+	}
+
+	n.tagEnvironment(alert)
+
+	results := make(map[string]error)
 	var wg sync.WaitGroup
-	var errors []error
 	var mu sync.Mutex
-
-	// 并发发送到所有启用的通知渠道
-	if n.email.IsEnabled() {
+	for _, name := range channels {
+		sender := senders[name]
+		if !sender.enabled() {
+			// 未指定渠道时跳过未启用的渠道，显式指定时返回错误
+			if explicit {
+				results[name] = fmt.Errorf("渠道未启用")
+			}
+			continue
+		}
+		// 低于渠道 min_level 的告警不发送，也不记录发送结果
+		if !sender.accepts(alert) {
+			n.logger.Debugf("告警 %s 级别 %s 低于渠道 %s 的 min_level，跳过", alert.RuleName, alert.Level, name)
+			continue
+		}
 		wg.Add(1)
-		go func() {
+		go func(name string, sender channelSender) {
 			defer wg.Done()
-			if err := n.email.Send(alert); err != nil {
-				mu.Lock()
-				errors = append(errors, err)
-				mu.Unlock()
-			}
-		}()
+			err := sender.send(alert)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, sender)
 	}
+	wg.Wait()
 
-	if n.dingtalk.IsEnabled() {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			if err := n.dingtalk.Send(alert); err != nil {
-				mu.Lock()
-				errors = append(errors, err)
-				mu.Unlock()
-			}
-		}()
+	return results, nil
+}
+
+// SendAlertTo 将告警仅发送到单个渠道，渠道未知、未启用或发送失败时返回错误
+func (n *Notifier) SendAlertTo(alert *types.Alert, channel string) error {
+	results, err := n.SendAlertToChannels(alert, []string{channel})
+	if err != nil {
+		return err
 	}
+	return results[channel]
+}
 
-	if n.wechat.IsEnabled() {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			if err := n.wechat.Send(alert); err != nil {
-				mu.Lock()
-				errors = append(errors, err)
-				mu.Unlock()
-			}
-		}()
+// SendAlert 发送告警到所有启用的渠道，返回各渠道的发送结果；有渠道失败时返回错误
+func (n *Notifier) SendAlert(alert *types.Alert) (map[string]error, error) {
+	n.logger.Debugf("开始发送告警: %s (级别: %s)", alert.RuleName, alert.Level)
+
+	results, err := n.SendAlertToChannels(alert, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	if n.feishu.IsEnabled() {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			if err := n.feishu.Send(alert); err != nil {
-				mu.Lock()
-				errors = append(errors, err)
-				mu.Unlock()
-			}
-		}()
+	var failed []string
+	for _, name := range ChannelNames {
+		if err := results[name]; err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return results, fmt.Errorf("部分通知发送失败: %s", strings.Join(failed, "; "))
 	}
+	return results, nil
+}
 
-	wg.Wait()
+// ResultRecords 将各渠道发送结果转换为落库记录（按渠道固定顺序）
+func ResultRecords(alertID string, results map[string]error) []types.NotificationResult {
+	now := time.Now()
+	records := make([]types.NotificationResult, 0, len(results))
+	for _, channel := range ChannelNames {
+		err, ok := results[channel]
+		if !ok {
+			continue
+		}
+		record := types.NotificationResult{AlertID: alertID, Channel: channel, Success: err == nil, SentAt: now}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		records = append(records, record)
+	}
+	return records
+}
 
-	// 如果有错误，记录但不中断流程
-	if len(errors) > 0 {
-		n.logger.Errorf("部分通知发送失败: %v", errors)
+// FailedChannels 所有渠道均发送失败时返回各渠道错误，否则（含没有任何渠道发送）返回 nil
+func FailedChannels(results map[string]error) map[string]string {
+	if len(results) == 0 {
+		return nil
 	}
+	errs := make(map[string]string, len(results))
+	for channel, err := range results {
+		if err == nil {
+			return nil
+		}
+		errs[channel] = err.Error()
+	}
+	return errs
+}
 
-	return nil
+// tagEnvironment 在告警数据中记录实例环境（随告警一并落库）
+func (n *Notifier) tagEnvironment(alert *types.Alert) {
+	if n.environment == "" {
+		return
+	}
+	if alert.Data == nil {
+		alert.Data = make(map[string]interface{})
+	}
+	alert.Data["environment"] = n.environment
 }
 
 // TestNotifications 测试所有启用的通知渠道
 func (n *Notifier) TestNotifications() error {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
 	// 创建测试告警
 	testAlert := &types.Alert{
-		ID:        "test-alert-" + fmt.Sprintf("%d", time.Now().Unix()),
+		ID:        types.NewAlertID("test-alert"),
 		RuleName:  "连接测试",
 		Level:     "Info",
 		Message:   "这是一条测试消息，用于验证通知渠道是否正常工作。",
@@ -113,6 +337,7 @@ func (n *Notifier) TestNotifications() error {
 		Matches: 1,
 	}
 
+	n.tagEnvironment(testAlert)
 	n.logger.Info("开始测试通知渠道...")
 
 	var wg sync.WaitGroup
@@ -183,6 +408,38 @@ func (n *Notifier) TestNotifications() error {
 		}()
 	}
 
+	// 测试 ntfy 通知
+	if n.ntfy.IsEnabled() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n.logger.Info("测试 ntfy 通知...")
+			if err := n.ntfy.Send(testAlert); err != nil {
+				mu.Lock()
+				errors = append(errors, fmt.Errorf("ntfy 通知测试失败: %w", err))
+				mu.Unlock()
+			} else {
+				n.logger.Info("✅ ntfy 通知测试成功")
+			}
+		}()
+	}
+
+	// 测试 PagerDuty 通知
+	if n.pagerduty.IsEnabled() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n.logger.Info("测试 PagerDuty 通知...")
+			if err := n.pagerduty.Send(testAlert); err != nil {
+				mu.Lock()
+				errors = append(errors, fmt.Errorf("PagerDuty 通知测试失败: %w", err))
+				mu.Unlock()
+			} else {
+				n.logger.Info("✅ PagerDuty 通知测试成功")
+			}
+		}()
+	}
+
 	wg.Wait()
 
 	if len(errors) > 0 {
@@ -0,0 +1,160 @@
+package notification
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestReloadSwapsDingTalkWebhook(t *testing.T) {
+	oldHook := newWebhookStub(t, 0)
+	newHook := newWebhookStub(t, 0)
+
+	config := &types.Config{}
+	config.Notifications.DingTalk = types.DingTalkConfig{Enabled: true, WebhookURL: oldHook.URL}
+	n := newTestNotifier(t, config)
+	if err := n.SendAlertTo(testAlert("High"), "dingtalk"); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+
+	updated := &types.Config{}
+	updated.Notifications.DingTalk = types.DingTalkConfig{Enabled: true, WebhookURL: newHook.URL}
+	n.Reload(updated)
+	if err := n.SendAlertTo(testAlert("High"), "dingtalk"); err != nil {
+		t.Fatalf("重新加载后发送失败: %v", err)
+	}
+
+	if got := len(oldHook.received()); got != 1 {
+		t.Errorf("旧 Webhook 应只收到重新加载前的 1 条消息，实际 %d 条", got)
+	}
+	if got := len(newHook.received()); got != 1 {
+		t.Errorf("新 Webhook 应收到重新加载后的 1 条消息，实际 %d 条", got)
+	}
+
+	// 修改传入的配置不影响已加载的渠道
+	updated.Notifications.DingTalk.WebhookURL = oldHook.URL
+	if err := n.SendAlertTo(testAlert("High"), "dingtalk"); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+	if got := len(newHook.received()); got != 2 {
+		t.Errorf("渠道应持有配置副本，新 Webhook 实际收到 %d 条", got)
+	}
+}
+
+func TestSendAlertMixedResults(t *testing.T) {
+	ok := newWebhookStub(t, 0)
+	broken := newWebhookStub(t, http.StatusInternalServerError)
+
+	config := &types.Config{}
+	config.Notifications.DingTalk = types.DingTalkConfig{Enabled: true, WebhookURL: ok.URL}
+	config.Notifications.WeChat = types.WeChatConfig{Enabled: true, WebhookURL: broken.URL}
+	n := newTestNotifier(t, config)
+
+	results, err := n.SendAlert(testAlert("High"))
+	if err == nil || !strings.Contains(err.Error(), "wechat") {
+		t.Fatalf("部分渠道失败时应返回包含失败渠道的错误，实际 %v", err)
+	}
+	// 只记录启用的渠道
+	if len(results) != 2 {
+		t.Fatalf("应返回 2 个渠道的结果，实际 %v", results)
+	}
+	if results["dingtalk"] != nil || results["wechat"] == nil {
+		t.Errorf("钉钉应成功、企业微信应失败，实际 %v", results)
+	}
+
+	records := ResultRecords("alert-1", results)
+	if len(records) != 2 || records[0].Channel != "dingtalk" || records[1].Channel != "wechat" {
+		t.Fatalf("记录应按渠道固定顺序排列: %+v", records)
+	}
+	if !records[0].Success || records[0].Error != "" {
+		t.Errorf("钉钉记录应为成功: %+v", records[0])
+	}
+	if records[1].Success || !strings.Contains(records[1].Error, "500") {
+		t.Errorf("企业微信记录应为失败并带状态码: %+v", records[1])
+	}
+
+	// 有渠道成功时不进入死信，全部失败时返回各渠道错误
+	if failed := FailedChannels(results); failed != nil {
+		t.Errorf("部分成功时不应视为全部失败: %v", failed)
+	}
+	if failed := FailedChannels(map[string]error{"wechat": results["wechat"]}); len(failed) != 1 {
+		t.Errorf("全部失败时应返回各渠道错误: %v", failed)
+	}
+}
+
+func TestMinLevelSkipsLowerAlerts(t *testing.T) {
+	paged := newWebhookStub(t, 0)
+	all := newWebhookStub(t, 0)
+
+	config := &types.Config{}
+	config.Notifications.DingTalk = types.DingTalkConfig{Enabled: true, WebhookURL: paged.URL, MinLevel: "High"}
+	config.Notifications.WeChat = types.WeChatConfig{Enabled: true, WebhookURL: all.URL}
+	n := newTestNotifier(t, config)
+
+	results, err := n.SendAlert(testAlert("Low"))
+	if err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+	if _, ok := results["dingtalk"]; ok {
+		t.Errorf("低于 min_level 的渠道不应记录发送结果: %v", results)
+	}
+	if got := len(paged.received()); got != 0 {
+		t.Errorf("Low 告警不应发往 min_level=High 的钉钉，实际 %d 条", got)
+	}
+	if got := len(all.received()); got != 1 {
+		t.Errorf("未配置 min_level 的企业微信应收到告警，实际 %d 条", got)
+	}
+
+	for _, level := range []string{"High", "Critical"} {
+		if _, err := n.SendAlert(testAlert(level)); err != nil {
+			t.Fatalf("发送失败: %v", err)
+		}
+	}
+	if got := len(paged.received()); got != 2 {
+		t.Errorf("High 及以上的告警应发往钉钉，实际 %d 条", got)
+	}
+
+	// 测试告警不受 min_level 限制
+	probe := testAlert("Info")
+	probe.Data["test"] = true
+	if err := n.SendAlertTo(probe, "dingtalk"); err != nil {
+		t.Fatalf("发送测试告警失败: %v", err)
+	}
+	if got := len(paged.received()); got != 3 {
+		t.Errorf("测试告警应忽略 min_level，实际钉钉共收到 %d 条", got)
+	}
+}
+
+func TestSlowWebhookTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+
+	config := &types.Config{}
+	config.Notifications.HTTPTimeout = 1
+	config.Notifications.DingTalk = types.DingTalkConfig{Enabled: true, WebhookURL: slow.URL}
+	n := newTestNotifier(t, config)
+
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() { done <- n.SendAlertTo(testAlert("High"), "dingtalk") }()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("接口无响应时应返回超时错误")
+		}
+		if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+			t.Errorf("应在配置的超时后才返回，实际 %v", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("发送未在超时后返回，协程被阻塞")
+	}
+}
@@ -0,0 +1,146 @@
+package notification
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"opensearch-alert/pkg/types"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NtfyNotifier ntfy 推送通知器
+type NtfyNotifier struct {
+	config   *types.NtfyConfig
+	logger   *logrus.Logger
+	guard    channelGuard
+	location *time.Location
+	// client 共用的 HTTP 客户端（带超时），由 Notifier 统一设置
+	client *http.Client
+	// prefix 环境前缀，由 Notifier 统一设置
+	prefix string
+	// maxLength 详情长度上限（字节），由 Notifier 统一设置；0 表示使用渠道默认值
+	maxLength int
+	// levels 级别图标与颜色，由 Notifier 统一设置（为空时使用内置表）
+	levels types.LevelTable
+}
+
+// NewNtfyNotifier 创建 ntfy 通知器
+func NewNtfyNotifier(config *types.NtfyConfig, location *time.Location, logger *logrus.Logger) *NtfyNotifier {
+	return &NtfyNotifier{
+		config:   config,
+		logger:   logger,
+		location: location,
+		client:   newHTTPClient(DefaultHTTPTimeout),
+	}
+}
+
+// validateConfig 验证 ntfy 配置
+func (n *NtfyNotifier) validateConfig() error {
+	u, err := url.Parse(n.config.ServerURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("server_url 必须是 http(s) 地址")
+	}
+	if strings.TrimSpace(n.config.Topic) == "" {
+		return fmt.Errorf("topic 不能为空")
+	}
+	if n.config.Priority < 0 || n.config.Priority > 5 {
+		return fmt.Errorf("priority 必须在 1-5 之间: %d", n.config.Priority)
+	}
+	return nil
+}
+
+// IsEnabled 检查是否启用
+func (n *NtfyNotifier) IsEnabled() bool {
+	return n.config.Enabled && n.guard.disabledReason() == ""
+}
+
+// Accepts 告警级别是否达到渠道的 min_level
+func (n *NtfyNotifier) Accepts(alert *types.Alert) bool {
+	return meetsMinLevel(alert, n.config.MinLevel)
+}
+
+// Send 以纯文本消息发布到 ntfy 主题，标题、优先级与标签通过请求头传递
+func (n *NtfyNotifier) Send(alert *types.Alert) error {
+	if !n.IsEnabled() || !n.Accepts(alert) {
+		return nil
+	}
+
+	endpoint := strings.TrimRight(n.config.ServerURL, "/") + "/" + url.PathEscape(n.config.Topic)
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(n.buildMessage(alert)))
+	if err != nil {
+		return fmt.Errorf("创建 ntfy 请求失败: %w", err)
+	}
+	// 请求头只能是 ASCII，中文标题按 RFC 2047 编码（ntfy 会自动解码）
+	req.Header.Set("Title", mime.QEncoding.Encode("utf-8", alertTitle(n.prefix, alert)+" - "+alert.RuleName))
+	req.Header.Set("Priority", strconv.Itoa(n.priority(alert)))
+	req.Header.Set("Tags", strings.Join(n.tags(alert), ","))
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if n.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.config.Token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送 ntfy 消息失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ntfy 消息发送失败，状态码: %d，响应: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	n.logger.Infof("ntfy 告警已发送: %s", alert.RuleName)
+	return nil
+}
+
+// buildMessage 构建纯文本消息内容
+func (n *NtfyNotifier) buildMessage(alert *types.Alert) string {
+	return fmt.Sprintf("规则: %s\n级别: %s\n%s: %s\n匹配: %d\n\n%s",
+		alert.RuleName, alert.Level, timeLabel(alert),
+		alert.Timestamp.In(n.location).Format("2006-01-02 15:04:05"),
+		alert.Count, truncateMessage(markdownToPlain(alert.Message), messageLimit(n.maxLength, ntfyMaxDetailBytes)))
+}
+
+// priority 消息优先级：配置了固定优先级时使用配置，否则按告警级别映射（Critical=5 … Info=1）
+func (n *NtfyNotifier) priority(alert *types.Alert) int {
+	if n.config.Priority > 0 {
+		return n.config.Priority
+	}
+	// 恢复通知与未知级别使用默认优先级 3
+	rank := types.LevelRank(alert.Level)
+	if alert.Resolved || rank == 0 {
+		return 3
+	}
+	return rank
+}
+
+// tags 消息标签：级别对应的 emoji 短代码与级别名称，恢复通知为绿色对勾
+func (n *NtfyNotifier) tags(alert *types.Alert) []string {
+	if alert.Resolved {
+		return []string{"white_check_mark", "resolved"}
+	}
+	var emoji string
+	switch types.LevelRank(alert.Level) {
+	case 5:
+		emoji = "rotating_light"
+	case 4:
+		emoji = "warning"
+	case 3:
+		emoji = "large_orange_diamond"
+	case 2:
+		emoji = "large_blue_diamond"
+	default:
+		emoji = "information_source"
+	}
+	if alert.Level == "" {
+		return []string{emoji}
+	}
+	return []string{emoji, strings.ToLower(alert.Level)}
+}
@@ -0,0 +1,86 @@
+package notification
+
+import (
+	"mime"
+	"strings"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// sendNtfy 通过桩服务发送告警并返回收到的请求
+func sendNtfy(t *testing.T, config types.NtfyConfig, alert *types.Alert) capturedRequest {
+	t.Helper()
+	stub := newWebhookStub(t, 0)
+	config.Enabled = true
+	config.ServerURL = stub.URL + "/"
+	n := NewNtfyNotifier(&config, time.UTC, newTestLogger())
+	if err := n.Send(alert); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+	requests := stub.received()
+	if len(requests) != 1 {
+		t.Fatalf("应发送 1 条消息，实际 %d 条", len(requests))
+	}
+	return requests[0]
+}
+
+func TestNtfyHeaders(t *testing.T) {
+	req := sendNtfy(t, types.NtfyConfig{Topic: "ops alerts", Token: "tk_123"}, testAlert("Critical"))
+
+	if req.Path != "/ops alerts" {
+		t.Errorf("请求路径 = %q, 期望发布到主题", req.Path)
+	}
+	title, err := new(mime.WordDecoder).DecodeHeader(req.Header.Get("Title"))
+	if err != nil || title != "KubeSphere-OpenSearch 告警通知 - error-logs" {
+		t.Errorf("Title 解码后 = %q (err=%v)", title, err)
+	}
+	for header, want := range map[string]string{
+		"Priority":      "5",
+		"Tags":          "rotating_light,critical",
+		"Authorization": "Bearer tk_123",
+		"Content-Type":  "text/plain; charset=utf-8",
+	} {
+		if got := req.Header.Get(header); got != want {
+			t.Errorf("%s = %q, 期望 %q", header, got, want)
+		}
+	}
+	body := string(req.Body)
+	if !strings.Contains(body, "规则: error-logs") || strings.Contains(body, "**") {
+		t.Errorf("消息体应为去掉 Markdown 的纯文本:\n%s", body)
+	}
+}
+
+func TestNtfyPriorityMapping(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority int
+		level    string
+		resolved bool
+		want     string
+		tags     string
+	}{
+		{"按级别映射", 0, "Low", false, "2", "large_blue_diamond,low"},
+		{"级别不区分大小写", 0, "high", false, "4", "warning,high"},
+		{"固定优先级", 1, "Critical", false, "1", "rotating_light,critical"},
+		{"恢复通知", 0, "Critical", true, "3", "white_check_mark,resolved"},
+		{"未知级别", 0, "Custom", false, "3", "information_source,custom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := testAlert(tt.level)
+			alert.Resolved = tt.resolved
+			req := sendNtfy(t, types.NtfyConfig{Topic: "alerts", Priority: tt.priority}, alert)
+			if got := req.Header.Get("Priority"); got != tt.want {
+				t.Errorf("Priority = %q, 期望 %q", got, tt.want)
+			}
+			if got := req.Header.Get("Tags"); got != tt.tags {
+				t.Errorf("Tags = %q, 期望 %q", got, tt.tags)
+			}
+			if req.Header.Get("Authorization") != "" {
+				t.Error("未配置 token 时不应发送 Authorization")
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"opensearch-alert/pkg/types"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultPagerDutyEventsURL PagerDuty Events API v2 默认地址
+	defaultPagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	// maxPagerDutySummary summary 字段长度上限（PagerDuty 限制 1024 字符）
+	maxPagerDutySummary = 1024
+)
+
+// PagerDutyNotifier PagerDuty Events API v2 通知器
+type PagerDutyNotifier struct {
+	config   *types.PagerDutyConfig
+	logger   *logrus.Logger
+	guard    channelGuard
+	location *time.Location
+	// client 共用的 HTTP 客户端（带超时），由 Notifier 统一设置
+	client *http.Client
+	// prefix 环境前缀，由 Notifier 统一设置
+	prefix string
+}
+
+// NewPagerDutyNotifier 创建 PagerDuty 通知器
+func NewPagerDutyNotifier(config *types.PagerDutyConfig, location *time.Location, logger *logrus.Logger) *PagerDutyNotifier {
+	return &PagerDutyNotifier{
+		config:   config,
+		logger:   logger,
+		location: location,
+		client:   newHTTPClient(DefaultHTTPTimeout),
+	}
+}
+
+// validateConfig 验证 PagerDuty 配置
+func (p *PagerDutyNotifier) validateConfig() error {
+	if strings.TrimSpace(p.config.RoutingKey) == "" {
+		return fmt.Errorf("routing_key 不能为空")
+	}
+	if p.config.EventsURL != "" {
+		if err := validateWebhookURL(p.config.EventsURL); err != nil {
+			return fmt.Errorf("events_url 无效: %w", err)
+		}
+	}
+	return nil
+}
+
+// IsEnabled 检查是否启用
+func (p *PagerDutyNotifier) IsEnabled() bool {
+	return p.config.Enabled && p.guard.disabledReason() == ""
+}
+
+// Accepts 告警级别是否达到渠道的 min_level
+func (p *PagerDutyNotifier) Accepts(alert *types.Alert) bool {
+	return meetsMinLevel(alert, p.config.MinLevel)
+}
+
+// Send 发送 trigger 事件；恢复通知发送 resolve 事件，按相同 dedup_key 关闭对应的 incident
+func (p *PagerDutyNotifier) Send(alert *types.Alert) error {
+	if !p.IsEnabled() || !p.Accepts(alert) {
+		return nil
+	}
+
+	jsonData, err := json.Marshal(p.buildEvent(alert))
+	if err != nil {
+		return fmt.Errorf("序列化 PagerDuty 事件失败: %w", err)
+	}
+
+	resp, err := p.client.Post(p.eventsURL(), "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("发送 PagerDuty 事件失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Events API 成功时返回 202
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("PagerDuty 事件发送失败，状态码: %d，响应: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	p.logger.Infof("PagerDuty 事件已发送: %s (%s)", alert.RuleName, pagerDutyAction(alert))
+	return nil
+}
+
+// eventsURL Events API 地址
+func (p *PagerDutyNotifier) eventsURL() string {
+	if p.config.EventsURL != "" {
+		return p.config.EventsURL
+	}
+	return defaultPagerDutyEventsURL
+}
+
+// buildEvent 构建 Events API v2 事件
+func (p *PagerDutyNotifier) buildEvent(alert *types.Alert) map[string]interface{} {
+	event := map[string]interface{}{
+		"routing_key":  p.config.RoutingKey,
+		"event_action": pagerDutyAction(alert),
+		"dedup_key":    pagerDutyDedupKey(alert),
+	}
+	// resolve 事件只需 dedup_key
+	if alert.Resolved {
+		return event
+	}
+
+	summary := withPrefix(p.prefix, fmt.Sprintf("[%s] %s：匹配 %d 条记录", alert.Level, alert.RuleName, alert.Count))
+	if len([]rune(summary)) > maxPagerDutySummary {
+		summary = string([]rune(summary)[:maxPagerDutySummary])
+	}
+
+	source := "opensearch-alert"
+	if env, ok := alert.Data["environment"].(string); ok && env != "" {
+		source = "opensearch-alert/" + env
+	}
+
+	details := map[string]interface{}{
+		"alert_id": alert.ID,
+		"rule":     alert.RuleName,
+		"level":    alert.Level,
+		"count":    alert.Count,
+		"message":  markdownToPlain(alert.Message),
+	}
+	if key, ok := alert.Data["query_key"]; ok {
+		details["query_key"] = key
+	}
+
+	event["payload"] = map[string]interface{}{
+		"summary":        summary,
+		"source":         source,
+		"severity":       PagerDutySeverity(alert.Level),
+		"timestamp":      alert.Timestamp.Format(time.RFC3339),
+		"component":      alert.RuleName,
+		"custom_details": details,
+	}
+	return event
+}
+
+// pagerDutyAction 事件类型：恢复通知为 resolve，其余为 trigger
+func pagerDutyAction(alert *types.Alert) string {
+	if alert.Resolved {
+		return "resolve"
+	}
+	return "trigger"
+}
+
+// pagerDutyDedupKey 按规则名生成：规则的告警（含不同 query_key 对象）合并到同一个 incident，
+// 恢复按规则整体判定，resolve 事件以同一键关闭该 incident
+func pagerDutyDedupKey(alert *types.Alert) string {
+	return "opensearch-alert/" + alert.RuleName
+}
+
+// PagerDutySeverity 告警级别映射为 PagerDuty severity（critical/error/warning/info）
+func PagerDutySeverity(level string) string {
+	switch types.LevelRank(level) {
+	case 5:
+		return "critical"
+	case 4:
+		return "error"
+	case 3:
+		return "warning"
+	default:
+		return "info"
+	}
+}
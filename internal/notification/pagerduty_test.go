@@ -0,0 +1,85 @@
+package notification
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestPagerDutyTriggerPayload(t *testing.T) {
+	stub := newWebhookStub(t, 202)
+	p := NewPagerDutyNotifier(&types.PagerDutyConfig{Enabled: true, RoutingKey: "R0UT1NG", EventsURL: stub.URL + "/v2/enqueue"}, time.UTC, newTestLogger())
+	p.prefix = "[PROD]"
+
+	alert := testAlert("High")
+	alert.Data = map[string]interface{}{"environment": "prod", "query_key": "host-1"}
+	if err := p.Send(alert); err != nil {
+		t.Fatalf("202 应视为发送成功: %v", err)
+	}
+	requests := stub.received()
+	if len(requests) != 1 || requests[0].Path != "/v2/enqueue" {
+		t.Fatalf("应向 events_url 发送 1 个事件，实际 %+v", requests)
+	}
+
+	var event struct {
+		RoutingKey  string `json:"routing_key"`
+		EventAction string `json:"event_action"`
+		DedupKey    string `json:"dedup_key"`
+		Payload     struct {
+			Summary       string                 `json:"summary"`
+			Source        string                 `json:"source"`
+			Severity      string                 `json:"severity"`
+			Timestamp     string                 `json:"timestamp"`
+			Component     string                 `json:"component"`
+			CustomDetails map[string]interface{} `json:"custom_details"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(requests[0].Body, &event); err != nil {
+		t.Fatalf("事件不是合法的 JSON: %v", err)
+	}
+	if event.RoutingKey != "R0UT1NG" || event.EventAction != "trigger" || event.DedupKey != "opensearch-alert/error-logs" {
+		t.Errorf("事件头部字段不符: %+v", event)
+	}
+	payload := event.Payload
+	if payload.Summary != "[PROD] [High] error-logs：匹配 3 条记录" {
+		t.Errorf("summary = %q", payload.Summary)
+	}
+	if payload.Source != "opensearch-alert/prod" || payload.Severity != "error" || payload.Component != "error-logs" {
+		t.Errorf("payload 字段不符: %+v", payload)
+	}
+	if payload.Timestamp != "2024-05-01T08:00:00Z" {
+		t.Errorf("timestamp = %q, 期望 RFC3339", payload.Timestamp)
+	}
+	details := payload.CustomDetails
+	if details["alert_id"] != "alert-1" || details["query_key"] != "host-1" || details["count"] != float64(3) {
+		t.Errorf("custom_details 不符: %v", details)
+	}
+}
+
+func TestPagerDutyRejectedEvent(t *testing.T) {
+	stub := newWebhookStub(t, 400)
+	p := NewPagerDutyNotifier(&types.PagerDutyConfig{Enabled: true, RoutingKey: "bad", EventsURL: stub.URL}, time.UTC, newTestLogger())
+	if err := p.Send(testAlert("High")); err == nil {
+		t.Error("Events API 返回 400 时应报错")
+	}
+}
+
+func TestPagerDutySeverity(t *testing.T) {
+	tests := map[string]string{
+		"Critical": "critical",
+		"CRITICAL": "critical",
+		"High":     "error",
+		"Medium":   "warning",
+		"Low":      "info",
+		"Info":     "info",
+		"":         "info",
+		"Custom":   "info",
+	}
+	for level, want := range tests {
+		if got := PagerDutySeverity(level); got != want {
+			t.Errorf("PagerDutySeverity(%q) = %q, 期望 %q", level, got, want)
+		}
+	}
+}
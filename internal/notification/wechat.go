@@ -6,48 +6,91 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"opensearch-alert/pkg/types"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
 // WeChatNotifier 企业微信通知器
 type WeChatNotifier struct {
-	config *types.WeChatConfig
-	logger *logrus.Logger
+	config   *types.WeChatConfig
+	logger   *logrus.Logger
+	guard    channelGuard
+	location *time.Location
+	// client 共用的 HTTP 客户端（带超时），由 Notifier 统一设置
+	client *http.Client
+	// prefix 环境前缀，由 Notifier 统一设置
+	prefix string
+	// maxLength 详情长度上限（字节），由 Notifier 统一设置；0 表示使用渠道默认值
+	maxLength int
+	// levels 级别图标与颜色，由 Notifier 统一设置（为空时使用内置表）
+	levels types.LevelTable
 }
 
 // NewWeChatNotifier 创建企业微信通知器
-func NewWeChatNotifier(config *types.WeChatConfig, logger *logrus.Logger) *WeChatNotifier {
+func NewWeChatNotifier(config *types.WeChatConfig, location *time.Location, logger *logrus.Logger) *WeChatNotifier {
 	return &WeChatNotifier{
-		config: config,
-		logger: logger,
+		config:   config,
+		logger:   logger,
+		location: location,
+		client:   newHTTPClient(DefaultHTTPTimeout),
 	}
 }
 
+// validateConfig 验证企业微信配置
+func (w *WeChatNotifier) validateConfig() error {
+	return validateWebhookURL(w.config.WebhookURL)
+}
+
 // IsEnabled 检查是否启用
 func (w *WeChatNotifier) IsEnabled() bool {
-	return w.config.Enabled
+	return w.config.Enabled && w.guard.disabledReason() == ""
+}
+
+// Accepts 告警级别是否达到渠道的 min_level
+func (w *WeChatNotifier) Accepts(alert *types.Alert) bool {
+	return meetsMinLevel(alert, w.config.MinLevel)
 }
 
 // Send 发送企业微信消息
 func (w *WeChatNotifier) Send(alert *types.Alert) error {
-	if !w.IsEnabled() {
+	if !w.IsEnabled() || !w.Accepts(alert) {
 		return nil
 	}
 
-	// 构建消息
-	message := w.buildWeChatMessage(alert)
+	// 构建消息：默认 text；markdown 模式下需要 @ 时追加一条仅含 @ 的 text 消息
+	var messages []map[string]interface{}
+	if w.config.UseMarkdown {
+		messages = append(messages, w.buildWeChatMarkdown(alert))
+		if mention := w.buildMentionMessage(alert); mention != nil {
+			messages = append(messages, mention)
+		}
+	} else {
+		messages = append(messages, w.buildWeChatMessage(alert))
+	}
+
+	for _, message := range messages {
+		if err := w.post(message); err != nil {
+			return err
+		}
+	}
 
-	// 发送请求
+	w.logger.Infof("企业微信告警已发送: %s", alert.RuleName)
+	return nil
+}
+
+// post 发送单条消息到企业微信机器人
+func (w *WeChatNotifier) post(message map[string]interface{}) error {
 	jsonData, err := json.Marshal(message)
 	if err != nil {
 		return fmt.Errorf("序列化消息失败: %w", err)
 	}
 
-	resp, err := http.Post(w.config.WebhookURL, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := w.client.Post(w.config.WebhookURL, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("发送企业微信消息失败: %w", err)
 	}
@@ -60,25 +103,65 @@ func (w *WeChatNotifier) Send(alert *types.Alert) error {
 	// 读取响应内容用于调试
 	body, _ := io.ReadAll(resp.Body)
 	w.logger.Debugf("企业微信消息发送成功，响应: %s", string(body))
-
-	w.logger.Infof("企业微信告警已发送: %s", alert.RuleName)
 	return nil
 }
 
 // buildWeChatMessage 构建企业微信消息
 func (w *WeChatNotifier) buildWeChatMessage(alert *types.Alert) map[string]interface{} {
 	// 构建文本内容，使用表情+标签格式，并包含简要详情
-	content := fmt.Sprintf("%s KubeSphere-OpenSearch 告警通知\n\n"+
+	content := fmt.Sprintf("%s %s\n\n"+
 		"🏷️ 规则: %s\n"+
 		"%s 级别: %s\n"+
 		"🕒 时间: %s\n"+
 		"📈 匹配: %d\n\n"+
 		"📝 详情:\n%s",
-		w.getLevelEmoji(alert.Level), alert.RuleName,
+		titleEmoji(alert, w.getLevelEmoji(alert.Level)), alertTitle(w.prefix, alert), alert.RuleName,
 		w.getLevelEmoji(alert.Level), alert.Level,
-		alert.Timestamp.Format("2006-01-02 15:04:05"),
-		alert.Count, w.formatMessageContent(alert.Message))
+		alert.Timestamp.In(w.location).Format("2006-01-02 15:04:05"),
+		alert.Count, truncateMessage(w.formatMessageContent(alert.Message), messageLimit(w.maxLength, weChatTextMaxDetailBytes)))
 
+	return w.textMessage(content, alert)
+}
+
+// buildWeChatMarkdown 构建企业微信 markdown 消息，保留加粗并附带管理台链接
+func (w *WeChatNotifier) buildWeChatMarkdown(alert *types.Alert) map[string]interface{} {
+	content := fmt.Sprintf("**%s %s**\n"+
+		"> 🏷️ **规则:** %s\n"+
+		"> %s **级别:** <font color=\"%s\">%s</font>\n"+
+		"> 🕒 **时间:** %s\n"+
+		"> 📈 **匹配:** %d\n\n"+
+		"📝 **详情:**\n%s",
+		titleEmoji(alert, w.getLevelEmoji(alert.Level)), alertTitle(w.prefix, alert),
+		alert.RuleName,
+		w.getLevelEmoji(alert.Level), w.levelColor(alert), alert.Level,
+		alert.Timestamp.In(w.location).Format("2006-01-02 15:04:05"),
+		alert.Count, truncateMessage(w.formatMarkdownContent(alert.Message), messageLimit(w.maxLength, weChatMarkdownMaxDetailBytes)))
+
+	if w.config.DashboardBaseURL != "" {
+		link := strings.TrimRight(w.config.DashboardBaseURL, "/") + "/alerts?rule=" + url.QueryEscape(alert.RuleName)
+		content += fmt.Sprintf("\n\n[在管理台中查看](%s)", link)
+	}
+
+	return map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]interface{}{
+			"content": content,
+		},
+	}
+}
+
+// buildMentionMessage 构建仅用于 @ 的 text 消息，无需 @ 时返回 nil
+func (w *WeChatNotifier) buildMentionMessage(alert *types.Alert) map[string]interface{} {
+	message := w.textMessage(fmt.Sprintf("%s 请关注告警: %s", w.getLevelEmoji(alert.Level), alert.RuleName), alert)
+	text := message["text"].(map[string]interface{})
+	if text["mentioned_list"] == nil && text["mentioned_mobile_list"] == nil {
+		return nil
+	}
+	return message
+}
+
+// textMessage 构建 text 消息并按级别附加 @ 信息
+func (w *WeChatNotifier) textMessage(content string, alert *types.Alert) map[string]interface{} {
 	// 构建消息体
 	message := map[string]interface{}{
 		"msgtype": "text",
@@ -92,7 +175,7 @@ func (w *WeChatNotifier) buildWeChatMessage(alert *types.Alert) map[string]inter
 	mentionedMobileList := []string{}
 
 	// 只有严重告警才@用户
-	if w.shouldAtUser(alert.Level) {
+	if !alert.Resolved && w.shouldAtUser(alert.Level) {
 		// 如果配置了@所有人，则@所有人
 		if w.config.AtAll {
 			mentionedList = []string{"@all"}
@@ -119,46 +202,38 @@ func (w *WeChatNotifier) buildWeChatMessage(alert *types.Alert) map[string]inter
 	return message
 }
 
-// formatMessageContent 格式化消息内容，将Markdown格式转换为纯文本
-func (w *WeChatNotifier) formatMessageContent(message string) string {
-	// 将Markdown格式转换为纯文本格式
-	formatted := message
-
-	// 移除粗体标记 **text** -> text
-	formatted = strings.ReplaceAll(formatted, "**", "")
-
-	// 移除代码块标记 ``` -> 空行
-	formatted = strings.ReplaceAll(formatted, "```", "")
-
-	// 移除分隔线标记 '---' 以及日志中仅由横线组成的分割线
+// formatMarkdownContent 企业微信 markdown 不支持代码块与分隔线，移除这些标记但保留加粗
+func (w *WeChatNotifier) formatMarkdownContent(message string) string {
+	formatted := strings.ReplaceAll(message, "```", "")
 	formatted = strings.ReplaceAll(formatted, "---", "")
 	hyphenDivider := regexp.MustCompile(`(?m)^\s*-{6,}\s*$`)
 	formatted = hyphenDivider.ReplaceAllString(formatted, "")
-
-	// 清理多余的空行（将3个及以上连续换行压缩为2个）
 	multiEmptyLines := regexp.MustCompile(`\n{3,}`)
 	formatted = multiEmptyLines.ReplaceAllString(formatted, "\n\n")
+	return strings.TrimSpace(formatted)
+}
 
-	// 确保开头和结尾没有多余的空行
-	formatted = strings.TrimSpace(formatted)
+// levelColor 级别文字颜色，恢复通知使用绿色（info）
+func (w *WeChatNotifier) levelColor(alert *types.Alert) string {
+	if alert.Resolved {
+		return "info"
+	}
+	return w.getLevelColor(alert.Level)
+}
 
-	return formatted
+// getLevelColor markdown 中级别文字颜色（企业微信仅支持 info/comment/warning）
+func (w *WeChatNotifier) getLevelColor(level string) string {
+	return w.levels.Lookup(level).WeChatColor
+}
+
+// formatMessageContent 格式化消息内容，将Markdown格式转换为纯文本
+func (w *WeChatNotifier) formatMessageContent(message string) string {
+	return markdownToPlain(message)
 }
 
 // getLevelEmoji 不同级别对应的图标
 func (w *WeChatNotifier) getLevelEmoji(level string) string {
-	switch level {
-	case "Critical":
-		return "🚨"
-	case "High":
-		return "🚩"
-	case "Medium":
-		return "🔔"
-	case "Low", "Info":
-		return "ℹ️"
-	default:
-		return "🔔"
-	}
+	return w.levels.Lookup(level).Emoji
 }
 
 // extractK8sInfo 从 alert.Data.sample_hit 中提取 K8s 相关信息
@@ -0,0 +1,103 @@
+package notification
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// weChatMessage 企业微信机器人消息
+type weChatMessage struct {
+	MsgType string `json:"msgtype"`
+	Text    struct {
+		Content             string   `json:"content"`
+		MentionedList       []string `json:"mentioned_list"`
+		MentionedMobileList []string `json:"mentioned_mobile_list"`
+	} `json:"text"`
+	Markdown struct {
+		Content string `json:"content"`
+	} `json:"markdown"`
+}
+
+// sendWeChat 通过桩服务发送告警并解析收到的全部消息
+func sendWeChat(t *testing.T, config types.WeChatConfig, alert *types.Alert) []weChatMessage {
+	t.Helper()
+	stub := newWebhookStub(t, 0)
+	config.Enabled = true
+	config.WebhookURL = stub.URL
+	w := NewWeChatNotifier(&config, time.UTC, newTestLogger())
+	if err := w.Send(alert); err != nil {
+		t.Fatalf("发送失败: %v", err)
+	}
+
+	var messages []weChatMessage
+	for _, req := range stub.received() {
+		var message weChatMessage
+		if err := json.Unmarshal(req.Body, &message); err != nil {
+			t.Fatalf("消息不是合法的 JSON: %v", err)
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func TestWeChatTextMode(t *testing.T) {
+	messages := sendWeChat(t, types.WeChatConfig{
+		MentionedList:       []string{"zhangsan"},
+		MentionedMobileList: []string{"13800000000"},
+		DashboardBaseURL:    "https://alert.example.com",
+	}, testAlert("Critical"))
+
+	if len(messages) != 1 {
+		t.Fatalf("text 模式应只发送 1 条消息，实际 %d 条", len(messages))
+	}
+	message := messages[0]
+	if message.MsgType != "text" {
+		t.Fatalf("msgtype = %q, 期望 text", message.MsgType)
+	}
+	if strings.Contains(message.Text.Content, "**") || strings.Contains(message.Text.Content, "alerts?rule=") {
+		t.Errorf("text 消息不应包含 Markdown 标记或管理台链接:\n%s", message.Text.Content)
+	}
+	if !reflect.DeepEqual(message.Text.MentionedList, []string{"zhangsan"}) || !reflect.DeepEqual(message.Text.MentionedMobileList, []string{"13800000000"}) {
+		t.Errorf("text 消息应直接 @ 配置的用户，实际 %v / %v", message.Text.MentionedList, message.Text.MentionedMobileList)
+	}
+}
+
+func TestWeChatMarkdownModeWithMention(t *testing.T) {
+	messages := sendWeChat(t, types.WeChatConfig{
+		UseMarkdown:      true,
+		AtAll:            true,
+		DashboardBaseURL: "https://alert.example.com/",
+	}, testAlert("Critical"))
+
+	if len(messages) != 2 {
+		t.Fatalf("需要 @ 时应发送 markdown 与 text 两条消息，实际 %d 条", len(messages))
+	}
+	markdown, mention := messages[0], messages[1]
+	if markdown.MsgType != "markdown" {
+		t.Fatalf("第一条 msgtype = %q, 期望 markdown", markdown.MsgType)
+	}
+	for _, want := range []string{"**规则:** error-logs", "**错误日志**", "[在管理台中查看](https://alert.example.com/alerts?rule=error-logs)"} {
+		if !strings.Contains(markdown.Markdown.Content, want) {
+			t.Errorf("markdown 内容缺少 %q:\n%s", want, markdown.Markdown.Content)
+		}
+	}
+	if mention.MsgType != "text" || !reflect.DeepEqual(mention.Text.MentionedList, []string{"@all"}) {
+		t.Errorf("第二条应为 @所有人 的 text 消息，实际 %+v", mention)
+	}
+}
+
+func TestWeChatMarkdownModeWithoutMention(t *testing.T) {
+	// 级别不需要 @ 时只发送 markdown
+	messages := sendWeChat(t, types.WeChatConfig{UseMarkdown: true, AtAll: true}, testAlert("Low"))
+	if len(messages) != 1 || messages[0].MsgType != "markdown" {
+		t.Fatalf("不需要 @ 时应只发送 1 条 markdown 消息，实际 %+v", messages)
+	}
+	if strings.Contains(messages[0].Markdown.Content, "在管理台中查看") {
+		t.Error("未配置管理台地址时不应附带链接")
+	}
+}
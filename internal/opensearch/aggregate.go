@@ -0,0 +1,55 @@
+package opensearch
+
+import (
+	"context"
+	"fmt"
+	"opensearch-alert/pkg/types"
+)
+
+// MetricAggName 指标规则聚合在查询中的名称
+const MetricAggName = "metric_value"
+
+// MetricAggs 支持的指标聚合类型
+var MetricAggs = []string{"avg", "max", "min", "sum"}
+
+// IsMetricAgg 判断聚合类型是否受支持
+func IsMetricAgg(agg string) bool {
+	for _, a := range MetricAggs {
+		if a == agg {
+			return true
+		}
+	}
+	return false
+}
+
+// metricAggregation 构建指标聚合子句
+func metricAggregation(rule types.AlertRule) map[string]interface{} {
+	return map[string]interface{}{
+		MetricAggName: map[string]interface{}{
+			rule.MetricAgg: map[string]interface{}{"field": rule.MetricField},
+		},
+	}
+}
+
+// MetricValue 从响应中读取指标聚合值；窗口内没有文档时（聚合值为 null）返回 false
+func MetricValue(response *types.OpenSearchResponse) (float64, bool) {
+	agg, ok := response.Aggregations[MetricAggName].(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+	value, ok := agg["value"].(float64)
+	return value, ok
+}
+
+// Aggregate 执行带指标聚合的查询并返回聚合值
+func (c *Client) Aggregate(ctx context.Context, index string, query map[string]interface{}) (float64, error) {
+	response, err := c.Search(ctx, index, query)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := MetricValue(response)
+	if !ok {
+		return 0, fmt.Errorf("响应中没有聚合值 %s（时间窗口内可能没有文档）", MetricAggName)
+	}
+	return value, nil
+}
@@ -0,0 +1,68 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestBuildTimeRangeQueryMetricAggregation(t *testing.T) {
+	rule := types.AlertRule{Name: "latency", Type: "metric", Timeframe: 300, MetricAgg: "avg", MetricField: "response_ms"}
+	query := (&Client{}).BuildTimeRangeQuery(rule, 0)
+
+	want := map[string]interface{}{MetricAggName: map[string]interface{}{"avg": map[string]interface{}{"field": "response_ms"}}}
+	if !reflect.DeepEqual(query["aggs"], want) {
+		t.Errorf("aggs = %v, 期望 %v", query["aggs"], want)
+	}
+	if query["size"] != 1 {
+		t.Errorf("未设置 max_hits 的指标规则 size = %v, 期望 1", query["size"])
+	}
+
+	if _, ok := (&Client{}).BuildTimeRangeQuery(types.AlertRule{Name: "plain", Type: "any"}, 0)["aggs"]; ok {
+		t.Error("非指标规则不应追加聚合")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    float64
+		wantErr bool
+	}{
+		{name: "聚合值", body: `{"hits": {"total": {"value": 12}}, "aggregations": {"metric_value": {"value": 512.5}}}`, want: 512.5},
+		{name: "窗口内无文档", body: `{"hits": {"total": {"value": 0}}, "aggregations": {"metric_value": {"value": null}}}`, wantErr: true},
+		{name: "缺少聚合", body: `{"hits": {"total": {"value": 3}}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				json.Unmarshal(body, &request)
+				w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := NewClient(types.OpenSearchConfig{Host: server.URL, Timeout: 5})
+			if err != nil {
+				t.Fatalf("创建客户端失败: %v", err)
+			}
+			rule := types.AlertRule{Name: "queue", Type: "metric", Timeframe: 60, MetricAgg: "max", MetricField: "queue_depth"}
+			value, err := client.Aggregate(context.Background(), "metrics-*", client.BuildTimeRangeQuery(rule, 0))
+			if (err != nil) != tt.wantErr || value != tt.want {
+				t.Fatalf("Aggregate = %v, %v，期望 %v（wantErr=%v）", value, err, tt.want, tt.wantErr)
+			}
+			if _, ok := request["aggs"]; !ok {
+				t.Errorf("请求体缺少 aggs: %v", request)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestAuthHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		config types.OpenSearchConfig
+		want   string
+	}{
+		{"basic（默认）", types.OpenSearchConfig{Username: "admin", Password: "p@ss"}, "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:p@ss"))},
+		{"basic 显式指定", types.OpenSearchConfig{AuthType: "basic", Username: "admin", Password: "p@ss"}, "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:p@ss"))},
+		{"未配置凭据", types.OpenSearchConfig{}, ""},
+		{"apikey", types.OpenSearchConfig{AuthType: "apikey", APIKey: "aWQ6a2V5", Username: "ignored"}, "ApiKey aWQ6a2V5"},
+		{"bearer 大小写不敏感", types.OpenSearchConfig{AuthType: "Bearer", Token: "tok-123", Password: "ignored"}, "Bearer tok-123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = append(got, r.Header.Get("Authorization"))
+				if r.URL.Path == "/_cluster/health" {
+					w.Write([]byte(`{"status":"green"}`))
+					return
+				}
+				w.Write([]byte(`{"count":1}`))
+			}))
+			defer server.Close()
+
+			config := tt.config
+			config.Host = server.URL
+			config.Timeout = 5
+			client, err := NewClient(config)
+			if err != nil {
+				t.Fatalf("创建客户端失败: %v", err)
+			}
+			if err := client.HealthCheck(context.Background()); err != nil {
+				t.Fatalf("健康检查失败: %v", err)
+			}
+			if _, err := client.Count(context.Background(), "logs-*", map[string]interface{}{}); err != nil {
+				t.Fatalf("计数查询失败: %v", err)
+			}
+
+			// GET 与 POST 请求都应携带认证头
+			if len(got) != 2 || got[0] != tt.want || got[1] != tt.want {
+				t.Errorf("Authorization = %q, 期望均为 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthErrors(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		client, err := NewClient(types.OpenSearchConfig{Host: server.URL, Timeout: 5, AuthType: "bearer", Token: "expired"})
+		if err != nil {
+			t.Fatalf("创建客户端失败: %v", err)
+		}
+		_, err = client.Count(context.Background(), "logs-*", map[string]interface{}{})
+		server.Close()
+
+		if !IsAuthError(err) {
+			t.Errorf("%d 应返回认证错误，实际: %v", status, err)
+		}
+		if IsQueryError(err) {
+			t.Errorf("%d 不应视为查询错误", status)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package opensearch
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// queryCache 短时查询结果缓存：相同路径与请求体的查询在 TTL 内复用响应，并发的相同查询只请求一次
+type queryCache struct {
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry 缓存项，ready 关闭后 body/err 可读
+type cacheEntry struct {
+	ready   chan struct{}
+	body    []byte
+	err     error
+	expires time.Time
+}
+
+// newQueryCache 创建查询缓存，ttl 不大于 0 时返回 nil（不缓存）
+func newQueryCache(ttl time.Duration) *queryCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &queryCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+// get 返回 key 对应的响应体：命中未过期的缓存或进行中的相同查询时等待并复用，否则调用 fetch；失败的结果不缓存
+func (q *queryCache) get(ctx context.Context, key string, fetch func() ([]byte, error)) ([]byte, bool, error) {
+	q.mu.Lock()
+	if entry, ok := q.entries[key]; ok && !q.expired(entry) {
+		q.mu.Unlock()
+		select {
+		case <-entry.ready:
+			return entry.body, true, entry.err
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+	q.sweep()
+	entry := &cacheEntry{ready: make(chan struct{})}
+	q.entries[key] = entry
+	q.mu.Unlock()
+
+	body, err := fetch()
+
+	q.mu.Lock()
+	entry.body, entry.err = body, err
+	entry.expires = q.now().Add(q.ttl)
+	if err != nil && q.entries[key] == entry {
+		delete(q.entries, key)
+	}
+	q.mu.Unlock()
+	close(entry.ready)
+	return body, false, err
+}
+
+// expired 缓存项是否已完成且过期（调用方持有锁）
+func (q *queryCache) expired(entry *cacheEntry) bool {
+	select {
+	case <-entry.ready:
+		return !q.now().Before(entry.expires)
+	default:
+		return false
+	}
+}
+
+// sweep 清理过期的缓存项（调用方持有锁）
+func (q *queryCache) sweep() {
+	for key, entry := range q.entries {
+		if q.expired(entry) {
+			delete(q.entries, key)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package opensearch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestQueryCacheTTL(t *testing.T) {
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	cache := newQueryCache(10 * time.Second)
+	cache.now = func() time.Time { return now }
+
+	var fetches int
+	fetch := func() ([]byte, error) {
+		fetches++
+		return []byte("body"), nil
+	}
+
+	if _, hit, _ := cache.get(context.Background(), "k", fetch); hit {
+		t.Error("首次查询不应命中缓存")
+	}
+	now = now.Add(9 * time.Second)
+	body, hit, err := cache.get(context.Background(), "k", fetch)
+	if !hit || err != nil || string(body) != "body" || fetches != 1 {
+		t.Errorf("TTL 内应命中缓存: hit=%v body=%q err=%v fetches=%d", hit, body, err, fetches)
+	}
+	if _, hit, _ := cache.get(context.Background(), "other", fetch); hit || fetches != 2 {
+		t.Errorf("不同的查询不应共用缓存: hit=%v fetches=%d", hit, fetches)
+	}
+
+	now = now.Add(time.Second)
+	if _, hit, _ := cache.get(context.Background(), "k", fetch); hit || fetches != 3 {
+		t.Errorf("过期后应重新查询: hit=%v fetches=%d", hit, fetches)
+	}
+}
+
+func TestQueryCacheSkipsErrors(t *testing.T) {
+	cache := newQueryCache(time.Minute)
+	var fetches int
+	fetch := func() ([]byte, error) {
+		fetches++
+		return nil, errors.New("boom")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, hit, err := cache.get(context.Background(), "k", fetch); hit || err == nil {
+			t.Fatalf("失败的查询不应缓存: hit=%v err=%v", hit, err)
+		}
+	}
+	if fetches != 2 {
+		t.Errorf("失败后应重新查询，实际查询 %d 次", fetches)
+	}
+}
+
+func TestQueryCacheCoalescesConcurrentQueries(t *testing.T) {
+	cache := newQueryCache(time.Minute)
+	release := make(chan struct{})
+	var fetches int32
+	fetch := func() ([]byte, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return []byte("body"), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body, _, err := cache.get(context.Background(), "k", fetch); err != nil || string(body) != "body" {
+				t.Errorf("并发查询结果不正确: %q %v", body, err)
+			}
+		}()
+	}
+	// 等待所有调用方进入缓存后再放行查询
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Errorf("并发的相同查询应只请求一次，实际 %d 次", got)
+	}
+}
+
+func TestNewQueryCacheDisabled(t *testing.T) {
+	if newQueryCache(0) != nil {
+		t.Error("ttl 为 0 时不应创建缓存")
+	}
+}
+
+func TestClientQueryCache(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"count":7,"hits":{"total":{"value":1},"hits":[{"_source":{"log":"x"}}]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(types.OpenSearchConfig{Host: server.URL, Timeout: 5, QueryCacheTTL: 30})
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	query := map[string]interface{}{"query": map[string]interface{}{"match_all": map[string]interface{}{}}}
+
+	first, err := client.Search(context.Background(), "logs-*", query)
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	// 调用方修改响应不影响缓存中的结果
+	first.Hits.Hits[0].Source["log"] = "changed"
+	second, err := client.Search(context.Background(), "logs-*", query)
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if second.Hits.Hits[0].Source["log"] != "x" {
+		t.Errorf("缓存命中应返回独立的响应，实际 %v", second.Hits.Hits[0].Source["log"])
+	}
+
+	if _, err := client.Search(context.Background(), "other-*", query); err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if count, err := client.Count(context.Background(), "logs-*", query); err != nil || count != 7 {
+			t.Fatalf("计数查询结果不正确: %d %v", count, err)
+		}
+	}
+
+	// 相同索引的 Search 命中一次缓存；不同索引与 _count 路径各自请求一次
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("期望请求 OpenSearch 3 次，实际 %d 次", got)
+	}
+}
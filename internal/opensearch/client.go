@@ -1,14 +1,16 @@
 package opensearch
 
 import (
-	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"opensearch-alert/pkg/types"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -18,77 +20,141 @@ import (
 type Client struct {
 	config     types.OpenSearchConfig
 	httpClient *http.Client
-	baseURL    string
+	hosts      *hostPool
 	logger     *logrus.Logger
+	// cache 短时查询结果缓存，query_cache_ttl 为 0 时为 nil
+	cache *queryCache
 }
 
-// NewClient 创建新的 OpenSearch 客户端
-func NewClient(config types.OpenSearchConfig) *Client {
-	baseURL := fmt.Sprintf("%s://%s:%d", config.Protocol, config.Host, config.Port)
+// AuthError OpenSearch 认证/授权失败（401/403）
+type AuthError struct {
+	StatusCode int
+	Body       string
+}
 
-	// 创建 HTTP 客户端，根据配置决定是否验证证书
-	httpClient := &http.Client{
-		Timeout: time.Duration(config.Timeout) * time.Second,
+func (e *AuthError) Error() string {
+	if e.StatusCode == http.StatusUnauthorized {
+		return fmt.Sprintf("OpenSearch 认证失败(401)，请检查用户名/密码: %s", e.Body)
 	}
+	return fmt.Sprintf("OpenSearch 授权失败(403)，请检查账号的索引权限: %s", e.Body)
+}
 
-	// 如果配置了不验证证书，则跳过 TLS 验证
-	if !config.VerifyCerts {
-		httpClient.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		}
+// IsAuthError 判断错误是否为认证/授权失败
+func IsAuthError(err error) bool {
+	var authErr *AuthError
+	return errors.As(err, &authErr)
+}
+
+// StatusError OpenSearch 返回的非 200 响应（认证/授权失败除外）
+type StatusError struct {
+	Prefix     string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s，状态码: %d, 响应: %s", e.Prefix, e.StatusCode, e.Body)
+}
+
+// IsQueryError 判断错误是否为查询本身的问题（4xx，如 DSL 错误、索引不存在），重试无法恢复
+func IsQueryError(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.StatusCode >= 400 && statusErr.StatusCode < 500 && statusErr.StatusCode != http.StatusTooManyRequests
+}
+
+// IsNotFound 判断错误是否为 404（如索引不存在）
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// statusError 根据响应状态码构造错误，401/403 返回 *AuthError，其余返回 *StatusError
+func statusError(prefix string, statusCode int, body []byte) error {
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		return &AuthError{StatusCode: statusCode, Body: string(body)}
+	}
+	return &StatusError{Prefix: prefix, StatusCode: statusCode, Body: string(body)}
+}
+
+// NewClient 创建新的 OpenSearch 客户端
+func NewClient(config types.OpenSearchConfig) (*Client, error) {
+	// TLS 设置：自定义 CA、客户端证书，或显式跳过校验
+	tlsConfig, err := buildTLSConfig(config)
+	if err != nil {
+		return nil, err
 	}
 
-	// 创建日志器
-	logger := logrus.New()
-	logger.SetLevel(logrus.InfoLevel)
+	// 创建 HTTP 客户端
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	httpClient := &http.Client{
+		Timeout:   time.Duration(config.Timeout) * time.Second,
+		Transport: transport,
+	}
 
+	// 使用全局日志器，与主程序的格式、级别和输出保持一致
 	return &Client{
 		config:     config,
 		httpClient: httpClient,
-		baseURL:    baseURL,
-		logger:     logger,
+		hosts:      newHostPool(config),
+		logger:     logrus.StandardLogger(),
+		cache:      newQueryCache(time.Duration(config.QueryCacheTTL) * time.Second),
+	}, nil
+}
+
+// setAuth 按认证方式设置请求头
+func (c *Client) setAuth(req *http.Request) {
+	switch strings.ToLower(c.config.AuthType) {
+	case "apikey":
+		req.Header.Set("Authorization", "ApiKey "+c.config.APIKey)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	default:
+		if c.config.Username != "" || c.config.Password != "" {
+			req.SetBasicAuth(c.config.Username, c.config.Password)
+		}
 	}
 }
 
 // Search 执行搜索查询
 func (c *Client) Search(ctx context.Context, index string, query map[string]interface{}) (*types.OpenSearchResponse, error) {
-	url := fmt.Sprintf("%s/%s/_search", c.baseURL, index)
-	c.logger.Debugf("执行 OpenSearch 查询: %s", url)
+	path := fmt.Sprintf("/%s/_search", ResolveIndex(index))
+	c.logger.Debugf("执行 OpenSearch 查询: %s", path)
 
 	queryBytes, err := json.Marshal(query)
 	if err != nil {
 		return nil, fmt.Errorf("序列化查询失败: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(queryBytes))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.SetBasicAuth(c.config.Username, c.config.Password)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		c.logger.Errorf("OpenSearch 查询请求失败: %v", err)
-		return nil, fmt.Errorf("执行请求失败: %w", err)
-	}
-	defer resp.Body.Close()
+	body, err := c.cachedPost(ctx, path, queryBytes, func() ([]byte, error) {
+		resp, err := c.postWithRetry(ctx, path, queryBytes)
+		if err != nil {
+			c.logger.Errorf("OpenSearch 查询请求失败: %v", err)
+			return nil, fmt.Errorf("执行请求失败: %w", err)
+		}
+		defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		c.logger.Errorf("OpenSearch 查询失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
-		return nil, fmt.Errorf("OpenSearch 查询失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
-	}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			c.logger.Errorf("OpenSearch 查询失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
+			return nil, statusError("OpenSearch 查询失败", resp.StatusCode, body)
+		}
 
-	body, err := io.ReadAll(resp.Body)
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			c.logger.Errorf("读取 OpenSearch 响应失败: %v", err)
+			return nil, fmt.Errorf("读取响应失败: %w", err)
+		}
+		return body, nil
+	})
 	if err != nil {
-		c.logger.Errorf("读取 OpenSearch 响应失败: %v", err)
-		return nil, fmt.Errorf("读取响应失败: %w", err)
+		return nil, err
 	}
 
+	// 每次调用解析出独立的响应，缓存命中时调用方之间互不影响
 	var response types.OpenSearchResponse
 	if err := json.Unmarshal(body, &response); err != nil {
 		c.logger.Errorf("解析 OpenSearch 响应失败: %v", err)
@@ -101,35 +167,33 @@ func (c *Client) Search(ctx context.Context, index string, query map[string]inte
 
 // Count 执行计数查询
 func (c *Client) Count(ctx context.Context, index string, query map[string]interface{}) (int, error) {
-	url := fmt.Sprintf("%s/%s/_count", c.baseURL, index)
+	path := fmt.Sprintf("/%s/_count", ResolveIndex(index))
 
 	queryBytes, err := json.Marshal(query)
 	if err != nil {
 		return 0, fmt.Errorf("序列化查询失败: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(queryBytes))
-	if err != nil {
-		return 0, fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.SetBasicAuth(c.config.Username, c.config.Password)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return 0, fmt.Errorf("执行请求失败: %w", err)
-	}
-	defer resp.Body.Close()
+	body, err := c.cachedPost(ctx, path, queryBytes, func() ([]byte, error) {
+		resp, err := c.postWithRetry(ctx, path, queryBytes)
+		if err != nil {
+			return nil, fmt.Errorf("执行请求失败: %w", err)
+		}
+		defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("OpenSearch 计数查询失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
-	}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return nil, statusError("OpenSearch 计数查询失败", resp.StatusCode, body)
+		}
 
-	body, err := io.ReadAll(resp.Body)
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("读取响应失败: %w", err)
+		}
+		return body, nil
+	})
 	if err != nil {
-		return 0, fmt.Errorf("读取响应失败: %w", err)
+		return 0, err
 	}
 
 	var countResp struct {
@@ -143,24 +207,28 @@ func (c *Client) Count(ctx context.Context, index string, query map[string]inter
 	return countResp.Count, nil
 }
 
+// cachedPost 开启查询缓存时按路径与请求体复用响应体，否则直接调用 fetch
+func (c *Client) cachedPost(ctx context.Context, path string, queryBytes []byte, fetch func() ([]byte, error)) ([]byte, error) {
+	if c.cache == nil {
+		return fetch()
+	}
+	body, hit, err := c.cache.get(ctx, path+"\n"+string(queryBytes), fetch)
+	if hit {
+		c.logger.Debugf("OpenSearch 查询命中缓存: %s", path)
+	}
+	return body, err
+}
+
 // Index 索引文档
 func (c *Client) Index(ctx context.Context, index string, id string, doc interface{}) error {
-	url := fmt.Sprintf("%s/%s/_doc/%s", c.baseURL, index, id)
+	path := fmt.Sprintf("/%s/_doc/%s", index, id)
 
 	docBytes, err := json.Marshal(doc)
 	if err != nil {
 		return fmt.Errorf("序列化文档失败: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(docBytes))
-	if err != nil {
-		return fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.SetBasicAuth(c.config.Username, c.config.Password)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(ctx, "PUT", path, docBytes)
 	if err != nil {
 		return fmt.Errorf("执行请求失败: %w", err)
 	}
@@ -168,7 +236,7 @@ func (c *Client) Index(ctx context.Context, index string, id string, doc interfa
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("OpenSearch 索引失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
+		return statusError("OpenSearch 索引失败", resp.StatusCode, body)
 	}
 
 	return nil
@@ -176,22 +244,14 @@ func (c *Client) Index(ctx context.Context, index string, id string, doc interfa
 
 // IndexDocument 索引文档（自动生成ID）
 func (c *Client) IndexDocument(ctx context.Context, index string, doc interface{}) error {
-	url := fmt.Sprintf("%s/%s/_doc", c.baseURL, index)
+	path := fmt.Sprintf("/%s/_doc", index)
 
 	docBytes, err := json.Marshal(doc)
 	if err != nil {
 		return fmt.Errorf("序列化文档失败: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(docBytes))
-	if err != nil {
-		return fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.SetBasicAuth(c.config.Username, c.config.Password)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(ctx, "POST", path, docBytes)
 	if err != nil {
 		return fmt.Errorf("执行请求失败: %w", err)
 	}
@@ -199,68 +259,261 @@ func (c *Client) IndexDocument(ctx context.Context, index string, doc interface{
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("OpenSearch 索引失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
+		return statusError("OpenSearch 索引失败", resp.StatusCode, body)
 	}
 
 	return nil
 }
 
+// ResolveIndex 返回请求路径中实际使用的索引段（日期数学表达式如 <logs-{now/d}> 需转义，由 OpenSearch 解析）
+func ResolveIndex(index string) string {
+	parts := splitIndices(index)
+	for i, part := range parts {
+		// 通配符与逗号保持原样，便于阅读
+		parts[i] = strings.ReplaceAll(url.PathEscape(part), "%2A", "*")
+	}
+	return strings.Join(parts, ",")
+}
+
+// RuleIndex 返回规则查询的索引列表（index 与 indices 合并、去空去重后以逗号连接）
+func RuleIndex(rule types.AlertRule) string {
+	var parts []string
+	seen := make(map[string]bool)
+	for _, index := range append([]string{rule.Index}, rule.Indices...) {
+		for _, part := range splitIndices(index) {
+			if !seen[part] {
+				seen[part] = true
+				parts = append(parts, part)
+			}
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitIndices 按逗号拆分索引列表，去除空白与空项
+func splitIndices(index string) []string {
+	var parts []string
+	for _, part := range strings.Split(index, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
 // BuildTimeRangeQuery 构建时间范围查询
+// 窗口终点为 now - bufferTime（秒），为写入延迟预留时间，晚到的文档仍会落在后续窗口内
 func (c *Client) BuildTimeRangeQuery(rule types.AlertRule, bufferTime int) map[string]interface{} {
-	now := time.Now()
-	// 只使用规则的时间窗口，不使用bufferTime
-	startTime := now.Add(-time.Duration(rule.Timeframe) * time.Second)
+	return c.BuildWindowQuery(rule, SlidingWindow(rule, BufferedEnd(time.Now(), bufferTime)))
+}
 
-	query := map[string]interface{}{
-		"query": map[string]interface{}{
-			"bool": map[string]interface{}{
-				"must": []map[string]interface{}{
-					{
-						"range": map[string]interface{}{
-							"@timestamp": map[string]interface{}{
-								"gte": startTime.Format(time.RFC3339),
-								"lte": now.Format(time.RFC3339),
-							},
-						},
-					},
-				},
+// BufferedEnd 返回扣除写入缓冲后的窗口终点
+func BufferedEnd(now time.Time, bufferTime int) time.Time {
+	if bufferTime <= 0 {
+		return now
+	}
+	return now.Add(-time.Duration(bufferTime) * time.Second)
+}
+
+// TimeWindow 查询的时间范围
+type TimeWindow struct {
+	Start time.Time
+	End   time.Time
+	// StartExclusive 起点不包含在窗口内（增量窗口接续上一窗口的终点时使用，避免边界文档重复统计）
+	StartExclusive bool
+	// ExcludeIDs 不计入窗口的文档 _id（增量窗口与上一窗口重叠部分中已统计过的文档）
+	ExcludeIDs []string
+}
+
+// SlidingWindow 规则默认的滑动窗口：[end - timeframe, end]
+func SlidingWindow(rule types.AlertRule, end time.Time) TimeWindow {
+	return TimeWindow{
+		Start: end.Add(-time.Duration(rule.Timeframe) * time.Second),
+		End:   end,
+	}
+}
+
+// rangeClause 构建时间窗口的 range 条件
+func (w TimeWindow) rangeClause() map[string]interface{} {
+	startOp := "gte"
+	if w.StartExclusive {
+		startOp = "gt"
+	}
+	return map[string]interface{}{
+		"range": map[string]interface{}{
+			"@timestamp": map[string]interface{}{
+				startOp: w.Start.Format(time.RFC3339),
+				"lte":   w.End.Format(time.RFC3339),
 			},
 		},
-		"size": 100, // 减少返回结果数量，只用于告警判断
-		"sort": []map[string]interface{}{
-			{
-				"@timestamp": map[string]interface{}{
-					"order": "desc",
-				},
-			},
+	}
+}
+
+// DefaultMaxHits 规则未设置 max_hits 时每次查询拉取的文档数
+const DefaultMaxHits = 100
+
+// RuleMaxHits 返回规则每次查询拉取的文档数
+func RuleMaxHits(rule types.AlertRule) int {
+	if rule.MaxHits == nil || *rule.MaxHits < 0 {
+		return DefaultMaxHits
+	}
+	return *rule.MaxHits
+}
+
+// DefaultSortField 规则未设置 sort_field 时的排序字段
+const DefaultSortField = "@timestamp"
+
+// RuleSort 返回规则查询的排序条件：默认按 @timestamp 倒序（示例文档为最新一条）；
+// 指定其他字段时以 @timestamp 倒序作为次序，同值文档中取最新一条
+func RuleSort(rule types.AlertRule) []map[string]interface{} {
+	field := strings.TrimSpace(rule.SortField)
+	if field == "" {
+		field = DefaultSortField
+	}
+	order := strings.ToLower(strings.TrimSpace(rule.SortOrder))
+	if order == "" {
+		order = "desc"
+	}
+
+	sort := []map[string]interface{}{
+		{field: map[string]interface{}{"order": order}},
+	}
+	if field != DefaultSortField {
+		sort = append(sort, map[string]interface{}{
+			DefaultSortField: map[string]interface{}{"order": "desc"},
+		})
+	}
+	return sort
+}
+
+// BuildWindowQuery 构建指定时间窗口的规则查询
+func (c *Client) BuildWindowQuery(rule types.AlertRule, window TimeWindow) map[string]interface{} {
+	boolQuery := map[string]interface{}{
+		"must": []map[string]interface{}{window.rangeClause()},
+	}
+
+	size := RuleMaxHits(rule)
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": boolQuery,
 		},
+		"size": size,
+	}
+
+	// 指标规则：追加聚合，未设置 max_hits 时只需 1 条样本文档用于渲染消息
+	if rule.Type == "metric" {
+		query["aggs"] = metricAggregation(rule)
+		if rule.MaxHits == nil {
+			size = 1
+			query["size"] = size
+		}
+	}
+
+	// 仅统计总数时无需排序；首条命中即告警消息中的示例文档
+	if size > 0 {
+		query["sort"] = RuleSort(rule)
 	}
 
 	// 合并规则查询条件
 	if rule.Query != nil {
-		if boolQuery, ok := query["query"].(map[string]interface{})["bool"].(map[string]interface{}); ok {
-			if must, ok := boolQuery["must"].([]map[string]interface{}); ok {
-				must = append(must, rule.Query)
-				boolQuery["must"] = must
-			}
+		boolQuery["must"] = append(boolQuery["must"].([]map[string]interface{}), rule.Query)
+	}
+
+	// 排除条件放入 bool.must_not，命中的文档不计入阈值
+	if len(rule.ExcludeQuery) > 0 {
+		appendMustNot(boolQuery, rule.ExcludeQuery)
+	}
+
+	// 增量窗口重叠部分中已统计过的文档按 _id 排除
+	if len(window.ExcludeIDs) > 0 {
+		appendMustNot(boolQuery, map[string]interface{}{"ids": map[string]interface{}{"values": window.ExcludeIDs}})
+	}
+
+	// 脚本过滤放入 bool.filter（不参与评分）
+	if rule.Script != nil && rule.Script.Source != "" {
+		lang := rule.Script.Lang
+		if lang == "" {
+			lang = "painless"
+		}
+		script := map[string]interface{}{
+			"source": rule.Script.Source,
+			"lang":   lang,
+		}
+		if len(rule.Script.Params) > 0 {
+			script["params"] = rule.Script.Params
+		}
+		appendFilter(boolQuery, map[string]interface{}{"script": map[string]interface{}{"script": script}})
+	}
+
+	// 白名单：字段值必须在列表中（bool.filter），黑名单：字段值命中即排除（bool.must_not）
+	// 同时配置时两者叠加，同一值既在白名单又在黑名单时以黑名单为准（被排除）
+	for _, field := range sortedKeys(rule.Whitelist) {
+		if values := rule.Whitelist[field]; len(values) > 0 {
+			appendFilter(boolQuery, termsClause(field, values))
+		}
+	}
+	for _, field := range sortedKeys(rule.Blacklist) {
+		if values := rule.Blacklist[field]; len(values) > 0 {
+			appendMustNot(boolQuery, termsClause(field, values))
 		}
 	}
 
 	return query
 }
 
-// HealthCheck 检查 OpenSearch 连接状态
-func (c *Client) HealthCheck(ctx context.Context) error {
-	url := fmt.Sprintf("%s/_cluster/health", c.baseURL)
+// appendFilter 向 bool 查询追加 filter 子句
+func appendFilter(boolQuery map[string]interface{}, clause map[string]interface{}) {
+	filter, _ := boolQuery["filter"].([]map[string]interface{})
+	boolQuery["filter"] = append(filter, clause)
+}
+
+// termsClause 构建 terms 子句
+func termsClause(field string, values []string) map[string]interface{} {
+	return map[string]interface{}{
+		"terms": map[string]interface{}{field: values},
+	}
+}
+
+// sortedKeys 返回排序后的键，保证生成的查询稳定
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// appendMustNot 向 bool 查询追加 must_not 子句
+func appendMustNot(boolQuery map[string]interface{}, clause map[string]interface{}) {
+	mustNot, _ := boolQuery["must_not"].([]map[string]interface{})
+	boolQuery["must_not"] = append(mustNot, clause)
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// IndexExists 检查索引（可含通配符与逗号分隔的多个索引）是否存在：通配符未匹配任何索引、
+// 或任一具体索引不存在时返回 false
+func (c *Client) IndexExists(ctx context.Context, index string) (bool, error) {
+	path := "/" + ResolveIndex(index) + "?allow_no_indices=false&ignore_unavailable=false"
+	resp, err := c.send(ctx, http.MethodHead, path, nil)
 	if err != nil {
-		return fmt.Errorf("创建健康检查请求失败: %w", err)
+		return false, fmt.Errorf("执行索引检查请求失败: %w", err)
 	}
+	defer resp.Body.Close()
 
-	req.SetBasicAuth(c.config.Username, c.config.Password)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		// HEAD 响应没有响应体
+		return false, statusError("OpenSearch 索引检查失败", resp.StatusCode, nil)
+	}
+}
 
-	resp, err := c.httpClient.Do(req)
+// HealthCheck 检查 OpenSearch 连接状态
+func (c *Client) HealthCheck(ctx context.Context) error {
+	resp, err := c.send(ctx, "GET", "/_cluster/health", nil)
 	if err != nil {
 		return fmt.Errorf("执行健康检查请求失败: %w", err)
 	}
@@ -268,7 +521,7 @@ func (c *Client) HealthCheck(ctx context.Context) error {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("OpenSearch 健康检查失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
+		return statusError("OpenSearch 健康检查失败", resp.StatusCode, body)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -301,7 +554,6 @@ func (c *Client) TestConnection(ctx context.Context) error {
 	}
 
 	// 尝试执行一个简单的搜索查询
-	url := fmt.Sprintf("%s/_search", c.baseURL)
 	query := map[string]interface{}{
 		"query": map[string]interface{}{
 			"match_all": map[string]interface{}{},
@@ -314,15 +566,7 @@ func (c *Client) TestConnection(ctx context.Context) error {
 		return fmt.Errorf("序列化测试查询失败: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(queryBytes))
-	if err != nil {
-		return fmt.Errorf("创建测试查询请求失败: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.SetBasicAuth(c.config.Username, c.config.Password)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(ctx, "POST", "/_search", queryBytes)
 	if err != nil {
 		return fmt.Errorf("执行测试查询失败: %w", err)
 	}
@@ -330,7 +574,7 @@ func (c *Client) TestConnection(ctx context.Context) error {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("测试查询失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
+		return statusError("测试查询失败", resp.StatusCode, body)
 	}
 
 	return nil
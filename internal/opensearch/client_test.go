@@ -0,0 +1,257 @@
+package opensearch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// testWindow 固定的查询窗口
+var testWindow = TimeWindow{
+	Start: time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC),
+	End:   time.Date(2024, 1, 2, 3, 5, 0, 0, time.UTC),
+}
+
+// boolClauses 返回查询中 bool 子句经 JSON 往返后的结构，便于与期望值比较
+func boolClauses(t *testing.T, query map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(query["query"])
+	if err != nil {
+		t.Fatalf("序列化查询失败: %v", err)
+	}
+	var decoded struct {
+		Bool map[string]interface{} `json:"bool"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("解析查询失败: %v", err)
+	}
+	return decoded.Bool
+}
+
+// mustJSON 将 JSON 文本解析为通用结构
+func mustJSON(t *testing.T, text string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		t.Fatalf("解析期望 JSON 失败: %v", err)
+	}
+	return v
+}
+
+func TestBuildWindowQueryExcludeQueryInMustNot(t *testing.T) {
+	rule := types.AlertRule{
+		Name:         "exclude",
+		Query:        map[string]interface{}{"match": map[string]interface{}{"level": "error"}},
+		ExcludeQuery: map[string]interface{}{"term": map[string]interface{}{"kubernetes.namespace_name": "kube-system"}},
+		Blacklist:    map[string][]string{"host": {"canary-1"}},
+	}
+
+	clauses := boolClauses(t, (&Client{}).BuildWindowQuery(rule, testWindow))
+
+	wantMust := mustJSON(t, `[
+		{"range": {"@timestamp": {"gte": "2024-01-02T03:00:00Z", "lte": "2024-01-02T03:05:00Z"}}},
+		{"match": {"level": "error"}}
+	]`)
+	if !reflect.DeepEqual(clauses["must"], wantMust) {
+		t.Errorf("must 子句不符: %v", clauses["must"])
+	}
+
+	// exclude_query 作为 bool.must_not 的独立子句，不嵌入 must，黑名单追加在其后
+	wantMustNot := mustJSON(t, `[
+		{"term": {"kubernetes.namespace_name": "kube-system"}},
+		{"terms": {"host": ["canary-1"]}}
+	]`)
+	if !reflect.DeepEqual(clauses["must_not"], wantMustNot) {
+		t.Errorf("must_not 子句不符: %v", clauses["must_not"])
+	}
+	if _, ok := clauses["filter"]; ok {
+		t.Errorf("未配置白名单与脚本时不应生成 filter: %v", clauses["filter"])
+	}
+}
+
+func TestBuildWindowQueryWithoutExcludeQuery(t *testing.T) {
+	clauses := boolClauses(t, (&Client{}).BuildWindowQuery(types.AlertRule{Name: "plain"}, testWindow))
+	if _, ok := clauses["must_not"]; ok {
+		t.Errorf("未配置 exclude_query 时不应生成 must_not: %v", clauses["must_not"])
+	}
+	if must, _ := clauses["must"].([]interface{}); len(must) != 1 {
+		t.Errorf("must 应只包含时间范围，实际 %v", clauses["must"])
+	}
+}
+
+func TestBuildTimeRangeQueryWhitelistBlacklist(t *testing.T) {
+	rule := types.AlertRule{
+		Name:      "lists",
+		Timeframe: 300,
+		Whitelist: map[string][]string{"service": {"api", "web"}, "env": {"prod"}, "empty": {}},
+		Blacklist: map[string][]string{"kubernetes.namespace_name": {"kube-system", "monitoring"}, "host": {"canary-1"}},
+	}
+
+	clauses := boolClauses(t, (&Client{}).BuildTimeRangeQuery(rule, 0))
+
+	// 字段按名称排序，值为空的字段不生成子句
+	wantFilter := mustJSON(t, `[
+		{"terms": {"env": ["prod"]}},
+		{"terms": {"service": ["api", "web"]}}
+	]`)
+	if !reflect.DeepEqual(clauses["filter"], wantFilter) {
+		t.Errorf("filter 子句不符: %v", clauses["filter"])
+	}
+	wantMustNot := mustJSON(t, `[
+		{"terms": {"host": ["canary-1"]}},
+		{"terms": {"kubernetes.namespace_name": ["kube-system", "monitoring"]}}
+	]`)
+	if !reflect.DeepEqual(clauses["must_not"], wantMustNot) {
+		t.Errorf("must_not 子句不符: %v", clauses["must_not"])
+	}
+	if must, _ := clauses["must"].([]interface{}); len(must) != 1 {
+		t.Errorf("黑白名单不应进入 must，实际 %v", clauses["must"])
+	}
+}
+
+func TestBuildTimeRangeQueryWhitelistOnly(t *testing.T) {
+	rule := types.AlertRule{Name: "allow", Timeframe: 300, Whitelist: map[string][]string{"level": {"error"}}}
+
+	clauses := boolClauses(t, (&Client{}).BuildTimeRangeQuery(rule, 0))
+	if !reflect.DeepEqual(clauses["filter"], mustJSON(t, `[{"terms": {"level": ["error"]}}]`)) {
+		t.Errorf("filter 子句不符: %v", clauses["filter"])
+	}
+	if _, ok := clauses["must_not"]; ok {
+		t.Errorf("未配置黑名单时不应生成 must_not: %v", clauses["must_not"])
+	}
+}
+
+func TestBufferedEnd(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 10, 0, 0, time.UTC)
+	tests := []struct {
+		bufferTime int
+		want       time.Time
+	}{
+		{0, now},
+		{-30, now},
+		{60, now.Add(-time.Minute)},
+		{300, now.Add(-5 * time.Minute)},
+	}
+	for _, tt := range tests {
+		if got := BufferedEnd(now, tt.bufferTime); !got.Equal(tt.want) {
+			t.Errorf("BufferedEnd(%d) = %v，期望 %v", tt.bufferTime, got, tt.want)
+		}
+	}
+}
+
+// rangeBounds 返回查询中 @timestamp 范围的 gte 与 lte
+func rangeBounds(t *testing.T, query map[string]interface{}) (time.Time, time.Time) {
+	t.Helper()
+	must, _ := boolClauses(t, query)["must"].([]interface{})
+	if len(must) == 0 {
+		t.Fatalf("查询缺少时间范围: %v", query)
+	}
+	var clause struct {
+		Range struct {
+			Timestamp struct {
+				Gte string `json:"gte"`
+				Lte string `json:"lte"`
+			} `json:"@timestamp"`
+		} `json:"range"`
+	}
+	data, _ := json.Marshal(must[0])
+	if err := json.Unmarshal(data, &clause); err != nil {
+		t.Fatalf("解析时间范围失败: %v", err)
+	}
+	gte, err := time.Parse(time.RFC3339, clause.Range.Timestamp.Gte)
+	if err != nil {
+		t.Fatalf("gte 格式错误: %v", err)
+	}
+	lte, err := time.Parse(time.RFC3339, clause.Range.Timestamp.Lte)
+	if err != nil {
+		t.Fatalf("lte 格式错误: %v", err)
+	}
+	return gte, lte
+}
+
+func TestBuildTimeRangeQueryAppliesBufferTime(t *testing.T) {
+	rule := types.AlertRule{Name: "buffered", Timeframe: 600}
+
+	for _, bufferTime := range []int{0, 120} {
+		before := time.Now().Truncate(time.Second)
+		gte, lte := rangeBounds(t, (&Client{}).BuildTimeRangeQuery(rule, bufferTime))
+		after := time.Now()
+
+		// 窗口终点为 now - buffer_time，RFC3339 精度为秒
+		buffer := time.Duration(bufferTime) * time.Second
+		if lte.Before(before.Add(-buffer)) || lte.After(after.Add(-buffer)) {
+			t.Errorf("buffer_time=%d: lte = %v，期望位于 %v 与 %v 之间", bufferTime, lte, before.Add(-buffer), after.Add(-buffer))
+		}
+		// 窗口长度保持 timeframe 不变
+		if got := lte.Sub(gte); got != 10*time.Minute {
+			t.Errorf("buffer_time=%d: 窗口长度 = %v，期望 10m", bufferTime, got)
+		}
+	}
+}
+
+func TestBuildWindowQuerySize(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	tests := []struct {
+		name     string
+		rule     types.AlertRule
+		wantSize int
+		wantSort bool
+	}{
+		{"未设置 max_hits 使用默认值", types.AlertRule{Type: "any"}, DefaultMaxHits, true},
+		{"自定义 max_hits", types.AlertRule{Type: "frequency", MaxHits: intPtr(25)}, 25, true},
+		{"max_hits 为 0 仅统计总数", types.AlertRule{Type: "frequency", MaxHits: intPtr(0)}, 0, false},
+		{"max_hits 为负退回默认值", types.AlertRule{Type: "any", MaxHits: intPtr(-5)}, DefaultMaxHits, true},
+		{"指标规则默认 1 条样本", types.AlertRule{Type: "metric", MetricAgg: "max", MetricField: "cpu"}, 1, true},
+		{"指标规则 max_hits 为 0", types.AlertRule{Type: "metric", MetricAgg: "max", MetricField: "cpu", MaxHits: intPtr(0)}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := (&Client{}).BuildWindowQuery(tt.rule, testWindow)
+			if query["size"] != tt.wantSize {
+				t.Errorf("size = %v，期望 %d", query["size"], tt.wantSize)
+			}
+			if _, ok := query["sort"]; ok != tt.wantSort {
+				t.Errorf("是否包含 sort = %v，期望 %v", ok, tt.wantSort)
+			}
+		})
+	}
+}
+
+func TestBuildWindowQuerySort(t *testing.T) {
+	tests := []struct {
+		name string
+		rule types.AlertRule
+		want string
+	}{
+		{"默认按时间倒序", types.AlertRule{}, `[{"@timestamp": {"order": "desc"}}]`},
+		{"时间正序", types.AlertRule{SortOrder: "asc"}, `[{"@timestamp": {"order": "asc"}}]`},
+		{"自定义字段以时间倒序为次序",
+			types.AlertRule{SortField: "severity_num", SortOrder: "DESC"},
+			`[{"severity_num": {"order": "desc"}}, {"@timestamp": {"order": "desc"}}]`},
+		{"自定义字段默认倒序",
+			types.AlertRule{SortField: " response_ms "},
+			`[{"response_ms": {"order": "desc"}}, {"@timestamp": {"order": "desc"}}]`},
+		{"自定义字段正序",
+			types.AlertRule{SortField: "response_ms", SortOrder: "asc"},
+			`[{"response_ms": {"order": "asc"}}, {"@timestamp": {"order": "desc"}}]`},
+		{"显式指定 @timestamp 不重复", types.AlertRule{SortField: "@timestamp", SortOrder: "asc"}, `[{"@timestamp": {"order": "asc"}}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal((&Client{}).BuildWindowQuery(tt.rule, testWindow)["sort"])
+			if err != nil {
+				t.Fatalf("序列化 sort 失败: %v", err)
+			}
+			var got interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("解析 sort 失败: %v", err)
+			}
+			if want := mustJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("sort = %s，期望 %s", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"opensearch-alert/pkg/types"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostCooldown 主机连接失败后的冷却时间，期间优先尝试其他主机
+const hostCooldown = 30 * time.Second
+
+// hostPool 多主机轮询与故障切换
+type hostPool struct {
+	mu        sync.Mutex
+	baseURLs  []string
+	downUntil []time.Time
+	next      int
+}
+
+// newHostPool 根据配置生成主机列表：优先 hosts，为空时使用 host（兼容旧配置）
+func newHostPool(config types.OpenSearchConfig) *hostPool {
+	hosts := config.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{config.Host}
+	}
+
+	pool := &hostPool{}
+	for _, host := range hosts {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		pool.baseURLs = append(pool.baseURLs, hostBaseURL(config, host))
+	}
+	if len(pool.baseURLs) == 0 {
+		pool.baseURLs = []string{hostBaseURL(config, config.Host)}
+	}
+	pool.downUntil = make([]time.Time, len(pool.baseURLs))
+	return pool
+}
+
+// hostBaseURL 将主机配置转换为基础地址，支持 host、host:port 与完整 URL
+func hostBaseURL(config types.OpenSearchConfig, host string) string {
+	if strings.Contains(host, "://") {
+		return strings.TrimRight(host, "/")
+	}
+	if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+		return fmt.Sprintf("%s://%s", config.Protocol, host)
+	}
+	return fmt.Sprintf("%s://%s:%d", config.Protocol, host, config.Port)
+}
+
+// candidates 返回本次请求的主机尝试顺序：从轮询位置开始的健康主机在前，冷却中的主机在后
+func (p *hostPool) candidates() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	n := len(p.baseURLs)
+	start := p.next % n
+	p.next = (p.next + 1) % n
+
+	healthy := make([]int, 0, n)
+	var cooling []int
+	for i := 0; i < n; i++ {
+		idx := (start + i) % n
+		if now.Before(p.downUntil[idx]) {
+			cooling = append(cooling, idx)
+			continue
+		}
+		healthy = append(healthy, idx)
+	}
+	return append(healthy, cooling...)
+}
+
+// markDown 标记主机连接失败
+func (p *hostPool) markDown(idx int) {
+	p.mu.Lock()
+	p.downUntil[idx] = time.Now().Add(hostCooldown)
+	p.mu.Unlock()
+}
+
+// markUp 主机请求成功，清除冷却状态
+func (p *hostPool) markUp(idx int) {
+	p.mu.Lock()
+	p.downUntil[idx] = time.Time{}
+	p.mu.Unlock()
+}
+
+// send 发送请求，连接失败时依次切换到下一个主机；收到任何 HTTP 响应即返回
+func (c *Client) send(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	var lastErr error
+	for _, idx := range c.hosts.candidates() {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, c.hosts.baseURLs[idx]+path, reader)
+		if err != nil {
+			return nil, fmt.Errorf("创建请求失败: %w", err)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		c.setAuth(req)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			// 调用方取消或超时不属于主机故障
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			c.hosts.markDown(idx)
+			c.logger.Warnf("OpenSearch 主机 %s 请求失败，尝试下一个主机: %v", c.hosts.baseURLs[idx], err)
+			lastErr = err
+			continue
+		}
+		c.hosts.markUp(idx)
+		return resp, nil
+	}
+	return nil, lastErr
+}
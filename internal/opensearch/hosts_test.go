@@ -0,0 +1,135 @@
+package opensearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// countingServer 启动返回健康状态的服务并统计请求数
+func countingServer(t *testing.T, calls *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		w.Write([]byte(`{"status":"green"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// closedURL 返回已关闭服务的地址（连接被拒绝）
+func closedURL() string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+	return url
+}
+
+func TestHostFailover(t *testing.T) {
+	var calls int32
+	healthy := countingServer(t, &calls)
+	down := closedURL()
+
+	client, err := NewClient(types.OpenSearchConfig{Hosts: []string{down, healthy.URL}, Timeout: 5})
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+
+	// 第一个主机连接失败时切换到下一个主机
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("故障切换后请求应成功: %v", err)
+	}
+	if !time.Now().Before(client.hosts.downUntil[0]) {
+		t.Error("连接失败的主机应进入冷却")
+	}
+
+	// 冷却期内后续请求优先使用健康主机
+	for i := 0; i < 3; i++ {
+		if got := client.hosts.candidates()[0]; got != 1 {
+			t.Fatalf("冷却期内首选主机应为健康主机，实际 %d", got)
+		}
+	}
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("健康主机收到 %d 次请求, 期望 2", got)
+	}
+}
+
+func TestHostFailoverAllDown(t *testing.T) {
+	client, err := NewClient(types.OpenSearchConfig{Hosts: []string{closedURL(), closedURL()}, Timeout: 5})
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	if err := client.HealthCheck(context.Background()); err == nil {
+		t.Fatal("所有主机不可用时应返回错误")
+	}
+
+	// 全部冷却时仍会按顺序尝试，而不是直接放弃
+	if got := len(client.hosts.candidates()); got != 2 {
+		t.Errorf("候选主机数 = %d, 期望 2", got)
+	}
+}
+
+func TestHostRoundRobin(t *testing.T) {
+	var first, second int32
+	a := countingServer(t, &first)
+	b := countingServer(t, &second)
+
+	client, err := NewClient(types.OpenSearchConfig{Hosts: []string{a.URL, " ", b.URL}, Timeout: 5})
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	if got := len(client.hosts.baseURLs); got != 2 {
+		t.Fatalf("空白主机应被忽略，实际 %d 个主机", got)
+	}
+	for i := 0; i < 4; i++ {
+		if err := client.HealthCheck(context.Background()); err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+	}
+	if atomic.LoadInt32(&first) != 2 || atomic.LoadInt32(&second) != 2 {
+		t.Errorf("健康主机应轮询使用，实际 %d/%d", first, second)
+	}
+}
+
+func TestHostMarkUpAfterRecovery(t *testing.T) {
+	pool := newHostPool(types.OpenSearchConfig{Hosts: []string{"http://a:9200", "http://b:9200"}})
+	pool.markDown(0)
+	if got := pool.candidates(); got[len(got)-1] != 0 {
+		t.Fatalf("冷却中的主机应排在最后，实际 %v", got)
+	}
+	pool.markUp(0)
+	if now := time.Now(); now.Before(pool.downUntil[0]) {
+		t.Fatal("恢复后应清除冷却状态")
+	}
+}
+
+func TestHostBaseURL(t *testing.T) {
+	config := types.OpenSearchConfig{Protocol: "https", Port: 9200}
+	tests := []struct {
+		host, want string
+	}{
+		{"es-1", "https://es-1:9200"},
+		{"es-1:9300", "https://es-1:9300"},
+		{"http://es-1:9200/", "http://es-1:9200"},
+		{"[::1]", "https://[::1]:9200"},
+	}
+	for _, tt := range tests {
+		if got := hostBaseURL(config, tt.host); got != tt.want {
+			t.Errorf("hostBaseURL(%q) = %q, 期望 %q", tt.host, got, tt.want)
+		}
+	}
+
+	// 未配置 hosts 时使用 host
+	pool := newHostPool(types.OpenSearchConfig{Protocol: "http", Host: "legacy", Port: 9200})
+	if len(pool.baseURLs) != 1 || pool.baseURLs[0] != "http://legacy:9200" {
+		t.Errorf("兼容 host 配置失败: %v", pool.baseURLs)
+	}
+}
@@ -0,0 +1,49 @@
+package opensearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestIndexExists(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		want    bool
+		wantErr bool
+	}{
+		{name: "存在", status: http.StatusOK, want: true},
+		{name: "不存在", status: http.StatusNotFound, want: false},
+		{name: "服务端错误", status: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var method, path, rawQuery string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method, path, rawQuery = r.Method, r.URL.Path, r.URL.RawQuery
+				w.WriteHeader(tt.status)
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := NewClient(types.OpenSearchConfig{Host: server.URL, Timeout: 5})
+			if err != nil {
+				t.Fatalf("创建客户端失败: %v", err)
+			}
+			exists, err := client.IndexExists(context.Background(), "logs-*, audit")
+			if (err != nil) != tt.wantErr || exists != tt.want {
+				t.Fatalf("IndexExists = %v, %v，期望 %v（wantErr=%v）", exists, err, tt.want, tt.wantErr)
+			}
+			if method != http.MethodHead || path != "/logs-*,audit" {
+				t.Errorf("请求应为 HEAD /logs-*,audit，实际 %s %s", method, path)
+			}
+			if rawQuery != "allow_no_indices=false&ignore_unavailable=false" {
+				t.Errorf("通配符未匹配与索引缺失都应返回 404，实际参数 %s", rawQuery)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package opensearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestRuleIndex(t *testing.T) {
+	tests := []struct {
+		name string
+		rule types.AlertRule
+		want string
+	}{
+		{"单个索引", types.AlertRule{Index: "logs-*"}, "logs-*"},
+		{"逗号列表去空白", types.AlertRule{Index: " logs-* , audit-*,"}, "logs-*,audit-*"},
+		{"index 与 indices 合并", types.AlertRule{Index: "logs-*", Indices: []string{"k8s-events", "audit-*"}}, "logs-*,k8s-events,audit-*"},
+		{"仅 indices", types.AlertRule{Indices: []string{"k8s-events", " ", "audit-*"}}, "k8s-events,audit-*"},
+		{"去重", types.AlertRule{Index: "logs-*,audit-*", Indices: []string{"audit-*", "logs-*,k8s-events"}}, "logs-*,audit-*,k8s-events"},
+		{"未配置", types.AlertRule{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RuleIndex(tt.rule); got != tt.want {
+				t.Errorf("RuleIndex = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveIndex(t *testing.T) {
+	tests := []struct {
+		index string
+		want  string
+	}{
+		{"logs-*", "logs-*"},
+		{"logs-*,audit-*", "logs-*,audit-*"},
+		{"<logs-{now/d}>", "%3Clogs-%7Bnow%2Fd%7D%3E"},
+		{"<logs-{now/d}>,audit-*", "%3Clogs-%7Bnow%2Fd%7D%3E,audit-*"},
+	}
+	for _, tt := range tests {
+		if got := ResolveIndex(tt.index); got != tt.want {
+			t.Errorf("ResolveIndex(%q) = %q，期望 %q", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestSearchAndCountMultiIndexPath(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		if r.URL.Path == "/logs-*,k8s-events,audit-*/_count" {
+			w.Write([]byte(`{"count": 0}`))
+			return
+		}
+		w.Write([]byte(`{"hits": {"total": {"value": 0}, "hits": []}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(types.OpenSearchConfig{Host: server.URL, Timeout: 5})
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	rule := types.AlertRule{Index: "logs-*", Indices: []string{"k8s-events", "audit-*"}}
+	ctx := context.Background()
+	if _, err := client.Search(ctx, RuleIndex(rule), map[string]interface{}{"size": 0}); err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if _, err := client.Count(ctx, RuleIndex(rule), map[string]interface{}{}); err != nil {
+		t.Fatalf("计数失败: %v", err)
+	}
+
+	// 多个索引以逗号连接在同一请求路径中，而不是分别请求
+	want := []string{"/logs-*,k8s-events,audit-*/_search", "/logs-*,k8s-events,audit-*/_count"}
+	if len(paths) != len(want) {
+		t.Fatalf("请求路径 = %v，期望 %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("第 %d 个请求路径 = %s，期望 %s", i+1, paths[i], want[i])
+		}
+	}
+}
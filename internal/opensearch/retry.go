@@ -0,0 +1,91 @@
+package opensearch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"opensearch-alert/pkg/types"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries 未设置 max_retries 时的最大重试次数
+	DefaultMaxRetries = 3
+	// retryBaseDelay 首次重试的等待时间，之后按指数退避
+	retryBaseDelay = 500 * time.Millisecond
+	// retryMaxDelay 单次重试等待上限
+	retryMaxDelay = 10 * time.Second
+)
+
+// isRetryableStatus 判断响应状态是否为可重试的瞬时错误
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// postWithRetry 发送 JSON POST 请求，遇到 429/502/503/504 时按退避重试（优先遵循 Retry-After），
+// 等待时间超出 ctx 截止时间时不再重试，直接返回最后一次响应
+func (c *Client) postWithRetry(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	maxRetries := MaxRetries(c.config)
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, "POST", path, body)
+		if err != nil {
+			return nil, err
+		}
+		if !isRetryableStatus(resp.StatusCode) || attempt >= maxRetries {
+			return resp, nil
+		}
+
+		wait := retryDelay(resp.Header.Get("Retry-After"), attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return resp, nil
+		}
+
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		c.logger.Warnf("OpenSearch 返回 %d，%s 后进行第 %d 次重试: %s", resp.StatusCode, wait, attempt+1, path)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// MaxRetries 返回查询的最大重试次数：未设置时为 DefaultMaxRetries，0 或负数表示不重试
+func MaxRetries(config types.OpenSearchConfig) int {
+	if config.MaxRetries == nil {
+		return DefaultMaxRetries
+	}
+	if *config.MaxRetries < 0 {
+		return 0
+	}
+	return *config.MaxRetries
+}
+
+// retryDelay 计算重试等待时间：Retry-After（秒或 HTTP 日期）优先，否则指数退避
+func retryDelay(retryAfter string, attempt int) time.Duration {
+	if retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if t, err := http.ParseTime(retryAfter); err == nil {
+			if d := time.Until(t); d > 0 {
+				return d
+			}
+			return 0
+		}
+	}
+
+	delay := retryBaseDelay << attempt
+	if delay > retryMaxDelay || delay <= 0 {
+		delay = retryMaxDelay
+	}
+	return delay
+}
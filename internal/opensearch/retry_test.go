@@ -0,0 +1,115 @@
+package opensearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// newRetryTestClient 启动按 statuses 顺序响应的 _count 桩服务（之后均返回 200），返回客户端与请求计数
+func newRetryTestClient(t *testing.T, maxRetries *int, statuses ...int) (*Client, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		if n <= len(statuses) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(statuses[n-1])
+			w.Write([]byte(`{"error":"busy"}`))
+			return
+		}
+		w.Write([]byte(`{"count":42}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(types.OpenSearchConfig{Host: server.URL, Timeout: 5, MaxRetries: maxRetries})
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	return client, &calls
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestCountRetriesTooManyRequests(t *testing.T) {
+	client, calls := newRetryTestClient(t, nil, http.StatusTooManyRequests)
+
+	count, err := client.Count(context.Background(), "logs-*", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("429 后重试应成功: %v", err)
+	}
+	if count != 42 {
+		t.Errorf("count = %d, 期望 42", count)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("请求次数 = %d, 期望 2（429 + 重试）", got)
+	}
+}
+
+func TestCountRetryLimit(t *testing.T) {
+	busy := []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusGatewayTimeout, http.StatusTooManyRequests, http.StatusTooManyRequests}
+	tests := []struct {
+		name       string
+		maxRetries *int
+		wantCalls  int32
+	}{
+		{"未设置时默认重试 3 次", nil, 4},
+		{"设为 0 关闭重试", intPtr(0), 1},
+		{"负数同样关闭重试", intPtr(-1), 1},
+		{"自定义次数", intPtr(1), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, calls := newRetryTestClient(t, tt.maxRetries, busy...)
+
+			_, err := client.Count(context.Background(), "logs-*", map[string]interface{}{})
+			if err == nil {
+				t.Fatal("重试耗尽后应返回错误")
+			}
+			if IsQueryError(err) {
+				t.Errorf("可重试状态不应视为查询错误: %v", err)
+			}
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("请求次数 = %d, 期望 %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCountDoesNotRetryQueryErrors(t *testing.T) {
+	client, calls := newRetryTestClient(t, nil, http.StatusBadRequest)
+
+	_, err := client.Count(context.Background(), "logs-*", map[string]interface{}{})
+	if !IsQueryError(err) {
+		t.Fatalf("400 应返回查询错误，实际: %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("请求次数 = %d, 期望 1", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		retryAfter string
+		attempt    int
+		want       time.Duration
+	}{
+		{"", 0, retryBaseDelay},
+		{"", 2, 4 * retryBaseDelay},
+		{"", 10, retryMaxDelay},
+		{"3", 0, 3 * time.Second},
+		{"0", 5, 0},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+		{"soon", 1, 2 * retryBaseDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.retryAfter, tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%q, %d) = %s, 期望 %s", tt.retryAfter, tt.attempt, got, tt.want)
+		}
+	}
+}
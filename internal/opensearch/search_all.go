@@ -0,0 +1,65 @@
+package opensearch
+
+import (
+	"context"
+	"opensearch-alert/pkg/types"
+)
+
+// searchAllPageSize search_after 分页时每页的文档数
+const searchAllPageSize = 500
+
+// SearchAll 使用 search_after 分页收集全部匹配文档（最多 maxHits 条），并返回精确的总数
+func (c *Client) SearchAll(ctx context.Context, index string, query map[string]interface{}, maxHits int) (*types.OpenSearchResponse, error) {
+	// 复制顶层查询，避免修改调用方的查询
+	paged := make(map[string]interface{}, len(query)+3)
+	for k, v := range query {
+		paged[k] = v
+	}
+	paged["track_total_hits"] = true
+	// search_after 需要稳定排序：沿用查询的排序（默认 @timestamp 倒序），以 _id 作为同值文档的次序
+	sortClause := []map[string]interface{}{
+		{"@timestamp": map[string]interface{}{"order": "desc"}},
+	}
+	if querySort, ok := query["sort"].([]map[string]interface{}); ok && len(querySort) > 0 {
+		sortClause = append([]map[string]interface{}{}, querySort...)
+	}
+	paged["sort"] = append(sortClause, map[string]interface{}{"_id": map[string]interface{}{"order": "asc"}})
+
+	var result *types.OpenSearchResponse
+	for {
+		size := searchAllPageSize
+		if maxHits > 0 {
+			collected := 0
+			if result != nil {
+				collected = len(result.Hits.Hits)
+			}
+			if remaining := maxHits - collected; remaining < size {
+				size = remaining
+			}
+		}
+		paged["size"] = size
+
+		page, err := c.Search(ctx, index, paged)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			result = page
+		} else {
+			result.Hits.Hits = append(result.Hits.Hits, page.Hits.Hits...)
+		}
+
+		hits := page.Hits.Hits
+		if len(hits) < size || len(hits) == 0 {
+			break
+		}
+		if maxHits > 0 && len(result.Hits.Hits) >= maxHits {
+			c.logger.Warnf("索引 %s 匹配文档超过上限 %d，仅收集前 %d 条", index, maxHits, maxHits)
+			break
+		}
+		paged["search_after"] = hits[len(hits)-1].Sort
+	}
+
+	c.logger.Debugf("分页查询完成，共收集 %d 条文档（总数 %d）", len(result.Hits.Hits), result.Hits.Total.Value)
+	return result, nil
+}
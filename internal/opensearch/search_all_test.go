@@ -0,0 +1,156 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+// pagingStub 模拟 search_after 分页的 OpenSearch：文档按 sort 值 [total-i, "doc-i"] 排序
+type pagingStub struct {
+	total int
+
+	mu       sync.Mutex
+	requests []map[string]interface{}
+}
+
+func (s *pagingStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var req map[string]interface{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	start := 0
+	if after, ok := req["search_after"].([]interface{}); ok && len(after) == 2 {
+		var i int
+		fmt.Sscanf(after[1].(string), "doc-%d", &i)
+		start = i + 1
+	}
+	size := int(req["size"].(float64))
+
+	hits := []map[string]interface{}{}
+	for i := start; i < s.total && len(hits) < size; i++ {
+		hits = append(hits, map[string]interface{}{
+			"_id":     fmt.Sprintf("doc-%d", i),
+			"_source": map[string]interface{}{"n": i},
+			"sort":    []interface{}{s.total - i, fmt.Sprintf("doc-%d", i)},
+		})
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"hits": map[string]interface{}{
+			"total": map[string]interface{}{"value": s.total, "relation": "eq"},
+			"hits":  hits,
+		},
+	})
+}
+
+// newPagingClient 启动分页桩服务并返回客户端
+func newPagingClient(t *testing.T, total int) (*Client, *pagingStub) {
+	t.Helper()
+	stub := &pagingStub{total: total}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	client, err := NewClient(types.OpenSearchConfig{Host: server.URL, Timeout: 5})
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	return client, stub
+}
+
+func TestSearchAllPaginates(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		maxHits   int
+		wantHits  int
+		wantPages int
+	}{
+		{"不足一页", 120, 0, 120, 1},
+		{"多页且末页不满", 1203, 0, 1203, 3},
+		{"恰好整页时多请求一次空页", 1000, 0, 1000, 3},
+		{"达到 maxHits 停止", 1203, 700, 700, 2},
+		{"maxHits 小于一页", 1203, 50, 50, 1},
+		{"无匹配", 0, 0, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, stub := newPagingClient(t, tt.total)
+			query := map[string]interface{}{"query": map[string]interface{}{"match_all": map[string]interface{}{}}}
+
+			response, err := client.SearchAll(context.Background(), "logs-*", query, tt.maxHits)
+			if err != nil {
+				t.Fatalf("SearchAll 失败: %v", err)
+			}
+			if got := len(response.Hits.Hits); got != tt.wantHits {
+				t.Errorf("收集文档数 = %d, 期望 %d", got, tt.wantHits)
+			}
+			if response.Hits.Total.Value != tt.total {
+				t.Errorf("总数 = %d, 期望 %d", response.Hits.Total.Value, tt.total)
+			}
+			if got := len(stub.requests); got != tt.wantPages {
+				t.Errorf("请求页数 = %d, 期望 %d", got, tt.wantPages)
+			}
+
+			// 文档按顺序收集，没有重复或遗漏
+			for i, hit := range response.Hits.Hits {
+				if hit.ID != fmt.Sprintf("doc-%d", i) {
+					t.Fatalf("第 %d 条文档为 %s，分页出现重复或遗漏", i, hit.ID)
+				}
+			}
+
+			// 调用方的查询不被修改
+			if _, ok := query["search_after"]; ok || len(query) != 1 {
+				t.Errorf("SearchAll 不应修改调用方的查询: %v", query)
+			}
+		})
+	}
+}
+
+func TestSearchAllRequestShape(t *testing.T) {
+	client, stub := newPagingClient(t, 600)
+	query := map[string]interface{}{
+		"query": map[string]interface{}{"match_all": map[string]interface{}{}},
+		"sort":  []map[string]interface{}{{"level": map[string]interface{}{"order": "asc"}}},
+	}
+	if _, err := client.SearchAll(context.Background(), "logs-*", query, 0); err != nil {
+		t.Fatalf("SearchAll 失败: %v", err)
+	}
+	if len(stub.requests) != 2 {
+		t.Fatalf("请求页数 = %d, 期望 2", len(stub.requests))
+	}
+
+	first, second := stub.requests[0], stub.requests[1]
+	if first["track_total_hits"] != true {
+		t.Error("应请求精确总数 track_total_hits")
+	}
+	if _, ok := first["search_after"]; ok {
+		t.Error("首页不应携带 search_after")
+	}
+
+	// 沿用查询排序，并以 _id 作为稳定的次序
+	sortJSON, _ := json.Marshal(first["sort"])
+	if string(sortJSON) != `[{"level":{"order":"asc"}},{"_id":{"order":"asc"}}]` {
+		t.Errorf("排序 = %s", sortJSON)
+	}
+
+	// 第二页从首页最后一条文档的 sort 值继续
+	afterJSON, _ := json.Marshal(second["search_after"])
+	if string(afterJSON) != `[101,"doc-499"]` {
+		t.Errorf("search_after = %s, 期望首页末条的 sort 值", afterJSON)
+	}
+	if second["size"].(float64) != searchAllPageSize {
+		t.Errorf("每页大小 = %v, 期望 %d", second["size"], searchAllPageSize)
+	}
+}
@@ -0,0 +1,45 @@
+package opensearch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"opensearch-alert/pkg/types"
+	"os"
+)
+
+// buildTLSConfig 根据配置构建 TLS 设置：加载自定义 CA 与客户端证书（mTLS）；
+// verify_certs 为 false 时显式跳过证书校验
+func buildTLSConfig(config types.OpenSearchConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: !config.VerifyCerts,
+	}
+
+	if config.CACertFile != "" {
+		caPEM, err := os.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 CA 证书失败: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("CA 证书文件 %s 中没有有效的 PEM 证书", config.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCertFile != "" || config.ClientKeyFile != "" {
+		if config.ClientCertFile == "" || config.ClientKeyFile == "" {
+			return nil, fmt.Errorf("客户端证书需同时配置 client_cert_file 与 client_key_file")
+		}
+		cert, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,213 @@
+package opensearch
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// testCA 测试用的自签名 CA
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// newTestCA 生成自签名 CA 证书
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成 CA 私钥失败: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "opensearch-alert test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("生成 CA 证书失败: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("解析 CA 证书失败: %v", err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue 用 CA 签发证书，返回 tls.Certificate 及 PEM 格式的证书与私钥
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) (tls.Certificate, []byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("签发证书失败: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("编码私钥失败: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("加载证书失败: %v", err)
+	}
+	return pair, certPEM, keyPEM
+}
+
+// writeFile 将内容写入临时目录并返回路径
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("写入 %s 失败: %v", name, err)
+	}
+	return path
+}
+
+// newTLSServer 启动使用 CA 签发证书的 HTTPS 服务；requireClientCert 为 true 时要求并校验客户端证书（mTLS）
+func newTLSServer(t *testing.T, ca *testCA, requireClientCert bool) *httptest.Server {
+	t.Helper()
+	serverCert, _, _ := ca.issue(t, "opensearch", x509.ExtKeyUsageServerAuth)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requireClientCert && (r.TLS == nil || len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "alert-client") {
+			http.Error(w, "client certificate required", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"status":"green","cluster_name":"test","number_of_nodes":1}`))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert}}
+	if requireClientCert {
+		pool := x509.NewCertPool()
+		pool.AddCert(ca.cert)
+		server.TLS.ClientCAs = pool
+		server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// healthCheck 用给定配置创建客户端并执行健康检查
+func healthCheck(t *testing.T, config types.OpenSearchConfig) error {
+	t.Helper()
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	return client.HealthCheck(context.Background())
+}
+
+func TestBuildTLSConfigCustomCA(t *testing.T) {
+	ca := newTestCA(t)
+	server := newTLSServer(t, ca, false)
+	dir := t.TempDir()
+	caFile := writeFile(t, dir, "ca.pem", ca.pem)
+
+	if err := healthCheck(t, types.OpenSearchConfig{Host: server.URL, VerifyCerts: true, Timeout: 5, CACertFile: caFile}); err != nil {
+		t.Fatalf("配置 CA 后应能校验私有 CA 签发的证书: %v", err)
+	}
+
+	err := healthCheck(t, types.OpenSearchConfig{Host: server.URL, VerifyCerts: true, Timeout: 5})
+	if err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("未配置 CA 时应校验失败，实际: %v", err)
+	}
+
+	if err := healthCheck(t, types.OpenSearchConfig{Host: server.URL, VerifyCerts: false, Timeout: 5}); err != nil {
+		t.Fatalf("verify_certs 为 false 时应跳过校验: %v", err)
+	}
+}
+
+func TestBuildTLSConfigClientCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	server := newTLSServer(t, ca, true)
+	dir := t.TempDir()
+	caFile := writeFile(t, dir, "ca.pem", ca.pem)
+	_, certPEM, keyPEM := ca.issue(t, "alert-client", x509.ExtKeyUsageClientAuth)
+	certFile := writeFile(t, dir, "client.pem", certPEM)
+	keyFile := writeFile(t, dir, "client-key.pem", keyPEM)
+
+	base := types.OpenSearchConfig{Host: server.URL, VerifyCerts: true, Timeout: 5, CACertFile: caFile}
+	if err := healthCheck(t, base); err == nil {
+		t.Fatal("服务端要求客户端证书时，未配置证书的请求应失败")
+	}
+
+	mtls := base
+	mtls.ClientCertFile = certFile
+	mtls.ClientKeyFile = keyFile
+	if err := healthCheck(t, mtls); err != nil {
+		t.Fatalf("配置客户端证书后 mTLS 应成功: %v", err)
+	}
+}
+
+func TestBuildTLSConfigErrors(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	_, certPEM, keyPEM := ca.issue(t, "alert-client", x509.ExtKeyUsageClientAuth)
+	certFile := writeFile(t, dir, "client.pem", certPEM)
+	keyFile := writeFile(t, dir, "client-key.pem", keyPEM)
+	invalid := writeFile(t, dir, "invalid.pem", []byte("not a certificate"))
+
+	tests := []struct {
+		name   string
+		config types.OpenSearchConfig
+		want   string
+	}{
+		{"CA 文件不存在", types.OpenSearchConfig{CACertFile: filepath.Join(dir, "missing.pem")}, "读取 CA 证书失败"},
+		{"CA 文件无 PEM 证书", types.OpenSearchConfig{CACertFile: invalid}, "没有有效的 PEM 证书"},
+		{"只配置证书", types.OpenSearchConfig{ClientCertFile: certFile}, "需同时配置"},
+		{"只配置私钥", types.OpenSearchConfig{ClientKeyFile: keyFile}, "需同时配置"},
+		{"证书与私钥不匹配", types.OpenSearchConfig{ClientCertFile: certFile, ClientKeyFile: invalid}, "加载客户端证书失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := buildTLSConfig(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("期望包含 %q 的错误，实际: %v", tt.want, err)
+			}
+		})
+	}
+
+	tlsConfig, err := buildTLSConfig(types.OpenSearchConfig{VerifyCerts: true, ClientCertFile: certFile, ClientKeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("buildTLSConfig 失败: %v", err)
+	}
+	if tlsConfig.InsecureSkipVerify || len(tlsConfig.Certificates) != 1 || tlsConfig.RootCAs != nil {
+		t.Errorf("TLS 配置不符合预期: %+v", tlsConfig)
+	}
+}
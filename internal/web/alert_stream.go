@@ -0,0 +1,119 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+const (
+	// maxAlertSubscribers 同时订阅告警推送的连接上限
+	maxAlertSubscribers = 100
+	// alertSubscriberBuffer 单个订阅者的待发送队列长度，队列满时丢弃新告警
+	alertSubscriberBuffer = 32
+)
+
+// alertHub 新告警广播，订阅者数量有上限
+type alertHub struct {
+	mu          sync.Mutex
+	subscribers map[chan types.AlertDetail]struct{}
+}
+
+// newAlertHub 创建告警广播
+func newAlertHub() *alertHub {
+	return &alertHub{subscribers: make(map[chan types.AlertDetail]struct{})}
+}
+
+// subscribe 订阅新告警，订阅者已满时返回 false
+func (h *alertHub) subscribe() (chan types.AlertDetail, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.subscribers) >= maxAlertSubscribers {
+		return nil, false
+	}
+	ch := make(chan types.AlertDetail, alertSubscriberBuffer)
+	h.subscribers[ch] = struct{}{}
+	return ch, true
+}
+
+// unsubscribe 取消订阅
+func (h *alertHub) unsubscribe(ch chan types.AlertDetail) {
+	h.mu.Lock()
+	delete(h.subscribers, ch)
+	h.mu.Unlock()
+}
+
+// publish 向所有订阅者推送告警，订阅者处理不过来时丢弃，不阻塞告警引擎
+func (h *alertHub) publish(alert *types.Alert) {
+	detail := types.AlertDetail{
+		ID:        alert.ID,
+		RuleName:  alert.RuleName,
+		Level:     alert.Level,
+		Message:   alert.Message,
+		Timestamp: alert.Timestamp,
+		Count:     int64(alert.Count),
+		Matches:   int64(alert.Matches),
+		Data:      alert.Data,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- detail:
+		default:
+		}
+	}
+}
+
+// handleStreamAlerts 以 SSE 实时推送新触发的告警（event: alert，data 为 AlertDetail）
+func (s *Server) handleStreamAlerts(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.respondJSON(w, map[string]string{"error": "当前连接不支持流式响应"}, http.StatusInternalServerError)
+		return
+	}
+
+	alerts, ok := s.alertHub.subscribe()
+	if !ok {
+		s.respondJSON(w, map[string]string{"error": "实时推送连接数已达上限"}, http.StatusServiceUnavailable)
+		return
+	}
+	defer s.alertHub.unsubscribe(alerts)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.streamsDone:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case alert := <-alerts:
+			data, err := json.Marshal(alert)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: alert\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"opensearch-alert/internal/alert"
+	"opensearch-alert/internal/notification"
+	"opensearch-alert/pkg/types"
+)
+
+// openStream 连接 SSE 接口，返回逐行读取响应的 reader
+func openStream(t *testing.T, url string) (*bufio.Reader, io.Closer) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("连接 %s 失败: %v", url, err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		resp.Body.Close()
+		t.Fatalf("期望 SSE 响应，实际 %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return bufio.NewReader(resp.Body), resp.Body
+}
+
+// readEvent 读取下一个 SSE 事件（跳过心跳注释），超时或连接断开时返回错误
+func readEvent(reader *bufio.Reader, timeout time.Duration) (string, string, error) {
+	type result struct {
+		event, data string
+		err         error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				r.err = err
+				break
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				r.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				r.data = strings.TrimPrefix(line, "data: ")
+			case line == "" && r.event != "":
+				done <- r
+				return
+			}
+		}
+		done <- r
+	}()
+	select {
+	case r := <-done:
+		return r.event, r.data, r.err
+	case <-time.After(timeout):
+		return "", "", context.DeadlineExceeded
+	}
+}
+
+// waitSubscribers 等待告警推送的订阅者数量达到 n
+func waitSubscribers(t *testing.T, hub *alertHub, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		hub.mu.Lock()
+		count := len(hub.subscribers)
+		hub.mu.Unlock()
+		if count == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("订阅者数量未达到 %d", n)
+}
+
+func TestStreamAlertsPushesNewAlert(t *testing.T) {
+	cfg := newTestConfig()
+	db := newTestDatabase(t)
+	client := newTestOpenSearch(t, &searchStub{total: 3})
+	engine := alert.NewEngine(cfg, client, notification.NewNotifier(cfg, newTestLogger()), db, newTestLogger())
+	s := NewServer(cfg, db, nil, engine, client, newTestLogger())
+	engine.LoadRules([]types.AlertRule{{Name: "pushed", Type: "any", Index: "app-*", Timeframe: 300, Level: "High", Enabled: true}})
+
+	server := httptest.NewServer(s.router)
+	defer server.Close()
+	reader, body := openStream(t, server.URL+"/api/alerts/stream")
+	defer body.Close()
+	waitSubscribers(t, s.alertHub, 1)
+
+	result, err := engine.RunRuleNow("pushed", true)
+	if err != nil || !result.Fired {
+		t.Fatalf("规则应触发告警: %+v %v", result, err)
+	}
+
+	event, data, err := readEvent(reader, 2*time.Second)
+	if err != nil {
+		t.Fatalf("未收到推送: %v", err)
+	}
+	var detail types.AlertDetail
+	if err := json.Unmarshal([]byte(data), &detail); err != nil {
+		t.Fatalf("推送数据不是合法 JSON: %v (%s)", err, data)
+	}
+	if event != "alert" || detail.RuleName != "pushed" || detail.Level != "High" || detail.ID != result.Alert.ID {
+		t.Errorf("推送内容不符: event=%q %+v", event, detail)
+	}
+}
+
+func TestStreamAlertsEndsOnShutdown(t *testing.T) {
+	s := newTestServer(t, newTestConfig(), nil, nil)
+	server := httptest.NewServer(s.router)
+	defer server.Close()
+	reader, body := openStream(t, server.URL+"/api/alerts/stream")
+	defer body.Close()
+	waitSubscribers(t, s.alertHub, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("关闭服务失败: %v", err)
+	}
+	if _, _, err := readEvent(reader, time.Second); err != io.EOF {
+		t.Errorf("关闭服务后连接应结束，实际: %v", err)
+	}
+	waitSubscribers(t, s.alertHub, 0)
+}
+
+func TestAlertHubSubscriberLimit(t *testing.T) {
+	hub := newAlertHub()
+	for i := 0; i < maxAlertSubscribers; i++ {
+		if _, ok := hub.subscribe(); !ok {
+			t.Fatalf("第 %d 个订阅者应成功", i+1)
+		}
+	}
+	if _, ok := hub.subscribe(); ok {
+		t.Error("超过上限的订阅应被拒绝")
+	}
+}
@@ -0,0 +1,144 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// alertsPage /api/alerts 的响应
+type alertsPage struct {
+	Alerts []types.AlertHistory `json:"alerts"`
+	Total  int64                `json:"total"`
+	Error  string               `json:"error"`
+}
+
+// getAlerts 请求 /api/alerts 并解析响应
+func getAlerts(t *testing.T, s *Server, params url.Values) (int, alertsPage) {
+	t.Helper()
+	rec := serve(s, "GET", "/api/alerts?"+params.Encode(), "", nil, nil)
+	var page alertsPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("解析响应失败: %v\n%s", err, rec.Body.String())
+	}
+	return rec.Code, page
+}
+
+func TestGetAlertsByStartEnd(t *testing.T) {
+	db := newTestDatabase(t)
+	s := newTestServer(t, newTestConfig(), db, nil)
+	now := time.Now().Truncate(time.Second)
+	for i, age := range []time.Duration{72 * time.Hour, 48 * time.Hour, 24 * time.Hour, time.Hour} {
+		alert := &types.Alert{ID: fmt.Sprintf("alert-%d", i), RuleName: "rule", Level: "High", Message: "msg", Timestamp: now.Add(-age)}
+		if err := db.SaveAlert(alert); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	code, page := getAlerts(t, s, url.Values{
+		"start": {now.Add(-50 * time.Hour).Format(time.RFC3339)},
+		"end":   {now.Add(-23 * time.Hour).UTC().Format(time.RFC3339)},
+	})
+	if code != http.StatusOK || page.Total != 2 || len(page.Alerts) != 2 {
+		t.Fatalf("绝对时间范围查询 = %d, total=%d，期望 200, 2", code, page.Total)
+	}
+	if page.Alerts[0].AlertID != "alert-2" || page.Alerts[1].AlertID != "alert-1" {
+		t.Errorf("告警 = %s, %s，期望 alert-2, alert-1", page.Alerts[0].AlertID, page.Alerts[1].AlertID)
+	}
+
+	// 只给 start 时 end 为当前时间
+	if _, page := getAlerts(t, s, url.Values{"start": {now.Add(-25 * time.Hour).Format(time.RFC3339)}}); page.Total != 2 {
+		t.Errorf("仅指定 start 时 total = %d, 期望 2", page.Total)
+	}
+
+	// 未指定 start/end 时沿用 hours 相对窗口，同时给出时以 start/end 为准
+	if _, page := getAlerts(t, s, url.Values{"hours": {"30"}}); page.Total != 2 {
+		t.Errorf("hours=30 时 total = %d, 期望 2", page.Total)
+	}
+	if _, page := getAlerts(t, s, url.Values{"hours": {"2"}, "start": {now.Add(-100 * time.Hour).Format(time.RFC3339)}}); page.Total != 4 {
+		t.Errorf("同时指定 hours 与 start 时 total = %d, 期望 4", page.Total)
+	}
+}
+
+func TestGetAlertsRejectsInvalidRange(t *testing.T) {
+	s := newTestServer(t, newTestConfig(), newTestDatabase(t), nil)
+	tests := []struct {
+		name   string
+		params url.Values
+	}{
+		{"start 格式错误", url.Values{"start": {"2024-01-02 15:04:05"}}},
+		{"end 格式错误", url.Values{"start": {"2024-01-02T00:00:00Z"}, "end": {"yesterday"}}},
+		{"start 晚于 end", url.Values{"start": {"2024-01-03T00:00:00Z"}, "end": {"2024-01-02T00:00:00Z"}}},
+		{"start 等于 end", url.Values{"start": {"2024-01-02T00:00:00Z"}, "end": {"2024-01-02T08:00:00+08:00"}}},
+		{"仅 end 时 start 缺省为零值", url.Values{"end": {"0001-01-01T00:00:00Z"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, page := getAlerts(t, s, tt.params)
+			if code != http.StatusBadRequest || page.Error == "" {
+				t.Errorf("状态码 = %d, error = %q，期望 400 与错误信息", code, page.Error)
+			}
+		})
+	}
+}
+
+func TestParseTimeRange(t *testing.T) {
+	start, end, errMsg := parseTimeRange("2024-01-02T08:00:00+08:00", "2024-01-02T01:30:00Z")
+	if errMsg != "" {
+		t.Fatalf("解析失败: %s", errMsg)
+	}
+	if !start.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2024, 1, 2, 1, 30, 0, 0, time.UTC)) {
+		t.Errorf("start, end = %v, %v", start, end)
+	}
+
+	before := time.Now()
+	_, end, errMsg = parseTimeRange("2024-01-02T00:00:00Z", "")
+	if errMsg != "" || end.Before(before) {
+		t.Errorf("缺省 end 应为当前时间，实际 %v（%s）", end, errMsg)
+	}
+}
+
+func TestGetAlertsCombinedFilters(t *testing.T) {
+	db := newTestDatabase(t)
+	s := newTestServer(t, newTestConfig(), db, nil)
+	now := time.Now()
+	seed := []struct {
+		id    string
+		rule  string
+		level string
+		age   time.Duration
+		ack   bool
+	}{
+		{"match", "errors", "Critical", time.Hour, true},
+		{"other-rule", "latency", "Critical", time.Hour, true},
+		{"other-level", "errors", "Low", time.Hour, true},
+		{"too-old", "errors", "Critical", 48 * time.Hour, true},
+		{"unacked", "errors", "Critical", time.Hour, false},
+	}
+	for _, a := range seed {
+		if err := db.SaveAlert(&types.Alert{ID: a.id, RuleName: a.rule, Level: a.level, Message: "msg", Timestamp: now.Add(-a.age)}); err != nil {
+			t.Fatal(err)
+		}
+		if a.ack {
+			if _, err := db.AcknowledgeAlert(a.id, "ops"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	code, page := getAlerts(t, s, url.Values{"rule": {"errors"}, "level": {"Critical"}, "hours": {"24"}, "acknowledged": {"true"}})
+	if code != http.StatusOK || page.Total != 1 || len(page.Alerts) != 1 || page.Alerts[0].AlertID != "match" {
+		t.Errorf("组合过滤结果 = %d, %+v，期望仅 match", code, page.Alerts)
+	}
+	if _, page := getAlerts(t, s, url.Values{"rule": {"errors"}, "acknowledged": {"false"}}); page.Total != 1 {
+		t.Errorf("rule+acknowledged=false 时 total = %d, 期望 1", page.Total)
+	}
+	if code, _ := getAlerts(t, s, url.Values{"acknowledged": {"maybe"}}); code != http.StatusBadRequest {
+		t.Errorf("acknowledged 非法时状态码 = %d, 期望 400", code)
+	}
+}
@@ -0,0 +1,102 @@
+package web
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"opensearch-alert/pkg/types"
+)
+
+// 审计操作类型
+const (
+	auditRuleCreate   = "rule.create"
+	auditRuleUpdate   = "rule.update"
+	auditRuleEnable   = "rule.enable"
+	auditRuleDisable  = "rule.disable"
+	auditRuleDelete   = "rule.delete"
+	auditRuleSnooze   = "rule.snooze"
+	auditRuleUnsnooze = "rule.unsnooze"
+	auditConfigUpdate = "config.update"
+)
+
+// recordAudit 记录一次配置或规则变更，写入失败仅记录日志，不影响本次操作
+func (s *Server) recordAudit(user *types.User, action, target, summary string) {
+	entry := &types.AuditEntry{
+		Action:  action,
+		Target:  target,
+		Summary: summary,
+	}
+	if user != nil {
+		entry.Username = user.Username
+	}
+	if err := s.database.SaveAudit(entry); err != nil {
+		s.logger.Warnf("写入审计记录失败（%s %s）: %v", action, target, err)
+	}
+}
+
+// handleGetAudit 分页获取审计记录（仅 admin）
+func (s *Server) handleGetAudit(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	pageSize, _ := strconv.Atoi(query.Get("page_size"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	entries, total, err := s.database.ListAudit(page, pageSize)
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": "获取审计记录失败"}, http.StatusInternalServerError)
+		return
+	}
+
+	s.respondJSON(w, map[string]interface{}{
+		"entries":   entries,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	}, http.StatusOK)
+}
+
+// changedLines 从 lineDiff 输出中仅保留新增与删除的行（及省略差异的说明），作为审计摘要
+func changedLines(diff string) string {
+	var out strings.Builder
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "+ ") || strings.HasPrefix(line, "! ") {
+			out.WriteString(line)
+		}
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// changedConfigSections 比较 Web 可编辑的配置段，返回有变化的段名（不记录具体值，避免密钥写入审计）
+func changedConfigSections(oldCfg, newCfg *types.Config) []string {
+	sections := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"opensearch", oldCfg.OpenSearch, newCfg.OpenSearch},
+		{"alert_engine", oldCfg.AlertEngine, newCfg.AlertEngine},
+		{"web", oldCfg.Web, newCfg.Web},
+		{"database", oldCfg.Database, newCfg.Database},
+		{"notifications", oldCfg.Notifications, newCfg.Notifications},
+	}
+
+	var changed []string
+	for _, section := range sections {
+		if !reflect.DeepEqual(section.old, section.new) {
+			changed = append(changed, section.name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,102 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"opensearch-alert/internal/config"
+	"opensearch-alert/pkg/types"
+)
+
+// newAuditTestServer 创建开启认证、规则目录位于临时目录的服务器，用户 alice 为 admin，bob 为 viewer
+func newAuditTestServer(t *testing.T) *Server {
+	t.Helper()
+	hash, err := config.HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword 失败: %v", err)
+	}
+	cfg := newTestConfig()
+	cfg.Rules.RulesFolder = t.TempDir()
+	cfg.Auth.Enabled = true
+	cfg.Auth.Users = []types.User{
+		{Username: "alice", Password: hash, Role: "admin"},
+		{Username: "bob", Password: hash, Role: "viewer"},
+	}
+	return newTestServer(t, cfg, newTestDatabase(t), nil)
+}
+
+// loginAs 以指定用户登录，返回 CSRF Token 与会话 Cookie
+func loginAs(t *testing.T, s *Server, username string) (string, []*http.Cookie) {
+	t.Helper()
+	rec := serve(s, http.MethodPost, "/api/login", `{"username":"`+username+`","password":"secret"}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s 登录失败: %d %s", username, rec.Code, rec.Body.String())
+	}
+	return fetchCSRFToken(t, s, rec.Result().Cookies())
+}
+
+// listAudit 获取审计记录
+func listAudit(t *testing.T, s *Server, cookies []*http.Cookie) (int, []types.AuditEntry) {
+	t.Helper()
+	rec := serve(s, http.MethodGet, "/api/audit", "", cookies, nil)
+	var resp struct {
+		Entries []types.AuditEntry `json:"entries"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec.Code, resp.Entries
+}
+
+func TestUpsertRuleWritesAudit(t *testing.T) {
+	s := newAuditTestServer(t)
+	token, cookies := loginAs(t, s, "alice")
+	headers := map[string]string{csrfHeader: token}
+
+	rec := serve(s, http.MethodPost, "/api/rules", `{"name":"errors","type":"frequency","index":"app-*","threshold":5,"enabled":true}`, cookies, headers)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("创建规则失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = serve(s, http.MethodPost, "/api/rules", `{"name":"errors","type":"frequency","index":"app-*","threshold":8,"enabled":true}`, cookies, headers)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("更新规则失败 %d: %s", rec.Code, rec.Body.String())
+	}
+
+	code, entries := listAudit(t, s, cookies)
+	if code != http.StatusOK || len(entries) != 2 {
+		t.Fatalf("审计记录 = %d, %+v，期望 2 条", code, entries)
+	}
+	// 时间倒序：最新的更新在前
+	update, create := entries[0], entries[1]
+	if create.Action != auditRuleCreate || create.Target != "errors" || create.Username != "alice" {
+		t.Errorf("创建记录不符: %+v", create)
+	}
+	if update.Action != auditRuleUpdate || update.Target != "errors" || update.Username != "alice" {
+		t.Errorf("更新记录不符: %+v", update)
+	}
+	if !strings.Contains(update.Summary, "threshold: 5") || !strings.Contains(update.Summary, "threshold: 8") {
+		t.Errorf("更新记录应包含变更差异，实际 %q", update.Summary)
+	}
+	if update.CreatedAt.IsZero() {
+		t.Error("审计记录应包含时间")
+	}
+}
+
+func TestAuditRequiresAdmin(t *testing.T) {
+	s := newAuditTestServer(t)
+	token, cookies := loginAs(t, s, "bob")
+
+	// 无权限的修改被拒绝，也不写审计
+	rec := serve(s, http.MethodPost, "/api/rules", `{"name":"errors","type":"any","index":"app-*"}`, cookies, map[string]string{csrfHeader: token})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("viewer 保存规则状态码 = %d, 期望 403", rec.Code)
+	}
+	if code, _ := listAudit(t, s, cookies); code != http.StatusForbidden {
+		t.Errorf("viewer 查看审计状态码 = %d, 期望 403", code)
+	}
+
+	_, adminCookies := loginAs(t, s, "alice")
+	if code, entries := listAudit(t, s, adminCookies); code != http.StatusOK || len(entries) != 0 {
+		t.Errorf("被拒绝的操作不应写审计，实际 %d, %+v", code, entries)
+	}
+}
@@ -0,0 +1,42 @@
+package web
+
+import "opensearch-alert/pkg/types"
+
+// secretMask 配置接口中已设置密钥的占位符；更新配置时收到该值表示保留原密钥
+const secretMask = "********"
+
+// maskSecret 已设置的密钥返回占位符，未设置时返回空串
+func maskSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return secretMask
+}
+
+// secretFields 返回配置中所有密钥字段的指针，顺序固定，用于新旧配置逐项对应
+func secretFields(cfg *types.Config) []*string {
+	return []*string{
+		&cfg.OpenSearch.Password,
+		&cfg.OpenSearch.APIKey,
+		&cfg.OpenSearch.Token,
+		&cfg.Web.SessionSecret,
+		&cfg.Database.Password,
+		&cfg.Database.DSN,
+		&cfg.Notifications.Email.Password,
+		&cfg.Notifications.DingTalk.Secret,
+		&cfg.Notifications.Feishu.Secret,
+		&cfg.Notifications.Feishu.AppSecret,
+		&cfg.Notifications.Ntfy.Token,
+		&cfg.Notifications.PagerDuty.RoutingKey,
+	}
+}
+
+// preserveMaskedSecrets 新配置中仍为占位符的密钥恢复为当前值，避免被写成星号
+func preserveMaskedSecrets(current, updated *types.Config) {
+	currentFields := secretFields(current)
+	for i, field := range secretFields(updated) {
+		if *field == secretMask {
+			*field = *currentFields[i]
+		}
+	}
+}
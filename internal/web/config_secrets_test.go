@@ -0,0 +1,108 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestMaskSecret(t *testing.T) {
+	if got := maskSecret("s3cret"); got != secretMask {
+		t.Errorf("已设置的密钥应返回占位符，实际 %q", got)
+	}
+	if got := maskSecret(""); got != "" {
+		t.Errorf("未设置的密钥应返回空串，实际 %q", got)
+	}
+}
+
+func TestPreserveMaskedSecrets(t *testing.T) {
+	current := &types.Config{}
+	for i, field := range secretFields(current) {
+		*field = "old-" + string(rune('a'+i))
+	}
+
+	updated := &types.Config{}
+	for _, field := range secretFields(updated) {
+		*field = secretMask
+	}
+	updated.OpenSearch.Password = "rotated"
+	updated.Notifications.Feishu.Secret = ""
+
+	preserveMaskedSecrets(current, updated)
+
+	currentFields := secretFields(current)
+	for i, field := range secretFields(updated) {
+		want := *currentFields[i]
+		switch field {
+		case &updated.OpenSearch.Password:
+			want = "rotated"
+		case &updated.Notifications.Feishu.Secret:
+			want = ""
+		}
+		if *field != want {
+			t.Errorf("第 %d 个密钥字段 = %q, 期望 %q", i, *field, want)
+		}
+	}
+}
+
+func TestGetConfigMasksSecrets(t *testing.T) {
+	s, cfg, _ := newConfigUpdateServer(t)
+	cfg.OpenSearch.Password = "os-password"
+	cfg.Notifications.Email.Password = "smtp-password"
+	cfg.Notifications.DingTalk.Secret = "dingtalk-secret"
+	cfg.Notifications.Feishu.Secret = "feishu-secret"
+	cfg.Database.Password = "db-password"
+
+	rec := serve(s, http.MethodGet, "/api/config", "", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("获取配置失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	for _, secret := range []string{"os-password", "smtp-password", "dingtalk-secret", "feishu-secret", "db-password", cfg.Web.SessionSecret} {
+		if strings.Contains(body, secret) {
+			t.Errorf("响应中不应包含明文密钥 %q", secret)
+		}
+	}
+
+	var resp map[string]map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if got := resp["opensearch"]["password"]; got != secretMask {
+		t.Errorf("opensearch.password = %v, 期望占位符", got)
+	}
+	if got := resp["opensearch"]["token"]; got != "" {
+		t.Errorf("未设置的 opensearch.token = %v, 期望空串", got)
+	}
+}
+
+func TestUpdateConfigPreservesMaskedSecrets(t *testing.T) {
+	s, cfg, configPath := newConfigUpdateServer(t)
+	cfg.OpenSearch.Password = "os-password"
+	cfg.Notifications.Email.Password = "smtp-password"
+
+	// 前端原样提交占位符表示不修改，提交新值表示更换
+	rec := serve(s, http.MethodPut, "/api/config",
+		`{"opensearch":{"password":"********"},"notifications":{"email":{"password":"new-smtp-password"}}}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("更新配置失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	if cfg.OpenSearch.Password != "os-password" {
+		t.Errorf("提交占位符时应保留原密码，实际 %q", cfg.OpenSearch.Password)
+	}
+	if cfg.Notifications.Email.Password != "new-smtp-password" {
+		t.Errorf("提交新值时应更换密码，实际 %q", cfg.Notifications.Email.Password)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("读取配置文件失败: %v", err)
+	}
+	if strings.Contains(string(data), secretMask) {
+		t.Errorf("写入的配置文件不应包含占位符:\n%s", data)
+	}
+}
@@ -0,0 +1,117 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"opensearch-alert/internal/notification"
+	"opensearch-alert/pkg/types"
+)
+
+// newConfigUpdateServer 返回可通过 PUT /api/config 修改的服务器，配置文件写入临时目录
+func newConfigUpdateServer(t *testing.T) (*Server, *types.Config, string) {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("OPENSEARCH_ALERT_CONFIG_PATH", configPath)
+
+	cfg := newTestConfig()
+	cfg.OpenSearch.Host = "opensearch.local"
+	cfg.OpenSearch.Port = 9200
+	cfg.AlertEngine.RunInterval = 60
+	cfg.Database.Type = "sqlite"
+	cfg.Database.Path = "data/alert.db"
+	secure := true
+	cfg.Web.CookieSecure = &secure
+	cfg.Web.Enabled = true
+	cfg.Web.Port = 8080
+
+	s := newTestServer(t, cfg, newTestDatabase(t), nil)
+	s.notifier = notification.NewNotifier(cfg, newTestLogger())
+	return s, cfg, configPath
+}
+
+func TestUpdateConfigKeepsOmittedKeys(t *testing.T) {
+	s, cfg, configPath := newConfigUpdateServer(t)
+
+	rec := serve(s, http.MethodPut, "/api/config", `{"alert_engine":{"run_interval":30}}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("更新配置应成功: %d %s", rec.Code, rec.Body.String())
+	}
+	if cfg.AlertEngine.RunInterval != 30 {
+		t.Errorf("提交的字段应生效，run_interval = %d", cfg.AlertEngine.RunInterval)
+	}
+	if cfg.OpenSearch.Host != "opensearch.local" || cfg.Database.Path != "data/alert.db" || cfg.Web.Port != 8080 {
+		t.Errorf("未提交的字段应保留原值: %+v %+v %+v", cfg.OpenSearch, cfg.Database, cfg.Web)
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		t.Errorf("配置应写入文件: %v", err)
+	}
+}
+
+func TestUpdateConfigParseErrorLeavesConfigUntouched(t *testing.T) {
+	s, cfg, _ := newConfigUpdateServer(t)
+
+	// cookie_secure 可正常解码，port 类型错误使整个请求失败
+	rec := serve(s, http.MethodPut, "/api/config", `{"web":{"cookie_secure":false,"port":"not-a-port"}}`, nil, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("解析失败应返回 400，实际 %d", rec.Code)
+	}
+	if cfg.Web.CookieSecure == nil || !*cfg.Web.CookieSecure {
+		t.Error("解析失败的请求不应修改运行中的配置（cookie_secure 指针被写入）")
+	}
+	if cfg.Web.Port != 8080 {
+		t.Errorf("web.port 不应被修改，实际 %d", cfg.Web.Port)
+	}
+}
+
+func TestCloneConfigIsDeep(t *testing.T) {
+	retries := 3
+	cfg := &types.Config{Levels: map[string]types.LevelMeta{"Critical": {WeChatColor: "warning"}}}
+	cfg.OpenSearch.MaxRetries = &retries
+
+	clone, err := cloneConfig(cfg)
+	if err != nil {
+		t.Fatalf("复制配置失败: %v", err)
+	}
+	*clone.OpenSearch.MaxRetries = 0
+	delete(clone.Levels, "Critical")
+
+	if *cfg.OpenSearch.MaxRetries != 3 || len(cfg.Levels) != 1 {
+		data, _ := json.Marshal(cfg)
+		t.Errorf("修改副本不应影响原配置: %s", data)
+	}
+}
+
+func TestUpdateConfigRejectsInvalidConfig(t *testing.T) {
+	s, cfg, configPath := newConfigUpdateServer(t)
+
+	body := `{"notifications":{"email":{"enabled":true,"smtp_server":"","smtp_port":0}},"web":{"port":0}}`
+	rec := serve(s, http.MethodPut, "/api/config", body, nil, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("校验失败应返回 400，实际 %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Error  string   `json:"error"`
+		Errors []string `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	joined := strings.Join(resp.Errors, "\n")
+	for _, want := range []string{"smtp_server 不能为空", "smtp_port", "username 不能为空", "web.port"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("errors 应列出全部问题，缺少 %q:\n%s", want, joined)
+		}
+	}
+
+	if cfg.Notifications.Email.Enabled || cfg.Web.Port != 8080 {
+		t.Error("校验失败时不应修改运行中的配置")
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Errorf("校验失败时不应写入配置文件: %v", err)
+	}
+}
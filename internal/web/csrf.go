@@ -0,0 +1,80 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	// csrfSessionKey 会话中保存 CSRF Token 的键
+	csrfSessionKey = "csrf_token"
+	// csrfHeader 前端提交 CSRF Token 的请求头
+	csrfHeader = "X-CSRF-Token"
+)
+
+// csrfToken 获取当前会话的 CSRF Token，不存在时生成并写入会话
+func (s *Server) csrfToken(w http.ResponseWriter, r *http.Request) string {
+	if !s.config.Auth.Enabled {
+		return ""
+	}
+
+	session, err := s.store.Get(r, "opensearch-alert-session")
+	if err != nil {
+		session, _ = s.store.New(r, "opensearch-alert-session")
+	}
+	if token, ok := session.Values[csrfSessionKey].(string); ok && token != "" {
+		return token
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		s.logger.Errorf("生成 CSRF Token 失败: %v", err)
+		return ""
+	}
+	token := hex.EncodeToString(buf)
+	session.Values[csrfSessionKey] = token
+	if err := session.Save(r, w); err != nil {
+		s.logger.Errorf("保存 CSRF Token 到会话失败: %v", err)
+		return ""
+	}
+	return token
+}
+
+// requireCSRF 校验非 GET 的 API 请求携带的 CSRF Token（未开启认证时跳过）
+func (s *Server) requireCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.config.Auth.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// 登录前尚无会话，登录接口不校验
+		if r.URL.Path == "/api/login" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		session, _ := s.store.Get(r, "opensearch-alert-session")
+		expected, _ := session.Values[csrfSessionKey].(string)
+		provided := r.Header.Get(csrfHeader)
+		if expected == "" || provided == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(provided)) != 1 {
+			s.respondJSON(w, map[string]string{"error": "CSRF 校验失败，请刷新页面后重试"}, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleCSRFToken 获取 CSRF Token
+func (s *Server) handleCSRFToken(w http.ResponseWriter, r *http.Request) {
+	s.respondJSON(w, map[string]string{"csrf_token": s.csrfToken(w, r)}, http.StatusOK)
+}
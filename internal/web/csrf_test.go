@@ -0,0 +1,111 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"opensearch-alert/internal/config"
+	"opensearch-alert/pkg/types"
+)
+
+// newAuthTestServer 创建开启认证的服务器，返回服务器与管理员登录后的会话 Cookie
+func newAuthTestServer(t *testing.T) (*Server, []*http.Cookie) {
+	t.Helper()
+	hash, err := config.HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword 失败: %v", err)
+	}
+	cfg := newTestConfig()
+	cfg.Auth.Enabled = true
+	cfg.Auth.MaxLoginAttempts = 5
+	cfg.Auth.LockoutMinutes = 15
+	cfg.Auth.Users = []types.User{{Username: "admin", Password: hash, Role: "admin"}}
+	s := newTestServer(t, cfg, newTestDatabase(t), nil)
+
+	rec := serve(s, http.MethodPost, "/api/login", `{"username":"admin","password":"secret"}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("登录失败: %d %s", rec.Code, rec.Body.String())
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("登录后应返回会话 Cookie")
+	}
+	return s, cookies
+}
+
+// fetchCSRFToken 获取会话的 CSRF Token，返回 Token 与更新后的 Cookie
+func fetchCSRFToken(t *testing.T, s *Server, cookies []*http.Cookie) (string, []*http.Cookie) {
+	t.Helper()
+	rec := serve(s, http.MethodGet, "/api/csrf", "", cookies, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("获取 CSRF Token 失败: %d %s", rec.Code, rec.Body.String())
+	}
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["csrf_token"] == "" {
+		t.Fatalf("CSRF Token 响应无效: %s", rec.Body.String())
+	}
+	if updated := rec.Result().Cookies(); len(updated) > 0 {
+		cookies = updated
+	}
+	return body["csrf_token"], cookies
+}
+
+func TestRequireCSRF(t *testing.T) {
+	s, cookies := newAuthTestServer(t)
+	token, cookies := fetchCSRFToken(t, s, cookies)
+
+	// 引擎未初始化：通过 CSRF 校验后处理器返回 503，可区分于 CSRF 的 403
+	const path = "/api/rules/test"
+	const body = `{"name":"r","index":"app-*"}`
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"缺少 Token", nil, http.StatusForbidden},
+		{"Token 错误", map[string]string{csrfHeader: token + "x"}, http.StatusForbidden},
+		{"Token 正确", map[string]string{csrfHeader: token}, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(s, http.MethodPost, path, body, cookies, tt.headers)
+			if rec.Code != tt.want {
+				t.Fatalf("状态码 = %d, 期望 %d, 响应: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want == http.StatusForbidden && !strings.Contains(rec.Body.String(), "CSRF") {
+				t.Errorf("403 应来自 CSRF 校验，实际响应: %s", rec.Body.String())
+			}
+		})
+	}
+
+	// 其他会话的 Token 不能通过校验
+	_, otherCookies := loginSession(t, s)
+	if rec := serve(s, http.MethodPost, path, body, otherCookies, map[string]string{csrfHeader: token}); rec.Code != http.StatusForbidden {
+		t.Errorf("其他会话使用该 Token 时状态码 = %d, 期望 403", rec.Code)
+	}
+
+	// GET 请求不校验 Token
+	if rec := serve(s, http.MethodGet, "/api/auth/check", "", cookies, nil); rec.Code == http.StatusForbidden {
+		t.Errorf("GET 请求不应校验 CSRF，实际 %d", rec.Code)
+	}
+}
+
+// loginSession 在已有服务器上重新登录，返回新的会话 Cookie
+func loginSession(t *testing.T, s *Server) (string, []*http.Cookie) {
+	t.Helper()
+	rec := serve(s, http.MethodPost, "/api/login", `{"username":"admin","password":"secret"}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("登录失败: %d", rec.Code)
+	}
+	return fetchCSRFToken(t, s, rec.Result().Cookies())
+}
+
+func TestRequireCSRFSkippedWithoutAuth(t *testing.T) {
+	s := newTestServer(t, newTestConfig(), nil, nil)
+	if rec := serve(s, http.MethodPost, "/api/rules/test", `{"name":"r","index":"app-*"}`, nil, nil); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("未开启认证时不应校验 CSRF，实际 %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+
+	"opensearch-alert/internal/notification"
+
+	"github.com/gorilla/mux"
+)
+
+// handleGetFailedAlerts 分页获取所有渠道均发送失败的告警（死信）
+func (s *Server) handleGetFailedAlerts(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	pageSize, _ := strconv.Atoi(query.Get("page_size"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	failed, total, err := s.database.ListFailedAlerts(page, pageSize)
+	if err != nil {
+		s.logger.Errorf("获取死信告警失败: %v", err)
+		s.respondJSON(w, map[string]string{"error": "获取死信告警失败"}, http.StatusInternalServerError)
+		return
+	}
+
+	s.respondJSON(w, map[string]interface{}{
+		"failed":    failed,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	}, http.StatusOK)
+}
+
+// handleRetryFailedAlert 重新发送死信告警，至少一个渠道发送成功后从死信表移除
+func (s *Server) handleRetryFailedAlert(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		s.respondJSON(w, map[string]string{"error": "无效的死信 ID"}, http.StatusBadRequest)
+		return
+	}
+
+	item, err := s.database.GetFailedAlert(id)
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if item == nil || item.Alert == nil {
+		s.respondJSON(w, map[string]string{"error": "未找到该死信告警"}, http.StatusNotFound)
+		return
+	}
+
+	results, _ := s.notifier.SendAlert(item.Alert)
+	if len(results) == 0 {
+		s.respondJSON(w, map[string]string{"error": "当前没有启用的通知渠道"}, http.StatusServiceUnavailable)
+		return
+	}
+	if err := s.database.SaveNotificationResults(notification.ResultRecords(item.AlertID, results)); err != nil {
+		s.logger.Warnf("保存通知发送结果失败: %v", err)
+	}
+
+	// 各渠道结果：ok 或错误信息
+	channelResults := make(map[string]string, len(results))
+	for name, err := range results {
+		if err != nil {
+			channelResults[name] = err.Error()
+			continue
+		}
+		channelResults[name] = "ok"
+	}
+
+	if errs := notification.FailedChannels(results); errs != nil {
+		if err := s.database.UpdateFailedAlertAttempt(id, errs); err != nil {
+			s.logger.Warnf("更新死信告警 %d 失败: %v", id, err)
+		}
+		s.respondJSON(w, map[string]interface{}{
+			"error":    "重试失败，所有渠道均发送失败",
+			"channels": channelResults,
+		}, http.StatusBadGateway)
+		return
+	}
+
+	if err := s.database.DeleteFailedAlert(id); err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	s.logger.Infof("死信告警 %s 已由 %s 重试发送", item.AlertID, user.Username)
+	s.respondJSON(w, map[string]interface{}{
+		"message":  "重试发送成功",
+		"channels": channelResults,
+	}, http.StatusOK)
+}
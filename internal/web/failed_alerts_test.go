@@ -0,0 +1,97 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"opensearch-alert/internal/notification"
+	"opensearch-alert/pkg/types"
+)
+
+func TestRetryFailedAlert(t *testing.T) {
+	var status int32 = http.StatusServiceUnavailable
+	var sent int32
+	ntfy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&sent, 1)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer ntfy.Close()
+
+	cfg := newTestConfig()
+	cfg.Notifications.Ntfy = types.NtfyConfig{Enabled: true, ServerURL: ntfy.URL, Topic: "alerts"}
+	db := newTestDatabase(t)
+	s := newTestServer(t, cfg, db, nil)
+	s.notifier = notification.NewNotifier(cfg, newTestLogger())
+
+	alert := &types.Alert{ID: "a-1", RuleName: "rule", Level: "High", Message: "boom", Timestamp: time.Now()}
+	if err := db.SaveFailedAlert(alert, map[string]string{"ntfy": "503"}); err != nil {
+		t.Fatalf("保存死信失败: %v", err)
+	}
+	failed, _, _ := db.ListFailedAlerts(1, 1)
+	path := "/api/alerts/failed/" + strconv.FormatInt(failed[0].ID, 10) + "/retry"
+
+	// 渠道仍然失败：保留死信并累加重试次数
+	rec := serve(s, http.MethodPost, path, "", nil, nil)
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("重试失败时状态码 = %d, 期望 502: %s", rec.Code, rec.Body.String())
+	}
+	if item, _ := db.GetFailedAlert(failed[0].ID); item == nil || item.Attempts != 2 {
+		t.Fatalf("重试失败后应保留死信并累加次数: %+v", item)
+	}
+
+	// 渠道恢复：重试成功后移出死信表
+	atomic.StoreInt32(&status, http.StatusOK)
+	rec = serve(s, http.MethodPost, path, "", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("重试成功时状态码 = %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Channels map[string]string `json:"channels"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if body.Channels["ntfy"] != "ok" {
+		t.Errorf("应返回各渠道结果: %s", rec.Body.String())
+	}
+	if item, _ := db.GetFailedAlert(failed[0].ID); item != nil {
+		t.Error("重试成功后应删除死信")
+	}
+	if results, _ := db.GetNotificationResults("a-1"); len(results) != 2 || !results[1].Success {
+		t.Errorf("每次重试都应记录发送结果: %+v", results)
+	}
+	if got := atomic.LoadInt32(&sent); got != 2 {
+		t.Errorf("ntfy 收到 %d 次请求, 期望 2", got)
+	}
+
+	// 列表接口
+	rec = serve(s, http.MethodGet, "/api/alerts/failed", "", nil, nil)
+	var list struct {
+		Total int64 `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || list.Total != 0 {
+		t.Errorf("重试成功后列表应为空: %s", rec.Body.String())
+	}
+}
+
+func TestRetryFailedAlertErrors(t *testing.T) {
+	s := newTestServer(t, newTestConfig(), newTestDatabase(t), nil)
+	s.notifier = notification.NewNotifier(s.config, newTestLogger())
+
+	if rec := serve(s, http.MethodPost, "/api/alerts/failed/abc/retry", "", nil, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("无效 ID 状态码 = %d, 期望 400", rec.Code)
+	}
+	if rec := serve(s, http.MethodPost, "/api/alerts/failed/42/retry", "", nil, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("不存在的死信状态码 = %d, 期望 404", rec.Code)
+	}
+
+	alert := &types.Alert{ID: "a-2", RuleName: "rule", Level: "High", Timestamp: time.Now()}
+	s.database.SaveFailedAlert(alert, map[string]string{"ntfy": "503"})
+	failed, _, _ := s.database.ListFailedAlerts(1, 1)
+	if rec := serve(s, http.MethodPost, "/api/alerts/failed/"+strconv.FormatInt(failed[0].ID, 10)+"/retry", "", nil, nil); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("没有启用渠道时状态码 = %d, 期望 503", rec.Code)
+	}
+}
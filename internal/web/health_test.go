@@ -0,0 +1,93 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"opensearch-alert/internal/opensearch"
+)
+
+// readyzResponse /readyz 的响应
+type readyzResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]componentStatus `json:"components"`
+}
+
+// clusterHealth 返回固定集群状态的 OpenSearch 桩
+func clusterHealth(status string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_cluster/health" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "cluster_name": "test", "number_of_nodes": 1})
+	})
+}
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.Handler
+		noClient   bool
+		closeDB    bool
+		wantCode   int
+		wantDB     string
+		wantSearch string
+	}{
+		{name: "全部正常", handler: clusterHealth("green"), wantCode: http.StatusOK, wantDB: "ok", wantSearch: "ok"},
+		{name: "集群黄色仍就绪", handler: clusterHealth("yellow"), wantCode: http.StatusOK, wantDB: "ok", wantSearch: "ok"},
+		{name: "集群红色", handler: clusterHealth("red"), wantCode: http.StatusServiceUnavailable, wantDB: "ok", wantSearch: "unavailable"},
+		{name: "OpenSearch 返回错误", handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}), wantCode: http.StatusServiceUnavailable, wantDB: "ok", wantSearch: "unavailable"},
+		{name: "OpenSearch 未初始化", noClient: true, wantCode: http.StatusServiceUnavailable, wantDB: "ok", wantSearch: "unavailable"},
+		{name: "数据库不可用", handler: clusterHealth("green"), closeDB: true, wantCode: http.StatusServiceUnavailable, wantDB: "unavailable", wantSearch: "ok"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig()
+			// 运维端点无需认证
+			cfg.Auth.Enabled = true
+			db := newTestDatabase(t)
+			var client *opensearch.Client
+			if !tt.noClient {
+				client = newTestOpenSearch(t, tt.handler)
+			}
+			s := NewServer(cfg, db, nil, nil, client, newTestLogger())
+			if tt.closeDB {
+				db.Close()
+			}
+
+			rec := serve(s, "GET", "/readyz", "", nil, nil)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("状态码 = %d, 期望 %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			var resp readyzResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			wantStatus := "ok"
+			if tt.wantCode != http.StatusOK {
+				wantStatus = "unavailable"
+			}
+			if resp.Status != wantStatus {
+				t.Errorf("status = %q, 期望 %q", resp.Status, wantStatus)
+			}
+			for component, want := range map[string]string{"database": tt.wantDB, "opensearch": tt.wantSearch} {
+				got := resp.Components[component]
+				if got.Status != want {
+					t.Errorf("%s = %+v, 期望 %s", component, got, want)
+				}
+				if (got.Status == "unavailable") != (got.Error != "") {
+					t.Errorf("%s 仅在不可用时附带错误信息: %+v", component, got)
+				}
+			}
+
+			// 存活探针不检查依赖
+			if rec := serve(s, "GET", "/healthz", "", nil, nil); rec.Code != http.StatusOK {
+				t.Errorf("/healthz 状态码 = %d, 期望 200", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"opensearch-alert/internal/alert"
+	"opensearch-alert/internal/database"
+	"opensearch-alert/internal/opensearch"
+	"opensearch-alert/pkg/types"
+)
+
+// newTestLogger 返回丢弃输出的日志器
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// newTestConfig 返回未开启认证的最小配置
+func newTestConfig() *types.Config {
+	cfg := &types.Config{}
+	cfg.Web.SessionSecret = "0123456789abcdef0123456789abcdef"
+	cfg.Web.TemplatePath = filepath.Join("testdata", "no-templates")
+	cfg.Rules.DefaultTimeframe = 300
+	cfg.Rules.DefaultThreshold = 1
+	return cfg
+}
+
+// newTestDatabase 在临时目录创建 SQLite 数据库
+func newTestDatabase(t *testing.T) *database.Database {
+	t.Helper()
+	db, err := database.NewDatabase(types.DatabaseConfig{Type: "sqlite", Path: filepath.Join(t.TempDir(), "alert.db")}, newTestLogger())
+	if err != nil {
+		t.Fatalf("创建测试数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// newTestOpenSearch 启动 OpenSearch 桩服务并返回连接它的客户端
+func newTestOpenSearch(t *testing.T, handler http.Handler) *opensearch.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := opensearch.NewClient(types.OpenSearchConfig{Host: server.URL, Timeout: 5})
+	if err != nil {
+		t.Fatalf("创建 OpenSearch 客户端失败: %v", err)
+	}
+	return client
+}
+
+// newTestServer 创建用于接口测试的服务器，engine、db、client 均可为 nil
+func newTestServer(t *testing.T, cfg *types.Config, db *database.Database, client *opensearch.Client) *Server {
+	t.Helper()
+	var engine *alert.Engine
+	if client != nil {
+		engine = alert.NewEngine(cfg, client, nil, db, newTestLogger())
+	}
+	return NewServer(cfg, db, nil, engine, client, newTestLogger())
+}
+
+// serve 向服务器路由发送请求，headers 为附加请求头
+func serve(s *Server, method, path, body string, cookies []*http.Cookie, headers map[string]string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
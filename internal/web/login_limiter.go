@@ -0,0 +1,151 @@
+package web
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// loginLimiter 登录失败计数与锁定，每个统计键有各自的失败上限
+type loginLimiter struct {
+	mu       sync.Mutex
+	window   time.Duration
+	attempts map[string]*loginAttempt
+}
+
+// loginAttempt 单个键的失败记录
+type loginAttempt struct {
+	failures    int
+	firstFailed time.Time
+	lockedUntil time.Time
+}
+
+// loginKey 登录限流的统计键及其失败上限
+type loginKey struct {
+	key         string
+	maxAttempts int
+}
+
+// newLoginLimiter 创建登录限流器
+func newLoginLimiter(lockoutMinutes int) *loginLimiter {
+	return &loginLimiter{
+		window:   time.Duration(lockoutMinutes) * time.Minute,
+		attempts: make(map[string]*loginAttempt),
+	}
+}
+
+// lockedFor 返回各键中最长的剩余锁定时间，未锁定返回 0
+func (l *loginLimiter) lockedFor(keys ...loginKey) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	var remaining time.Duration
+	for _, k := range keys {
+		a, ok := l.attempts[k.key]
+		if !ok {
+			continue
+		}
+		if d := a.lockedUntil.Sub(now); d > remaining {
+			remaining = d
+		}
+	}
+	return remaining
+}
+
+// fail 记录一次失败，窗口内达到该键的上限时锁定
+func (l *loginLimiter) fail(keys ...loginKey) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for _, k := range keys {
+		a, ok := l.attempts[k.key]
+		if !ok || now.Sub(a.firstFailed) > l.window {
+			a = &loginAttempt{firstFailed: now}
+			l.attempts[k.key] = a
+		}
+		a.failures++
+		if a.failures >= k.maxAttempts {
+			a.lockedUntil = now.Add(l.window)
+		}
+	}
+}
+
+// reset 登录成功后清除计数
+func (l *loginLimiter) reset(keys ...loginKey) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, k := range keys {
+		delete(l.attempts, k.key)
+	}
+}
+
+// cleanup 清理已过期的记录
+func (l *loginLimiter) cleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for key, a := range l.attempts {
+		if now.After(a.lockedUntil) && now.Sub(a.firstFailed) > l.window {
+			delete(l.attempts, key)
+		}
+	}
+}
+
+// loginLimitKeys 登录限流的统计键：账号键只按用户名统计，分散到多个来源的猜测也会锁定该账号；
+// IP 键不区分用户名，上限更高，用于限制单个来源尝试多个账号
+func (s *Server) loginLimitKeys(r *http.Request, username string) (account, ip loginKey) {
+	account = loginKey{key: "user:" + username, maxAttempts: s.config.Auth.MaxLoginAttempts}
+	ip = loginKey{key: "ip:" + s.clientIP(r), maxAttempts: s.config.Auth.MaxLoginAttemptsPerIP}
+	return account, ip
+}
+
+// clientIP 返回请求的客户端 IP：直连地址属于受信任代理时，取 X-Forwarded-For 中从右往左第一个
+// 非受信任代理的地址（其左侧的值可由客户端伪造），没有该头时取 X-Real-IP
+func (s *Server) clientIP(r *http.Request) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	if !s.isTrustedProxy(ip) {
+		return ip
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			ip = hop
+			if !s.isTrustedProxy(hop) {
+				return hop
+			}
+		}
+		return ip
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return ip
+}
+
+// isTrustedProxy 判断地址是否属于受信任的反向代理
+func (s *Server) isTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range s.trustedProxies {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
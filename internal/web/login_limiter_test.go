@@ -0,0 +1,135 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"opensearch-alert/internal/config"
+	"opensearch-alert/pkg/types"
+)
+
+// newLoginTestServer 创建只包含登录所需字段的服务器
+func newLoginTestServer(t *testing.T, trustedProxies ...string) *Server {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	hash, err := config.HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword 失败: %v", err)
+	}
+	cfg := &types.Config{}
+	cfg.Auth.MaxLoginAttempts = 3
+	cfg.Auth.MaxLoginAttemptsPerIP = 5
+	cfg.Auth.LockoutMinutes = 15
+	cfg.Auth.Users = []types.User{{Username: "admin", Password: hash, Role: "admin"}}
+	cfg.Web.TrustedProxies = trustedProxies
+
+	s := &Server{config: cfg, logger: logger, loginLimiter: newLoginLimiter(cfg.Auth.LockoutMinutes)}
+	s.loadTrustedProxies()
+	return s
+}
+
+// login 以指定来源提交一次登录，返回状态码
+func login(s *Server, remoteAddr, forwardedFor, username, password string) int {
+	body := `{"username": "` + username + `", "password": "` + password + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	rec := httptest.NewRecorder()
+	s.handleLogin(rec, req)
+	return rec.Code
+}
+
+func TestLoginLockoutPerAccountAndIP(t *testing.T) {
+	s := newLoginTestServer(t)
+
+	for i := 0; i < 3; i++ {
+		if code := login(s, "192.0.2.1:1000", "", "admin", "wrong"); code != http.StatusUnauthorized {
+			t.Fatalf("第 %d 次错误密码应返回 401，实际 %d", i+1, code)
+		}
+	}
+	if code := login(s, "192.0.2.1:1000", "", "admin", "wrong"); code != http.StatusTooManyRequests {
+		t.Fatalf("达到上限后应锁定，实际 %d", code)
+	}
+
+	// 账号只按用户名计数，换 IP 继续猜测同样被锁定
+	if code := login(s, "198.51.100.7:1000", "", "admin", "wrong"); code != http.StatusTooManyRequests {
+		t.Fatalf("换 IP 后账号仍应锁定，实际 %d", code)
+	}
+
+	// 同一 IP 尝试其他账号受 IP 上限约束
+	for _, user := range []string{"a", "b"} {
+		if code := login(s, "192.0.2.1:1000", "", user, "wrong"); code != http.StatusUnauthorized {
+			t.Fatalf("IP 未达到上限时应返回 401，实际 %d", code)
+		}
+	}
+	if code := login(s, "192.0.2.1:1000", "", "c", "wrong"); code != http.StatusTooManyRequests {
+		t.Fatalf("IP 达到上限后应锁定，实际 %d", code)
+	}
+	if code := login(s, "198.51.100.7:1000", "", "c", "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("其他 IP 上未锁定的账号应返回 401，实际 %d", code)
+	}
+}
+
+func TestLoginLockoutBehindTrustedProxy(t *testing.T) {
+	s := newLoginTestServer(t, "10.0.0.0/8")
+
+	// 攻击者经同一代理轮换用户名，耗尽自己来源的尝试次数
+	for i := 0; i < 5; i++ {
+		login(s, "10.1.2.3:443", "203.0.113.9", "user"+strconv.Itoa(i), "wrong")
+	}
+	if code := login(s, "10.1.2.3:443", "203.0.113.9", "other", "wrong"); code != http.StatusTooManyRequests {
+		t.Fatalf("攻击来源应被锁定，实际 %d", code)
+	}
+
+	// 同一代理后的其他用户不受影响，伪造的左侧 X-Forwarded-For 不能冒充其他来源
+	if code := login(s, "10.1.2.3:443", "198.51.100.20", "other", "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("其他客户端不应被锁定，实际 %d", code)
+	}
+	if code := login(s, "10.1.2.3:443", "198.51.100.30, 203.0.113.9", "another", "wrong"); code != http.StatusTooManyRequests {
+		t.Fatalf("伪造的 X-Forwarded-For 不应绕过锁定，实际 %d", code)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	s := newLoginTestServer(t, "10.0.0.0/8", "192.0.2.10")
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"直连", "203.0.113.1:1234", "", "", "203.0.113.1"},
+		{"不受信任的来源忽略代理头", "203.0.113.1:1234", "198.51.100.1", "198.51.100.2", "203.0.113.1"},
+		{"受信任代理", "10.0.0.5:1234", "198.51.100.1", "", "198.51.100.1"},
+		{"多级代理取最右的非代理地址", "10.0.0.5:1234", "198.51.100.9, 198.51.100.1, 192.0.2.10", "", "198.51.100.1"},
+		{"X-Real-IP", "192.0.2.10:1234", "", "198.51.100.3", "198.51.100.3"},
+		{"全部为代理时取最左", "10.0.0.5:1234", "10.0.0.7, 10.0.0.6", "", "10.0.0.7"},
+		{"非法值", "10.0.0.5:1234", "unknown", "", "10.0.0.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := s.clientIP(req); got != tt.want {
+				t.Errorf("clientIP() = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
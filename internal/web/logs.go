@@ -0,0 +1,245 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultLogBufferSize 内存中保留的最近日志条数
+	DefaultLogBufferSize = 1000
+	// defaultLogLimit GET /api/logs 默认返回条数
+	defaultLogLimit = 200
+	// streamHeartbeat SSE 心跳间隔，避免代理断开空闲连接
+	streamHeartbeat = 30 * time.Second
+	// logSubscriberBuffer 单个 SSE 订阅者的待发送队列长度，队列满时丢弃新日志
+	logSubscriberBuffer = 256
+)
+
+// LogEntry 内存日志条目
+type LogEntry struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// LogBuffer 保留最近 N 条日志的环形缓冲（logrus Hook），并向实时订阅者推送
+type LogBuffer struct {
+	mu          sync.RWMutex
+	entries     []LogEntry
+	levels      []logrus.Level
+	next        int
+	full        bool
+	subscribers map[chan LogEntry]logrus.Level
+}
+
+// NewLogBuffer 创建日志缓冲，size 不大于 0 时使用默认值
+func NewLogBuffer(size int) *LogBuffer {
+	if size <= 0 {
+		size = DefaultLogBufferSize
+	}
+	return &LogBuffer{
+		entries:     make([]LogEntry, size),
+		levels:      make([]logrus.Level, size),
+		subscribers: make(map[chan LogEntry]logrus.Level),
+	}
+}
+
+// Levels 捕获全部级别（实际输出仍受日志器级别控制）
+func (b *LogBuffer) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 记录一条日志并推送给订阅者
+func (b *LogBuffer) Fire(entry *logrus.Entry) error {
+	item := LogEntry{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+	}
+	if len(entry.Data) > 0 {
+		item.Fields = make(map[string]interface{}, len(entry.Data))
+		for k, v := range entry.Data {
+			if err, ok := v.(error); ok {
+				v = err.Error()
+			}
+			item.Fields[k] = v
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[b.next] = item
+	b.levels[b.next] = entry.Level
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+	for ch, minLevel := range b.subscribers {
+		if entry.Level > minLevel {
+			continue
+		}
+		// 订阅者处理不过来时丢弃，不阻塞日志输出
+		select {
+		case ch <- item:
+		default:
+		}
+	}
+	return nil
+}
+
+// Entries 按时间顺序返回不低于 minLevel 的最近 limit 条日志（limit 不大于 0 表示全部）
+func (b *LogBuffer) Entries(minLevel logrus.Level, limit int) []LogEntry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	count, start := b.next, 0
+	if b.full {
+		count, start = len(b.entries), b.next
+	}
+	result := make([]LogEntry, 0, count)
+	for i := 0; i < count; i++ {
+		idx := (start + i) % len(b.entries)
+		if b.levels[idx] <= minLevel {
+			result = append(result, b.entries[idx])
+		}
+	}
+	if limit > 0 && len(result) > limit {
+		result = result[len(result)-limit:]
+	}
+	return result
+}
+
+// Subscribe 订阅不低于 minLevel 的新日志，返回的函数用于取消订阅
+func (b *LogBuffer) Subscribe(minLevel logrus.Level) (<-chan LogEntry, func()) {
+	ch := make(chan LogEntry, logSubscriberBuffer)
+	b.mu.Lock()
+	b.subscribers[ch] = minLevel
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+		})
+	}
+}
+
+// SetLogBuffer 设置日志缓冲，供 /api/logs 查询与实时推送
+func (s *Server) SetLogBuffer(buffer *LogBuffer) {
+	s.logBuffer = buffer
+}
+
+// parseLogLevel 解析 level 参数，为空时返回 trace（全部级别）
+func parseLogLevel(raw string) (logrus.Level, error) {
+	if raw == "" {
+		return logrus.TraceLevel, nil
+	}
+	level, err := logrus.ParseLevel(strings.ToLower(raw))
+	if err != nil {
+		return 0, fmt.Errorf("无效的日志级别: %s（可选 trace/debug/info/warning/error/fatal/panic）", raw)
+	}
+	return level, nil
+}
+
+// handleGetLogs 返回内存中的最近日志，level 过滤该级别及更严重的日志
+func (s *Server) handleGetLogs(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+	if s.logBuffer == nil {
+		s.respondJSON(w, map[string]string{"error": "日志缓冲未启用"}, http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	level, err := parseLogLevel(query.Get("level"))
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	limit := defaultLogLimit
+	if raw := query.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			s.respondJSON(w, map[string]string{"error": "limit 必须为正整数"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries := s.logBuffer.Entries(level, limit)
+	s.respondJSON(w, map[string]interface{}{
+		"logs":  entries,
+		"total": len(entries),
+	}, http.StatusOK)
+}
+
+// handleStreamLogs 以 SSE 实时推送新日志，level 过滤该级别及更严重的日志
+func (s *Server) handleStreamLogs(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+	if s.logBuffer == nil {
+		s.respondJSON(w, map[string]string{"error": "日志缓冲未启用"}, http.StatusServiceUnavailable)
+		return
+	}
+	level, err := parseLogLevel(r.URL.Query().Get("level"))
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.respondJSON(w, map[string]string{"error": "当前连接不支持流式响应"}, http.StatusInternalServerError)
+		return
+	}
+
+	entries, unsubscribe := s.logBuffer.Subscribe(level)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.streamsDone:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case entry := <-entries:
+			data, err := json.Marshal(entry)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: log\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
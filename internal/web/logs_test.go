@@ -0,0 +1,195 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newBufferedLogger 返回输出到日志缓冲的日志器
+func newBufferedLogger(buffer *LogBuffer) *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetLevel(logrus.TraceLevel)
+	logger.AddHook(buffer)
+	return logger
+}
+
+func messages(entries []LogEntry) []string {
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, entry.Message)
+	}
+	return result
+}
+
+func TestLogBufferKeepsMostRecent(t *testing.T) {
+	buffer := NewLogBuffer(3)
+	logger := newBufferedLogger(buffer)
+
+	logger.Info("one")
+	logger.Info("two")
+	if got := messages(buffer.Entries(logrus.TraceLevel, 0)); len(got) != 2 || got[0] != "one" || got[1] != "two" {
+		t.Errorf("未写满时应按时间顺序返回全部日志: %v", got)
+	}
+
+	logger.Warn("three")
+	logger.Error("four")
+	logger.Info("five")
+	got := messages(buffer.Entries(logrus.TraceLevel, 0))
+	if len(got) != 3 || got[0] != "three" || got[1] != "four" || got[2] != "five" {
+		t.Errorf("写满后应只保留最近 3 条并保持时间顺序: %v", got)
+	}
+	if got := messages(buffer.Entries(logrus.TraceLevel, 2)); len(got) != 2 || got[0] != "four" {
+		t.Errorf("limit 应返回最近的若干条: %v", got)
+	}
+}
+
+func TestLogBufferLevelFilter(t *testing.T) {
+	buffer := NewLogBuffer(10)
+	logger := newBufferedLogger(buffer)
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warning")
+	logger.WithError(io.EOF).Error("error")
+
+	tests := []struct {
+		level logrus.Level
+		want  []string
+	}{
+		{logrus.TraceLevel, []string{"debug", "info", "warning", "error"}},
+		{logrus.InfoLevel, []string{"info", "warning", "error"}},
+		{logrus.WarnLevel, []string{"warning", "error"}},
+		{logrus.ErrorLevel, []string{"error"}},
+		{logrus.FatalLevel, []string{}},
+	}
+	for _, tt := range tests {
+		got := messages(buffer.Entries(tt.level, 0))
+		if len(got) != len(tt.want) {
+			t.Errorf("level=%s: %v, 期望 %v", tt.level, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("level=%s: %v, 期望 %v", tt.level, got, tt.want)
+				break
+			}
+		}
+	}
+
+	entries := buffer.Entries(logrus.ErrorLevel, 0)
+	if entries[0].Level != "error" || entries[0].Fields["error"] != "EOF" {
+		t.Errorf("错误字段应转换为字符串: %+v", entries[0])
+	}
+}
+
+func TestLogBufferSubscribeFiltersLevel(t *testing.T) {
+	buffer := NewLogBuffer(10)
+	logger := newBufferedLogger(buffer)
+	entries, unsubscribe := buffer.Subscribe(logrus.WarnLevel)
+
+	logger.Info("ignored")
+	logger.Error("delivered")
+	select {
+	case entry := <-entries:
+		if entry.Message != "delivered" {
+			t.Errorf("订阅者只应收到 warning 及以上的日志，实际 %q", entry.Message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("未收到订阅的日志")
+	}
+
+	unsubscribe()
+	unsubscribe()
+	logger.Error("after")
+	select {
+	case entry := <-entries:
+		t.Errorf("取消订阅后不应再收到日志: %q", entry.Message)
+	default:
+	}
+}
+
+func TestGetLogs(t *testing.T) {
+	s := newTestServer(t, newTestConfig(), nil, nil)
+	if rec := serve(s, http.MethodGet, "/api/logs", "", nil, nil); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("未设置日志缓冲时应返回 503，实际 %d", rec.Code)
+	}
+
+	buffer := NewLogBuffer(10)
+	s.SetLogBuffer(buffer)
+	logger := newBufferedLogger(buffer)
+	logger.Info("info")
+	logger.Warn("warn-1")
+	logger.Warn("warn-2")
+
+	rec := serve(s, http.MethodGet, "/api/logs?level=WARNING&limit=1", "", nil, nil)
+	var resp struct {
+		Logs  []LogEntry `json:"logs"`
+		Total int        `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("查询日志失败: %d %s", rec.Code, rec.Body.String())
+	}
+	if resp.Total != 1 || resp.Logs[0].Message != "warn-2" {
+		t.Errorf("应返回最近 1 条 warning 日志: %+v", resp)
+	}
+
+	for _, query := range []string{"level=loud", "limit=0", "limit=abc"} {
+		if rec := serve(s, http.MethodGet, "/api/logs?"+query, "", nil, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s 应返回 400，实际 %d", query, rec.Code)
+		}
+	}
+}
+
+func TestStreamLogs(t *testing.T) {
+	s := newTestServer(t, newTestConfig(), nil, nil)
+	buffer := NewLogBuffer(10)
+	s.SetLogBuffer(buffer)
+	logger := newBufferedLogger(buffer)
+
+	server := httptest.NewServer(s.router)
+	defer server.Close()
+	reader, body := openStream(t, server.URL+"/api/logs/stream?level=error")
+	defer body.Close()
+
+	// 等待订阅建立后再输出日志
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		buffer.mu.RLock()
+		subscribed := len(buffer.subscribers) == 1
+		buffer.mu.RUnlock()
+		if subscribed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("订阅未建立")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	logger.Info("filtered")
+	logger.Error("streamed")
+
+	event, data, err := readEvent(reader, 2*time.Second)
+	if err != nil {
+		t.Fatalf("未收到日志推送: %v", err)
+	}
+	var entry LogEntry
+	if err := json.Unmarshal([]byte(data), &entry); err != nil || event != "log" || entry.Message != "streamed" {
+		t.Errorf("推送内容不符: event=%q data=%s err=%v", event, data, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("关闭服务失败: %v", err)
+	}
+	if _, _, err := readEvent(reader, time.Second); err != io.EOF {
+		t.Errorf("关闭服务后日志流应结束，实际: %v", err)
+	}
+}
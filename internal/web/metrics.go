@@ -0,0 +1,92 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"opensearch-alert/pkg/types"
+)
+
+// opsMetrics /metrics 端点的进程内计数
+type opsMetrics struct {
+	startTime time.Time
+
+	mu sync.Mutex
+	// alertsFired 按级别累计的告警触发次数（不含恢复通知）
+	alertsFired map[string]int64
+	// alertsResolved 累计的恢复通知次数
+	alertsResolved int64
+}
+
+// newOpsMetrics 创建计数器
+func newOpsMetrics() *opsMetrics {
+	return &opsMetrics{
+		startTime:   time.Now(),
+		alertsFired: make(map[string]int64),
+	}
+}
+
+// recordAlert 引擎告警回调，累计触发与恢复次数
+func (m *opsMetrics) recordAlert(alert *types.Alert) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if alert.Resolved {
+		m.alertsResolved++
+		return
+	}
+	m.alertsFired[alert.Level]++
+}
+
+// handleMetrics 以 Prometheus 文本格式输出运行指标（无需认证）
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	metric("opensearch_alert_uptime_seconds", "gauge", "Seconds since the process started.")
+	fmt.Fprintf(&b, "opensearch_alert_uptime_seconds %g\n", time.Since(s.metrics.startTime).Seconds())
+
+	if s.engine != nil {
+		enabled, disabled := 0, 0
+		for _, rule := range s.engine.Rules() {
+			if rule.Enabled {
+				enabled++
+			} else {
+				disabled++
+			}
+		}
+		metric("opensearch_alert_rules", "gauge", "Number of loaded alert rules by state.")
+		fmt.Fprintf(&b, "opensearch_alert_rules{state=\"enabled\"} %d\n", enabled)
+		fmt.Fprintf(&b, "opensearch_alert_rules{state=\"disabled\"} %d\n", disabled)
+
+		metric("opensearch_alert_rule_errors", "gauge", "Number of rules whose last run failed.")
+		fmt.Fprintf(&b, "opensearch_alert_rule_errors %d\n", len(s.engine.RuleErrorStates()))
+	}
+
+	s.metrics.mu.Lock()
+	levels := make([]string, 0, len(s.metrics.alertsFired))
+	for level := range s.metrics.alertsFired {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+	metric("opensearch_alert_alerts_fired_total", "counter", "Alerts fired since start by level.")
+	for _, level := range levels {
+		fmt.Fprintf(&b, "opensearch_alert_alerts_fired_total{level=%q} %d\n", level, s.metrics.alertsFired[level])
+	}
+	metric("opensearch_alert_alerts_resolved_total", "counter", "Resolve notifications sent since start.")
+	fmt.Fprintf(&b, "opensearch_alert_alerts_resolved_total %d\n", s.metrics.alertsResolved)
+	s.metrics.mu.Unlock()
+
+	metric("go_goroutines", "gauge", "Number of goroutines that currently exist.")
+	fmt.Fprintf(&b, "go_goroutines %d\n", runtime.NumGoroutine())
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(b.String()))
+}
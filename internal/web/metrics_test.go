@@ -0,0 +1,68 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+func TestMetricsReportsRulesAndAlerts(t *testing.T) {
+	client := newTestOpenSearch(t, http.NotFoundHandler())
+	s := newTestServer(t, newTestConfig(), newTestDatabase(t), client)
+	s.engine.LoadRules([]types.AlertRule{
+		{Name: "a", Type: "any", Enabled: true},
+		{Name: "b", Type: "any", Enabled: true},
+		{Name: "c", Type: "any"},
+	})
+	s.metrics.recordAlert(&types.Alert{RuleName: "a", Level: "High"})
+	s.metrics.recordAlert(&types.Alert{RuleName: "a", Level: "High"})
+	s.metrics.recordAlert(&types.Alert{RuleName: "b", Level: "Low"})
+	s.metrics.recordAlert(&types.Alert{RuleName: "a", Level: "High", Resolved: true})
+
+	rec := serve(s, "GET", "/metrics", "", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, 期望 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE opensearch_alert_rules gauge",
+		`opensearch_alert_rules{state="enabled"} 2`,
+		`opensearch_alert_rules{state="disabled"} 1`,
+		"opensearch_alert_rule_errors 0",
+		"# TYPE opensearch_alert_alerts_fired_total counter",
+		`opensearch_alert_alerts_fired_total{level="High"} 2`,
+		`opensearch_alert_alerts_fired_total{level="Low"} 1`,
+		"opensearch_alert_alerts_resolved_total 1",
+		"opensearch_alert_uptime_seconds ",
+		"go_goroutines ",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("指标缺少 %q\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsServedOnAdminListener(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Web.AdminAddr = "127.0.0.1:0"
+	s := newTestServer(t, cfg, newTestDatabase(t), nil)
+
+	rec := httptest.NewRecorder()
+	s.opsRouter.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("运维端口 /metrics 状态码 = %d, 期望 200", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "opensearch_alert_rules") {
+		t.Error("未加载引擎时不应输出规则指标")
+	}
+
+	if rec := serve(s, "GET", "/metrics", "", nil, nil); rec.Code == http.StatusOK {
+		t.Error("配置 admin_addr 后主端口不应提供 /metrics")
+	}
+}
@@ -0,0 +1,42 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestPreviewRuleQuery(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Rules.RulesFolder = t.TempDir()
+	client := newTestOpenSearch(t, http.NotFoundHandler())
+	s := newTestServer(t, cfg, newTestDatabase(t), client)
+
+	rec := serve(s, http.MethodPost, "/api/rules", `{"name":"preview","type":"frequency","index":"app-*,audit","threshold":3,"enabled":true}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("保存规则失败 %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = serve(s, http.MethodPost, "/api/rules/preview/preview-query", "", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("预览查询失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Rule  string                 `json:"rule"`
+		Path  string                 `json:"path"`
+		Query map[string]interface{} `json:"query"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if resp.Rule != "preview" || resp.Path != "/app-*,audit/_search" || resp.Query["query"] == nil {
+		t.Errorf("预览结果不正确: %s", rec.Body.String())
+	}
+
+	if rec := serve(s, http.MethodGet, "/api/rules/preview/preview-query", "", nil, nil); rec.Code == http.StatusOK {
+		t.Error("预览接口只接受 POST，GET 请求不应成功")
+	}
+	if rec := serve(s, http.MethodPost, "/api/rules/missing/preview-query", "", nil, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("规则不存在时应返回 404，实际 %d", rec.Code)
+	}
+}
@@ -0,0 +1,264 @@
+package web
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"opensearch-alert/internal/config"
+	"opensearch-alert/pkg/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// maxRuleArchiveSize 导入的规则压缩包大小上限
+	maxRuleArchiveSize = 10 << 20
+	// maxRuleFileSize 压缩包内单个规则文件解压后的大小上限
+	maxRuleFileSize = 1 << 20
+)
+
+// handleExportRules 将规则目录（含子目录）中的全部规则文件打包为 zip 下载
+func (s *Server) handleExportRules(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+
+	rulesDir := s.rulesDir()
+	files, err := config.RuleFiles(rulesDir)
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	// 先写入内存，出错时仍可返回 JSON 错误
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		rel, err := filepath.Rel(rulesDir, file)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			s.logger.Warnf("导出时读取规则文件失败: %s: %v", file, err)
+			continue
+		}
+		fw, err := zw.Create(filepath.ToSlash(rel))
+		if err == nil {
+			_, err = fw.Write(data)
+		}
+		if err != nil {
+			s.respondJSON(w, map[string]string{"error": "打包规则失败"}, http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		s.respondJSON(w, map[string]string{"error": "打包规则失败"}, http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("rules-%s.zip", time.Now().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	_, _ = w.Write(buf.Bytes())
+}
+
+// handleImportRules 导入 zip 压缩包中的规则文件（请求体为 zip，或 multipart 表单字段 file）
+// 同名规则已存在时默认跳过，?overwrite=true 时原位覆盖
+func (s *Server) handleImportRules(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+	overwrite, _ := strconv.ParseBool(r.URL.Query().Get("overwrite"))
+
+	archive, err := readRuleArchive(w, r)
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": "无效的 zip 文件"}, http.StatusBadRequest)
+		return
+	}
+
+	rulesDir := s.rulesDir()
+	existing, err := s.ruleFilesByName()
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	// 各文件结果：ok、skipped（已存在）或错误信息
+	results := make(map[string]string)
+	imported, skipped, failed := 0, 0, 0
+	for _, entry := range zr.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		rel, err := archiveRulePath(entry.Name)
+		if err != nil {
+			results[entry.Name] = err.Error()
+			failed++
+			continue
+		}
+
+		data, rule, err := readArchiveRule(entry)
+		if err != nil {
+			results[entry.Name] = err.Error()
+			failed++
+			continue
+		}
+
+		// 同名规则已存在时原位覆盖（与保存规则一致），否则按压缩包中的相对路径写入
+		target := filepath.Join(rulesDir, filepath.FromSlash(rel))
+		action := auditRuleCreate
+		if file, ok := existing[rule.Name]; ok {
+			if !overwrite {
+				results[entry.Name] = "skipped: 规则 " + rule.Name + " 已存在"
+				skipped++
+				continue
+			}
+			target = file
+			action = auditRuleUpdate
+		} else if _, err := os.Stat(target); err == nil && !overwrite {
+			results[entry.Name] = "skipped: 文件已存在"
+			skipped++
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			results[entry.Name] = "创建规则目录失败"
+			failed++
+			continue
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			results[entry.Name] = "写入规则文件失败"
+			failed++
+			continue
+		}
+		existing[rule.Name] = target
+		s.recordAudit(user, action, rule.Name, "导入规则文件 "+rel)
+		results[entry.Name] = "ok"
+		imported++
+	}
+	if imported > 0 {
+		s.reloadRules()
+	}
+
+	s.respondJSON(w, map[string]interface{}{
+		"imported": imported,
+		"skipped":  skipped,
+		"failed":   failed,
+		"results":  results,
+	}, http.StatusOK)
+}
+
+// readRuleArchive 读取请求中的压缩包内容，限制大小
+func readRuleArchive(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRuleArchiveSize)
+
+	var reader io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("读取上传文件失败: %w", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("读取压缩包失败（上限 %dMB）: %w", maxRuleArchiveSize>>20, err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("压缩包为空")
+	}
+	return data, nil
+}
+
+// archiveRulePath 校验压缩包内的文件路径，返回规范化的相对路径（拒绝绝对路径、.. 与隐藏目录）
+func archiveRulePath(name string) (string, error) {
+	if strings.Contains(name, "\\") || strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("无效的文件路径")
+	}
+	clean := path.Clean(name)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("无效的文件路径")
+	}
+	for _, part := range strings.Split(clean, "/") {
+		if strings.HasPrefix(part, ".") {
+			return "", fmt.Errorf("无效的文件路径")
+		}
+	}
+	if !config.IsRuleFile(clean) {
+		return "", fmt.Errorf("不是规则文件（仅支持 .yaml/.yml）")
+	}
+	return clean, nil
+}
+
+// readArchiveRule 读取并校验压缩包中的单个规则文件
+func readArchiveRule(entry *zip.File) ([]byte, *types.AlertRule, error) {
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxRuleFileSize+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	if len(data) > maxRuleFileSize {
+		return nil, nil, fmt.Errorf("文件超过 %dMB", maxRuleFileSize>>20)
+	}
+
+	var rule types.AlertRule
+	if err := yaml.Unmarshal(data, &rule); err != nil {
+		return nil, nil, fmt.Errorf("解析规则失败: %w", err)
+	}
+	if rule.Name == "" {
+		return nil, nil, fmt.Errorf("规则名称不能为空")
+	}
+	if err := config.ValidateRule(rule); err != nil {
+		return nil, nil, err
+	}
+	return data, &rule, nil
+}
+
+// ruleFilesByName 返回规则目录中规则名称到文件路径的映射
+func (s *Server) ruleFilesByName() (map[string]string, error) {
+	files, err := config.RuleFiles(s.rulesDir())
+	if err != nil {
+		return nil, fmt.Errorf("读取规则目录失败: %w", err)
+	}
+
+	byName := make(map[string]string, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var rule types.AlertRule
+		if err := yaml.Unmarshal(data, &rule); err != nil || rule.Name == "" {
+			continue
+		}
+		byName[rule.Name] = file
+	}
+	return byName, nil
+}
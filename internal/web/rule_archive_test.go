@@ -0,0 +1,153 @@
+package web
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeRuleFile 在规则目录下写入规则文件
+func writeRuleFile(t *testing.T, dir, rel, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入规则文件失败: %v", err)
+	}
+}
+
+// buildZip 按文件名与内容生成 zip
+func buildZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		fw, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("创建 zip 条目失败: %v", err)
+		}
+		fw.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("生成 zip 失败: %v", err)
+	}
+	return buf.String()
+}
+
+// importResult 导入接口的响应
+type importResult struct {
+	Imported int               `json:"imported"`
+	Skipped  int               `json:"skipped"`
+	Failed   int               `json:"failed"`
+	Results  map[string]string `json:"results"`
+}
+
+// importRules 调用导入接口并解析响应
+func importRules(t *testing.T, s *Server, archive, query string) importResult {
+	t.Helper()
+	rec := serve(s, http.MethodPost, "/api/rules/import"+query, archive, nil, map[string]string{"Content-Type": "application/zip"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("导入失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	var result importResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("解析导入结果失败: %v", err)
+	}
+	return result
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	files := map[string]string{
+		"app.yaml":      "name: app\ntype: any\nindex: app-*\nenabled: true\n",
+		"team/db.yml":   "name: db\ntype: frequency\nindex: db-*\nthreshold: 3\nenabled: false\n",
+		"team/x/k.yaml": "name: k8s\ntype: any\nindex: k8s-*\n",
+	}
+	source, sourceDir := newRulesTestServer(t)
+	for rel, content := range files {
+		writeRuleFile(t, sourceDir, rel, content)
+	}
+
+	rec := serve(source, http.MethodGet, "/api/rules/export", "", nil, nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("导出失败 %d: %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	archive := rec.Body.String()
+
+	target, targetDir := newRulesTestServer(t)
+	result := importRules(t, target, archive, "")
+	if result.Imported != len(files) || result.Failed != 0 {
+		t.Fatalf("应导入全部 %d 个规则文件: %+v", len(files), result)
+	}
+	for rel, content := range files {
+		data, err := os.ReadFile(filepath.Join(targetDir, filepath.FromSlash(rel)))
+		if err != nil || string(data) != content {
+			t.Errorf("%s 导入后内容不一致: %q (err=%v)", rel, data, err)
+		}
+	}
+
+	// 再次导入时同名规则默认跳过，overwrite=true 时覆盖
+	if result := importRules(t, target, archive, ""); result.Skipped != len(files) || result.Imported != 0 {
+		t.Errorf("已存在的规则应跳过: %+v", result)
+	}
+	if result := importRules(t, target, archive, "?overwrite=true"); result.Imported != len(files) {
+		t.Errorf("overwrite=true 时应覆盖全部规则: %+v", result)
+	}
+}
+
+func TestImportRejectsUnsafeEntries(t *testing.T) {
+	s, dir := newRulesTestServer(t)
+	valid := "name: evil\ntype: any\nindex: app-*\n"
+	result := importRules(t, s, buildZip(t, map[string]string{
+		"../x.yaml":      valid,
+		"/abs.yaml":      valid,
+		".hidden/x.yaml": valid,
+		`a\b.yaml`:       valid,
+		"notes.txt":      valid,
+		"bad.yaml":       "name: [",
+		"ok/safe.yaml":   "name: safe\ntype: any\nindex: app-*\n",
+	}), "")
+
+	if result.Imported != 1 || result.Failed != 6 {
+		t.Fatalf("只应导入安全的规则文件: %+v", result)
+	}
+	if result.Results["ok/safe.yaml"] != "ok" {
+		t.Errorf("安全的规则文件应导入成功: %v", result.Results)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "x.yaml")); err == nil {
+		t.Error("路径穿越的文件不应写到规则目录之外")
+	}
+}
+
+func TestArchiveRulePath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"app.yaml", "app.yaml", true},
+		{"team/app.yml", "team/app.yml", true},
+		{"team/./sub/../app.yaml", "team/app.yaml", true},
+		{"../x.yaml", "", false},
+		{"team/../../x.yaml", "", false},
+		{"/abs.yaml", "", false},
+		{".hidden/x.yaml", "", false},
+		{"team/.x.yaml", "", false},
+		{`a\b.yaml`, "", false},
+		{"notes.txt", "", false},
+		{".", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := archiveRulePath(tt.name)
+			if (err == nil) != tt.ok || got != tt.want {
+				t.Errorf("archiveRulePath(%q) = %q, %v; 期望 %q, ok=%v", tt.name, got, err, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"opensearch-alert/internal/config"
+	"opensearch-alert/pkg/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// maxValidateYAMLBody 规则 YAML 校验请求体大小上限
+	maxValidateYAMLBody = 1 << 20
+	// maxDiffLines 逐行差异的行数上限，超过时不计算差异（LCS 表为 O(n·m)）
+	maxDiffLines = 2000
+)
+
+// validateYAMLRequest 规则 YAML 校验请求
+type validateYAMLRequest struct {
+	YAML string `json:"yaml"`
+}
+
+// handleValidateRuleYAML 校验规则 YAML，返回规范化结果及与现有文件的差异（不写入文件）
+func (s *Server) handleValidateRuleYAML(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxValidateYAMLBody)
+	var req validateYAMLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			s.respondJSON(w, map[string]string{"error": fmt.Sprintf("请求体过大（上限 %dMB）", maxValidateYAMLBody>>20)}, http.StatusRequestEntityTooLarge)
+			return
+		}
+		s.respondJSON(w, map[string]string{"error": "无效的请求格式"}, http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.YAML) == "" {
+		s.respondJSON(w, map[string]string{"error": "YAML 内容不能为空"}, http.StatusBadRequest)
+		return
+	}
+
+	var rule types.AlertRule
+	if err := yaml.Unmarshal([]byte(req.YAML), &rule); err != nil {
+		s.respondJSON(w, map[string]interface{}{
+			"valid":  false,
+			"errors": []string{"YAML 解析失败: " + err.Error()},
+		}, http.StatusOK)
+		return
+	}
+
+	var validationErrors []string
+	if err := config.ValidateRule(rule); err != nil {
+		validationErrors = append(validationErrors, err.Error())
+	}
+	warnings := lintRuleYAML([]byte(req.YAML), rule)
+
+	normalized, err := yaml.Marshal(&rule)
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": "序列化规则失败"}, http.StatusInternalServerError)
+		return
+	}
+
+	// 与现有同名规则文件比较
+	var existing []byte
+	file := ""
+	if rule.Name != "" {
+		path, _, err := s.findRuleFile(rule.Name)
+		if err != nil && !errors.Is(err, errRuleNotFound) {
+			s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		if path != "" {
+			if existing, err = os.ReadFile(path); err != nil {
+				s.respondJSON(w, map[string]string{"error": "读取规则文件失败"}, http.StatusInternalServerError)
+				return
+			}
+			file = path
+		}
+	}
+
+	s.respondJSON(w, map[string]interface{}{
+		"valid":      len(validationErrors) == 0,
+		"errors":     validationErrors,
+		"warnings":   warnings,
+		"rule":       rule,
+		"normalized": string(normalized),
+		"exists":     file != "",
+		"file":       file,
+		"changed":    !bytes.Equal(existing, normalized),
+		"diff":       lineDiff(string(existing), string(normalized)),
+	}, http.StatusOK)
+}
+
+// lintRuleYAML 检查不影响加载但可能是笔误的问题（未知字段、未启用等）
+func lintRuleYAML(data []byte, rule types.AlertRule) []string {
+	var warnings []string
+
+	// 严格模式解码，发现拼写错误或不支持的字段
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var strict types.AlertRule
+	if err := decoder.Decode(&strict); err != nil {
+		warnings = append(warnings, "存在未识别的字段（将被忽略）: "+err.Error())
+	}
+
+	if !rule.Enabled {
+		warnings = append(warnings, "规则未启用（enabled: false），保存后不会执行")
+	}
+	if len(rule.Query) == 0 {
+		warnings = append(warnings, "未配置 query，将匹配索引中的全部文档")
+	}
+	if rule.AlertText != "" && rule.AlertTextType != "go_template" {
+		if placeholders := strings.Count(rule.AlertText, "{"); placeholders > 0 && len(rule.AlertTextArgs) == 0 {
+			warnings = append(warnings, "alert_text 包含占位符但未配置 alert_text_args")
+		}
+	}
+	return warnings
+}
+
+// lineDiff 按行比较两段文本，输出统一格式的差异（" " 未变，"-" 删除，"+" 新增）；
+// 任一侧超过 maxDiffLines 行时只输出一行 "!" 开头的说明
+func lineDiff(oldText, newText string) string {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+
+	n, m := len(oldLines), len(newLines)
+	if n > maxDiffLines || m > maxDiffLines {
+		return fmt.Sprintf("! 超过 %d 行，省略逐行差异（原 %d 行，新 %d 行）\n", maxDiffLines, n, m)
+	}
+
+	// 最长公共子序列
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && oldLines[i] == newLines[j]:
+			out.WriteString("  " + oldLines[i] + "\n")
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("- " + oldLines[i] + "\n")
+			i++
+		default:
+			out.WriteString("+ " + newLines[j] + "\n")
+			j++
+		}
+	}
+	return out.String()
+}
+
+// splitLines 按行切分文本（忽略末尾换行）
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,70 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateRuleYAMLBodyLimit(t *testing.T) {
+	s, _ := newRulesTestServer(t)
+	yaml := "name: big\ntype: any\nindex: app-*\n# " + strings.Repeat("x", maxValidateYAMLBody)
+	body, _ := json.Marshal(map[string]string{"yaml": yaml})
+
+	rec := serve(s, http.MethodPost, "/api/rules/validate-yaml", string(body), nil, nil)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("超过上限的请求体应返回 413，实际 %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestValidateRuleYAMLDiff(t *testing.T) {
+	s, _ := newRulesTestServer(t)
+	body, _ := json.Marshal(map[string]string{"yaml": "name: new\ntype: any\nindex: app-*\nenabled: true\n"})
+
+	rec := serve(s, http.MethodPost, "/api/rules/validate-yaml", string(body), nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("校验请求失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Valid  bool   `json:"valid"`
+		Exists bool   `json:"exists"`
+		Diff   string `json:"diff"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if !resp.Valid || resp.Exists || !strings.Contains(resp.Diff, "+ name: new") {
+		t.Errorf("新规则应校验通过且差异全部为新增: %+v", resp)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	got := lineDiff("a\nb\nc\n", "a\nc\nd\n")
+	want := "  a\n- b\n  c\n+ d\n"
+	if got != want {
+		t.Errorf("lineDiff = %q, 期望 %q", got, want)
+	}
+}
+
+func TestLineDiffSkipsLargeInput(t *testing.T) {
+	var large strings.Builder
+	for i := 0; i <= maxDiffLines; i++ {
+		fmt.Fprintf(&large, "line-%d\n", i)
+	}
+
+	start := time.Now()
+	diff := lineDiff(large.String(), large.String()+"extra\n")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("超过行数上限时不应计算 LCS，耗时 %s", elapsed)
+	}
+	if !strings.HasPrefix(diff, "! ") || strings.Count(diff, "\n") != 1 {
+		t.Errorf("超过行数上限时只应输出一行说明，实际 %q", diff)
+	}
+	// 审计摘要保留省略说明
+	if summary := changedLines(diff); summary == "" {
+		t.Error("审计摘要应保留省略差异的说明")
+	}
+}
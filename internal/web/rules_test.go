@@ -0,0 +1,448 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"opensearch-alert/internal/config"
+	"opensearch-alert/pkg/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// newRulesTestServer 创建规则目录位于临时目录的服务器
+func newRulesTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	cfg := newTestConfig()
+	cfg.Rules.RulesFolder = t.TempDir()
+	return newTestServer(t, cfg, newTestDatabase(t), nil), cfg.Rules.RulesFolder
+}
+
+// ruleFileCount 返回规则目录中的文件数
+func ruleFileCount(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("读取规则目录失败: %v", err)
+	}
+	return len(entries)
+}
+
+func TestUpsertRuleValidation(t *testing.T) {
+	s, dir := newRulesTestServer(t)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"未知类型", `{"name":"bad","type":"bogus","index":"app-*"}`, "不支持的规则类型"},
+		{"缺少索引", `{"name":"bad","type":"any"}`, "规则索引不能为空"},
+		{"阈值为负", `{"name":"bad","type":"frequency","index":"app-*","threshold":-1}`, "阈值不能为负数"},
+		{"未知级别", `{"name":"bad","type":"any","index":"app-*","level":"Urgent"}`, "未知的告警级别"},
+		{"无效调度", `{"name":"bad","type":"any","index":"app-*","schedule":"sometimes"}`, "无效的调度表达式"},
+		{"保留名称", `{"name":"schema","type":"any","index":"app-*"}`, "保留名称"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(s, http.MethodPost, "/api/rules", tt.body, nil, nil)
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.want) {
+				t.Fatalf("期望 400 且包含 %q，实际 %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if n := ruleFileCount(t, dir); n != 0 {
+		t.Fatalf("校验失败时不应写入规则文件，实际 %d 个", n)
+	}
+
+	rec := serve(s, http.MethodPost, "/api/rules", `{"name":"good","type":"frequency","index":"app-*","threshold":3,"level":"High","enabled":true}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("合法规则应保存成功，实际 %d: %s", rec.Code, rec.Body.String())
+	}
+	if n := ruleFileCount(t, dir); n != 1 {
+		t.Fatalf("合法规则应写入 1 个文件，实际 %d 个", n)
+	}
+}
+
+func TestCreateThenDeleteRule(t *testing.T) {
+	s, dir := newRulesTestServer(t)
+
+	rec := serve(s, http.MethodPost, "/api/rules", `{"name":"temp","type":"any","index":"app-*","enabled":true}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("创建规则失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	if n := ruleFileCount(t, dir); n != 1 {
+		t.Fatalf("创建后应有 1 个规则文件，实际 %d 个", n)
+	}
+
+	rec = serve(s, http.MethodDelete, "/api/rules/temp", "", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("删除规则失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	if n := ruleFileCount(t, dir); n != 0 {
+		t.Fatalf("删除后规则文件应被移除，实际 %d 个", n)
+	}
+
+	if rec := serve(s, http.MethodDelete, "/api/rules/temp", "", nil, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("重复删除应返回 404，实际 %d", rec.Code)
+	}
+}
+
+func TestDeleteRuleRejectsPathTraversal(t *testing.T) {
+	s, dir := newRulesTestServer(t)
+
+	// 规则目录之外的同名文件不能被删除
+	outside := filepath.Join(filepath.Dir(dir), "outside.yaml")
+	if err := os.WriteFile(outside, []byte("name: outside\ntype: any\nindex: app-*\n"), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(outside) })
+
+	for _, path := range []string{"/api/rules/..outside", "/api/rules/a%5Coutside", "/api/rules/..%2Foutside", "/api/rules/%2E%2E%2Foutside"} {
+		rec := serve(s, http.MethodDelete, path, "", nil, nil)
+		if rec.Code == http.StatusOK {
+			t.Errorf("DELETE %s 不应成功", path)
+		}
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Fatalf("规则目录之外的文件被删除: %v", err)
+	}
+
+	// 名称中的路径分隔符在保存时被替换，文件仍写在规则目录内
+	serve(s, http.MethodPost, "/api/rules", `{"name":"../escape","type":"any","index":"app-*"}`, nil, nil)
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.yaml")); err == nil {
+		t.Fatal("保存规则不应写到规则目录之外")
+	}
+}
+
+func TestGetRule(t *testing.T) {
+	s, dir := newRulesTestServer(t)
+	writeRuleFile(t, dir, "team/app.yaml", "name: app errors\ntype: frequency\nindex: app-*\nthreshold: 5\nlevel: High\nenabled: true\n")
+
+	rec := serve(s, http.MethodGet, "/api/rules/app%20errors", "", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("获取规则失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	var rule types.AlertRule
+	if err := json.Unmarshal(rec.Body.Bytes(), &rule); err != nil {
+		t.Fatalf("解析规则失败: %v", err)
+	}
+	if rule.Name != "app errors" || rule.Type != "frequency" || rule.Threshold != 5 || rule.Level != "High" || !rule.Enabled {
+		t.Errorf("返回的规则不符: %+v", rule)
+	}
+
+	rec = serve(s, http.MethodGet, "/api/rules/missing", "", nil, nil)
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "规则不存在") {
+		t.Errorf("不存在的规则应返回 404，实际 %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// readRule 解析规则文件
+func readRule(t *testing.T, path string) types.AlertRule {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取规则文件失败: %v", err)
+	}
+	var rule types.AlertRule
+	if err := yaml.Unmarshal(data, &rule); err != nil {
+		t.Fatalf("解析规则文件失败: %v", err)
+	}
+	return rule
+}
+
+func TestRulesInSubdirectories(t *testing.T) {
+	s, dir := newRulesTestServer(t)
+	writeRuleFile(t, dir, "prod/errors.yaml", "name: prod-errors\ntype: frequency\nindex: prod-*\nthreshold: 5\nenabled: true\n")
+	writeRuleFile(t, dir, "staging/deep/latency.yaml", "name: staging-latency\ntype: any\nindex: staging-*\nenabled: true\n")
+
+	rec := serve(s, http.MethodGet, "/api/rules", "", nil, nil)
+	var list struct {
+		Rules []types.AlertRule `json:"rules"`
+		Total int               `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || list.Total != 2 {
+		t.Fatalf("应列出子目录中的 2 个规则，实际 %d（%v）: %s", list.Total, err, rec.Body.String())
+	}
+
+	// 启用/禁用与更新均原位修改子目录中的文件，不在顶层生成新文件
+	prodFile := filepath.Join(dir, "prod", "errors.yaml")
+	if rec := serve(s, http.MethodPost, "/api/rules/prod-errors/disable", "", nil, nil); rec.Code != http.StatusOK {
+		t.Fatalf("禁用规则失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	if readRule(t, prodFile).Enabled {
+		t.Error("禁用后子目录中的规则文件应为 enabled: false")
+	}
+	rec = serve(s, http.MethodPost, "/api/rules", `{"name":"prod-errors","type":"frequency","index":"prod-*","threshold":9,"enabled":true}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("更新规则失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	if rule := readRule(t, prodFile); rule.Threshold != 9 || !rule.Enabled {
+		t.Errorf("更新应写回子目录中的文件，实际 %+v", rule)
+	}
+	if n := ruleFileCount(t, dir); n != 2 {
+		t.Errorf("顶层应只有 prod 与 staging 两个子目录，实际 %d 项", n)
+	}
+
+	// 删除同样定位到嵌套目录中的文件
+	if rec := serve(s, http.MethodDelete, "/api/rules/staging-latency", "", nil, nil); rec.Code != http.StatusOK {
+		t.Fatalf("删除规则失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "staging", "deep", "latency.yaml")); !os.IsNotExist(err) {
+		t.Errorf("嵌套目录中的规则文件应被删除: %v", err)
+	}
+}
+
+func TestToggleYmlRule(t *testing.T) {
+	s, dir := newRulesTestServer(t)
+	writeRuleFile(t, dir, "latency.yml", "name: latency\ntype: any\nindex: app-*\nenabled: true\n")
+	ymlFile := filepath.Join(dir, "latency.yml")
+
+	rec := serve(s, http.MethodGet, "/api/rules/latency", "", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf(".yml 规则应可被查询，实际 %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := serve(s, http.MethodPost, "/api/rules/latency/disable", "", nil, nil); rec.Code != http.StatusOK {
+		t.Fatalf("禁用 .yml 规则失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	if readRule(t, ymlFile).Enabled {
+		t.Error("禁用后 .yml 文件应为 enabled: false")
+	}
+	if rec := serve(s, http.MethodPost, "/api/rules/latency/enable", "", nil, nil); rec.Code != http.StatusOK {
+		t.Fatalf("启用 .yml 规则失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	if !readRule(t, ymlFile).Enabled {
+		t.Error("启用后 .yml 文件应为 enabled: true")
+	}
+
+	// 保存同名规则时更新 .yml 文件，不生成 .yaml 副本
+	rec = serve(s, http.MethodPost, "/api/rules", `{"name":"latency","type":"frequency","index":"app-*","threshold":4,"enabled":true}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("更新规则失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	if rule := readRule(t, ymlFile); rule.Threshold != 4 {
+		t.Errorf("更新应写回 .yml 文件，实际 %+v", rule)
+	}
+	if n := ruleFileCount(t, dir); n != 1 {
+		t.Errorf("不应生成重复的规则文件，实际 %d 个", n)
+	}
+}
+
+// ruleNames 返回规则名称列表
+func ruleNames(rules []types.AlertRule) []string {
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, rule.Name)
+	}
+	return names
+}
+
+func TestFilterRules(t *testing.T) {
+	rules := []types.AlertRule{
+		{Name: "api-errors", Index: "nginx-*", Enabled: true},
+		{Name: "db-latency", Index: "mysql-slow", Enabled: false},
+		{Name: "pod-events", Indices: []string{"k8s-events", "audit-*"}, Enabled: true},
+		{Name: "API-timeouts", Index: "gateway-*", Enabled: false},
+	}
+	yes, no := true, false
+	tests := []struct {
+		name    string
+		q       string
+		enabled *bool
+		want    []string
+	}{
+		{"无条件", "", nil, []string{"api-errors", "db-latency", "pod-events", "API-timeouts"}},
+		{"名称不区分大小写", " api ", nil, []string{"api-errors", "API-timeouts"}},
+		{"按索引", "MYSQL", nil, []string{"db-latency"}},
+		{"按多索引中的任一索引", "audit", nil, []string{"pod-events"}},
+		{"仅启用", "", &yes, []string{"api-errors", "pod-events"}},
+		{"仅禁用", "", &no, []string{"db-latency", "API-timeouts"}},
+		{"名称与状态组合", "api", &no, []string{"API-timeouts"}},
+		{"无匹配", "redis", nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ruleNames(filterRules(rules, tt.q, tt.enabled))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("filterRules(%q) = %v, 期望 %v", tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginateRules(t *testing.T) {
+	rules := []types.AlertRule{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}}
+	tests := []struct {
+		page, pageSize int
+		want           []string
+	}{
+		{1, 2, []string{"a", "b"}},
+		{3, 2, []string{"e"}},
+		{4, 2, []string{}},
+		{1, 10, []string{"a", "b", "c", "d", "e"}},
+	}
+	for _, tt := range tests {
+		got := ruleNames(paginateRules(rules, tt.page, tt.pageSize))
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("paginateRules(page=%d, size=%d) = %v, 期望 %v", tt.page, tt.pageSize, got, tt.want)
+		}
+	}
+}
+
+func TestGetRulesSearchAndPaging(t *testing.T) {
+	s, dir := newRulesTestServer(t)
+	for _, f := range []struct{ file, name, index, enabled string }{
+		{"z.yaml", "zeta", "app-*", "true"},
+		{"a.yaml", "alpha", "app-*", "false"},
+		{"m.yaml", "mu", "db-*", "true"},
+		{"b.yaml", "beta", "app-*", "true"},
+	} {
+		writeRuleFile(t, dir, f.file, "name: "+f.name+"\ntype: any\nindex: "+f.index+"\nenabled: "+f.enabled+"\n")
+	}
+
+	get := func(query string) (int, []string, int) {
+		t.Helper()
+		rec := serve(s, http.MethodGet, "/api/rules"+query, "", nil, nil)
+		var resp struct {
+			Rules []types.AlertRule `json:"rules"`
+			Total int               `json:"total"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, ruleNames(resp.Rules), resp.Total
+	}
+
+	// 结果按名称排序，分页时 total 为过滤后的总数
+	if _, names, total := get("?page=1&page_size=2"); total != 4 || strings.Join(names, ",") != "alpha,beta" {
+		t.Errorf("第 1 页 = %v（total %d），期望 alpha,beta（total 4）", names, total)
+	}
+	if _, names, _ := get("?page=2&page_size=2"); strings.Join(names, ",") != "mu,zeta" {
+		t.Errorf("第 2 页 = %v，期望 mu,zeta", names)
+	}
+	if _, names, total := get("?q=app&enabled=true"); total != 2 || strings.Join(names, ",") != "beta,zeta" {
+		t.Errorf("q=app&enabled=true = %v（total %d），期望 beta,zeta", names, total)
+	}
+	if _, names, total := get("?enabled=false"); total != 1 || strings.Join(names, ",") != "alpha" {
+		t.Errorf("enabled=false = %v（total %d），期望 alpha", names, total)
+	}
+	if code, _, _ := get("?enabled=maybe"); code != http.StatusBadRequest {
+		t.Errorf("enabled 非法时状态码 = %d, 期望 400", code)
+	}
+}
+
+func TestBulkToggleRulesWithMissingName(t *testing.T) {
+	s, dir := newRulesTestServer(t)
+	writeRuleFile(t, dir, "a.yaml", "name: a\ntype: any\nindex: app-*\nenabled: true\n")
+	writeRuleFile(t, dir, "b.yml", "name: b\ntype: any\nindex: app-*\nenabled: true\n")
+	writeRuleFile(t, dir, "team/c.yaml", "name: c\ntype: any\nindex: app-*\nenabled: true\n")
+
+	rec := serve(s, http.MethodPost, "/api/rules/bulk", `{"names":["a","missing","b","team/c","c"],"enabled":false}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("批量禁用失败 %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Results map[string]string `json:"results"`
+		Updated int               `json:"updated"`
+		Failed  int               `json:"failed"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if resp.Updated != 3 || resp.Failed != 2 {
+		t.Errorf("updated=%d failed=%d，期望 3 与 2: %+v", resp.Updated, resp.Failed, resp.Results)
+	}
+	for name, want := range map[string]string{"a": "ok", "b": "ok", "c": "ok"} {
+		if resp.Results[name] != want {
+			t.Errorf("%s 的结果 = %q, 期望 %q", name, resp.Results[name], want)
+		}
+	}
+	for _, name := range []string{"missing", "team/c"} {
+		if !strings.Contains(resp.Results[name], "未找到规则") {
+			t.Errorf("%s 的结果应为未找到，实际 %q", name, resp.Results[name])
+		}
+	}
+
+	// 不存在的名称不影响其余规则
+	for _, file := range []string{"a.yaml", "b.yml", "team/c.yaml"} {
+		if readRule(t, filepath.Join(dir, filepath.FromSlash(file))).Enabled {
+			t.Errorf("%s 应已禁用", file)
+		}
+	}
+	if n := ruleFileCount(t, dir); n != 3 {
+		t.Errorf("不应为不存在的规则生成文件，实际 %d 项", n)
+	}
+
+	if rec := serve(s, http.MethodPost, "/api/rules/bulk", `{"names":["a"]}`, nil, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("缺少 enabled 时状态码 = %d, 期望 400", rec.Code)
+	}
+}
+
+func TestBulkToggleRulesRequiresAdmin(t *testing.T) {
+	s := newAuditTestServer(t)
+	writeRuleFile(t, s.config.Rules.RulesFolder, "a.yaml", "name: a\ntype: any\nindex: app-*\nenabled: true\n")
+	token, cookies := loginAs(t, s, "bob")
+
+	rec := serve(s, http.MethodPost, "/api/rules/bulk", `{"names":["a"],"enabled":false}`, cookies, map[string]string{csrfHeader: token})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("viewer 批量操作状态码 = %d, 期望 403", rec.Code)
+	}
+	if !readRule(t, filepath.Join(s.config.Rules.RulesFolder, "a.yaml")).Enabled {
+		t.Error("被拒绝的批量操作不应修改规则")
+	}
+}
+
+func TestGetRuleSchema(t *testing.T) {
+	s, _ := newRulesTestServer(t)
+
+	rec := serve(s, http.MethodGet, "/api/rules/schema", "", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d，期望 200: %s", rec.Code, rec.Body.String())
+	}
+	var schema config.RuleSchemaInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &schema); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	fields := make(map[string]config.RuleField)
+	for _, f := range schema.Fields {
+		fields[f.Name] = f
+	}
+
+	want := config.RuleField{
+		Name:     "type",
+		Type:     "string",
+		Required: true,
+		Enum:     []string{"frequency", "any", "spike", "flatline", "change", "metric"},
+	}
+	if got := fields["type"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("type 字段 = %+v，期望 %+v", got, want)
+	}
+	if !fields["name"].Required || fields["level"].Required {
+		t.Errorf("仅 name 与 type 必填: name=%+v level=%+v", fields["name"], fields["level"])
+	}
+	if f := fields["indices"]; f.Type != "array" || f.Items != "string" {
+		t.Errorf("indices 应为字符串数组: %+v", f)
+	}
+	if f := fields["template_mode"]; !reflect.DeepEqual(f.Enum, []string{"append", "prepend", "replace"}) {
+		t.Errorf("template_mode 可选值不符: %+v", f)
+	}
+	if !reflect.DeepEqual(schema.OneOf, [][]string{{"index", "indices"}}) {
+		t.Errorf("one_of = %v，期望 index 与 indices 二选一", schema.OneOf)
+	}
+
+	// 每个 yaml 字段都出现在描述中
+	ruleType := reflect.TypeOf(types.AlertRule{})
+	for i := 0; i < ruleType.NumField(); i++ {
+		name := strings.Split(ruleType.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		if _, ok := fields[name]; !ok {
+			t.Errorf("字段描述缺少 %s", name)
+		}
+	}
+}
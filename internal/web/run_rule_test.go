@@ -0,0 +1,90 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"opensearch-alert/internal/alert"
+	"opensearch-alert/internal/notification"
+	"opensearch-alert/pkg/types"
+)
+
+// newRunRuleTestServer 创建带引擎与通知器（无渠道）的服务器，OpenSearch 由 stub 应答
+func newRunRuleTestServer(t *testing.T, stub http.Handler, rules ...types.AlertRule) *Server {
+	t.Helper()
+	cfg := newTestConfig()
+	db := newTestDatabase(t)
+	client := newTestOpenSearch(t, stub)
+	notifier := notification.NewNotifier(cfg, newTestLogger())
+	t.Cleanup(notifier.Stop)
+
+	engine := alert.NewEngine(cfg, client, notifier, db, newTestLogger())
+	engine.LoadRules(rules)
+	return NewServer(cfg, db, notifier, engine, client, newTestLogger())
+}
+
+// runRule 调用 POST /api/rules/{name}/run 并解析执行结果
+func runRule(t *testing.T, s *Server, path string) (int, alert.RuleRunResult) {
+	t.Helper()
+	rec := serve(s, http.MethodPost, path, "", nil, nil)
+	var result alert.RuleRunResult
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("解析响应失败: %v\n%s", err, rec.Body.String())
+		}
+	}
+	return rec.Code, result
+}
+
+func TestRunRuleNow(t *testing.T) {
+	stub := &searchStub{total: 3}
+	s := newRunRuleTestServer(t, stub,
+		types.AlertRule{Name: "errors", Type: "any", Index: "app-*", Timeframe: 300, Level: "High", Enabled: true, Schedule: "@every 24h"},
+		types.AlertRule{Name: "off", Type: "any", Index: "app-*", Timeframe: 300},
+	)
+
+	// 不等待调度周期，立即执行并落库
+	code, result := runRule(t, s, "/api/rules/errors/run")
+	if code != http.StatusOK || !result.Fired || result.Hits != 3 || result.Alert == nil {
+		t.Fatalf("执行结果 = %d, %+v，期望触发且命中 3 条", code, result)
+	}
+	if result.Alert.Level != "High" || result.Alert.RuleName != "errors" {
+		t.Errorf("告警摘要不符: %+v", result.Alert)
+	}
+	firstID := result.Alert.ID
+	stub.mu.Lock()
+	queried := len(stub.paths)
+	stub.mu.Unlock()
+	if queried == 0 {
+		t.Error("应查询 OpenSearch")
+	}
+
+	// 相同告警再次执行被去重，force=true 时跳过去重
+	if _, result := runRule(t, s, "/api/rules/errors/run"); result.Fired || result.Skipped == "" {
+		t.Errorf("重复执行应去重，实际 %+v", result)
+	}
+	if _, result := runRule(t, s, "/api/rules/errors/run?force=true"); !result.Fired {
+		t.Errorf("force=true 应跳过去重，实际 %+v", result)
+	}
+
+	if code, result := runRule(t, s, "/api/rules/off/run"); code != http.StatusOK || result.Fired || result.Skipped == "" {
+		t.Errorf("禁用规则应跳过执行，实际 %d, %+v", code, result)
+	}
+	if code, _ := runRule(t, s, "/api/rules/missing/run"); code != http.StatusNotFound {
+		t.Errorf("未加载的规则状态码 = %d, 期望 404", code)
+	}
+
+	// 告警批量落库，停止引擎时刷新缓冲
+	s.engine.Stop()
+	if detail, err := s.database.GetAlertByID(firstID); err != nil || detail == nil {
+		t.Errorf("手动执行触发的告警应写入历史: %v", err)
+	}
+}
+
+func TestRunRuleNowWithoutEngine(t *testing.T) {
+	s := newTestServer(t, newTestConfig(), newTestDatabase(t), nil)
+	if code, _ := runRule(t, s, "/api/rules/errors/run"); code != http.StatusServiceUnavailable {
+		t.Errorf("未初始化引擎时状态码 = %d, 期望 503", code)
+	}
+}
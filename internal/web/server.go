@@ -1,20 +1,27 @@
 package web
 
 import (
+	"context"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
+	"net"
 	"net/http"
 	"opensearch-alert/internal/alert"
+	"opensearch-alert/internal/config"
 	"opensearch-alert/internal/database"
 	"opensearch-alert/internal/notification"
+	"opensearch-alert/internal/opensearch"
 	"opensearch-alert/pkg/types"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -29,38 +36,68 @@ type Server struct {
 	database      *database.Database
 	notifier      *notification.Notifier
 	engine        *alert.Engine
+	opensearch    *opensearch.Client
 	logger        *logrus.Logger
 	store         *sessions.CookieStore
 	pageTemplates map[string]*template.Template
 	router        *mux.Router
+	opsRouter     *mux.Router
+	httpServer    *http.Server
+	adminServer   *http.Server
+	loginLimiter  *loginLimiter
+	// trustedProxies 受信任的反向代理网段，用于识别客户端真实 IP
+	trustedProxies []*net.IPNet
+	htpasswd       *config.Htpasswd
+	logBuffer      *LogBuffer
+	alertHub       *alertHub
+	// streamsDone 关闭服务时关闭，通知 SSE 长连接退出（http.Server.Shutdown 不会取消进行中请求的 Context）
+	streamsDone  chan struct{}
+	closeStreams sync.Once
+	// metrics /metrics 运维端点的进程内计数
+	metrics *opsMetrics
 }
 
 // NewServer 创建 Web 服务器
-func NewServer(config *types.Config, database *database.Database, notifier *notification.Notifier, engine *alert.Engine, logger *logrus.Logger) *Server {
+func NewServer(config *types.Config, database *database.Database, notifier *notification.Notifier, engine *alert.Engine, opensearchClient *opensearch.Client, logger *logrus.Logger) *Server {
 	// 注册User类型到gob编码器
 	gob.Register(&types.User{})
 
 	// 创建会话存储
 	store := sessions.NewCookieStore([]byte(config.Web.SessionSecret))
-	store.Options = &sessions.Options{
-		Path:     "/",
-		MaxAge:   int(config.Auth.SessionTimeout),
-		HttpOnly: true,
-		Secure:   false, // 在生产环境中应该设为 true
-		SameSite: http.SameSiteLaxMode,
-	}
+	store.Options = sessionOptions(config)
 
 	server := &Server{
 		config:        config,
 		database:      database,
 		notifier:      notifier,
 		engine:        engine,
+		opensearch:    opensearchClient,
 		logger:        logger,
 		store:         store,
 		pageTemplates: make(map[string]*template.Template),
 		router:        mux.NewRouter(),
+		opsRouter:     mux.NewRouter(),
+		loginLimiter:  newLoginLimiter(config.Auth.LockoutMinutes),
+		alertHub:      newAlertHub(),
+		streamsDone:   make(chan struct{}),
+		metrics:       newOpsMetrics(),
+	}
+
+	// 引擎触发的新告警实时推送到 Dashboard
+	if engine != nil {
+		engine.OnAlert(server.alertHub.publish)
+		engine.OnAlert(server.metrics.recordAlert)
 	}
 
+	// 明文密码兼容保留，提示迁移到 bcrypt
+	server.warnPlaintextPasswords()
+
+	// 加载 htpasswd 用户文件
+	server.loadHtpasswd()
+
+	// 解析受信任的反向代理
+	server.loadTrustedProxies()
+
 	// 加载模板
 	server.loadTemplates()
 
@@ -122,29 +159,64 @@ func (s *Server) setupRoutes() {
 
 	// API 路由
 	api := s.router.PathPrefix("/api").Subrouter()
+	api.Use(s.requireCSRF)
 
 	// 认证相关
 	api.HandleFunc("/login", s.handleLogin).Methods("POST")
 	api.HandleFunc("/logout", s.handleLogout).Methods("POST")
 	api.HandleFunc("/auth/check", s.handleAuthCheck).Methods("GET")
+	api.HandleFunc("/csrf", s.handleCSRFToken).Methods("GET")
 
 	// 告警相关
 	api.HandleFunc("/alerts", s.requireAuth(s.handleGetAlerts)).Methods("GET")
 	api.HandleFunc("/alerts/stats", s.requireAuth(s.handleGetAlertStats)).Methods("GET")
 	api.HandleFunc("/alerts/rule/{rule}", s.requireAuth(s.handleGetAlertsByRule)).Methods("GET")
 	api.HandleFunc("/alerts/level/{level}", s.requireAuth(s.handleGetAlertsByLevel)).Methods("GET")
+	api.HandleFunc("/alerts/stream", s.requireAuth(s.handleStreamAlerts)).Methods("GET")
+	api.HandleFunc("/alerts/failed", s.requireAuth(s.handleGetFailedAlerts)).Methods("GET")
+	api.HandleFunc("/alerts/failed/{id}/retry", s.requireAuth(s.handleRetryFailedAlert)).Methods("POST")
 	api.HandleFunc("/alerts/{id}", s.requireAuth(s.handleGetAlertByID)).Methods("GET")
+	api.HandleFunc("/alerts/{id}/ack", s.requireAuth(s.handleAcknowledgeAlert)).Methods("POST")
+	api.HandleFunc("/alerts/{id}/notifications", s.requireAuth(s.handleGetAlertNotifications)).Methods("GET")
 
 	// 规则相关
 	api.HandleFunc("/rules", s.requireAuth(s.handleGetRules)).Methods("GET")
 	api.HandleFunc("/rules", s.requireAuth(s.handleUpsertRule)).Methods("POST")
+	api.HandleFunc("/rules/test", s.requireAuth(s.handleTestRule)).Methods("POST")
+	api.HandleFunc("/rules/validate-yaml", s.requireAuth(s.handleValidateRuleYAML)).Methods("POST")
+	api.HandleFunc("/rules/bulk", s.requireAuth(s.handleBulkRules)).Methods("POST")
+	api.HandleFunc("/rules/export", s.requireAuth(s.handleExportRules)).Methods("GET")
+	api.HandleFunc("/rules/schema", s.requireAuth(s.handleGetRuleSchema)).Methods("GET")
+	api.HandleFunc("/rules/import", s.requireAuth(s.handleImportRules)).Methods("POST")
+	api.HandleFunc("/rules/{name}/preview-query", s.requireAuth(s.handlePreviewRuleQuery)).Methods("POST")
 	api.HandleFunc("/rules/{name}/enable", s.requireAuth(s.handleEnableRule)).Methods("POST")
+	api.HandleFunc("/rules/{name}/run", s.requireAuth(s.handleRunRule)).Methods("POST")
 	api.HandleFunc("/rules/{name}/disable", s.requireAuth(s.handleDisableRule)).Methods("POST")
+	api.HandleFunc("/rules/{name}/snooze", s.requireAuth(s.handleSnoozeRule)).Methods("POST")
+	api.HandleFunc("/rules/{name}/snooze", s.requireAuth(s.handleUnsnoozeRule)).Methods("DELETE")
+	api.HandleFunc("/rules/{name}", s.requireAuth(s.handleGetRule)).Methods("GET")
+	api.HandleFunc("/rules/{name}", s.requireAuth(s.handleDeleteRule)).Methods("DELETE")
+
+	// 静默窗口
+	api.HandleFunc("/silences", s.requireAuth(s.handleGetSilences)).Methods("GET")
+	api.HandleFunc("/silences", s.requireAuth(s.handleCreateSilence)).Methods("POST")
+	api.HandleFunc("/silences/{id}", s.requireAuth(s.handleDeleteSilence)).Methods("DELETE")
 
 	// 配置相关
 	api.HandleFunc("/config", s.requireAuth(s.handleGetConfig)).Methods("GET")
 	api.HandleFunc("/config", s.requireAuth(s.handleUpdateConfig)).Methods("PUT")
 
+	// 审计记录
+	api.HandleFunc("/audit", s.requireAuth(s.handleGetAudit)).Methods("GET")
+
+	// OpenSearch 相关
+	api.HandleFunc("/opensearch/health", s.requireAuth(s.handleOpenSearchHealth)).Methods("GET")
+	api.HandleFunc("/opensearch/validate", s.requireAuth(s.handleValidateQuery)).Methods("POST")
+
+	// 运行日志
+	api.HandleFunc("/logs", s.requireAuth(s.handleGetLogs)).Methods("GET")
+	api.HandleFunc("/logs/stream", s.requireAuth(s.handleStreamLogs)).Methods("GET")
+
 	// 测试通知
 	api.HandleFunc("/test/notification", s.requireAuth(s.handleTestNotification)).Methods("POST")
 
@@ -155,17 +227,187 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/alerts", s.requireAuth(s.handleAlertsPage)).Methods("GET")
 	s.router.HandleFunc("/rules", s.requireAuth(s.handleRulesPage)).Methods("GET")
 	s.router.HandleFunc("/config", s.requireAuth(s.handleConfigPage)).Methods("GET")
+
+	// 运维端点：配置了 AdminAddr 时挂在独立监听上，否则与 Web 共用
+	if s.config.Web.AdminAddr != "" {
+		s.setupOpsRoutes(s.opsRouter)
+	} else {
+		s.setupOpsRoutes(s.router)
+	}
+}
+
+// warnPlaintextPasswords 对仍使用明文密码的用户输出弃用警告
+func (s *Server) warnPlaintextPasswords() {
+	for _, u := range s.config.Auth.Users {
+		if u.Password != "" && !config.IsHashedPassword(u.Password) {
+			s.logger.Warnf("用户 %s 使用明文密码（已弃用），请使用 -hash-password 生成 bcrypt 哈希替换", u.Username)
+		}
+	}
+}
+
+// loadHtpasswd 加载配置的 htpasswd 用户文件
+func (s *Server) loadHtpasswd() {
+	if s.config.Auth.HtpasswdFile == "" {
+		return
+	}
+	h, err := config.NewHtpasswd(s.config.Auth.HtpasswdFile, s.config.Auth.HtpasswdAllowPlaintext)
+	if err != nil {
+		s.logger.Errorf("加载 htpasswd 文件失败，仅使用配置文件中的用户: %v", err)
+		return
+	}
+	s.htpasswd = h
+	s.logger.Infof("已加载 htpasswd 用户文件: %s", s.config.Auth.HtpasswdFile)
+	if users := h.Unsupported(); len(users) > 0 {
+		s.logger.Warnf("htpasswd 中以下用户的条目格式不受支持（仅支持 bcrypt/apr1/{SHA}，明文需开启 htpasswd_allow_plaintext），无法登录: %v", users)
+	}
+}
+
+// loadTrustedProxies 解析受信任的反向代理，解析失败时不信任任何代理头
+func (s *Server) loadTrustedProxies() {
+	trustedProxies, err := config.ParseTrustedProxies(s.config.Web.TrustedProxies)
+	if err != nil {
+		s.logger.Errorf("解析 web.trusted_proxies 失败，不信任任何代理头: %v", err)
+		return
+	}
+	s.trustedProxies = trustedProxies
+}
+
+// authenticateHtpasswd 通过 htpasswd 文件校验用户
+func (s *Server) authenticateHtpasswd(username, password string) *types.User {
+	if s.htpasswd == nil {
+		return nil
+	}
+	ok, err := s.htpasswd.Verify(username, password)
+	if err != nil {
+		s.logger.Warnf("htpasswd 校验失败: %v", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+
+	role := s.config.Auth.HtpasswdRole
+	for _, u := range s.config.Auth.Users {
+		if u.Username == username && u.Role != "" {
+			role = u.Role
+			break
+		}
+	}
+	return &types.User{Username: username, Role: role}
+}
+
+// setupOpsRoutes 设置运维端点路由（无需认证）
+func (s *Server) setupOpsRoutes(r *mux.Router) {
+	r.HandleFunc("/healthz", s.handleHealthz).Methods("GET")
+	r.HandleFunc("/readyz", s.handleReadyz).Methods("GET")
+	r.HandleFunc("/metrics", s.handleMetrics).Methods("GET")
 }
 
 // Start 启动 Web 服务器
 func (s *Server) Start() error {
 	addr := fmt.Sprintf("%s:%d", s.config.Web.Host, s.config.Web.Port)
-	s.logger.Infof("启动 Web 服务器: http://%s", addr)
+	tlsEnabled := s.config.Web.TLSEnabled()
+	if tlsEnabled {
+		s.logger.Infof("启动 Web 服务器: https://%s（证书 %s）", addr, s.config.Web.TLSCertFile)
+	} else {
+		s.logger.Infof("启动 Web 服务器: http://%s", addr)
+		if s.config.Auth.Enabled {
+			s.logger.Warn("⚠️  已开启鉴权但 Web 以明文 HTTP 提供服务，登录密码与会话 Cookie 可能被窃听；请配置 web.tls_cert_file/tls_key_file 或置于 HTTPS 反向代理之后")
+		}
+	}
 
 	// 启动清理过期会话的定时任务
 	go s.startSessionCleaner()
 
-	return http.ListenAndServe(addr, s.router)
+	// 独立的运维端点监听
+	if s.config.Web.AdminAddr != "" {
+		s.adminServer = &http.Server{Addr: s.config.Web.AdminAddr, Handler: s.opsRouter}
+		go func() {
+			s.logger.Infof("启动运维端点服务: http://%s", s.config.Web.AdminAddr)
+			if err := s.adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.logger.Errorf("运维端点服务启动失败: %v", err)
+			}
+		}()
+	}
+
+	s.httpServer = &http.Server{Addr: addr, Handler: s.router}
+	var err error
+	if tlsEnabled {
+		err = s.httpServer.ListenAndServeTLS(s.config.Web.TLSCertFile, s.config.Web.TLSKeyFile)
+	} else {
+		err = s.httpServer.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Shutdown 优雅关闭 Web 服务及运维端点服务
+func (s *Server) Shutdown(ctx context.Context) error {
+	// 先结束 SSE 长连接，否则 Shutdown 会一直等到超时
+	s.closeStreams.Do(func() { close(s.streamsDone) })
+
+	var firstErr error
+	if s.adminServer != nil {
+		if err := s.adminServer.Shutdown(ctx); err != nil {
+			firstErr = err
+		}
+	}
+	if s.httpServer != nil {
+		if err := s.httpServer.Shutdown(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// handleHealthz 存活探针，进程存活即返回 200
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	s.respondJSON(w, map[string]string{"status": "ok"}, http.StatusOK)
+}
+
+// componentStatus 就绪探针中单个依赖组件的状态
+type componentStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// handleReadyz 就绪探针，检查数据库与 OpenSearch 连接，任一不可用返回 503
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	check := func(err error) componentStatus {
+		if err != nil {
+			return componentStatus{Status: "unavailable", Error: err.Error()}
+		}
+		return componentStatus{Status: "ok"}
+	}
+
+	var dbErr, osErr error
+	if s.database == nil {
+		dbErr = fmt.Errorf("数据库未初始化")
+	} else {
+		dbErr = s.database.Ping(ctx)
+	}
+	if s.opensearch == nil {
+		osErr = fmt.Errorf("OpenSearch 客户端未初始化")
+	} else {
+		osErr = s.opensearch.HealthCheck(ctx)
+	}
+
+	status, code := "ok", http.StatusOK
+	if dbErr != nil || osErr != nil {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	s.respondJSON(w, map[string]interface{}{
+		"status": status,
+		"components": map[string]componentStatus{
+			"database":   check(dbErr),
+			"opensearch": check(osErr),
+		},
+	}, code)
 }
 
 // startSessionCleaner 启动会话清理器
@@ -177,6 +419,7 @@ func (s *Server) startSessionCleaner() {
 		if err := s.database.CleanExpiredSessions(); err != nil {
 			s.logger.Errorf("清理过期会话失败: %v", err)
 		}
+		s.loginLimiter.cleanup()
 	}
 }
 
@@ -249,16 +492,33 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 失败次数过多时拒绝登录
+	accountKey, ipKey := s.loginLimitKeys(r, req.Username)
+	if remaining := s.loginLimiter.lockedFor(accountKey, ipKey); remaining > 0 {
+		retryAfter := int(remaining.Seconds()) + 1
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		s.respondJSON(w, types.LoginResponse{
+			Success: false,
+			Message: fmt.Sprintf("登录失败次数过多，请 %d 秒后重试", retryAfter),
+		}, http.StatusTooManyRequests)
+		return
+	}
+
 	// 验证用户
 	var user *types.User
 	for _, u := range s.config.Auth.Users {
-		if u.Username == req.Username && u.Password == req.Password {
+		if u.Username == req.Username && config.VerifyPassword(u.Password, req.Password) {
 			user = &u
 			break
 		}
 	}
+	if user == nil {
+		user = s.authenticateHtpasswd(req.Username, req.Password)
+	}
 
 	if user == nil {
+		s.loginLimiter.fail(accountKey, ipKey)
+		s.logger.Warnf("用户 %s 登录失败（来源: %s）", req.Username, s.clientIP(r))
 		s.respondJSON(w, types.LoginResponse{
 			Success: false,
 			Message: "用户名或密码错误",
@@ -266,6 +526,9 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 仅清除账号计数；IP 计数不随成功登录清零，避免用一个有效账号重置对其他账号的尝试
+	s.loginLimiter.reset(accountKey)
+
 	// 创建会话（容错：旧密钥导致的解码失败时，创建新会话）
 	session, err := s.store.Get(r, "opensearch-alert-session")
 	if err != nil {
@@ -352,7 +615,7 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	user := s.getCurrentUser(r)
 
 	// 获取告警统计
-	stats, err := s.database.GetAlertStats(24) // 最近24小时
+	stats, err := s.database.GetAlertStats(24, false) // 最近24小时
 	if err != nil {
 		s.logger.Errorf("获取告警统计失败: %v", err)
 		stats = &types.AlertStats{}
@@ -364,6 +627,7 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		"AlertStats": *stats,
 		"Config":     s.config,
 		"User":       user,
+		"CSRFToken":  s.csrfToken(w, r),
 	}
 
 	if tmpl, ok := s.pageTemplates["dashboard.html"]; ok {
@@ -393,6 +657,7 @@ func (s *Server) handleAlertsPage(w http.ResponseWriter, r *http.Request) {
 		"ActivePage": "alerts",
 		"Rules":      rules,
 		"User":       user,
+		"CSRFToken":  s.csrfToken(w, r),
 	}
 
 	if tmpl, ok := s.pageTemplates["alerts.html"]; ok {
@@ -421,6 +686,7 @@ func (s *Server) handleRulesPage(w http.ResponseWriter, r *http.Request) {
 		"ActivePage": "rules",
 		"Rules":      rules,
 		"User":       user,
+		"CSRFToken":  s.csrfToken(w, r),
 	}
 
 	if tmpl, ok := s.pageTemplates["rules.html"]; ok {
@@ -449,6 +715,7 @@ func (s *Server) handleConfigPage(w http.ResponseWriter, r *http.Request) {
 		"ActivePage": "config",
 		"Config":     s.config,
 		"User":       user,
+		"CSRFToken":  s.csrfToken(w, r),
 	}
 
 	if tmpl, ok := s.pageTemplates["config.html"]; ok {
@@ -464,63 +731,88 @@ func (s *Server) handleConfigPage(w http.ResponseWriter, r *http.Request) {
 
 // handleGetAlerts 获取告警列表
 func (s *Server) handleGetAlerts(w http.ResponseWriter, r *http.Request) {
-	// 解析查询参数（旧逻辑：rule/level/hours + 分页）
-	ruleName := r.URL.Query().Get("rule")
-	level := r.URL.Query().Get("level")
-	limitStr := r.URL.Query().Get("limit")
-	pageStr := r.URL.Query().Get("page")
-	pageSizeStr := r.URL.Query().Get("page_size")
-	hoursStr := r.URL.Query().Get("hours")
-
-	limit := 50
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil {
-			limit = l
+	// 解析查询参数：rule/level/时间范围/确认状态可任意组合，结果分页返回
+	query := r.URL.Query()
+	filter := types.AlertFilter{
+		Rule:  query.Get("rule"),
+		Level: query.Get("level"),
+	}
+
+	filter.Page, _ = strconv.Atoi(query.Get("page"))
+	filter.PageSize, _ = strconv.Atoi(query.Get("page_size"))
+	// 兼容旧参数 limit：未指定 page_size 时作为每页数量
+	if filter.PageSize <= 0 {
+		if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 {
+			filter.PageSize = limit
 		}
 	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 10
+	}
 
-	var alerts []types.AlertHistory
-	var err error
-
-	if ruleName != "" {
-		alerts, err = s.database.GetAlertsByRule(ruleName, limit)
-	} else if level != "" {
-		alerts, err = s.database.GetAlertsByLevel(level, limit)
-	} else {
-		// 分页模式
-		page, _ := strconv.Atoi(pageStr)
-		pageSize, _ := strconv.Atoi(pageSizeStr)
-		hours := 0
-		if hoursStr != "" {
-			if h, err := strconv.Atoi(hoursStr); err == nil {
-				hours = h
-			}
+	// 指定 start/end（RFC3339）时按绝对时间范围查询，否则按 hours 相对窗口
+	startStr, endStr := query.Get("start"), query.Get("end")
+	if startStr != "" || endStr != "" {
+		start, end, errMsg := parseTimeRange(startStr, endStr)
+		if errMsg != "" {
+			s.respondJSON(w, map[string]string{"error": errMsg}, http.StatusBadRequest)
+			return
 		}
-		alerts, total, err := s.database.GetAlertsPaged(hours, page, pageSize)
+		filter.Start, filter.End = start, end
+	} else if hours, err := strconv.Atoi(query.Get("hours")); err == nil && hours > 0 {
+		filter.Start = time.Now().Add(-time.Duration(hours) * time.Hour)
+	}
+
+	if ackStr := query.Get("acknowledged"); ackStr != "" {
+		ack, err := strconv.ParseBool(ackStr)
 		if err != nil {
-			s.respondJSON(w, map[string]string{"error": "获取告警失败"}, http.StatusInternalServerError)
+			s.respondJSON(w, map[string]string{"error": "acknowledged 参数应为 true 或 false"}, http.StatusBadRequest)
 			return
 		}
-		s.respondJSON(w, map[string]interface{}{
-			"alerts":    alerts,
-			"total":     total,
-			"page":      page,
-			"page_size": pageSize,
-		}, http.StatusOK)
-		return
+		filter.Acknowledged = &ack
 	}
 
+	alerts, total, err := s.database.QueryAlerts(filter)
 	if err != nil {
 		s.respondJSON(w, map[string]string{"error": "获取告警失败"}, http.StatusInternalServerError)
 		return
 	}
 
 	s.respondJSON(w, map[string]interface{}{
-		"alerts": alerts,
-		"total":  len(alerts),
+		"alerts":    alerts,
+		"total":     total,
+		"page":      filter.Page,
+		"page_size": filter.PageSize,
 	}, http.StatusOK)
 }
 
+// parseTimeRange 解析 RFC3339 格式的 start/end，end 缺省为当前时间；出错时返回错误提示
+func parseTimeRange(startStr, endStr string) (time.Time, time.Time, string) {
+	var start time.Time
+	end := time.Now()
+	if startStr != "" {
+		t, err := time.Parse(time.RFC3339, startStr)
+		if err != nil {
+			return start, end, "start 格式错误，应为 RFC3339（如 2024-01-02T15:04:05+08:00）"
+		}
+		start = t
+	}
+	if endStr != "" {
+		t, err := time.Parse(time.RFC3339, endStr)
+		if err != nil {
+			return start, end, "end 格式错误，应为 RFC3339（如 2024-01-02T15:04:05+08:00）"
+		}
+		end = t
+	}
+	if !start.Before(end) {
+		return start, end, "start 必须早于 end"
+	}
+	return start, end, ""
+}
+
 // handleGetAlertByID 根据ID获取告警详情
 func (s *Server) handleGetAlertByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -543,6 +835,53 @@ func (s *Server) handleGetAlertByID(w http.ResponseWriter, r *http.Request) {
 	s.respondJSON(w, detail, http.StatusOK)
 }
 
+// handleGetAlertNotifications 获取告警各渠道的发送结果
+func (s *Server) handleGetAlertNotifications(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		s.respondJSON(w, map[string]string{"error": "缺少告警ID"}, http.StatusBadRequest)
+		return
+	}
+
+	results, err := s.database.GetNotificationResults(id)
+	if err != nil {
+		s.logger.Errorf("获取通知发送结果失败: %v", err)
+		s.respondJSON(w, map[string]string{"error": "获取通知发送结果失败"}, http.StatusInternalServerError)
+		return
+	}
+
+	s.respondJSON(w, map[string]interface{}{"notifications": results}, http.StatusOK)
+}
+
+// handleAcknowledgeAlert 确认告警
+func (s *Server) handleAcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil {
+		s.respondJSON(w, map[string]string{"error": "未登录"}, http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		s.respondJSON(w, map[string]string{"error": "缺少告警ID"}, http.StatusBadRequest)
+		return
+	}
+
+	detail, err := s.database.AcknowledgeAlert(id, user.Username)
+	if err != nil {
+		s.logger.Errorf("确认告警失败: %v", err)
+		s.respondJSON(w, map[string]string{"error": "确认告警失败"}, http.StatusInternalServerError)
+		return
+	}
+	if detail == nil {
+		s.respondJSON(w, map[string]string{"error": "未找到该告警"}, http.StatusNotFound)
+		return
+	}
+
+	s.logger.Infof("告警 %s 已被 %s 确认", id, user.Username)
+	s.respondJSON(w, detail, http.StatusOK)
+}
+
 // handleGetAlertStats 获取告警统计
 func (s *Server) handleGetAlertStats(w http.ResponseWriter, r *http.Request) {
 	hoursStr := r.URL.Query().Get("hours")
@@ -553,7 +892,8 @@ func (s *Server) handleGetAlertStats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	stats, err := s.database.GetAlertStats(hours)
+	byLevel, _ := strconv.ParseBool(r.URL.Query().Get("by_level"))
+	stats, err := s.database.GetAlertStats(hours, byLevel)
 	if err != nil {
 		s.respondJSON(w, map[string]string{"error": "获取统计失败"}, http.StatusInternalServerError)
 		return
@@ -614,16 +954,99 @@ func (s *Server) handleGetAlertsByLevel(w http.ResponseWriter, r *http.Request)
 
 // handleGetRules 获取规则列表
 func (s *Server) handleGetRules(w http.ResponseWriter, r *http.Request) {
+	// 查询参数：q 按名称/索引子串搜索，enabled 按启用状态筛选；指定 page/page_size 时分页返回，否则返回全部
+	query := r.URL.Query()
+	var enabled *bool
+	if enabledStr := query.Get("enabled"); enabledStr != "" {
+		v, err := strconv.ParseBool(enabledStr)
+		if err != nil {
+			s.respondJSON(w, map[string]string{"error": "enabled 参数应为 true 或 false"}, http.StatusBadRequest)
+			return
+		}
+		enabled = &v
+	}
+	page, _ := strconv.Atoi(query.Get("page"))
+	pageSize, _ := strconv.Atoi(query.Get("page_size"))
+
 	rules, err := s.loadRules()
 	if err != nil {
 		s.respondJSON(w, map[string]string{"error": "获取规则失败"}, http.StatusInternalServerError)
 		return
 	}
+	rules = filterRules(rules, query.Get("q"), enabled)
+	total := len(rules)
 
-	s.respondJSON(w, map[string]interface{}{
-		"rules": rules,
-		"total": len(rules),
-	}, http.StatusOK)
+	resp := map[string]interface{}{
+		"total": total,
+	}
+	if page > 0 || pageSize > 0 {
+		if page <= 0 {
+			page = 1
+		}
+		if pageSize <= 0 {
+			pageSize = 20
+		}
+		rules = paginateRules(rules, page, pageSize)
+		resp["page"] = page
+		resp["page_size"] = pageSize
+	}
+
+	// 连续查询失败的规则状态（errored 为 true 时规则已暂停执行）
+	ruleErrors := map[string]alert.RuleErrorState{}
+	if s.engine != nil {
+		ruleErrors = s.engine.RuleErrorStates()
+	}
+
+	resp["rules"] = rules
+	resp["errors"] = ruleErrors
+	s.respondJSON(w, resp, http.StatusOK)
+}
+
+// handleGetRule 按名称返回单条规则的完整定义（与规则文件内容一致）
+func (s *Server) handleGetRule(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	_, rule, err := s.findRuleFile(name)
+	if err != nil {
+		if errors.Is(err, errRuleNotFound) {
+			s.respondJSON(w, map[string]string{"error": "规则不存在"}, http.StatusNotFound)
+			return
+		}
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	s.respondJSON(w, rule, http.StatusOK)
+}
+
+// filterRules 按名称/索引子串（不区分大小写）与启用状态筛选规则
+func filterRules(rules []types.AlertRule, q string, enabled *bool) []types.AlertRule {
+	q = strings.ToLower(strings.TrimSpace(q))
+	if q == "" && enabled == nil {
+		return rules
+	}
+	filtered := make([]types.AlertRule, 0, len(rules))
+	for _, rule := range rules {
+		if enabled != nil && rule.Enabled != *enabled {
+			continue
+		}
+		if q != "" && !strings.Contains(strings.ToLower(rule.Name), q) && !strings.Contains(strings.ToLower(opensearch.RuleIndex(rule)), q) {
+			continue
+		}
+		filtered = append(filtered, rule)
+	}
+	return filtered
+}
+
+// paginateRules 返回第 page 页（从 1 开始）的规则，超出范围时返回空列表
+func paginateRules(rules []types.AlertRule, page, pageSize int) []types.AlertRule {
+	start := (page - 1) * pageSize
+	if start >= len(rules) {
+		return []types.AlertRule{}
+	}
+	end := start + pageSize
+	if end > len(rules) {
+		end = len(rules)
+	}
+	return rules[start:end]
 }
 
 // handleEnableRule 启用规则（修改规则文件 enabled:true）
@@ -639,10 +1062,42 @@ func (s *Server) handleEnableRule(w http.ResponseWriter, r *http.Request) {
 		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
 		return
 	}
+	s.recordAudit(user, auditRuleEnable, name, "enabled: true")
+	// 重新启用时清除出错状态
+	if s.engine != nil {
+		s.engine.ResetRuleError(name)
+	}
 	s.reloadRules()
 	s.respondJSON(w, map[string]string{"message": "规则已启用"}, http.StatusOK)
 }
 
+// handleRunRule 立即执行一次规则（?force=true 跳过告警抑制与去重）
+func (s *Server) handleRunRule(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+	if s.engine == nil {
+		s.respondJSON(w, map[string]string{"error": "告警引擎未初始化"}, http.StatusServiceUnavailable)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+	result, err := s.engine.RunRuleNow(name, force)
+	if errors.Is(err, alert.ErrRuleNotFound) {
+		s.respondJSON(w, map[string]string{"error": "未找到已加载的规则: " + name}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	s.respondJSON(w, result, http.StatusOK)
+}
+
 // handleDisableRule 禁用规则（修改规则文件 enabled:false）
 func (s *Server) handleDisableRule(w http.ResponseWriter, r *http.Request) {
 	user := s.getCurrentUser(r)
@@ -656,20 +1111,84 @@ func (s *Server) handleDisableRule(w http.ResponseWriter, r *http.Request) {
 		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
 		return
 	}
+	s.recordAudit(user, auditRuleDisable, name, "enabled: false")
 	s.reloadRules()
 	s.respondJSON(w, map[string]string{"message": "规则已禁用"}, http.StatusOK)
 }
 
-// updateRuleEnabled 在规则目录中查找匹配名称的 YAML 并更新 enabled 字段
-func (s *Server) updateRuleEnabled(ruleName string, enabled bool) error {
-	rulesDir := s.config.Rules.RulesFolder
-	if rulesDir == "" {
-		rulesDir = "configs/rules"
+// bulkRulesRequest 批量启用/禁用规则请求
+type bulkRulesRequest struct {
+	Names   []string `json:"names"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// handleBulkRules 批量启用/禁用规则，逐个处理，单个规则失败不影响其余规则
+func (s *Server) handleBulkRules(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+
+	var req bulkRulesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondJSON(w, map[string]string{"error": "无效的请求格式"}, http.StatusBadRequest)
+		return
+	}
+	if len(req.Names) == 0 || req.Enabled == nil {
+		s.respondJSON(w, map[string]string{"error": "names 与 enabled 不能为空"}, http.StatusBadRequest)
+		return
+	}
+	enabled := *req.Enabled
+	action, summary := auditRuleDisable, "enabled: false"
+	if enabled {
+		action, summary = auditRuleEnable, "enabled: true"
 	}
 
-	files, err := filepath.Glob(filepath.Join(rulesDir, "*.yaml"))
+	// 各规则结果：ok 或错误信息
+	results := make(map[string]string, len(req.Names))
+	failed := 0
+	for _, name := range req.Names {
+		if err := s.updateRuleEnabled(name, enabled); err != nil {
+			results[name] = err.Error()
+			failed++
+			continue
+		}
+		// 重新启用时清除出错状态
+		if enabled && s.engine != nil {
+			s.engine.ResetRuleError(name)
+		}
+		s.recordAudit(user, action, name, summary)
+		results[name] = "ok"
+	}
+	if failed < len(req.Names) {
+		s.reloadRules()
+	}
+
+	s.respondJSON(w, map[string]interface{}{
+		"enabled": enabled,
+		"results": results,
+		"updated": len(req.Names) - failed,
+		"failed":  failed,
+	}, http.StatusOK)
+}
+
+// errRuleNotFound 规则目录中不存在指定名称的规则
+var errRuleNotFound = errors.New("未找到规则")
+
+// rulesDir 返回生效的规则目录
+func (s *Server) rulesDir() string {
+	if s.config.Rules.RulesFolder == "" {
+		return "configs/rules"
+	}
+	return s.config.Rules.RulesFolder
+}
+
+// findRuleFile 在规则目录（含子目录）中查找 name 匹配的 YAML 文件
+func (s *Server) findRuleFile(ruleName string) (string, *types.AlertRule, error) {
+	files, err := config.RuleFiles(s.rulesDir())
 	if err != nil {
-		return fmt.Errorf("读取规则目录失败: %w", err)
+		return "", nil, fmt.Errorf("读取规则目录失败: %w", err)
 	}
 
 	for _, file := range files {
@@ -684,19 +1203,74 @@ func (s *Server) updateRuleEnabled(ruleName string, enabled bool) error {
 		}
 
 		if rule.Name == ruleName {
-			rule.Enabled = enabled
-			out, err := yaml.Marshal(&rule)
-			if err != nil {
-				return fmt.Errorf("序列化规则失败: %w", err)
-			}
-			if err := os.WriteFile(file, out, 0644); err != nil {
-				return fmt.Errorf("写入规则文件失败: %w", err)
-			}
-			// 重新加载模板缓存的页面数据使用最新规则数量
-			return nil
+			return file, &rule, nil
 		}
 	}
-	return fmt.Errorf("未找到规则: %s", ruleName)
+	return "", nil, errRuleNotFound
+}
+
+// updateRuleEnabled 在规则目录中查找匹配名称的 YAML 并更新 enabled 字段
+func (s *Server) updateRuleEnabled(ruleName string, enabled bool) error {
+	file, rule, err := s.findRuleFile(ruleName)
+	if err != nil {
+		if errors.Is(err, errRuleNotFound) {
+			return fmt.Errorf("未找到规则: %s", ruleName)
+		}
+		return err
+	}
+
+	rule.Enabled = enabled
+	out, err := yaml.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("序列化规则失败: %w", err)
+	}
+	if err := os.WriteFile(file, out, 0644); err != nil {
+		return fmt.Errorf("写入规则文件失败: %w", err)
+	}
+	return nil
+}
+
+// handleDeleteRule 删除规则（移除规则目录中 name 匹配的 YAML 文件）
+func (s *Server) handleDeleteRule(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if name == "" || strings.Contains(name, "..") || strings.ContainsAny(name, "/\\") {
+		s.respondJSON(w, map[string]string{"error": "无效的规则名称"}, http.StatusBadRequest)
+		return
+	}
+
+	file, _, err := s.findRuleFile(name)
+	if err != nil {
+		if errors.Is(err, errRuleNotFound) {
+			s.respondJSON(w, map[string]string{"error": "未找到该规则"}, http.StatusNotFound)
+			return
+		}
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	// 防御：确保待删除文件位于规则目录内
+	rel, err := filepath.Rel(s.rulesDir(), file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		s.respondJSON(w, map[string]string{"error": "无效的规则文件路径"}, http.StatusBadRequest)
+		return
+	}
+
+	if err := os.Remove(file); err != nil {
+		s.logger.Errorf("删除规则文件失败: %s: %v", file, err)
+		s.respondJSON(w, map[string]string{"error": "删除规则文件失败"}, http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Infof("规则 %s 已被 %s 删除: %s", name, user.Username, file)
+	s.recordAudit(user, auditRuleDelete, name, "删除规则文件 "+file)
+	s.reloadRules()
+	s.respondJSON(w, map[string]string{"message": "规则已删除"}, http.StatusOK)
 }
 
 // handleUpsertRule 新增或更新规则（根据 Name 匹配文件名；若存在则覆盖，不存在则创建）
@@ -727,6 +1301,10 @@ func (s *Server) handleUpsertRule(w http.ResponseWriter, r *http.Request) {
 		s.respondJSON(w, map[string]string{"error": "规则名称不能为空"}, http.StatusBadRequest)
 		return
 	}
+	if err := config.ValidateRule(rule); err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
 
 	rulesDir := s.config.Rules.RulesFolder
 	if rulesDir == "" {
@@ -737,29 +1315,18 @@ func (s *Server) handleUpsertRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 尝试在目录中查找同名规则的现有文件
+	// 尝试在目录（含子目录）中查找同名规则的现有文件，找到时原位更新
 	var rulePath string
-	files, _ := filepath.Glob(filepath.Join(rulesDir, "*.yaml"))
 	var existing types.AlertRule
-	for _, f := range files {
-		b, err := os.ReadFile(f)
-		if err != nil {
-			continue
-		}
-		if err := yaml.Unmarshal(b, &existing); err != nil {
-			continue
-		}
-		if existing.Name == rule.Name {
-			rulePath = f
-			break
-		}
+	if f, r, err := s.findRuleFile(rule.Name); err == nil {
+		rulePath = f
+		existing = *r
 	}
-	// 若未找到，则以规则名称生成安全的新文件名
+	// 若未找到，则以规则名称生成安全的新文件名（默认 .yaml，已有同名 .yml 文件时沿用，避免产生重复文件）
 	if rulePath == "" {
-		fileName := fmt.Sprintf("%s.yaml", rule.Name)
-		fileName = strings.ReplaceAll(fileName, "/", "_")
-		fileName = strings.ReplaceAll(fileName, "\\", "_")
-		rulePath = filepath.Join(rulesDir, fileName)
+		baseName := strings.ReplaceAll(rule.Name, "/", "_")
+		baseName = strings.ReplaceAll(baseName, "\\", "_")
+		rulePath = config.RuleFilePath(rulesDir, baseName)
 	}
 
 	// 合并：对未在请求中出现的字段，保留旧文件中的值
@@ -779,17 +1346,100 @@ func (s *Server) handleUpsertRule(w http.ResponseWriter, r *http.Request) {
 		s.respondJSON(w, map[string]string{"error": "序列化规则失败"}, http.StatusInternalServerError)
 		return
 	}
+	previous, readErr := os.ReadFile(rulePath)
 	if err := os.WriteFile(rulePath, data, 0644); err != nil {
 		s.respondJSON(w, map[string]string{"error": "写入规则文件失败"}, http.StatusInternalServerError)
 		return
 	}
+	if readErr != nil {
+		s.recordAudit(user, auditRuleCreate, rule.Name, "新建规则文件 "+rulePath)
+	} else {
+		s.recordAudit(user, auditRuleUpdate, rule.Name, changedLines(lineDiff(string(previous), string(data))))
+	}
 
-	// 保存完成后热加载规则
+	// 规则已修改，清除出错状态后热加载
+	if s.engine != nil {
+		s.engine.ResetRuleError(rule.Name)
+	}
 	s.reloadRules()
 
 	s.respondJSON(w, map[string]string{"message": "规则保存成功"}, http.StatusOK)
 }
 
+// handleTestRule 试运行规则：查询当前数据并渲染消息，不发送通知、不写库
+func (s *Server) handleTestRule(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+	if s.engine == nil {
+		s.respondJSON(w, map[string]string{"error": "告警引擎未初始化"}, http.StatusServiceUnavailable)
+		return
+	}
+
+	var rule types.AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		s.respondJSON(w, map[string]string{"error": "无效的规则格式"}, http.StatusBadRequest)
+		return
+	}
+	if opensearch.RuleIndex(rule) == "" {
+		s.respondJSON(w, map[string]string{"error": "规则索引不能为空"}, http.StatusBadRequest)
+		return
+	}
+
+	rules := []types.AlertRule{rule}
+	config.ApplyRuleDefaults(rules, s.config.Rules)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	result, err := s.engine.TestRule(ctx, rules[0])
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusBadGateway)
+		return
+	}
+
+	s.respondJSON(w, result, http.StatusOK)
+}
+
+// handleGetRuleSchema 返回规则字段描述（字段名、类型、是否必填、可选值），供前端动态渲染规则表单
+func (s *Server) handleGetRuleSchema(w http.ResponseWriter, r *http.Request) {
+	s.respondJSON(w, config.RuleSchema(), http.StatusOK)
+}
+
+// handlePreviewRuleQuery 返回规则将发送给 OpenSearch 的完整查询（不执行）
+func (s *Server) handlePreviewRuleQuery(w http.ResponseWriter, r *http.Request) {
+	if s.opensearch == nil {
+		s.respondJSON(w, map[string]string{"error": "OpenSearch 客户端未初始化"}, http.StatusServiceUnavailable)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	_, rule, err := s.findRuleFile(name)
+	if err != nil {
+		if errors.Is(err, errRuleNotFound) {
+			s.respondJSON(w, map[string]string{"error": "规则不存在"}, http.StatusNotFound)
+			return
+		}
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	rules := []types.AlertRule{*rule}
+	config.ApplyRuleDefaults(rules, s.config.Rules)
+
+	index := opensearch.RuleIndex(rules[0])
+	resolvedIndex := opensearch.ResolveIndex(index)
+	s.respondJSON(w, map[string]interface{}{
+		"rule":           rules[0].Name,
+		"index":          index,
+		"resolved_index": resolvedIndex,
+		"path":           fmt.Sprintf("/%s/_search", resolvedIndex),
+		"query":          s.opensearch.BuildTimeRangeQuery(rules[0], s.config.AlertEngine.BufferTime),
+	}, http.StatusOK)
+}
+
 // handleGetConfig 获取配置
 func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 	user := s.getCurrentUser(r)
@@ -798,17 +1448,25 @@ func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 转换为前端期望的小写键名结构
+	// 转换为前端期望的小写键名结构，密钥字段以占位符返回
 	cfg := s.config
 	apiConfig := map[string]interface{}{
 		"opensearch": map[string]interface{}{
 			"host":         cfg.OpenSearch.Host,
+			"hosts":        cfg.OpenSearch.Hosts,
 			"port":         cfg.OpenSearch.Port,
 			"protocol":     cfg.OpenSearch.Protocol,
 			"username":     cfg.OpenSearch.Username,
-			"password":     cfg.OpenSearch.Password,
+			"password":     maskSecret(cfg.OpenSearch.Password),
+			"auth_type":    cfg.OpenSearch.AuthType,
+			"api_key":      maskSecret(cfg.OpenSearch.APIKey),
+			"token":        maskSecret(cfg.OpenSearch.Token),
 			"verify_certs": cfg.OpenSearch.VerifyCerts,
 			"timeout":      cfg.OpenSearch.Timeout,
+
+			"ca_cert_file":     cfg.OpenSearch.CACertFile,
+			"client_cert_file": cfg.OpenSearch.ClientCertFile,
+			"client_key_file":  cfg.OpenSearch.ClientKeyFile,
 		},
 		"alert_engine": map[string]interface{}{
 			"run_interval":      cfg.AlertEngine.RunInterval,
@@ -818,12 +1476,19 @@ func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 			"alert_time_limit":  cfg.AlertEngine.AlertTimeLimit,
 		},
 		"web": map[string]interface{}{
-			"enabled":        cfg.Web.Enabled,
-			"host":           cfg.Web.Host,
-			"port":           cfg.Web.Port,
-			"static_path":    cfg.Web.StaticPath,
-			"template_path":  cfg.Web.TemplatePath,
-			"session_secret": cfg.Web.SessionSecret,
+			"enabled":          cfg.Web.Enabled,
+			"host":             cfg.Web.Host,
+			"port":             cfg.Web.Port,
+			"static_path":      cfg.Web.StaticPath,
+			"template_path":    cfg.Web.TemplatePath,
+			"session_secret":   maskSecret(cfg.Web.SessionSecret),
+			"admin_addr":       cfg.Web.AdminAddr,
+			"tls_cert_file":    cfg.Web.TLSCertFile,
+			"tls_key_file":     cfg.Web.TLSKeyFile,
+			"cookie_secure":    cfg.Web.CookieSecure,
+			"cookie_domain":    cfg.Web.CookieDomain,
+			"cookie_same_site": cfg.Web.CookieSameSite,
+			"trusted_proxies":  cfg.Web.TrustedProxies,
 		},
 		"database": map[string]interface{}{
 			"type":                 cfg.Database.Type,
@@ -833,9 +1498,10 @@ func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 			"host":                 cfg.Database.Host,
 			"port":                 cfg.Database.Port,
 			"username":             cfg.Database.Username,
-			"password":             cfg.Database.Password,
+			"password":             maskSecret(cfg.Database.Password),
 			"dbname":               cfg.Database.DBName,
 			"params":               cfg.Database.Params,
+			"dsn":                  maskSecret(cfg.Database.DSN),
 		},
 		"notifications": map[string]interface{}{
 			"email": map[string]interface{}{
@@ -843,17 +1509,21 @@ func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 				"smtp_server": cfg.Notifications.Email.SMTPServer,
 				"smtp_port":   cfg.Notifications.Email.SMTPPort,
 				"username":    cfg.Notifications.Email.Username,
-				"password":    cfg.Notifications.Email.Password,
+				"password":    maskSecret(cfg.Notifications.Email.Password),
 				"from_email":  cfg.Notifications.Email.FromEmail,
 				"to_emails":   cfg.Notifications.Email.ToEmails,
 				"use_tls":     cfg.Notifications.Email.UseTLS,
+				"min_level":   cfg.Notifications.Email.MinLevel,
 			},
 			"dingtalk": map[string]interface{}{
-				"enabled":     cfg.Notifications.DingTalk.Enabled,
-				"webhook_url": cfg.Notifications.DingTalk.WebhookURL,
-				"secret":      cfg.Notifications.DingTalk.Secret,
-				"at_mobiles":  cfg.Notifications.DingTalk.AtMobiles,
-				"at_all":      cfg.Notifications.DingTalk.AtAll,
+				"enabled":            cfg.Notifications.DingTalk.Enabled,
+				"webhook_url":        cfg.Notifications.DingTalk.WebhookURL,
+				"secret":             maskSecret(cfg.Notifications.DingTalk.Secret),
+				"at_mobiles":         cfg.Notifications.DingTalk.AtMobiles,
+				"at_all":             cfg.Notifications.DingTalk.AtAll,
+				"use_action_card":    cfg.Notifications.DingTalk.UseActionCard,
+				"dashboard_base_url": cfg.Notifications.DingTalk.DashboardBaseURL,
+				"min_level":          cfg.Notifications.DingTalk.MinLevel,
 			},
 			"wechat": map[string]interface{}{
 				"enabled":               cfg.Notifications.WeChat.Enabled,
@@ -861,15 +1531,39 @@ func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 				"mentioned_list":        cfg.Notifications.WeChat.MentionedList,
 				"mentioned_mobile_list": cfg.Notifications.WeChat.MentionedMobileList,
 				"at_all":                cfg.Notifications.WeChat.AtAll,
+				"use_markdown":          cfg.Notifications.WeChat.UseMarkdown,
+				"dashboard_base_url":    cfg.Notifications.WeChat.DashboardBaseURL,
+				"min_level":             cfg.Notifications.WeChat.MinLevel,
 			},
 			"feishu": map[string]interface{}{
 				"enabled":     cfg.Notifications.Feishu.Enabled,
 				"webhook_url": cfg.Notifications.Feishu.WebhookURL,
-				"secret":      cfg.Notifications.Feishu.Secret,
+				"secret":      maskSecret(cfg.Notifications.Feishu.Secret),
 				"at_mobiles":  cfg.Notifications.Feishu.AtMobiles,
 				"at_all":      cfg.Notifications.Feishu.AtAll,
+				"at_user_ids": cfg.Notifications.Feishu.AtUserIDs,
+				"app_id":      cfg.Notifications.Feishu.AppID,
+				"app_secret":  maskSecret(cfg.Notifications.Feishu.AppSecret),
+				"min_level":   cfg.Notifications.Feishu.MinLevel,
+			},
+			"ntfy": map[string]interface{}{
+				"enabled":    cfg.Notifications.Ntfy.Enabled,
+				"server_url": cfg.Notifications.Ntfy.ServerURL,
+				"topic":      cfg.Notifications.Ntfy.Topic,
+				"token":      maskSecret(cfg.Notifications.Ntfy.Token),
+				"priority":   cfg.Notifications.Ntfy.Priority,
+				"min_level":  cfg.Notifications.Ntfy.MinLevel,
 			},
+			"pagerduty": map[string]interface{}{
+				"enabled":     cfg.Notifications.PagerDuty.Enabled,
+				"routing_key": maskSecret(cfg.Notifications.PagerDuty.RoutingKey),
+				"events_url":  cfg.Notifications.PagerDuty.EventsURL,
+				"min_level":   cfg.Notifications.PagerDuty.MinLevel,
+			},
+			"http_timeout": cfg.Notifications.HTTPTimeout,
 		},
+		// 各通知渠道的实际生效状态（配置错误的渠道会被自动停用）
+		"notification_status": s.notifier.ChannelStatuses(),
 	}
 
 	s.respondJSON(w, apiConfig, http.StatusOK)
@@ -897,29 +1591,89 @@ func (s *Server) handleUpdateConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var newCfg types.Config
-	if err := yaml.Unmarshal(yamlBytes, &newCfg); err != nil {
+	// 以当前配置的深拷贝为底，未提交的字段保留原值；解析失败时不影响运行中的配置
+	newCfg, err := cloneConfig(s.config)
+	if err != nil {
+		s.logger.Errorf("复制当前配置失败: %v", err)
+		s.respondJSON(w, map[string]string{"error": "配置解析失败"}, http.StatusInternalServerError)
+		return
+	}
+	if err := yaml.Unmarshal(yamlBytes, newCfg); err != nil {
 		s.respondJSON(w, map[string]string{"error": "配置解析失败"}, http.StatusBadRequest)
 		return
 	}
+	// 前端回传的密钥占位符表示未修改，保留原值
+	preserveMaskedSecrets(s.config, newCfg)
+
+	// 3) 校验合并后的配置，与启动时一致；不通过时不修改内存与文件，避免写入重启后无法启动的配置
+	candidate := *s.config
+	candidate.OpenSearch = newCfg.OpenSearch
+	candidate.AlertEngine = newCfg.AlertEngine
+	candidate.Web = newCfg.Web
+	candidate.Database = newCfg.Database
+	candidate.Notifications = newCfg.Notifications
+	if err := config.ValidateConfig(&candidate); err != nil {
+		s.respondJSON(w, map[string]interface{}{
+			"error":  "配置校验失败",
+			"errors": validationErrors(err),
+		}, http.StatusBadRequest)
+		return
+	}
 
-	// 3) 合并到现有配置（仅覆盖前端可编辑的部分）
+	// 4) 合并到现有配置（仅覆盖前端可编辑的部分）
+	changed := changedConfigSections(s.config, newCfg)
 	s.config.OpenSearch = newCfg.OpenSearch
 	s.config.AlertEngine = newCfg.AlertEngine
 	s.config.Web = newCfg.Web
 	s.config.Database = newCfg.Database
 	s.config.Notifications = newCfg.Notifications
 
-	// 4) 落盘持久化到配置文件
+	// 按新配置重建通知渠道，Webhook/SMTP 等修改立即生效
+	s.notifier.Reload(s.config)
+
+	// 5) 落盘持久化到配置文件
 	if err := s.saveConfigToFile(); err != nil {
 		s.logger.Errorf("保存配置到文件失败: %v", err)
 		s.respondJSON(w, map[string]string{"error": "保存配置到文件失败"}, http.StatusInternalServerError)
 		return
 	}
 
+	summary := "无变化"
+	if len(changed) > 0 {
+		summary = "修改配置段: " + strings.Join(changed, ", ")
+	}
+	s.recordAudit(user, auditConfigUpdate, "config", summary)
+
 	s.respondJSON(w, map[string]string{"message": "配置更新成功"}, http.StatusOK)
 }
 
+// validationErrors 将 ValidateConfig 合并返回的错误拆分为逐条的问题列表
+func validationErrors(err error) []string {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []string{err.Error()}
+	}
+	var problems []string
+	for _, e := range joined.Unwrap() {
+		problems = append(problems, e.Error())
+	}
+	return problems
+}
+
+// cloneConfig 通过 YAML 往返深拷贝配置；yaml 解码会写入已有的指针与 map，浅拷贝会改动原配置
+func cloneConfig(cfg *types.Config) (*types.Config, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+	clone := &types.Config{}
+	if err := yaml.Unmarshal(data, clone); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	clone.EnvPlaceholders = cfg.EnvPlaceholders
+	return clone, nil
+}
+
 // saveConfigToFile 将当前内存配置写回 YAML 文件，实现持久化
 func (s *Server) saveConfigToFile() error {
 	// 优先使用环境变量指定路径，其次使用默认路径
@@ -928,9 +1682,10 @@ func (s *Server) saveConfigToFile() error {
 		configPath = "configs/config.yaml"
 	}
 
-	data, err := yaml.Marshal(s.config)
+	// 由环境变量展开的密钥写回占位符，不落盘
+	data, err := config.MarshalConfig(s.config)
 	if err != nil {
-		return fmt.Errorf("序列化配置失败: %w", err)
+		return err
 	}
 	// 确保目录存在
 	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
@@ -943,6 +1698,112 @@ func (s *Server) saveConfigToFile() error {
 	return nil
 }
 
+// handleOpenSearchHealth 检查 OpenSearch 集群健康状态
+func (s *Server) handleOpenSearchHealth(w http.ResponseWriter, r *http.Request) {
+	if s.opensearch == nil {
+		s.respondJSON(w, map[string]string{"error": "OpenSearch 客户端未初始化"}, http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := s.opensearch.HealthCheck(ctx); err != nil {
+		s.respondJSON(w, map[string]string{"status": "unhealthy", "error": err.Error()}, http.StatusServiceUnavailable)
+		return
+	}
+
+	s.respondJSON(w, map[string]string{"status": "healthy"}, http.StatusOK)
+}
+
+// validateQueryRequest 查询校验请求
+type validateQueryRequest struct {
+	Index string `json:"index"`
+	// Query 与规则 query 相同的查询条件；包含顶层 query 键时视为完整的请求体
+	Query map[string]interface{} `json:"query"`
+}
+
+// handleValidateQuery 以 size:0 执行查询，返回命中总数；查询出错时原样返回 OpenSearch 的错误响应
+func (s *Server) handleValidateQuery(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+	if s.opensearch == nil {
+		s.respondJSON(w, map[string]string{"error": "OpenSearch 客户端未初始化"}, http.StatusServiceUnavailable)
+		return
+	}
+
+	var req validateQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondJSON(w, map[string]string{"error": "无效的请求格式"}, http.StatusBadRequest)
+		return
+	}
+	if req.Index == "" {
+		s.respondJSON(w, map[string]string{"error": "索引不能为空"}, http.StatusBadRequest)
+		return
+	}
+
+	body := map[string]interface{}{}
+	if _, ok := req.Query["query"]; ok {
+		for k, v := range req.Query {
+			body[k] = v
+		}
+	} else if len(req.Query) > 0 {
+		body["query"] = req.Query
+	}
+	body["size"] = 0
+	body["track_total_hits"] = true
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	response, err := s.opensearch.Search(ctx, req.Index, body)
+	if err != nil {
+		var statusErr *opensearch.StatusError
+		if errors.As(err, &statusErr) {
+			resp := map[string]interface{}{
+				"valid":            false,
+				"error":            "OpenSearch 查询失败",
+				"status":           statusErr.StatusCode,
+				"opensearch_error": rawJSONOrString(statusErr.Body),
+			}
+			status := http.StatusBadRequest
+			if statusErr.StatusCode == http.StatusNotFound {
+				resp["error"] = fmt.Sprintf("索引不存在: %s", req.Index)
+				status = http.StatusNotFound
+			}
+			s.respondJSON(w, resp, status)
+			return
+		}
+		s.respondJSON(w, map[string]interface{}{"valid": false, "error": err.Error()}, http.StatusBadGateway)
+		return
+	}
+
+	s.respondJSON(w, map[string]interface{}{
+		"valid": true,
+		"hits":  response.Hits.Total.Value,
+		"query": body,
+	}, http.StatusOK)
+}
+
+// rawJSONOrString 响应体为合法 JSON 时原样嵌入，否则作为字符串返回
+func rawJSONOrString(body string) interface{} {
+	if json.Valid([]byte(body)) {
+		return json.RawMessage(body)
+	}
+	return body
+}
+
+// testNotificationRequest 测试通知请求（均可省略）
+type testNotificationRequest struct {
+	// Level 测试告警级别，默认 Info；可用 Critical/High 验证配色与 @ 提醒
+	Level string `json:"level"`
+	// Channels 仅发送到指定渠道（可只指定一个，排查单个渠道时不打扰其他渠道），为空表示全部启用的渠道
+	Channels []string `json:"channels"`
+}
+
 // handleTestNotification 测试通知
 func (s *Server) handleTestNotification(w http.ResponseWriter, r *http.Request) {
 	user := s.getCurrentUser(r)
@@ -951,12 +1812,28 @@ func (s *Server) handleTestNotification(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	var req testNotificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		s.respondJSON(w, map[string]string{"error": "无效的请求格式"}, http.StatusBadRequest)
+		return
+	}
+
+	level := "Info"
+	if req.Level != "" {
+		normalized, ok := config.NormalizeLevel(req.Level)
+		if !ok {
+			s.respondJSON(w, map[string]string{"error": fmt.Sprintf("未知的告警级别: %q（可选 Critical/High/Medium/Low/Info）", req.Level)}, http.StatusBadRequest)
+			return
+		}
+		level = normalized
+	}
+
 	// 创建测试告警
 	testAlert := &types.Alert{
-		ID:        fmt.Sprintf("test-web-%d", time.Now().Unix()),
+		ID:        types.NewAlertID("test-web"),
 		RuleName:  "Web 测试告警",
-		Level:     "Info",
-		Message:   "这是一条通过 Web 界面发送的测试告警消息。",
+		Level:     level,
+		Message:   fmt.Sprintf("这是一条通过 Web 界面发送的 %s 级别测试告警消息。", level),
 		Timestamp: time.Now(),
 		Data: map[string]interface{}{
 			"test":    true,
@@ -967,16 +1844,46 @@ func (s *Server) handleTestNotification(w http.ResponseWriter, r *http.Request)
 		Matches: 1,
 	}
 
-	// 发送通知
-	if err := s.notifier.SendAlert(testAlert); err != nil {
-		s.respondJSON(w, map[string]string{"error": "发送测试通知失败"}, http.StatusInternalServerError)
+	// 发送通知：指定渠道时仅发往这些渠道，显式指定但未启用的渠道返回错误
+	channels := make([]string, 0, len(req.Channels))
+	for _, name := range req.Channels {
+		channels = append(channels, strings.ToLower(strings.TrimSpace(name)))
+	}
+	results, err := s.notifier.SendAlertToChannels(testAlert, channels)
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusBadRequest)
 		return
 	}
 
+	// 各渠道结果：ok 或错误信息
+	channelResults := make(map[string]string, len(results))
+	failed := 0
+	for name, err := range results {
+		if err != nil {
+			channelResults[name] = err.Error()
+			failed++
+			continue
+		}
+		channelResults[name] = "ok"
+	}
+
 	// 保存到数据库
 	s.database.SaveAlert(testAlert)
 
-	s.respondJSON(w, map[string]string{"message": "测试通知发送成功"}, http.StatusOK)
+	if failed > 0 {
+		s.respondJSON(w, map[string]interface{}{
+			"error":    fmt.Sprintf("%d 个渠道测试通知发送失败", failed),
+			"level":    level,
+			"channels": channelResults,
+		}, http.StatusBadGateway)
+		return
+	}
+
+	s.respondJSON(w, map[string]interface{}{
+		"message":  "测试通知发送成功",
+		"level":    level,
+		"channels": channelResults,
+	}, http.StatusOK)
 }
 
 // loadRules 加载规则
@@ -987,7 +1894,7 @@ func (s *Server) loadRules() ([]types.AlertRule, error) {
 		rulesDir = "configs/rules"
 	}
 
-	files, err := filepath.Glob(filepath.Join(rulesDir, "*.yaml"))
+	files, err := config.RuleFiles(rulesDir)
 	if err != nil {
 		s.logger.Errorf("读取规则目录失败: %v", err)
 		return []types.AlertRule{}, err
@@ -1035,11 +1942,12 @@ func (s *Server) loadRules() ([]types.AlertRule, error) {
 			nameToRule[rule.Name] = meta
 		}
 	}
-	// 转为切片
+	// 转为切片，按名称排序保证顺序稳定
 	rules := make([]types.AlertRule, 0, len(nameToRule))
 	for _, v := range nameToRule {
 		rules = append(rules, v.rule)
 	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
 	return rules, nil
 }
 
@@ -1054,14 +1962,7 @@ func (s *Server) reloadRules() {
 		return
 	}
 	// 回填默认值
-	for i := range rules {
-		if rules[i].Timeframe == 0 {
-			rules[i].Timeframe = s.config.Rules.DefaultTimeframe
-		}
-		if rules[i].Threshold == 0 {
-			rules[i].Threshold = s.config.Rules.DefaultThreshold
-		}
-	}
+	config.ApplyRuleDefaults(rules, s.config.Rules)
 	s.engine.LoadRules(rules)
 	s.logger.Infof("规则热加载完成: %d 条", len(rules))
 }
@@ -0,0 +1,45 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/sessions"
+
+	"opensearch-alert/pkg/types"
+)
+
+// sessionOptions 根据 web 配置生成会话 Cookie 选项
+func sessionOptions(config *types.Config) *sessions.Options {
+	// 未显式配置时，以 HTTPS 提供服务即带 Secure
+	secure := config.Web.TLSEnabled()
+	if config.Web.CookieSecure != nil {
+		secure = *config.Web.CookieSecure
+	}
+	sameSite := sameSiteMode(config.Web.CookieSameSite)
+	// SameSite=None 的 Cookie 浏览器要求必须带 Secure
+	if sameSite == http.SameSiteNoneMode {
+		secure = true
+	}
+
+	return &sessions.Options{
+		Path:     "/",
+		Domain:   config.Web.CookieDomain,
+		MaxAge:   int(config.Auth.SessionTimeout),
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: sameSite,
+	}
+}
+
+// sameSiteMode 解析 cookie_same_site：lax（默认）、strict、none
+func sameSiteMode(value string) http.SameSite {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
@@ -0,0 +1,78 @@
+package web
+
+import (
+	"net/http"
+	"testing"
+
+	"opensearch-alert/internal/config"
+	"opensearch-alert/pkg/types"
+)
+
+func TestSessionOptions(t *testing.T) {
+	boolPtr := func(v bool) *bool { return &v }
+	tests := []struct {
+		name         string
+		modify       func(*types.Config)
+		wantSecure   bool
+		wantSameSite http.SameSite
+	}{
+		{"默认", func(c *types.Config) {}, false, http.SameSiteLaxMode},
+		{"HTTPS 时默认 Secure", func(c *types.Config) { c.Web.TLSCertFile, c.Web.TLSKeyFile = "cert.pem", "key.pem" }, true, http.SameSiteLaxMode},
+		{"显式关闭 Secure 优先于 HTTPS", func(c *types.Config) {
+			c.Web.TLSCertFile, c.Web.TLSKeyFile = "cert.pem", "key.pem"
+			c.Web.CookieSecure = boolPtr(false)
+		}, false, http.SameSiteLaxMode},
+		{"显式开启 Secure", func(c *types.Config) { c.Web.CookieSecure = boolPtr(true) }, true, http.SameSiteLaxMode},
+		{"SameSite=Strict", func(c *types.Config) { c.Web.CookieSameSite = " Strict " }, false, http.SameSiteStrictMode},
+		{"SameSite=None 强制 Secure", func(c *types.Config) { c.Web.CookieSameSite = "none" }, true, http.SameSiteNoneMode},
+		{"未知值回退 Lax", func(c *types.Config) { c.Web.CookieSameSite = "loose" }, false, http.SameSiteLaxMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig()
+			cfg.Auth.SessionTimeout = 3600
+			cfg.Web.CookieDomain = "alert.example.com"
+			tt.modify(cfg)
+
+			opts := sessionOptions(cfg)
+			if opts.Secure != tt.wantSecure || opts.SameSite != tt.wantSameSite {
+				t.Errorf("Secure = %v, SameSite = %v，期望 %v, %v", opts.Secure, opts.SameSite, tt.wantSecure, tt.wantSameSite)
+			}
+			if opts.Domain != "alert.example.com" || opts.MaxAge != 3600 || !opts.HttpOnly || opts.Path != "/" {
+				t.Errorf("Cookie 选项不符: %+v", opts)
+			}
+		})
+	}
+}
+
+func TestLoginCookieAttributes(t *testing.T) {
+	hash, err := config.HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword 失败: %v", err)
+	}
+	secure := true
+	cfg := newTestConfig()
+	cfg.Auth.Enabled = true
+	cfg.Auth.SessionTimeout = 1800
+	cfg.Auth.Users = []types.User{{Username: "alice", Password: hash, Role: "admin"}}
+	cfg.Web.CookieSecure = &secure
+	cfg.Web.CookieDomain = "alert.example.com"
+	cfg.Web.CookieSameSite = "strict"
+	s := newTestServer(t, cfg, newTestDatabase(t), nil)
+
+	rec := serve(s, http.MethodPost, "/api/login", `{"username":"alice","password":"secret"}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("登录失败: %d %s", rec.Code, rec.Body.String())
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("登录响应未设置会话 Cookie")
+	}
+	for _, cookie := range cookies {
+		if !cookie.Secure || !cookie.HttpOnly || cookie.Domain != "alert.example.com" ||
+			cookie.SameSite != http.SameSiteStrictMode || cookie.MaxAge != 1800 {
+			t.Errorf("Cookie %s 属性不符: Secure=%v HttpOnly=%v Domain=%q SameSite=%v MaxAge=%d",
+				cookie.Name, cookie.Secure, cookie.HttpOnly, cookie.Domain, cookie.SameSite, cookie.MaxAge)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"opensearch-alert/internal/config"
+	"opensearch-alert/pkg/types"
+
+	"github.com/gorilla/mux"
+)
+
+// silenceView 静默窗口及其当前状态
+type silenceView struct {
+	types.Silence
+	// Source 来源：config（配置文件，只读）或 runtime（数据库）
+	Source string `json:"source"`
+	Active bool   `json:"active"`
+}
+
+// handleGetSilences 获取全部静默窗口
+func (s *Server) handleGetSilences(w http.ResponseWriter, r *http.Request) {
+	if s.engine == nil {
+		s.respondJSON(w, map[string]string{"error": "告警引擎未初始化"}, http.StatusServiceUnavailable)
+		return
+	}
+
+	silences, err := s.engine.Silences()
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	views := make([]silenceView, 0, len(silences))
+	for _, silence := range silences {
+		source := "runtime"
+		if silence.ID == 0 {
+			source = "config"
+		}
+		views = append(views, silenceView{
+			Silence: silence,
+			Source:  source,
+			Active:  s.engine.IsSilenceActive(silence),
+		})
+	}
+
+	s.respondJSON(w, map[string]interface{}{
+		"silences": views,
+		"total":    len(views),
+	}, http.StatusOK)
+}
+
+// handleCreateSilence 新增运行时静默窗口（保存到数据库，重启后仍生效）
+func (s *Server) handleCreateSilence(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+
+	var silence types.Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		s.respondJSON(w, map[string]string{"error": "无效的静默格式"}, http.StatusBadRequest)
+		return
+	}
+	if err := config.ValidateSilence(silence); err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	silence.ID = 0
+	silence.CreatedBy = user.Username
+	silence.CreatedAt = time.Now()
+	if _, err := s.database.SaveSilence(&silence); err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Infof("用户 %s 新增静默窗口 #%d（规则: %q）", user.Username, silence.ID, silence.Rule)
+	s.respondJSON(w, silence, http.StatusCreated)
+}
+
+// handleDeleteSilence 删除运行时静默窗口（配置文件中的静默需修改配置）
+func (s *Server) handleDeleteSilence(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		s.respondJSON(w, map[string]string{"error": "无效的静默 ID"}, http.StatusBadRequest)
+		return
+	}
+
+	found, err := s.database.DeleteSilence(id)
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		s.respondJSON(w, map[string]string{"error": "未找到该静默"}, http.StatusNotFound)
+		return
+	}
+
+	s.logger.Infof("用户 %s 删除静默窗口 #%d", user.Username, id)
+	s.respondJSON(w, map[string]string{"message": "静默已删除"}, http.StatusOK)
+}
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"opensearch-alert/internal/alert"
+)
+
+// maxSnoozeMinutes 单次暂停告警的最长时间（7 天）
+const maxSnoozeMinutes = 7 * 24 * 60
+
+// handleSnoozeRule 暂停规则告警指定分钟数，请求体 {"minutes": 30}
+func (s *Server) handleSnoozeRule(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+	if s.engine == nil {
+		s.respondJSON(w, map[string]string{"error": "告警引擎未初始化"}, http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Minutes int `json:"minutes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondJSON(w, map[string]string{"error": "请求格式错误"}, http.StatusBadRequest)
+		return
+	}
+	if req.Minutes <= 0 || req.Minutes > maxSnoozeMinutes {
+		s.respondJSON(w, map[string]string{"error": fmt.Sprintf("minutes 必须在 1-%d 之间", maxSnoozeMinutes)}, http.StatusBadRequest)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	status, err := s.engine.SnoozeRule(name, time.Duration(req.Minutes)*time.Minute)
+	if errors.Is(err, alert.ErrRuleNotFound) {
+		s.respondJSON(w, map[string]string{"error": "未找到已加载的规则: " + name}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	s.recordAudit(user, auditRuleSnooze, name, fmt.Sprintf("暂停告警 %d 分钟，至 %s", req.Minutes, status.SnoozedUntil.Format(time.RFC3339)))
+	s.respondJSON(w, status, http.StatusOK)
+}
+
+// handleUnsnoozeRule 解除规则的告警暂停
+func (s *Server) handleUnsnoozeRule(w http.ResponseWriter, r *http.Request) {
+	user := s.getCurrentUser(r)
+	if user == nil || user.Role != "admin" {
+		s.respondJSON(w, map[string]string{"error": "权限不足"}, http.StatusForbidden)
+		return
+	}
+	if s.engine == nil {
+		s.respondJSON(w, map[string]string{"error": "告警引擎未初始化"}, http.StatusServiceUnavailable)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	status, err := s.engine.UnsnoozeRule(name)
+	if errors.Is(err, alert.ErrRuleNotFound) {
+		s.respondJSON(w, map[string]string{"error": "未找到已加载的规则: " + name}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.respondJSON(w, map[string]string{"error": err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	s.recordAudit(user, auditRuleUnsnooze, name, "解除告警暂停")
+	s.respondJSON(w, status, http.StatusOK)
+}
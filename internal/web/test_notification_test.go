@@ -0,0 +1,68 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"opensearch-alert/internal/notification"
+	"opensearch-alert/pkg/types"
+)
+
+// countingEndpoint 启动统计请求数的通知桩服务
+func countingEndpoint(t *testing.T, calls *int32) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestTestNotificationChannels(t *testing.T) {
+	var ntfyCalls, pagerDutyCalls int32
+	cfg := newTestConfig()
+	cfg.Notifications.Ntfy = types.NtfyConfig{Enabled: true, ServerURL: countingEndpoint(t, &ntfyCalls), Topic: "alerts"}
+	cfg.Notifications.PagerDuty = types.PagerDutyConfig{Enabled: true, RoutingKey: "key", EventsURL: countingEndpoint(t, &pagerDutyCalls)}
+	s := newTestServer(t, cfg, newTestDatabase(t), nil)
+	s.notifier = notification.NewNotifier(cfg, newTestLogger())
+
+	send := func(body string) (int, map[string]string) {
+		t.Helper()
+		rec := serve(s, http.MethodPost, "/api/test/notification", body, nil, nil)
+		var resp struct {
+			Channels map[string]string `json:"channels"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp.Channels
+	}
+
+	// 只指定一个渠道时不打扰其他渠道
+	code, channels := send(`{"channels": [" NTFY "]}`)
+	if code != http.StatusOK || !reflect.DeepEqual(channels, map[string]string{"ntfy": "ok"}) {
+		t.Fatalf("单个渠道: %d %v", code, channels)
+	}
+	if atomic.LoadInt32(&ntfyCalls) != 1 || atomic.LoadInt32(&pagerDutyCalls) != 0 {
+		t.Fatalf("只应发送到 ntfy: ntfy=%d pagerduty=%d", ntfyCalls, pagerDutyCalls)
+	}
+
+	// 未指定渠道时发送到全部启用渠道
+	code, channels = send("")
+	if code != http.StatusOK || !reflect.DeepEqual(channels, map[string]string{"ntfy": "ok", "pagerduty": "ok"}) {
+		t.Fatalf("全部渠道: %d %v", code, channels)
+	}
+	if atomic.LoadInt32(&ntfyCalls) != 2 || atomic.LoadInt32(&pagerDutyCalls) != 1 {
+		t.Fatalf("应发送到全部启用渠道: ntfy=%d pagerduty=%d", ntfyCalls, pagerDutyCalls)
+	}
+
+	// 显式指定未启用的渠道返回错误，未知渠道返回 400
+	if code, channels = send(`{"channels": ["email"]}`); code != http.StatusBadGateway || channels["email"] == "" || channels["email"] == "ok" {
+		t.Errorf("未启用的渠道应返回错误: %d %v", code, channels)
+	}
+	if code, _ = send(`{"channels": ["carrier-pigeon"]}`); code != http.StatusBadRequest {
+		t.Errorf("未知渠道应返回 400，实际 %d", code)
+	}
+}
@@ -0,0 +1,132 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"opensearch-alert/pkg/types"
+)
+
+// searchStub 记录收到的请求并返回固定命中数的 OpenSearch 桩
+type searchStub struct {
+	mu     sync.Mutex
+	paths  []string
+	bodies []string
+	total  int
+}
+
+func (s *searchStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.paths = append(s.paths, r.Method+" "+r.URL.Path)
+	s.bodies = append(s.bodies, string(body))
+	s.mu.Unlock()
+
+	if strings.HasSuffix(r.URL.Path, "/_count") {
+		json.NewEncoder(w).Encode(map[string]int{"count": s.total})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"hits": map[string]interface{}{
+			"total": map[string]interface{}{"value": s.total, "relation": "eq"},
+			"hits": []map[string]interface{}{
+				{"_index": "app-1", "_id": "1", "_source": map[string]interface{}{"message": "boom"}},
+			},
+		},
+	})
+}
+
+func TestHandleTestRuleDryRun(t *testing.T) {
+	stub := &searchStub{total: 7}
+	db := newTestDatabase(t)
+	s := newTestServer(t, newTestConfig(), db, newTestOpenSearch(t, stub))
+
+	rec := serve(s, http.MethodPost, "/api/rules/test", `{"name":"dry","index":"app-*","type":"frequency","threshold":5,"level":"High"}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, 响应: %s", rec.Code, rec.Body.String())
+	}
+
+	var result struct {
+		Hits         int                    `json:"hits"`
+		Matches      int                    `json:"matches"`
+		WouldTrigger bool                   `json:"would_trigger"`
+		Level        string                 `json:"level"`
+		Message      string                 `json:"message"`
+		Query        map[string]interface{} `json:"query"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if result.Hits != 7 || result.Matches != 1 || !result.WouldTrigger || result.Level != "High" {
+		t.Errorf("试运行结果不符: %+v", result)
+	}
+	if !strings.Contains(result.Message, "dry") || result.Query["query"] == nil {
+		t.Errorf("应返回渲染后的消息与查询: %+v", result)
+	}
+
+	stub.mu.Lock()
+	paths := append([]string(nil), stub.paths...)
+	stub.mu.Unlock()
+	if len(paths) != 1 || paths[0] != "POST /app-*/_search" {
+		t.Errorf("试运行应只执行一次查询，实际请求: %v", paths)
+	}
+
+	// 试运行不写告警历史
+	alerts, total, err := db.QueryAlerts(types.AlertFilter{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("查询告警历史失败: %v", err)
+	}
+	if total != 0 || len(alerts) != 0 {
+		t.Errorf("试运行不应写入告警历史，实际 %d 条", total)
+	}
+}
+
+func TestHandleTestRuleBelowThreshold(t *testing.T) {
+	stub := &searchStub{total: 2}
+	s := newTestServer(t, newTestConfig(), nil, newTestOpenSearch(t, stub))
+
+	rec := serve(s, http.MethodPost, "/api/rules/test", `{"name":"dry","index":"app-*","type":"frequency","threshold":5}`, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, 响应: %s", rec.Code, rec.Body.String())
+	}
+	var result struct {
+		WouldTrigger bool `json:"would_trigger"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if result.WouldTrigger {
+		t.Error("未达到阈值时不应触发")
+	}
+}
+
+func TestHandleTestRuleErrors(t *testing.T) {
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"index_not_found_exception"}`, http.StatusNotFound)
+	})
+	s := newTestServer(t, newTestConfig(), nil, newTestOpenSearch(t, failing))
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"无效 JSON", `{`, http.StatusBadRequest},
+		{"缺少索引", `{"name":"dry"}`, http.StatusBadRequest},
+		{"查询失败", `{"name":"dry","index":"missing-*"}`, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serve(s, http.MethodPost, "/api/rules/test", tt.body, nil, nil); rec.Code != tt.want {
+				t.Errorf("状态码 = %d, 期望 %d, 响应: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	noEngine := newTestServer(t, newTestConfig(), nil, nil)
+	if rec := serve(noEngine, http.MethodPost, "/api/rules/test", `{"name":"dry","index":"app-*"}`, nil, nil); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("引擎未初始化时状态码 = %d, 期望 503", rec.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// validateStub 按索引返回结果的 OpenSearch 桩：missing-* 返回 404，bad-* 返回 DSL 解析错误，其余返回 42 条命中
+type validateStub struct {
+	mu    sync.Mutex
+	path  string
+	query map[string]interface{}
+}
+
+func (s *validateStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.path = r.URL.Path
+	s.query = nil
+	json.Unmarshal(body, &s.query)
+	s.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/missing-"):
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"type":"index_not_found_exception","reason":"no such index [missing-logs]"},"status":404}`))
+	case strings.HasPrefix(r.URL.Path, "/bad-"):
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"type":"parsing_exception","reason":"unknown query [mtach]"},"status":400}`))
+	default:
+		w.Write([]byte(`{"hits":{"total":{"value":42,"relation":"eq"},"hits":[]}}`))
+	}
+}
+
+// validateResponse POST /api/opensearch/validate 的响应
+type validateResponse struct {
+	Valid           bool                   `json:"valid"`
+	Hits            int                    `json:"hits"`
+	Error           string                 `json:"error"`
+	Status          int                    `json:"status"`
+	OpenSearchError map[string]interface{} `json:"opensearch_error"`
+}
+
+func TestValidateQuery(t *testing.T) {
+	stub := &validateStub{}
+	s := newTestServer(t, newTestConfig(), newTestDatabase(t), newTestOpenSearch(t, stub))
+	validate := func(body string) (int, validateResponse) {
+		t.Helper()
+		rec := serve(s, http.MethodPost, "/api/opensearch/validate", body, nil, nil)
+		var resp validateResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	// 仅提供查询子句时包装为 query，且只统计总数
+	code, resp := validate(`{"index":"app-logs","query":{"match":{"level":"error"}}}`)
+	if code != http.StatusOK || !resp.Valid || resp.Hits != 42 {
+		t.Fatalf("合法查询 = %d, %+v，期望 200 且命中 42", code, resp)
+	}
+	stub.mu.Lock()
+	path, query := stub.path, stub.query
+	stub.mu.Unlock()
+	if path != "/app-logs/_search" {
+		t.Errorf("请求路径 = %s", path)
+	}
+	if query["size"] != 0.0 || query["track_total_hits"] != true {
+		t.Errorf("预览查询应为 size:0 并统计总数: %v", query)
+	}
+	if _, ok := query["query"].(map[string]interface{})["match"]; !ok {
+		t.Errorf("查询子句应放入 query: %v", query)
+	}
+
+	// 提供完整请求体时保留其余字段
+	validate(`{"index":"app-logs","query":{"query":{"term":{"host":"a"}},"aggs":{"by_host":{"terms":{"field":"host"}}},"size":100}}`)
+	stub.mu.Lock()
+	query = stub.query
+	stub.mu.Unlock()
+	if _, ok := query["aggs"]; !ok || query["size"] != 0.0 {
+		t.Errorf("完整请求体应保留 aggs 并强制 size:0: %v", query)
+	}
+
+	// DSL 错误：原样返回 OpenSearch 错误
+	code, resp = validate(`{"index":"bad-logs","query":{"mtach":{}}}`)
+	if code != http.StatusBadRequest || resp.Valid || resp.Status != http.StatusBadRequest {
+		t.Fatalf("DSL 错误 = %d, %+v，期望 400", code, resp)
+	}
+	if errBody, _ := resp.OpenSearchError["error"].(map[string]interface{}); errBody["type"] != "parsing_exception" {
+		t.Errorf("应原样返回 OpenSearch 错误，实际 %v", resp.OpenSearchError)
+	}
+
+	// 索引不存在：透传 404 并给出明确提示
+	code, resp = validate(`{"index":"missing-logs","query":{"match_all":{}}}`)
+	if code != http.StatusNotFound || !strings.Contains(resp.Error, "索引不存在: missing-logs") {
+		t.Errorf("索引不存在 = %d, %+v，期望 404", code, resp)
+	}
+
+	if code, _ := validate(`{"query":{"match_all":{}}}`); code != http.StatusBadRequest {
+		t.Errorf("缺少索引时状态码 = %d, 期望 400", code)
+	}
+}
+
+func TestValidateQueryWithoutClient(t *testing.T) {
+	s := newTestServer(t, newTestConfig(), newTestDatabase(t), nil)
+	rec := serve(s, http.MethodPost, "/api/opensearch/validate", `{"index":"app-logs"}`, nil, nil)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("未初始化客户端时状态码 = %d, 期望 503", rec.Code)
+	}
+}
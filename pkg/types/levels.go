@@ -0,0 +1,119 @@
+package types
+
+import "strings"
+
+// AlertLevels 告警级别，从高到低
+var AlertLevels = []string{"Critical", "High", "Medium", "Low", "Info"}
+
+// LevelMeta 告警级别的展示元数据，各通知渠道与消息模板统一使用
+type LevelMeta struct {
+	// Emoji 标题与级别前的图标
+	Emoji string `yaml:"emoji" json:"emoji"`
+	// Color 级别主色（十六进制），用于邮件字段左边框等
+	Color string `yaml:"color" json:"color"`
+	// HeaderBg/HeaderBorder 邮件标题的背景色与边框色
+	HeaderBg     string `yaml:"header_bg" json:"header_bg"`
+	HeaderBorder string `yaml:"header_border" json:"header_border"`
+	// FeishuTemplate 飞书卡片主题色（red/orange/yellow/green/blue 等）
+	FeishuTemplate string `yaml:"feishu_template" json:"feishu_template"`
+	// WeChatColor 企业微信 markdown 字体颜色（仅支持 info/comment/warning）
+	WeChatColor string `yaml:"wechat_color" json:"wechat_color"`
+	// CSSClass 邮件中的级别样式类名后缀（level-<CSSClass>）
+	CSSClass string `yaml:"css_class" json:"css_class"`
+	// Rank 级别排序值（Critical 最高），由级别顺序决定，不可配置
+	Rank int `yaml:"-" json:"rank"`
+}
+
+// LevelTable 告警级别元数据表，键为标准级别名（如 Critical）
+type LevelTable map[string]LevelMeta
+
+// unknownLevel 未知级别使用的元数据
+var unknownLevel = LevelMeta{
+	Emoji:          "🔔",
+	Color:          "#17a2b8",
+	HeaderBg:       "#f8d7da",
+	HeaderBorder:   "#f5c6cb",
+	FeishuTemplate: "red",
+	WeChatColor:    "info",
+	CSSClass:       "info",
+}
+
+// builtinLevels 内置级别元数据（只读）
+var builtinLevels = DefaultLevels()
+
+// DefaultLevels 返回内置的级别元数据表
+func DefaultLevels() LevelTable {
+	return LevelTable{
+		"Critical": {Emoji: "🚨", Color: "#dc3545", HeaderBg: "#fdecea", HeaderBorder: "#f5c6cb", FeishuTemplate: "red", WeChatColor: "warning", CSSClass: "critical", Rank: 5},
+		"High":     {Emoji: "🚩", Color: "#fd7e14", HeaderBg: "#fff4e5", HeaderBorder: "#ffd7a8", FeishuTemplate: "orange", WeChatColor: "warning", CSSClass: "high", Rank: 4},
+		"Medium":   {Emoji: "🔔", Color: "#ffc107", HeaderBg: "#fffbe6", HeaderBorder: "#ffe58f", FeishuTemplate: "yellow", WeChatColor: "comment", CSSClass: "medium", Rank: 3},
+		"Low":      {Emoji: "ℹ️", Color: "#28a745", HeaderBg: "#e8f5e9", HeaderBorder: "#a3e4b8", FeishuTemplate: "green", WeChatColor: "info", CSSClass: "low", Rank: 2},
+		"Info":     {Emoji: "ℹ️", Color: "#17a2b8", HeaderBg: "#e8f4fd", HeaderBorder: "#a3d0f7", FeishuTemplate: "blue", WeChatColor: "info", CSSClass: "info", Rank: 1},
+	}
+}
+
+// NormalizeLevelName 将级别规范为标准写法（critical、CRITICAL → Critical），未知级别返回 false
+func NormalizeLevelName(level string) (string, bool) {
+	level = strings.TrimSpace(level)
+	for _, l := range AlertLevels {
+		if strings.EqualFold(l, level) {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Lookup 查找级别元数据（不区分大小写），表为空时使用内置表，未知级别返回通用元数据
+func (t LevelTable) Lookup(level string) LevelMeta {
+	if t == nil {
+		t = builtinLevels
+	}
+	if name, ok := NormalizeLevelName(level); ok {
+		if meta, ok := t[name]; ok {
+			return meta
+		}
+	}
+	return unknownLevel
+}
+
+// LevelTable 返回内置级别元数据合并 levels 覆盖后的结果
+func (c *Config) LevelTable() LevelTable {
+	table := DefaultLevels()
+	for level, override := range c.Levels {
+		name, ok := NormalizeLevelName(level)
+		if !ok {
+			continue
+		}
+		meta := table[name]
+		for _, f := range []struct {
+			dst *string
+			src string
+		}{
+			{&meta.Emoji, override.Emoji},
+			{&meta.Color, override.Color},
+			{&meta.HeaderBg, override.HeaderBg},
+			{&meta.HeaderBorder, override.HeaderBorder},
+			{&meta.FeishuTemplate, override.FeishuTemplate},
+			{&meta.WeChatColor, override.WeChatColor},
+			{&meta.CSSClass, override.CSSClass},
+		} {
+			if f.src != "" {
+				*f.dst = f.src
+			}
+		}
+		table[name] = meta
+	}
+	return table
+}
+
+// LevelRank 告警级别的排序值（Critical 最高，不区分大小写），未知级别返回 0
+func LevelRank(level string) int {
+	return builtinLevels.Lookup(level).Rank
+}
+
+// LevelAtLeast level 是否不低于 minLevel；minLevel 为空或任一级别未知时视为满足
+func LevelAtLeast(level, minLevel string) bool {
+	minRank := LevelRank(minLevel)
+	rank := LevelRank(level)
+	return minRank == 0 || rank == 0 || rank >= minRank
+}
@@ -0,0 +1,38 @@
+package types
+
+import "testing"
+
+func TestLevelLookupIsCaseInsensitive(t *testing.T) {
+	config := &Config{Levels: map[string]LevelMeta{"critical": {Emoji: "🔥"}}}
+	tables := map[string]LevelTable{"内置表": nil, "配置覆盖": config.LevelTable()}
+
+	for name, table := range tables {
+		t.Run(name, func(t *testing.T) {
+			want := table.Lookup("Critical")
+			if want.Rank != 5 {
+				t.Fatalf("Critical 的 Rank = %d, 期望 5", want.Rank)
+			}
+			for _, level := range []string{"critical", "CRITICAL", " Critical "} {
+				if got := table.Lookup(level); got != want {
+					t.Errorf("Lookup(%q) = %+v, 期望与 Critical 相同 %+v", level, got, want)
+				}
+				if name, ok := NormalizeLevelName(level); !ok || name != "Critical" {
+					t.Errorf("NormalizeLevelName(%q) = %q, %v", level, name, ok)
+				}
+				if got := LevelRank(level); got != 5 {
+					t.Errorf("LevelRank(%q) = %d, 期望 5", level, got)
+				}
+				if !LevelAtLeast(level, "high") || LevelAtLeast("HIGH", level) {
+					t.Errorf("LevelAtLeast 对 %q 的比较应不区分大小写", level)
+				}
+			}
+		})
+	}
+
+	if got := config.LevelTable().Lookup("CRITICAL").Emoji; got != "🔥" {
+		t.Errorf("小写键的配置覆盖应对所有写法生效，实际 %q", got)
+	}
+	if got := (LevelTable(nil)).Lookup("unknown"); got != unknownLevel {
+		t.Errorf("未知级别应返回通用元数据，实际 %+v", got)
+	}
+}
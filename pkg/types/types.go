@@ -1,6 +1,10 @@
 package types
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -15,17 +19,87 @@ type Config struct {
 	Database         DatabaseConfig         `yaml:"database"`
 	Auth             AuthConfig             `yaml:"auth"`
 	Rules            RulesConfig            `yaml:"rules"`
+	// Silences 维护/静默窗口（配置文件中定义，运行时新增的静默保存在数据库）
+	Silences []Silence `yaml:"silences"`
+	// Timezone 消息中时间的显示时区（IANA 名称，如 Asia/Shanghai），默认使用系统本地时区
+	Timezone string `yaml:"timezone"`
+	// Environment 实例所属环境（如 prod、staging），写入告警数据并用于默认通知前缀
+	Environment string `yaml:"environment"`
+	// AlertPrefix 所有通知标题/消息的前缀，为空时使用 "[ENVIRONMENT]"
+	AlertPrefix string `yaml:"alert_prefix"`
+	// Levels 覆盖告警级别的展示元数据（图标、颜色等），键不区分大小写，未填写的字段沿用默认值
+	Levels map[string]LevelMeta `yaml:"levels"`
+	// EnvPlaceholders 由环境变量展开的配置项（键为 YAML 路径），保存配置时写回占位符，避免密钥落盘
+	EnvPlaceholders map[string]EnvPlaceholder `yaml:"-" json:"-"`
+}
+
+// EnvPlaceholder 配置项的原始占位符与展开后的值
+type EnvPlaceholder struct {
+	Raw   string
+	Value string
+}
+
+// NotificationPrefix 返回通知前缀：优先 alert_prefix，其次由 environment 生成
+func (c *Config) NotificationPrefix() string {
+	if c.AlertPrefix != "" {
+		return c.AlertPrefix
+	}
+	if c.Environment != "" {
+		return "[" + strings.ToUpper(c.Environment) + "]"
+	}
+	return ""
+}
+
+// LoadLocation 解析时区配置，为空或 Local 时使用系统本地时区
+func (c *Config) LoadLocation() (*time.Location, error) {
+	if c.Timezone == "" || c.Timezone == "Local" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时区 %q: %w", c.Timezone, err)
+	}
+	return loc, nil
+}
+
+// Location 返回显示时区，配置无效时回退到系统本地时区
+func (c *Config) Location() *time.Location {
+	loc, err := c.LoadLocation()
+	if err != nil {
+		return time.Local
+	}
+	return loc
 }
 
 // OpenSearchConfig OpenSearch 连接配置
 type OpenSearchConfig struct {
-	Host        string `yaml:"host"`
-	Port        int    `yaml:"port"`
-	Protocol    string `yaml:"protocol"`
-	Username    string `yaml:"username"`
-	Password    string `yaml:"password"`
-	VerifyCerts bool   `yaml:"verify_certs"`
-	Timeout     int    `yaml:"timeout"`
+	Host string `yaml:"host"`
+	// Hosts 多个节点地址（host、host:port 或完整 URL），连接失败时自动切换；为空时使用 host
+	Hosts    []string `yaml:"hosts"`
+	Port     int      `yaml:"port"`
+	Protocol string   `yaml:"protocol"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	// AuthType 认证方式：basic（默认）、apikey、bearer
+	AuthType string `yaml:"auth_type"`
+	// APIKey API Key（已 base64 编码的 id:api_key），auth_type 为 apikey 时使用
+	APIKey string `yaml:"api_key"`
+	// Token Bearer Token，auth_type 为 bearer 时使用
+	Token string `yaml:"token"`
+	// VerifyCerts 是否校验服务端证书，false 为显式不安全模式
+	VerifyCerts bool `yaml:"verify_certs"`
+	// CACertFile 自定义 CA 证书（PEM），用于私有 CA 签发的集群证书
+	CACertFile string `yaml:"ca_cert_file"`
+	// ClientCertFile/ClientKeyFile 客户端证书与私钥（PEM），用于 mTLS
+	ClientCertFile string `yaml:"client_cert_file"`
+	ClientKeyFile  string `yaml:"client_key_file"`
+	Timeout        int    `yaml:"timeout"`
+	// MaxRetries 查询遇到 429/502/503/504 时的最大重试次数，未设置时为 3，设为 0 或负数关闭重试
+	MaxRetries *int `yaml:"max_retries"`
+	// AllowScriptQueries 是否允许规则使用 script 过滤（开销较大，默认关闭）
+	AllowScriptQueries bool `yaml:"allow_script_queries"`
+	// QueryCacheTTL 查询结果缓存时间（秒），同一轮中相同索引与查询的规则复用结果；0 表示不缓存，最大 60
+	QueryCacheTTL int `yaml:"query_cache_ttl"`
 }
 
 // AlertEngineConfig 告警引擎配置
@@ -35,6 +109,18 @@ type AlertEngineConfig struct {
 	MaxRunningRules int    `yaml:"max_running_rules"`
 	WritebackIndex  string `yaml:"writeback_index"`
 	AlertTimeLimit  int    `yaml:"alert_time_limit"`
+	// IncrementalOverlap 增量规则每轮回溯到上次终点之前的时长（秒），补查晚到的文档，已统计过的文档按 _id 排除；0 表示不重叠
+	IncrementalOverlap int `yaml:"incremental_overlap"`
+	// DedupeTTL 发送去重窗口（秒），规则未设置 dedupe_ttl 时使用，默认 120
+	DedupeTTL int `yaml:"dedupe_ttl"`
+	// MaxRuleFailures 规则连续以相同查询错误失败达到该次数后标记为出错并暂停执行，默认 5
+	MaxRuleFailures int `yaml:"max_rule_failures"`
+	// MaxFetchHits 规则开启 fetch_all 时最多收集的文档数，默认 10000
+	MaxFetchHits int `yaml:"max_fetch_hits"`
+	// QueryTimeout 单次规则执行（含查询）的超时（秒），规则未设置 query_timeout 时使用，默认 30；不应超过 opensearch.timeout
+	QueryTimeout int `yaml:"query_timeout"`
+	// LogSnippetLength 内置消息模板中日志内容的截取长度（字符），默认 500
+	LogSnippetLength int `yaml:"log_snippet_length"`
 }
 
 // AlertSuppressionConfig 告警抑制配置
@@ -52,10 +138,16 @@ type ExponentialRealertConfig struct {
 
 // NotificationsConfig 通知配置
 type NotificationsConfig struct {
-	Email    EmailConfig    `yaml:"email"`
-	DingTalk DingTalkConfig `yaml:"dingtalk"`
-	WeChat   WeChatConfig   `yaml:"wechat"`
-	Feishu   FeishuConfig   `yaml:"feishu"`
+	Email     EmailConfig     `yaml:"email"`
+	DingTalk  DingTalkConfig  `yaml:"dingtalk"`
+	WeChat    WeChatConfig    `yaml:"wechat"`
+	Feishu    FeishuConfig    `yaml:"feishu"`
+	Ntfy      NtfyConfig      `yaml:"ntfy"`
+	PagerDuty PagerDutyConfig `yaml:"pagerduty"`
+	// HTTPTimeout 钉钉、企业微信、飞书、ntfy、PagerDuty 请求超时时间（秒），默认 10
+	HTTPTimeout int `yaml:"http_timeout"`
+	// MaxMessageLength 钉钉、企业微信、飞书、ntfy 消息中告警详情的长度上限（字节），超出部分截断；0 表示按各平台限制使用默认值
+	MaxMessageLength int `yaml:"max_message_length"`
 }
 
 // EmailConfig 邮件配置
@@ -68,6 +160,22 @@ type EmailConfig struct {
 	FromEmail  string   `yaml:"from_email"`
 	ToEmails   []string `yaml:"to_emails"`
 	UseTLS     bool     `yaml:"use_tls"`
+	// Digest 开启后邮件不再逐条发送，而是按窗口汇总为一封邮件
+	Digest bool `yaml:"digest"`
+	// DigestInterval 汇总窗口（秒），默认等于 alert_engine.run_interval
+	DigestInterval int `yaml:"digest_interval"`
+	// AttachMatches 开启后 fetch_all 规则的全部匹配文档作为附件随告警邮件发送
+	AttachMatches bool `yaml:"attach_matches"`
+	// AttachFormat 附件格式：csv（默认）或 json
+	AttachFormat string `yaml:"attach_format"`
+	// AttachMaxRows 附件最多包含的文档数，默认 1000
+	AttachMaxRows int `yaml:"attach_max_rows"`
+	// AttachMaxBytes 附件大小上限（字节），默认 5MB
+	AttachMaxBytes int `yaml:"attach_max_bytes"`
+	// SubjectTemplate 邮件主题 Go 模板，数据为告警字段及 .PodName/.Namespace/.ContainerName/.ContainerImage；为空时使用 "[{{.Level}}] {{.RuleName}}"
+	SubjectTemplate string `yaml:"subject_template"`
+	// MinLevel 最低发送级别（Critical>High>Medium>Low>Info），低于该级别的告警不发送；为空表示全部发送
+	MinLevel string `yaml:"min_level"`
 }
 
 // DingTalkConfig 钉钉配置
@@ -77,6 +185,12 @@ type DingTalkConfig struct {
 	Secret     string   `yaml:"secret"`
 	AtMobiles  []string `yaml:"at_mobiles"`
 	AtAll      bool     `yaml:"at_all"`
+	// UseActionCard 使用 actionCard 消息，附带跳转到告警页面的按钮
+	UseActionCard bool `yaml:"use_action_card"`
+	// DashboardBaseURL 告警管理台地址（如 https://alert.example.com），按钮链接到 {DashboardBaseURL}/alerts?rule=规则名
+	DashboardBaseURL string `yaml:"dashboard_base_url"`
+	// MinLevel 最低发送级别（Critical>High>Medium>Low>Info），低于该级别的告警不发送；为空表示全部发送
+	MinLevel string `yaml:"min_level"`
 }
 
 // WeChatConfig 企业微信配置
@@ -86,6 +200,12 @@ type WeChatConfig struct {
 	MentionedList       []string `yaml:"mentioned_list"`
 	MentionedMobileList []string `yaml:"mentioned_mobile_list"`
 	AtAll               bool     `yaml:"at_all"`
+	// UseMarkdown 使用 markdown 消息（保留加粗与链接）；markdown 不支持 @，需要 @ 时另发一条 text 消息
+	UseMarkdown bool `yaml:"use_markdown"`
+	// DashboardBaseURL 告警管理台地址，markdown 消息中附带跳转到 {DashboardBaseURL}/alerts?rule=规则名 的链接
+	DashboardBaseURL string `yaml:"dashboard_base_url"`
+	// MinLevel 最低发送级别（Critical>High>Medium>Low>Info），低于该级别的告警不发送；为空表示全部发送
+	MinLevel string `yaml:"min_level"`
 }
 
 // FeishuConfig 飞书配置
@@ -95,6 +215,37 @@ type FeishuConfig struct {
 	Secret     string   `yaml:"secret"`
 	AtMobiles  []string `yaml:"at_mobiles"`
 	AtAll      bool     `yaml:"at_all"`
+	// AtUserIDs 需要 @ 的用户 open_id（飞书 @ 只认 open_id）
+	AtUserIDs []string `yaml:"at_user_ids"`
+	// AppID/AppSecret 飞书应用凭据（可选），配置后通过通讯录接口将 at_mobiles 中的手机号解析为 open_id
+	AppID     string `yaml:"app_id"`
+	AppSecret string `yaml:"app_secret"`
+	// MinLevel 最低发送级别（Critical>High>Medium>Low>Info），低于该级别的告警不发送；为空表示全部发送
+	MinLevel string `yaml:"min_level"`
+}
+
+// NtfyConfig ntfy 推送配置（自建或 ntfy.sh）
+type NtfyConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	ServerURL string `yaml:"server_url"`
+	Topic     string `yaml:"topic"`
+	// Token 访问令牌（可选），以 Bearer 方式发送
+	Token string `yaml:"token"`
+	// Priority 固定优先级 1-5（可选），为 0 时按告警级别映射
+	Priority int `yaml:"priority"`
+	// MinLevel 最低发送级别（Critical>High>Medium>Low>Info），低于该级别的告警不发送；为空表示全部发送
+	MinLevel string `yaml:"min_level"`
+}
+
+// PagerDutyConfig PagerDuty Events API v2 配置
+type PagerDutyConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// RoutingKey 服务集成的 Integration Key
+	RoutingKey string `yaml:"routing_key"`
+	// EventsURL Events API 地址（可选），默认 https://events.pagerduty.com/v2/enqueue，EU 区域等可覆盖
+	EventsURL string `yaml:"events_url"`
+	// MinLevel 最低发送级别（Critical>High>Medium>Low>Info），低于该级别的告警不发送；为空表示全部发送
+	MinLevel string `yaml:"min_level"`
 }
 
 // LoggingConfig 日志配置
@@ -114,6 +265,24 @@ type WebConfig struct {
 	StaticPath    string `yaml:"static_path"`
 	TemplatePath  string `yaml:"template_path"`
 	SessionSecret string `yaml:"session_secret"`
+	// AdminAddr 运维端点（/healthz 等）的独立监听地址，例如 "127.0.0.1:9090"；为空时与 Web 共用端口
+	AdminAddr string `yaml:"admin_addr"`
+	// TLSCertFile/TLSKeyFile 证书与私钥（PEM），同时配置时 Web 以 HTTPS 提供服务
+	TLSCertFile string `yaml:"tls_cert_file"`
+	TLSKeyFile  string `yaml:"tls_key_file"`
+	// CookieSecure 会话 Cookie 是否带 Secure 标记（仅经 HTTPS 发送），未设置时开启 TLS 即为 true
+	CookieSecure *bool `yaml:"cookie_secure"`
+	// CookieDomain 会话 Cookie 的 Domain，为空时仅当前主机
+	CookieDomain string `yaml:"cookie_domain"`
+	// CookieSameSite 会话 Cookie 的 SameSite：lax（默认）、strict、none（none 时强制 Secure）
+	CookieSameSite string `yaml:"cookie_same_site"`
+	// TrustedProxies 受信任的反向代理（CIDR 或 IP），来自这些地址的请求按 X-Forwarded-For/X-Real-IP 识别客户端 IP
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// TLSEnabled 是否同时配置了证书与私钥（以 HTTPS 提供服务）
+func (w WebConfig) TLSEnabled() bool {
+	return w.TLSCertFile != "" && w.TLSKeyFile != ""
 }
 
 // DatabaseConfig 数据库配置
@@ -129,6 +298,8 @@ type DatabaseConfig struct {
 	Password string `yaml:"password"`
 	DBName   string `yaml:"dbname"`
 	Params   string `yaml:"params"` // 额外 DSN 参数, 例如 "tls=false&charset=utf8mb4"
+	// DSN 完整连接串，设置后原样传给驱动，优先于 path 与上面的 MySQL 分项配置（可用于 unix socket 等）
+	DSN string `yaml:"dsn"`
 }
 
 // AuthConfig 认证配置
@@ -136,6 +307,18 @@ type AuthConfig struct {
 	Enabled        bool   `yaml:"enabled"`
 	SessionTimeout int    `yaml:"session_timeout"`
 	Users          []User `yaml:"users"`
+	// MaxLoginAttempts 窗口内同一用户名（不区分来源）允许的最大登录失败次数
+	MaxLoginAttempts int `yaml:"max_login_attempts"`
+	// MaxLoginAttemptsPerIP 窗口内同一客户端 IP 允许的最大登录失败次数（不区分用户名）
+	MaxLoginAttemptsPerIP int `yaml:"max_login_attempts_per_ip"`
+	// LockoutMinutes 失败统计窗口与锁定时长（分钟）
+	LockoutMinutes int `yaml:"lockout_minutes"`
+	// HtpasswdFile 可选的 htpasswd 用户文件（bcrypt/apr1/{SHA}），与 users 同时生效，修改后自动重新加载
+	HtpasswdFile string `yaml:"htpasswd_file"`
+	// HtpasswdRole htpasswd 用户的默认角色（同名用户在 users 中配置了角色时以其为准），默认 viewer
+	HtpasswdRole string `yaml:"htpasswd_role"`
+	// HtpasswdAllowPlaintext 是否接受 htpasswd 中的明文密码条目（htpasswd -p），默认拒绝
+	HtpasswdAllowPlaintext bool `yaml:"htpasswd_allow_plaintext"`
 }
 
 // User 用户配置
@@ -155,7 +338,7 @@ type RulesConfig struct {
 // AlertRule 告警规则结构
 type AlertRule struct {
 	Name          string                 `yaml:"name"`
-	Type          string                 `yaml:"type"` // frequency, any, spike, flatline, change
+	Type          string                 `yaml:"type"` // frequency, any, spike, flatline, change, metric
 	Index         string                 `yaml:"index"`
 	Query         map[string]interface{} `yaml:"query"`
 	Threshold     int                    `yaml:"threshold"`
@@ -167,6 +350,68 @@ type AlertRule struct {
 	AlertTextArgs []string               `yaml:"alert_text_args"`
 	Level         string                 `yaml:"level"` // Critical, High, Medium, Low, Info
 	Enabled       bool                   `yaml:"enabled"`
+	// WarmupWindows 对比类规则（spike/flatline/change/new_term）启动后仅收集基线、不告警的窗口数
+	WarmupWindows int `yaml:"warmup_windows"`
+	// Script 脚本过滤条件，需开启 opensearch.allow_script_queries
+	Script *RuleScript `yaml:"script"`
+	// ExcludeQuery 排除条件（放入 bool.must_not），命中的文档不参与告警判断
+	ExcludeQuery map[string]interface{} `yaml:"exclude_query"`
+	// AlertTextType alert_text 的渲染方式：默认 ${field} 占位符替换，go_template 为 Go 模板
+	AlertTextType string `yaml:"alert_text_type"`
+	// DedupeTTL 规则级发送去重窗口（秒），为 0 时使用 alert_engine.dedupe_ttl
+	DedupeTTL int `yaml:"dedupe_ttl"`
+	// Whitelist 字段→允许值列表，只统计字段值在列表中的文档（bool.filter terms）
+	Whitelist map[string][]string `yaml:"whitelist"`
+	// Blacklist 字段→排除值列表，字段值命中的文档不计入（bool.must_not terms），优先于 Whitelist
+	Blacklist map[string][]string `yaml:"blacklist"`
+	// MetricAgg 指标规则（type: metric）的聚合方式：avg、max、min、sum
+	MetricAgg string `yaml:"metric_agg"`
+	// MetricField 指标规则聚合的数值字段
+	MetricField string `yaml:"metric_field"`
+	// MetricThreshold 指标规则的比较阈值
+	MetricThreshold float64 `yaml:"metric_threshold"`
+	// MetricOperator 指标规则的比较方式：gt、gte、lt、lte
+	MetricOperator string `yaml:"metric_operator"`
+	// Schedule 规则独立的调度表达式（cron，支持可选的秒字段及 @every 30s 等），为空时使用全局 run_interval
+	Schedule string `yaml:"schedule"`
+	// FetchAll 使用 search_after 分页收集全部匹配文档（受 alert_engine.max_fetch_hits 限制），而非仅前 100 条
+	FetchAll bool `yaml:"fetch_all"`
+	// AutoResolve 条件不再满足时自动关闭已触发的告警，并发送恢复通知
+	AutoResolve bool `yaml:"auto_resolve"`
+	// QueryTimeout 规则执行超时（秒），覆盖 alert_engine.query_timeout，超过 opensearch.timeout 时按后者执行
+	QueryTimeout int `yaml:"query_timeout"`
+	// SpikeHeight spike 规则的突变倍数：当前窗口与上一窗口命中数之比达到该值时告警，默认 2
+	SpikeHeight float64 `yaml:"spike_height"`
+	// SpikeType spike 规则的检测方向：up（默认，突增）、down（突降）、both
+	SpikeType string `yaml:"spike_type"`
+	// Incremental 增量窗口：每轮从上次查询的结束时间（减去 alert_engine.incremental_overlap）接续查询，
+	// 每条文档只统计一次，阈值按本轮新增的文档数判断
+	Incremental bool `yaml:"incremental"`
+	// MaxHits 每次查询拉取的文档数，未设置时为 100；为 0 时仅统计总数（size: 0，不排序）
+	MaxHits *int `yaml:"max_hits"`
+	// MinHits 最少命中数：本轮命中少于该值时不告警；flatline 规则改为要求历史上曾有窗口达到该值，0 表示不限制
+	MinHits int `yaml:"min_hits"`
+	// SortField 查询排序字段，决定哪条命中作为告警示例文档；默认 @timestamp
+	SortField string `yaml:"sort_field"`
+	// SortOrder 排序方向：asc 或 desc（默认）
+	SortOrder string `yaml:"sort_order"`
+	// DigestSeconds 汇总窗口（秒），大于 0 时窗口内的告警缓冲后合并为一条通知发送
+	DigestSeconds int `yaml:"digest_seconds"`
+	// Indices 查询的多个索引（可含通配符），与 index 合并后以逗号连接
+	Indices []string `yaml:"indices"`
+	// TemplateMode alert_text 与系统默认详情的组合方式：append（默认，自定义文本在上）、prepend（详情在上）、replace（仅自定义文本）
+	TemplateMode string `yaml:"template_mode"`
+	// EventType 消息模板类型（events/logging/auditing/default），为空时按索引名推断
+	EventType string `yaml:"event_type"`
+	// EventSubtype 模板子类型，目前支持 system_component（系统组件日志模板）；为空时按规则名推断
+	EventSubtype string `yaml:"event_subtype"`
+}
+
+// RuleScript 规则脚本过滤条件（默认 painless）
+type RuleScript struct {
+	Source string                 `yaml:"source"`
+	Lang   string                 `yaml:"lang"`
+	Params map[string]interface{} `yaml:"params"`
 }
 
 // Alert 告警结构
@@ -179,6 +424,66 @@ type Alert struct {
 	Data      map[string]interface{} `json:"data"`
 	Count     int                    `json:"count"`
 	Matches   int                    `json:"matches"`
+	// Hits fetch_all 规则拉取的全部匹配文档，仅用于邮件附件，不落库
+	Hits []OpenSearchHit `json:"-"`
+	// Resolved 为 true 表示这是恢复通知（auto_resolve 规则条件解除），ID 为原告警 ID
+	Resolved bool `json:"resolved,omitempty"`
+}
+
+// AlertFilter 告警历史查询条件，零值字段表示不过滤
+type AlertFilter struct {
+	Rule         string
+	Level        string
+	Start        time.Time
+	End          time.Time
+	Acknowledged *bool
+	Page         int
+	PageSize     int
+}
+
+// NotificationResult 告警在单个通知渠道的发送结果
+type NotificationResult struct {
+	AlertID string    `json:"alert_id"`
+	Channel string    `json:"channel"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// FailedAlert 所有通知渠道均发送失败的告警（死信），可通过 Web 重试
+type FailedAlert struct {
+	ID       int64  `json:"id"`
+	AlertID  string `json:"alert_id"`
+	RuleName string `json:"rule_name"`
+	Level    string `json:"level"`
+	Alert    *Alert `json:"alert"`
+	// Errors 各渠道最近一次发送的错误信息
+	Errors        map[string]string `json:"errors"`
+	Attempts      int               `json:"attempts"`
+	CreatedAt     time.Time         `json:"created_at"`
+	LastAttemptAt time.Time         `json:"last_attempt_at"`
+}
+
+// AuditEntry 配置与规则变更的审计记录
+type AuditEntry struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// Action 操作类型，如 rule.create/rule.update/rule.enable/rule.disable/rule.delete/config.update
+	Action string `json:"action"`
+	// Target 操作对象（规则名称或配置文件）
+	Target string `json:"target"`
+	// Summary 变更摘要或差异
+	Summary   string    `json:"summary"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewAlertID 生成告警 ID：前缀 + 秒级时间戳 + 随机后缀，避免同一秒内冲突
+func NewAlertID(prefix string) string {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano())
+	}
+	return fmt.Sprintf("%s-%d-%s", prefix, time.Now().Unix(), hex.EncodeToString(buf))
 }
 
 // AlertStatus 告警状态
@@ -188,6 +493,25 @@ type AlertStatus struct {
 	AlertCount    int       `json:"alert_count"`
 	Suppressed    bool      `json:"suppressed"`
 	SuppressUntil time.Time `json:"suppress_until"`
+	// SnoozedUntil 手动暂停告警的截止时间，仅由暂停接口设置，不受 realert 与自动恢复影响
+	SnoozedUntil time.Time `json:"snoozed_until"`
+}
+
+// Silence 维护/静默窗口，窗口内匹配的规则不执行查询也不告警
+type Silence struct {
+	ID int64 `yaml:"-" json:"id"`
+	// Rule 规则名称匹配模式（glob，如 "k8s-*"），为空表示全部规则
+	Rule string `yaml:"rule" json:"rule"`
+	// StartsAt/EndsAt 一次性窗口的起止时间
+	StartsAt time.Time `yaml:"starts_at" json:"starts_at"`
+	EndsAt   time.Time `yaml:"ends_at" json:"ends_at"`
+	// Cron 周期性窗口的开始时间（标准 5 段 cron），与 Duration 配合使用
+	Cron string `yaml:"cron" json:"cron"`
+	// Duration 周期性窗口时长（分钟）
+	Duration  int       `yaml:"duration" json:"duration"`
+	Comment   string    `yaml:"comment" json:"comment"`
+	CreatedBy string    `yaml:"-" json:"created_by"`
+	CreatedAt time.Time `yaml:"-" json:"created_at"`
 }
 
 // OpenSearchHit OpenSearch 查询结果
@@ -196,6 +520,8 @@ type OpenSearchHit struct {
 	ID     string                 `json:"_id"`
 	Score  float64                `json:"_score"`
 	Source map[string]interface{} `json:"_source"`
+	// Sort 排序值，用于 search_after 分页
+	Sort []interface{} `json:"sort,omitempty"`
 }
 
 // OpenSearchResponse OpenSearch 查询响应
@@ -216,6 +542,7 @@ type OpenSearchResponse struct {
 		MaxScore float64         `json:"max_score"`
 		Hits     []OpenSearchHit `json:"hits"`
 	} `json:"hits"`
+	Aggregations map[string]interface{} `json:"aggregations,omitempty"`
 }
 
 // AlertHistory 告警历史记录
@@ -230,6 +557,13 @@ type AlertHistory struct {
 	Count     int64     `json:"count" db:"count"`
 	Matches   int64     `json:"matches" db:"matches"`
 	CreatedAt time.Time `json:"-" db:"created_at"`
+	// 确认状态
+	Acknowledged   bool       `json:"acknowledged" db:"acknowledged"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty" db:"acknowledged_by"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+	// 恢复状态（auto_resolve 规则）
+	Resolved   bool       `json:"resolved" db:"resolved"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
 }
 
 // AlertDetail 告警详情（用于API返回，包含数据）
@@ -242,20 +576,41 @@ type AlertDetail struct {
 	Count     int64                  `json:"count"`
 	Matches   int64                  `json:"matches"`
 	Data      map[string]interface{} `json:"data,omitempty"`
+	// 确认状态
+	Acknowledged   bool       `json:"acknowledged"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	// 恢复状态
+	Resolved   bool       `json:"resolved"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
 }
 
 // AlertStats 告警统计
 type AlertStats struct {
-	TotalAlerts  int64            `json:"total_alerts"`
-	LevelStats   map[string]int64 `json:"level_stats"`
-	RecentAlerts []AlertHistory   `json:"recent_alerts"`
-	HourlyStats  []HourlyStat     `json:"hourly_stats"`
+	TotalAlerts int64 `json:"total_alerts"`
+	// UnacknowledgedAlerts 时间窗口内未确认的告警数
+	UnacknowledgedAlerts int64            `json:"unacknowledged_alerts"`
+	LevelStats           map[string]int64 `json:"level_stats"`
+	// RuleStats 时间窗口内告警数最多的规则（前 N 条）及其告警数
+	RuleStats map[string]int64 `json:"rule_stats"`
+	// TopRules 与 RuleStats 相同的规则，按告警数降序（同数时按规则名）排列
+	TopRules     []RuleStat     `json:"top_rules"`
+	RecentAlerts []AlertHistory `json:"recent_alerts"`
+	HourlyStats  []HourlyStat   `json:"hourly_stats"`
+}
+
+// RuleStat 规则告警数统计
+type RuleStat struct {
+	Rule  string `json:"rule"`
+	Count int64  `json:"count"`
 }
 
 // HourlyStat 小时统计
 type HourlyStat struct {
-	Hour  int   `json:"hour"`
-	Count int64 `json:"count"`
+	Hour int `json:"hour"`
+	// Level 告警级别，仅按级别细分统计时填充
+	Level string `json:"level,omitempty"`
+	Count int64  `json:"count"`
 }
 
 // DashboardData Dashboard 数据
@@ -11,11 +11,21 @@ class AlertsPage {
 
     // 初始化
     init() {
+        this.applyUrlFilters();
         this.setupEventListeners();
         this.loadAlerts();
         this.startAutoRefresh();
     }
 
+    // 读取链接中的筛选参数（如通知消息中的 /alerts?rule=xxx）
+    applyUrlFilters() {
+        const rule = new URLSearchParams(window.location.search).get('rule');
+        if (!rule) return;
+        this.currentFilters.rule = rule;
+        const select = document.getElementById('ruleFilter');
+        if (select) select.value = rule;
+    }
+
     // 设置事件监听器
     setupEventListeners() {
         // 筛选按钮
@@ -176,6 +186,14 @@ class AlertsPage {
                         <button class="btn btn-sm btn-outline-primary" onclick="showAlertDetail('${alert.id}')">
                             <i class="bi bi-eye"></i> 详情
                         </button>
+                        ${alert.acknowledged ? `
+                        <span class="badge bg-secondary" title="${alert.acknowledged_by || ''}">已确认</span>
+                        ` : `
+                        <button class="btn btn-sm btn-outline-success" onclick="acknowledgeAlert('${alert.id}')">
+                            <i class="bi bi-check2"></i> 确认
+                        </button>
+                        `}
+                        ${alert.resolved ? `<span class="badge bg-success" title="${alert.resolved_at ? Utils.formatTime(alert.resolved_at) : ''}">已恢复</span>` : ''}
                     </td>
                 </tr>
             `;
@@ -202,6 +220,23 @@ class AlertsPage {
                 throw new Error(detail?.error || '未获取到详情');
             }
 
+            // 通知发送结果获取失败不影响详情展示
+            let notifications = [];
+            try {
+                const resp = await API.get(`/alerts/${encodeURIComponent(alertId)}/notifications`);
+                notifications = resp.notifications || [];
+            } catch (e) {
+                console.warn('加载通知发送结果失败:', e);
+            }
+            const notificationRows = notifications.length === 0
+                ? '<tr><td colspan="3" class="text-muted">暂无发送记录</td></tr>'
+                : notifications.map(n => `
+                    <tr>
+                        <td>${n.channel}</td>
+                        <td>${n.success ? '<span class="badge bg-success">成功</span>' : '<span class="badge bg-danger">失败</span>'}</td>
+                        <td class="small">${Utils.escapeHTML(n.error || '')}</td>
+                    </tr>`).join('');
+
             const levelColor = Utils.getLevelColor(detail.level);
             const dataPretty = detail.data ? JSON.stringify(detail.data, null, 2) : '{}';
             const rawMessage = Utils.escapeHTML(detail.message || '');
@@ -217,6 +252,11 @@ class AlertsPage {
                             <tr><td>时间:</td><td>${Utils.formatTime(detail.timestamp)}</td></tr>
                             <tr><td>匹配数:</td><td>${detail.count}</td></tr>
                         </table>
+                        <h6 class="mt-3">通知发送结果</h6>
+                        <table class="table table-sm">
+                            <thead><tr><th>渠道</th><th>结果</th><th>错误</th></tr></thead>
+                            <tbody>${notificationRows}</tbody>
+                        </table>
                     </div>
                     <div class="col-md-6">
                         <h6>详细信息</h6>
@@ -237,6 +277,22 @@ class AlertsPage {
         }
     }
 
+    // 确认告警
+    async acknowledgeAlert(alertId) {
+        try {
+            const resp = await API.post(`/alerts/${encodeURIComponent(alertId)}/ack`, {});
+            if (resp && !resp.error) {
+                Notification.success('告警已确认');
+                this.loadAlerts();
+            } else {
+                throw new Error(resp?.error || '确认失败');
+            }
+        } catch (error) {
+            console.error('确认告警失败:', error);
+            Notification.error('确认告警失败: ' + error.message);
+        }
+    }
+
     // 应用筛选器
     applyFilters() {
         this.updateFilters();
@@ -322,6 +378,12 @@ function showAlertDetail(alertId) {
     }
 }
 
+function acknowledgeAlert(alertId) {
+    if (window.alertsPage) {
+        window.alertsPage.acknowledgeAlert(alertId);
+    }
+}
+
 // 页面加载完成后初始化
 document.addEventListener('DOMContentLoaded', function() {
     // 检查是否在告警页面
@@ -131,6 +131,15 @@ const API = {
         };
 
         const config = { ...defaultOptions, ...options };
+
+        // 非 GET 请求携带 CSRF Token
+        const method = (config.method || 'GET').toUpperCase();
+        if (method !== 'GET' && method !== 'HEAD') {
+            const token = await this.getCSRFToken();
+            if (token) {
+                config.headers = { ...config.headers, 'X-CSRF-Token': token };
+            }
+        }
         
         try {
             const response = await fetch(url, config);
@@ -145,7 +154,7 @@ const API = {
                     return;
                 }
                 const data = await response.json().catch(() => ({}));
-                throw new Error(data.error || `HTTP ${response.status}`);
+                throw new Error(data.error || data.message || `HTTP ${response.status}`);
             }
             
             const data = await response.json();
@@ -156,6 +165,28 @@ const API = {
         }
     },
 
+    // 获取 CSRF Token：优先读取页面 meta，缺失时向后端获取
+    async getCSRFToken() {
+        if (this.csrfToken) {
+            return this.csrfToken;
+        }
+        const meta = document.querySelector('meta[name="csrf-token"]');
+        if (meta && meta.content) {
+            this.csrfToken = meta.content;
+            return this.csrfToken;
+        }
+        try {
+            const response = await fetch(`${OpenSearchAlert.config.apiBase}/csrf`, { credentials: 'same-origin' });
+            if (response.ok) {
+                const data = await response.json();
+                this.csrfToken = data.csrf_token || '';
+            }
+        } catch (error) {
+            console.error('获取 CSRF Token 失败:', error);
+        }
+        return this.csrfToken;
+    },
+
     // GET 请求
     async get(endpoint) {
         return this.request(`${OpenSearchAlert.config.apiBase}${endpoint}`);
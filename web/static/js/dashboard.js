@@ -7,6 +7,7 @@ class Dashboard {
         this.charts = {};
         this.currentTimeRange = 24;
         this.refreshTimer = null;
+        this.alertStream = null;
         this.init();
     }
 
@@ -16,6 +17,17 @@ class Dashboard {
         this.loadData();
         this.setupEventListeners();
         this.startAutoRefresh();
+        this.subscribeAlerts();
+    }
+
+    // 订阅新告警推送，收到告警后立即刷新（定时刷新保留为兜底）
+    subscribeAlerts() {
+        if (!window.EventSource) return;
+        this.alertStream = new EventSource('/api/alerts/stream');
+        this.alertStream.addEventListener('alert', () => {
+            clearTimeout(this.streamRefreshTimer);
+            this.streamRefreshTimer = setTimeout(() => this.loadData(), 500);
+        });
     }
 
     // 初始化图表
@@ -253,6 +265,10 @@ class Dashboard {
     // 销毁
     destroy() {
         this.stopAutoRefresh();
+        if (this.alertStream) {
+            this.alertStream.close();
+            this.alertStream = null;
+        }
         Object.values(this.charts).forEach(chart => {
             ChartManager.destroy(chart);
         });
@@ -51,6 +51,7 @@ class RulesPage {
             
             const data = await API.get('/rules');
             this.currentRules = data.rules || [];
+            this.ruleErrors = data.errors || {};
             
             this.displayRules(this.currentRules);
             this.updateRuleStats(this.currentRules);
@@ -77,8 +78,10 @@ class RulesPage {
         const isAdmin = currentRole === 'admin';
 
         rules.forEach(rule => {
-            const statusClass = rule.Enabled ? 'success' : 'danger';
-            const statusText = rule.Enabled ? '启用' : '禁用';
+            const ruleError = (this.ruleErrors || {})[rule.Name];
+            const errored = rule.Enabled && ruleError && ruleError.errored;
+            const statusClass = errored ? 'warning' : (rule.Enabled ? 'success' : 'danger');
+            const statusText = errored ? '出错' : (rule.Enabled ? '启用' : '禁用');
             const thresholdVal = rule.Threshold || rule.threshold || rule.ThresholdValue || 0;
             const levelColor = Utils.getLevelColor(rule.Level || 'Low');
             
@@ -87,7 +90,7 @@ class RulesPage {
                     <div class="card h-100">
                         <div class="card-header d-flex justify-content-between align-items-center">
                             <h6 class="mb-0">${rule.Name}</h6>
-                            <span class="badge bg-${statusClass}">${statusText}</span>
+                            <span class="badge bg-${statusClass}" ${errored ? `title="${String(ruleError.last_error).replace(/"/g, '&quot;')}"` : ''}>${statusText}</span>
                         </div>
                         <div class="card-body">
                             <div class="mb-2">
@@ -120,6 +123,12 @@ class RulesPage {
                                 <button class="btn btn-sm btn-outline-${rule.Enabled ? 'danger' : 'success'}" onclick="toggleRule('${rule.Name}', ${!rule.Enabled})">
                                     <i class="bi bi-${rule.Enabled ? 'pause' : 'play'}"></i> ${rule.Enabled ? '禁用' : '启用'}
                                 </button>
+                                <button class="btn btn-sm btn-outline-primary" onclick="runRule('${rule.Name}')" ${rule.Enabled ? '' : 'disabled'}>
+                                    <i class="bi bi-lightning"></i> 执行
+                                </button>
+                                <button class="btn btn-sm btn-outline-danger" onclick="deleteRule('${rule.Name}')">
+                                    <i class="bi bi-trash"></i> 删除
+                                </button>
                                 ` : `
                                 <button class="btn btn-sm btn-outline-secondary" disabled>
                                     <i class="bi bi-lock"></i> 只读
@@ -215,8 +224,15 @@ class RulesPage {
     }
 
     // 编辑规则
-    editRule(ruleName) {
-        const rule = this.currentRules.find(r => r.Name === ruleName);
+    async editRule(ruleName) {
+        // 从接口读取规则文件的最新定义，失败时退回列表中的数据
+        let rule;
+        try {
+            rule = await API.get(`/rules/${encodeURIComponent(ruleName)}`);
+        } catch (error) {
+            console.error('获取规则失败:', error);
+            rule = this.currentRules.find(r => r.Name === ruleName);
+        }
         if (!rule) return;
         
         const modal = document.getElementById('ruleEditModal');
@@ -321,6 +337,51 @@ class RulesPage {
         }
     }
 
+    // 立即执行规则
+    async runRule(ruleName) {
+        if (!confirm(`确定要立即执行规则 "${ruleName}" 吗？满足条件时将发送真实告警。`)) {
+            return;
+        }
+
+        try {
+            const resp = await API.post(`/rules/${encodeURIComponent(ruleName)}/run`, {});
+            if (!resp) {
+                throw new Error('执行失败');
+            }
+            if (resp.fired) {
+                Notification.success(`规则 "${ruleName}" 已触发告警（命中 ${resp.hits} 条，级别 ${resp.alert.level}）`);
+            } else if (resp.error) {
+                Notification.error(`规则 "${ruleName}" 执行失败: ${resp.error}`);
+            } else {
+                Notification.info(`规则 "${ruleName}" 未触发告警（命中 ${resp.hits} 条${resp.skipped ? '，' + resp.skipped : ''}）`);
+            }
+            this.loadRules();
+        } catch (error) {
+            console.error('执行规则失败:', error);
+            Notification.error('执行规则失败: ' + error.message);
+        }
+    }
+
+    // 删除规则
+    async deleteRule(ruleName) {
+        if (!confirm(`确定要删除规则 "${ruleName}" 吗？该操作将移除规则文件且不可恢复。`)) {
+            return;
+        }
+
+        try {
+            const resp = await API.delete(`/rules/${encodeURIComponent(ruleName)}`);
+            if (resp && !resp.error) {
+                Notification.success(`规则 "${ruleName}" 已删除！`);
+                this.loadRules();
+            } else {
+                throw new Error(resp?.error || '删除失败');
+            }
+        } catch (error) {
+            console.error('删除规则失败:', error);
+            Notification.error('删除规则失败: ' + error.message);
+        }
+    }
+
     // 刷新规则
     refreshRules() {
         this.loadRules();
@@ -358,6 +419,18 @@ function toggleRule(ruleName, enabled) {
     }
 }
 
+function runRule(ruleName) {
+    if (window.rulesPage) {
+        window.rulesPage.runRule(ruleName);
+    }
+}
+
+function deleteRule(ruleName) {
+    if (window.rulesPage) {
+        window.rulesPage.deleteRule(ruleName);
+    }
+}
+
 // 详情功能已移除
 
 // 页面加载完成后初始化